@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
 	"github.com/max-dunn/ai-rules-manager/internal/cli"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
 	"github.com/max-dunn/ai-rules-manager/internal/version"
@@ -19,18 +25,42 @@ var (
 func main() {
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		// Some errors, such as local git errors, already include their suggestion
+		if hint := armerr.HintOf(err); hint != "" && !strings.Contains(err.Error(), hint) {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+		os.Exit(armerr.KindOf(err).ExitCode())
 	}
 }
 
-func run() error {
+func run() (err error) {
+	// With --json, failures the command could not report itself, such as
+	// configuration errors, still print an Output document
+	if args := os.Args[1:]; cli.JSONRequested(args) {
+		defer func() {
+			if err != nil {
+				cli.WriteErrorOutput(os.Stdout, args, err)
+			}
+		}()
+	}
+
 	// Set version information in version package
 	version.Version = buildVersion
 	version.Commit = buildCommit
 	version.BuildTime = buildTimestamp
 
+	// Commands operate on the nearest project above the working directory,
+	// while path flags stay relative to it
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if err := enterProject(wd); err != nil {
+		return err
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(config.OptionsFromEnv())
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
@@ -43,6 +73,30 @@ func run() error {
 	}
 	rootCmd := cli.NewRootCommand(cfg, versionInfo)
 
-	// Execute command
-	return rootCmd.Execute()
+	// Ctrl-C and SIGTERM cancel the command, which then removes its temporary
+	// and staged files; a second signal exits immediately
+	ctx, stop := signal.NotifyContext(cli.WithWorkingDir(context.Background(), wd), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Execute command, letting background cache maintenance finish before exiting
+	defer cache.WaitForMaintenance()
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// enterProject changes to the root of the project containing wd, or to the
+// git root without a project, so that configuration files and channel
+// directories resolve against it and new projects are created there
+func enterProject(wd string) error {
+	root, _, err := config.FindProjectRoot(wd)
+	if err != nil || root == wd {
+		return err
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to change to project root %s: %w", root, err)
+	}
+	return nil
 }
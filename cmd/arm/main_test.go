@@ -1,7 +1,10 @@
 package main
 
 import (
+	"encoding/json"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -29,3 +32,135 @@ func TestMain(t *testing.T) {
 		t.Logf("run() returned error (expected for test): %v", err)
 	}
 }
+
+func TestRunFromSubdirectory(t *testing.T) {
+	// The working directory is reported with symbolic links resolved
+	tmpDir, _ := filepath.EvalSymlinks(t.TempDir())
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	project := filepath.Join(tmpDir, "project")
+	subdir := filepath.Join(project, "docs", "guide")
+	if err := os.MkdirAll(subdir, 0o755); err != nil {
+		t.Fatalf("Failed to create %s: %v", subdir, err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "arm.json"), []byte(`{"channels": {}, "rulesets": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to write arm.json: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(subdir)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"arm", "mirror", "--to", "out", "--dry-run", "--json"}
+
+	// Capture the JSON document printed to standard output
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := run()
+	_ = w.Close()
+	os.Stdout = oldStdout
+	output, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	// The command runs in the project root, with the path flag taken from the subdirectory
+	if wd, _ := os.Getwd(); wd != project {
+		t.Errorf("Expected to run in the project root %s, got %s", project, wd)
+	}
+	var doc struct {
+		Result struct {
+			Destination string `json:"destination"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(output, &doc); err != nil {
+		t.Fatalf("Expected a JSON document, got %v: %s", err, output)
+	}
+	if want := filepath.Join(subdir, "out"); doc.Result.Destination != want {
+		t.Errorf("Expected --to relative to the subdirectory, %s, got %s", want, doc.Result.Destination)
+	}
+}
+
+func TestRunReportsErrorsAsJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tmpDir)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	tests := []struct {
+		name    string
+		args    []string
+		profile string
+		command string
+	}{
+		{"configuration error", []string{"list", "--json"}, "missing", "list"},
+		{"invalid flag before --json", []string{"install", "--unknown", "--json"}, "", "install"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ARM_PROFILE", tt.profile)
+			os.Args = append([]string{"arm"}, tt.args...)
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err := run()
+			_ = w.Close()
+			os.Stdout = oldStdout
+			output, _ := io.ReadAll(r)
+			if err == nil {
+				t.Fatal("Expected run() to fail")
+			}
+
+			var doc struct {
+				Command string `json:"command"`
+				Success bool   `json:"success"`
+				Errors  []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(output, &doc); err != nil {
+				t.Fatalf("Expected a single JSON document, got %v: %s", err, output)
+			}
+			if doc.Command != tt.command || doc.Success || len(doc.Errors) != 1 || doc.Errors[0].Message != err.Error() {
+				t.Errorf("Expected the error of %s in the output document, got %+v", tt.command, doc)
+			}
+		})
+	}
+}
+
+func TestRunCreatesProjectAtGitRoot(t *testing.T) {
+	tmpDir, _ := filepath.EvalSymlinks(t.TempDir())
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	repo := filepath.Join(tmpDir, "repo")
+	subdir := filepath.Join(repo, "sub")
+	for _, dir := range []string{filepath.Join(repo, ".git"), subdir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(subdir)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"arm", "install", "--quiet"}
+
+	// Without a project, the stub files are created at the git root
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	for _, name := range []string{".armrc", "arm.json"} {
+		if _, err := os.Stat(filepath.Join(repo, name)); err != nil {
+			t.Errorf("Expected %s at the git root: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(subdir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected no %s in the subdirectory, got %v", name, err)
+		}
+	}
+}
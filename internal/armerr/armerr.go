@@ -0,0 +1,204 @@
+// Package armerr classifies ARM errors so that callers can tell failures apart
+// without matching message text. Each Kind maps to a stable code used in JSON
+// output and to a documented process exit status.
+package armerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Kind is the category of an error
+type Kind int
+
+const (
+	Unknown       Kind = iota // Unclassified failure
+	Config                    // Invalid or incomplete configuration
+	NotFound                  // Registry, ruleset or version does not exist
+	Unsatisfiable             // No version satisfies the requested constraint
+	Auth                      // Authentication or authorization failed
+	Network                   // Registry could not be reached
+	Integrity                 // Downloaded content does not match the lock file
+	NotCached                 // Content is not cached in offline mode
+	Canceled                  // Operation was interrupted
+)
+
+// kindInfo holds the code, exit status and default hint of a kind
+type kindInfo struct {
+	code     string
+	exitCode int
+	hint     string
+}
+
+var kinds = map[Kind]kindInfo{
+	Unknown:       {"error", 1, ""},
+	Config:        {"config_invalid", 2, "See the configuration guide for valid keys and values, and 'arm config list' for current settings"},
+	NotFound:      {"not_found", 3, "Check the registry and ruleset names with 'arm search' or 'arm list'"},
+	Unsatisfiable: {"version_unsatisfiable", 4, "Run 'arm info <registry>/<ruleset>' to see available versions"},
+	Auth:          {"auth_failed", 5, "Check the registry's authToken or credentialHelper and that the credentials have not expired"},
+	Network:       {"network_error", 6, "Check your network connection, or use --offline to work from the cache"},
+	Integrity:     {"integrity_mismatch", 7, "Run 'arm cache clean' and reinstall, or update the ruleset if the change is expected"},
+	NotCached:     {"not_cached", 8, "Run the command online once to populate the cache"},
+	Canceled:      {"canceled", 130, ""},
+}
+
+// Code returns the stable identifier of the kind used in JSON output
+func (k Kind) Code() string {
+	return kinds[k].code
+}
+
+// ExitCode returns the process exit status for errors of the kind
+func (k Kind) ExitCode() int {
+	return kinds[k].exitCode
+}
+
+// Hint returns the default remediation hint of the kind
+func (k Kind) Hint() string {
+	return kinds[k].hint
+}
+
+func (k Kind) String() string {
+	return k.Code()
+}
+
+// Error is an error with a kind and an optional remediation hint
+type Error struct {
+	Kind Kind
+	Err  error
+	Hint string // Overrides the default hint of the kind when set
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind returns the kind of the error
+func (e *Error) ErrorKind() Kind {
+	return e.Kind
+}
+
+// ErrorHint returns the remediation hint of the error
+func (e *Error) ErrorHint() string {
+	return e.Hint
+}
+
+// WithHint sets the remediation hint of the error
+func (e *Error) WithHint(hint string) *Error {
+	e.Hint = hint
+	return e
+}
+
+// New creates an error of the given kind
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Err: errors.New(message)}
+}
+
+// Errorf creates an error of the given kind, wrapping any %w arguments
+func Errorf(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap classifies err as kind, returning nil when err is nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// kinded is implemented by errors that know their kind
+type kinded interface {
+	ErrorKind() Kind
+}
+
+// hinted is implemented by errors that carry a remediation hint
+type hinted interface {
+	ErrorHint() string
+}
+
+// KindOf returns the kind of the outermost classified error in err's chain.
+// Unclassified cancellations, timeouts and network errors are recognised too.
+func KindOf(err error) Kind {
+	if err == nil {
+		return Unknown
+	}
+	var kind Kind
+	walk(err, func(e error) bool {
+		if k, ok := e.(kinded); ok && k.ErrorKind() != Unknown {
+			kind = k.ErrorKind()
+			return true
+		}
+		return false
+	})
+	if kind != Unknown {
+		return kind
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded), isNetworkError(err):
+		return Network
+	}
+	return Unknown
+}
+
+// isNetworkError reports whether err comes from a failed connection, name
+// lookup or timeout. syscall.Errno implements net.Error, so plain file system
+// errors are ruled out by requiring a network operation or a timeout.
+func isNetworkError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// HintOf returns the remediation hint for err: the first hint set in its
+// chain, or else the default hint of its kind
+func HintOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	var hint string
+	walk(err, func(e error) bool {
+		if h, ok := e.(hinted); ok && h.ErrorHint() != "" {
+			hint = h.ErrorHint()
+			return true
+		}
+		return false
+	})
+	if hint != "" {
+		return hint
+	}
+	return KindOf(err).Hint()
+}
+
+// walk visits err and the errors it wraps depth-first until visit returns true
+func walk(err error, visit func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if visit(err) {
+		return true
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), visit)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if walk(inner, visit) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package armerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestKindOf(t *testing.T) {
+	notFound := Errorf(NotFound, "ruleset %s not found", "rules")
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"nil", nil, Unknown},
+		{"plain", errors.New("boom"), Unknown},
+		{"direct", notFound, NotFound},
+		{"wrapped", fmt.Errorf("failed to install: %w", notFound), NotFound},
+		{"outermost wins", Wrap(Config, fmt.Errorf("bad: %w", notFound)), Config},
+		{"unknown is skipped", Wrap(Unknown, notFound), NotFound},
+		{"joined", errors.Join(errors.New("first"), Wrap(Auth, errors.New("denied"))), Auth},
+		{"canceled", fmt.Errorf("install: %w", context.Canceled), Canceled},
+		{"deadline", context.DeadlineExceeded, Network},
+		{"net error", fmt.Errorf("dial: %w", timeoutError{}), Network},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, Network},
+		{"missing file", fmt.Errorf("stat: %w", &os.PathError{Op: "stat", Path: "x", Err: syscall.ENOENT}), Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHintOf(t *testing.T) {
+	if got := HintOf(New(Network, "unreachable")); got != Network.Hint() {
+		t.Errorf("Expected default network hint, got %q", got)
+	}
+
+	custom := New(Config, "registry 'x' not found").WithHint("Add it with 'arm config add registry'")
+	if got := HintOf(fmt.Errorf("install: %w", custom)); got != custom.Hint {
+		t.Errorf("Expected custom hint, got %q", got)
+	}
+
+	if got := HintOf(errors.New("boom")); got != "" {
+		t.Errorf("Expected no hint for unclassified errors, got %q", got)
+	}
+}
+
+func TestErrorIdentity(t *testing.T) {
+	sentinel := New(NotCached, "not cached")
+	err := fmt.Errorf("rules@1.0.0: %w", sentinel)
+	if !errors.Is(err, sentinel) {
+		t.Error("Expected errors.Is to match the sentinel")
+	}
+	if err.Error() != "rules@1.0.0: not cached" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if Wrap(Config, nil) != nil {
+		t.Error("Expected Wrap(nil) to return nil")
+	}
+}
+
+func TestExitCodesAreDistinct(t *testing.T) {
+	seen := make(map[int]Kind)
+	for kind := range kinds {
+		code := kind.ExitCode()
+		if code == 0 {
+			t.Errorf("%s has exit code 0", kind)
+		}
+		if other, exists := seen[code]; exists {
+			t.Errorf("%s and %s share exit code %d", kind, other, code)
+		}
+		seen[code] = kind
+	}
+}
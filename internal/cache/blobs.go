@@ -0,0 +1,297 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LinkMode selects how files are placed from the blob store into a directory
+type LinkMode string
+
+const (
+	// LinkCopy copies blob contents
+	LinkCopy LinkMode = "copy"
+	// LinkHardlink hardlinks blobs, falling back to copying across filesystems
+	LinkHardlink LinkMode = "hardlink"
+	// LinkReflink clones blobs copy-on-write where the filesystem supports it, otherwise copies
+	LinkReflink LinkMode = "reflink"
+)
+
+// ParseLinkMode parses a link mode name, defaulting to copy when empty
+func ParseLinkMode(name string) (LinkMode, error) {
+	switch LinkMode(name) {
+	case "", LinkCopy:
+		return LinkCopy, nil
+	case LinkHardlink, LinkReflink:
+		return LinkMode(name), nil
+	default:
+		return "", fmt.Errorf("unknown link mode %q (use copy, hardlink or reflink)", name)
+	}
+}
+
+// hardlinkBlobs is false on Windows, where hardlinks share the read-only
+// attribute of the blob: removing or replacing an installed file would fail or
+// leave the blob writable. LinkHardlink copies blobs there instead.
+var hardlinkBlobs = runtime.GOOS != "windows"
+
+// blobGracePeriod protects blobs written by a concurrent store whose version
+// manifest does not reference them yet
+const blobGracePeriod = time.Hour
+
+// BlobStore is a content-addressable store of file contents keyed by their
+// hex SHA-256 digest, stored under <cache>/blobs/sha256/<ab>/<digest>
+type BlobStore struct {
+	root string
+}
+
+// NewBlobStore creates a blob store under the cache root
+func NewBlobStore(cacheRoot string) *BlobStore {
+	return &BlobStore{root: filepath.Join(cacheRoot, "blobs", "sha256")}
+}
+
+// Put stores content and returns its digest
+func (b *BlobStore) Put(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	if b.reuse(digest) {
+		return digest, nil
+	}
+
+	return digest, b.write(digest, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
+
+// PutFile stores the content of a file and returns its digest and size
+func (b *BlobStore) PutFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash file: %w", err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if b.reuse(digest) {
+		return digest, size, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to rewind file: %w", err)
+	}
+	err = b.write(digest, func(w io.Writer) error {
+		_, err := io.Copy(w, file)
+		return err
+	})
+	return digest, size, err
+}
+
+// Get returns the content of a blob, failing if it no longer matches its digest
+func (b *BlobStore) Get(digest string) ([]byte, error) {
+	path, err := b.Path(digest)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != digest {
+		return nil, fmt.Errorf("blob %s is corrupted", digest)
+	}
+	return content, nil
+}
+
+// Has reports whether a blob is stored
+func (b *BlobStore) Has(digest string) bool {
+	path, err := b.Path(digest)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Path returns the file path of a blob
+func (b *BlobStore) Path(digest string) (string, error) {
+	if !isDigest(digest) {
+		return "", fmt.Errorf("invalid blob digest %q", digest)
+	}
+	return filepath.Join(b.root, digest[:2], digest), nil
+}
+
+// List returns the digests of all stored blobs
+func (b *BlobStore) List() ([]string, error) {
+	var digests []string
+	err := filepath.Walk(b.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
+			digests = append(digests, info.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	return digests, nil
+}
+
+// Verify reports whether a blob still matches its digest
+func (b *BlobStore) Verify(digest string) bool {
+	_, err := b.Get(digest)
+	return err == nil
+}
+
+// Remove deletes a blob
+func (b *BlobStore) Remove(digest string) error {
+	path, err := b.Path(digest)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove blob %s: %w", digest, err)
+	}
+	return nil
+}
+
+// Size returns the total size of the stored blobs in bytes
+func (b *BlobStore) Size() int64 {
+	return getDirSize(b.root)
+}
+
+// Link places a blob at dst using the link mode, replacing any existing file.
+// Hardlinks and reflinks fall back to copying when the filesystem cannot
+// provide them, such as across devices, and hardlinks always do on Windows.
+func (b *BlobStore) Link(digest, dst string, mode LinkMode) error {
+	src, err := b.Path(digest)
+	if err != nil {
+		return err
+	}
+
+	// Never write through an existing file, which may itself be a hardlinked blob
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+
+	switch mode {
+	case LinkHardlink:
+		if hardlinkBlobs && os.Link(src, dst) == nil {
+			return nil
+		}
+	case LinkReflink:
+		if err := reflink(src, dst); err == nil {
+			return nil
+		}
+		_ = os.Remove(dst)
+	}
+	return copyBlob(src, dst)
+}
+
+// UnreferencedBlobs returns the blobs not in referenced, skipping blobs
+// written within the grace period
+func (b *BlobStore) UnreferencedBlobs(referenced map[string]bool) ([]string, error) {
+	digests, err := b.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var unreferenced []string
+	for _, digest := range digests {
+		if referenced[digest] {
+			continue
+		}
+		path, err := b.Path(digest)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > blobGracePeriod {
+			unreferenced = append(unreferenced, digest)
+		}
+	}
+	return unreferenced, nil
+}
+
+// reuse reports whether a blob is stored and refreshes its modification time,
+// so the grace period protects a reused blob as it does a newly written one
+// until the manifest referencing it is written
+func (b *BlobStore) reuse(digest string) bool {
+	path, err := b.Path(digest)
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	return os.Chtimes(path, now, now) == nil
+}
+
+// write stores a blob atomically through a temporary file
+func (b *BlobStore) write(digest string, fill func(io.Writer) error) error {
+	path, err := b.Path(digest)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer func() { _ = os.Remove(temp.Name()) }()
+
+	if err := fill(temp); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	// Blobs may be hardlinked into channels; keep them read-only
+	if err := os.Chmod(temp.Name(), 0o444); err != nil {
+		return fmt.Errorf("failed to set blob permissions: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+// copyBlob copies a blob to a writable file
+func copyBlob(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open blob: %w", err)
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		_ = dstFile.Close()
+		return fmt.Errorf("failed to copy blob: %w", err)
+	}
+	return dstFile.Close()
+}
+
+// errReflinkUnsupported is returned where copy-on-write clones are not available
+var errReflinkUnsupported = errors.New("reflink not supported")
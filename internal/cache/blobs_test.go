@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBlobStore_PutAndGet(t *testing.T) {
+	blobs := NewBlobStore(t.TempDir())
+
+	digest, err := blobs.Put([]byte("rule"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	again, err := blobs.Put([]byte("rule"))
+	if err != nil || again != digest {
+		t.Errorf("Expected identical content to share digest %s, got %s (%v)", digest, again, err)
+	}
+	if digests, _ := blobs.List(); len(digests) != 1 {
+		t.Errorf("Expected one blob, got %v", digests)
+	}
+
+	content, err := blobs.Get(digest)
+	if err != nil || string(content) != "rule" {
+		t.Errorf("Expected stored content, got %q (%v)", content, err)
+	}
+
+	path, _ := blobs.Path(digest)
+	_ = os.Chmod(path, 0o644)
+	_ = os.WriteFile(path, []byte("tampered"), 0o644)
+	if _, err := blobs.Get(digest); err == nil {
+		t.Error("Expected corrupted blob to fail")
+	}
+
+	if _, err := blobs.Path("../etc/passwd"); err == nil {
+		t.Error("Expected invalid digest to be rejected")
+	}
+}
+
+func TestBlobStore_Link(t *testing.T) {
+	dir := t.TempDir()
+	blobs := NewBlobStore(filepath.Join(dir, "cache"))
+	digest, err := blobs.Put([]byte("rule"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	blobPath, _ := blobs.Path(digest)
+	blobInfo, _ := os.Stat(blobPath)
+
+	for _, mode := range []LinkMode{LinkCopy, LinkHardlink, LinkReflink} {
+		dst := filepath.Join(dir, string(mode)+".md")
+		_ = os.WriteFile(dst, []byte("previous"), 0o644)
+		if err := blobs.Link(digest, dst, mode); err != nil {
+			t.Fatalf("Link(%s) failed: %v", mode, err)
+		}
+		content, _ := os.ReadFile(dst)
+		if string(content) != "rule" {
+			t.Errorf("Link(%s) content = %q", mode, content)
+		}
+		info, _ := os.Stat(dst)
+		if shared := os.SameFile(blobInfo, info); shared != (mode == LinkHardlink && hardlinkBlobs) {
+			t.Errorf("Link(%s) shares the blob file: %v", mode, shared)
+		}
+	}
+}
+
+func TestBlobStore_LinkWithoutHardlinks(t *testing.T) {
+	// Windows copies blobs, whose read-only attribute hardlinks would share
+	defer func(enabled bool) { hardlinkBlobs = enabled }(hardlinkBlobs)
+	hardlinkBlobs = false
+
+	dir := t.TempDir()
+	blobs := NewBlobStore(filepath.Join(dir, "cache"))
+	digest, err := blobs.Put([]byte("rule"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	blobPath, _ := blobs.Path(digest)
+
+	dst := filepath.Join(dir, "rule.md")
+	if err := blobs.Link(digest, dst, LinkHardlink); err != nil {
+		t.Fatalf("Link() failed: %v", err)
+	}
+	blobInfo, _ := os.Stat(blobPath)
+	info, err := os.Stat(dst)
+	if err != nil || os.SameFile(blobInfo, info) {
+		t.Fatalf("Expected a copy of the blob, got %v (%v)", info, err)
+	}
+	if info.Mode().Perm()&0o200 == 0 {
+		t.Errorf("Expected the installed file to be writable, got %v", info.Mode())
+	}
+
+	// Updating and uninstalling replace and remove the file; the blob stays read-only
+	if err := os.WriteFile(dst, []byte("edited"), 0o644); err != nil {
+		t.Errorf("Expected the installed file to be replaceable, got %v", err)
+	}
+	if err := os.Remove(dst); err != nil {
+		t.Errorf("Expected the installed file to be removable, got %v", err)
+	}
+	if blobInfo.Mode().Perm()&0o222 != 0 || !blobs.Verify(digest) {
+		t.Errorf("Expected the blob to stay read-only and intact, got %v", blobInfo.Mode())
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	storage := manager.GetRulesetStorage()
+	files := map[string][]byte{"shared.md": []byte("shared"), "old.md": []byte("old")}
+	if err := storage.StoreRulesetFiles("https", "https://rules.example.com", "go", "1.0.0", files, nil); err != nil {
+		t.Fatalf("Failed to store files: %v", err)
+	}
+	files = map[string][]byte{"shared.md": []byte("shared")}
+	if err := storage.StoreRulesetFiles("https", "https://rules.example.com", "go", "2.0.0", files, nil); err != nil {
+		t.Fatalf("Failed to store files: %v", err)
+	}
+	if digests, _ := manager.GetBlobStore().List(); len(digests) != 2 {
+		t.Fatalf("Expected shared content to be stored once, got %v", digests)
+	}
+
+	if err := storage.RemoveRulesetVersion("https", "https://rules.example.com", "go", "1.0.0", nil); err != nil {
+		t.Fatalf("Failed to remove version: %v", err)
+	}
+
+	// Fresh blobs are kept in case a concurrent store has not written its manifest yet
+	if count, _, err := manager.CollectGarbage(); err != nil || count != 0 {
+		t.Fatalf("Expected fresh blobs to be kept, removed %d (%v)", count, err)
+	}
+
+	old := time.Now().Add(-2 * blobGracePeriod)
+	digests, _ := manager.GetBlobStore().List()
+	for _, digest := range digests {
+		path, _ := manager.GetBlobStore().Path(digest)
+		_ = os.Chtimes(path, old, old)
+	}
+	count, freed, err := manager.CollectGarbage()
+	if err != nil || count != 1 || freed != int64(len("old")) {
+		t.Fatalf("Expected the blob of old.md to be removed, got %d blobs, %d bytes (%v)", count, freed, err)
+	}
+
+	content, err := storage.GetRulesetFiles("https", "https://rules.example.com", "go", "2.0.0", nil)
+	if err != nil || string(content["shared.md"]) != "shared" {
+		t.Errorf("Expected referenced blob to remain, got %v (%v)", content, err)
+	}
+}
+
+func TestBlobStore_ReuseRefreshesGracePeriod(t *testing.T) {
+	dir := t.TempDir()
+	blobs := NewBlobStore(dir)
+	source := filepath.Join(dir, "rule.md")
+	_ = os.WriteFile(source, []byte("rule"), 0o644)
+
+	old := time.Now().Add(-2 * blobGracePeriod)
+	for name, put := range map[string]func() (string, error){
+		"Put": func() (string, error) { return blobs.Put([]byte("rule")) },
+		"PutFile": func() (string, error) {
+			digest, _, err := blobs.PutFile(source)
+			return digest, err
+		},
+	} {
+		digest, err := put()
+		if err != nil {
+			t.Fatalf("%s: Expected no error, got %v", name, err)
+		}
+		path, _ := blobs.Path(digest)
+		_ = os.Chtimes(path, old, old)
+		if unreferenced, _ := blobs.UnreferencedBlobs(nil); len(unreferenced) != 1 {
+			t.Fatalf("%s: Expected the old blob to be collectable, got %v", name, unreferenced)
+		}
+
+		// Storing the same content again reuses the blob before its manifest
+		// references it; garbage collection must not remove it meanwhile
+		if _, err := put(); err != nil {
+			t.Fatalf("%s: Expected no error, got %v", name, err)
+		}
+		if unreferenced, _ := blobs.UnreferencedBlobs(nil); len(unreferenced) != 0 {
+			t.Errorf("%s: Expected the reused blob to be within the grace period, got %v", name, unreferenced)
+		}
+	}
+}
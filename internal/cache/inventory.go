@@ -0,0 +1,355 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RegistryEntry describes a cached registry
+type RegistryEntry struct {
+	CacheKey     string         `json:"cache_key"`
+	Type         string         `json:"type,omitempty"`
+	URL          string         `json:"url,omitempty"`
+	Size         int64          `json:"size"`
+	CreatedAt    time.Time      `json:"created_at"`
+	LastAccessed time.Time      `json:"last_accessed"`
+	AccessCount  int64          `json:"access_count"`
+	Rulesets     []RulesetEntry `json:"rulesets"`
+}
+
+// RulesetEntry describes a cached ruleset and pattern set of a registry
+type RulesetEntry struct {
+	CacheKey     string    `json:"cache_key"`
+	Name         string    `json:"name,omitempty"`
+	Patterns     []string  `json:"patterns,omitempty"`
+	Versions     []string  `json:"versions"`
+	Size         int64     `json:"size"`
+	LastAccessed time.Time `json:"last_accessed"`
+}
+
+// Problem describes an inconsistency found by Verify
+type Problem struct {
+	CacheKey string `json:"cache_key"`
+	Path     string `json:"path"`
+	Issue    string `json:"issue"`
+
+	repair func() error
+}
+
+// Repair fixes the problem, usually by removing the inconsistent entry
+func (p Problem) Repair() error {
+	if p.repair == nil {
+		return nil
+	}
+	return p.repair()
+}
+
+// List returns the cached registries and their rulesets sorted by type and URL,
+// including directories that have no registry mapping
+func (m *DefaultManager) List() ([]RegistryEntry, error) {
+	mappings, err := m.mapper.ListMappings()
+	if err != nil {
+		return nil, err
+	}
+	rulesetMappings, err := m.rulesetMapper.ListMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*RegistryEntry)
+	for _, mapping := range mappings {
+		entries[mapping.CacheKey] = &RegistryEntry{
+			CacheKey:     mapping.CacheKey,
+			Type:         mapping.RegistryType,
+			URL:          mapping.RegistryURL,
+			CreatedAt:    mapping.CreatedAt,
+			LastAccessed: mapping.LastAccessed,
+			AccessCount:  mapping.AccessCount,
+		}
+	}
+	for _, key := range m.registryDirs() {
+		if entries[key] == nil {
+			entries[key] = &RegistryEntry{CacheKey: key}
+		}
+	}
+
+	result := make([]RegistryEntry, 0, len(entries))
+	for key, entry := range entries {
+		registryPath := filepath.Join(m.cacheRoot, "registries", key)
+		entry.Size = getStoredSize(registryPath)
+		entry.Rulesets = m.listRulesets(registryPath, key, rulesetMappings)
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		if result[i].URL != result[j].URL {
+			return result[i].URL < result[j].URL
+		}
+		return result[i].CacheKey < result[j].CacheKey
+	})
+	return result, nil
+}
+
+// listRulesets returns the cached rulesets of a registry directory
+func (m *DefaultManager) listRulesets(registryPath, registryKey string, mappings []RulesetMapping) []RulesetEntry {
+	rulesetsPath := filepath.Join(registryPath, "rulesets")
+	dirs, err := os.ReadDir(rulesetsPath)
+	if err != nil {
+		return []RulesetEntry{}
+	}
+
+	byKey := make(map[string]RulesetMapping)
+	for _, mapping := range mappings {
+		if mapping.RegistryCacheKey == registryKey {
+			byKey[mapping.CacheKey] = mapping
+		}
+	}
+
+	rulesets := []RulesetEntry{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		rulesetPath := filepath.Join(rulesetsPath, dir.Name())
+		entry := RulesetEntry{
+			CacheKey: dir.Name(),
+			Versions: subdirs(rulesetPath),
+			Size:     getStoredSize(rulesetPath),
+		}
+		if mapping, exists := byKey[dir.Name()]; exists {
+			entry.Name = mapping.RulesetName
+			entry.Patterns = mapping.Patterns
+			entry.LastAccessed = mapping.LastAccessed
+		}
+		rulesets = append(rulesets, entry)
+	}
+	sort.Slice(rulesets, func(i, j int) bool { return rulesets[i].Name < rulesets[j].Name })
+	return rulesets
+}
+
+// Verify checks the cache for missing, orphaned and unreadable entries
+func (m *DefaultManager) Verify() ([]Problem, error) {
+	var problems []Problem
+
+	mappings, err := m.mapper.ListMappings()
+	if err != nil {
+		return []Problem{{
+			Path:   m.mapper.mapFilePath,
+			Issue:  fmt.Sprintf("unreadable registry map: %v", err),
+			repair: m.mapper.ValidateAndRecover,
+		}}, nil
+	}
+	rulesetMappings, err := m.rulesetMapper.ListMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	// Blobs must still match their digest
+	blobs, err := m.blobStore.List()
+	if err != nil {
+		return nil, err
+	}
+	corrupted := make(map[string]bool)
+	for _, digest := range blobs {
+		if m.blobStore.Verify(digest) {
+			continue
+		}
+		corrupted[digest] = true
+		path, _ := m.blobStore.Path(digest)
+		problems = append(problems, Problem{
+			CacheKey: digest,
+			Path:     path,
+			Issue:    "blob content does not match its digest",
+			repair:   func() error { return m.blobStore.Remove(digest) },
+		})
+	}
+
+	mapped := make(map[string]bool)
+	for _, mapping := range mappings {
+		key := mapping.CacheKey
+		mapped[key] = true
+		registryPath := filepath.Join(m.cacheRoot, "registries", key)
+		if _, err := os.Stat(registryPath); os.IsNotExist(err) {
+			problems = append(problems, Problem{
+				CacheKey: key,
+				Path:     registryPath,
+				Issue:    "registry mapping without cached files",
+				repair:   func() error { return m.mapper.RemoveMapping(key) },
+			})
+		}
+	}
+
+	for _, key := range m.registryDirs() {
+		registryPath := filepath.Join(m.cacheRoot, "registries", key)
+		if !mapped[key] {
+			problems = append(problems, Problem{
+				CacheKey: key,
+				Path:     registryPath,
+				Issue:    "cached files without registry mapping",
+				repair:   func() error { m.removeEntry(key); return nil },
+			})
+			continue
+		}
+		problems = append(problems, m.verifyRegistryDir(key, registryPath, rulesetMappings, corrupted)...)
+	}
+
+	// Ruleset mappings must point at a cached registry
+	for _, mapping := range rulesetMappings {
+		if mapped[mapping.RegistryCacheKey] {
+			continue
+		}
+		rulesetKey := mapping.CacheKey
+		problems = append(problems, Problem{
+			CacheKey: rulesetKey,
+			Path:     m.rulesetMapper.mapFilePath,
+			Issue:    fmt.Sprintf("ruleset mapping for %s without cached registry", mapping.RulesetName),
+			repair:   func() error { return m.rulesetMapper.RemoveMapping(rulesetKey) },
+		})
+	}
+
+	return problems, nil
+}
+
+// verifyRegistryDir checks the metadata files and ruleset versions of a cached registry
+func (m *DefaultManager) verifyRegistryDir(key, registryPath string, rulesetMappings []RulesetMapping, corrupted map[string]bool) []Problem {
+	var problems []Problem
+
+	for _, name := range []string{"cache-info.json", "versions.json", "metadata.json"} {
+		path := filepath.Join(registryPath, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // Metadata files are optional
+		}
+		var content map[string]interface{}
+		if json.Unmarshal(data, &content) != nil {
+			problems = append(problems, Problem{
+				CacheKey: key,
+				Path:     path,
+				Issue:    "unreadable " + name,
+				repair:   func() error { return os.Remove(path) },
+			})
+		}
+	}
+
+	mappedRulesets := make(map[string]bool)
+	for _, mapping := range rulesetMappings {
+		if mapping.RegistryCacheKey == key {
+			mappedRulesets[mapping.CacheKey] = true
+		}
+	}
+
+	rulesetsPath := filepath.Join(registryPath, "rulesets")
+	for _, rulesetKey := range subdirs(rulesetsPath) {
+		rulesetPath := filepath.Join(rulesetsPath, rulesetKey)
+		if !mappedRulesets[rulesetKey] {
+			problems = append(problems, Problem{
+				CacheKey: rulesetKey,
+				Path:     rulesetPath,
+				Issue:    "cached ruleset without ruleset mapping",
+				repair:   func() error { return os.RemoveAll(rulesetPath) },
+			})
+			continue
+		}
+		for _, version := range subdirs(rulesetPath) {
+			versionPath := filepath.Join(rulesetPath, version)
+			if issue := m.verifyVersionDir(versionPath, corrupted); issue != "" {
+				problems = append(problems, Problem{
+					CacheKey: rulesetKey,
+					Path:     versionPath,
+					Issue:    fmt.Sprintf("%s in version %s", issue, version),
+					repair:   func() error { return os.RemoveAll(versionPath) },
+				})
+			}
+		}
+	}
+
+	return problems
+}
+
+// verifyVersionDir describes what is wrong with a cached version directory, or
+// returns an empty string when it is intact
+func (m *DefaultManager) verifyVersionDir(versionPath string, corrupted map[string]bool) string {
+	if !hasFiles(versionPath) {
+		return "no files"
+	}
+
+	manifest, err := readManifest(versionPath)
+	if os.IsNotExist(err) {
+		return "" // Plain files cached before the blob store
+	}
+	if err != nil {
+		return "unreadable manifest"
+	}
+	for _, file := range manifest.Files {
+		if corrupted[file.SHA256] || !m.blobStore.Has(file.SHA256) {
+			return fmt.Sprintf("missing or corrupted content of %s", file.Path)
+		}
+	}
+	return ""
+}
+
+// RemoveRegistry deletes the cached content and mappings of a registry
+func (m *DefaultManager) RemoveRegistry(registryType, registryURL string) error {
+	cacheKey, err := m.GetCacheKey(registryType, registryURL)
+	if err != nil {
+		return fmt.Errorf("failed to generate cache key: %w", err)
+	}
+	m.removeEntry(cacheKey)
+	return nil
+}
+
+// Clear deletes all cached registries, blobs and mapping files
+func (m *DefaultManager) Clear() error {
+	for _, path := range []string{
+		filepath.Join(m.cacheRoot, "registries"),
+		filepath.Join(m.cacheRoot, "blobs"),
+		filepath.Join(m.cacheRoot, "temp"),
+		m.mapper.mapFilePath,
+		m.rulesetMapper.mapFilePath,
+	} {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// registryDirs returns the cache keys of the registry directories on disk
+func (m *DefaultManager) registryDirs() []string {
+	return subdirs(filepath.Join(m.cacheRoot, "registries"))
+}
+
+// subdirs returns the sorted names of the directories in path
+func subdirs(path string) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasFiles reports whether path contains any regular file
+func hasFiles(path string) bool {
+	found := false
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cacheRegistry stores a ruleset version for a registry and records its mapping
+func cacheRegistry(t *testing.T, manager *DefaultManager, registryType, registryURL, ruleset, version string, patterns []string) {
+	t.Helper()
+	if err := manager.UpdateCacheInfo(registryType, registryURL, version); err != nil {
+		t.Fatalf("Failed to update cache info: %v", err)
+	}
+	files := map[string][]byte{"rules.md": []byte(ruleset + "@" + version)}
+	if err := manager.GetRulesetStorage().StoreRulesetFiles(registryType, registryURL, ruleset, version, files, patterns); err != nil {
+		t.Fatalf("Failed to store ruleset files: %v", err)
+	}
+}
+
+// setLastAccessed rewrites the last access time of a registry mapping
+func setLastAccessed(t *testing.T, manager *DefaultManager, cacheKey string, when time.Time) {
+	t.Helper()
+	mapFile, err := manager.mapper.loadMapFile()
+	if err != nil {
+		t.Fatalf("Failed to load map file: %v", err)
+	}
+	for i := range mapFile.Mappings {
+		if mapFile.Mappings[i].CacheKey == cacheKey {
+			mapFile.Mappings[i].LastAccessed = when
+		}
+	}
+	if err := manager.mapper.saveMapFile(mapFile); err != nil {
+		t.Fatalf("Failed to save map file: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "git", "https://github.com/org/rules", "python", "abc123", []string{"**/*.md"})
+	cacheRegistry(t, manager, "git", "https://github.com/org/rules", "python", "def456", []string{"**/*.md"})
+	cacheRegistry(t, manager, "https", "https://rules.example.com", "go", "1.0.0", nil)
+
+	entries, err := manager.List()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 registries, got %d", len(entries))
+	}
+
+	git := entries[0]
+	if git.Type != "git" || git.URL != "https://github.com/org/rules" {
+		t.Errorf("Expected git registry first, got %s %s", git.Type, git.URL)
+	}
+	if git.Size <= 0 {
+		t.Errorf("Expected registry size to be reported, got %d", git.Size)
+	}
+	if len(git.Rulesets) != 1 {
+		t.Fatalf("Expected 1 ruleset, got %d", len(git.Rulesets))
+	}
+	ruleset := git.Rulesets[0]
+	if ruleset.Name != "python" || strings.Join(ruleset.Patterns, ",") != "**/*.md" {
+		t.Errorf("Expected python with patterns, got %s %v", ruleset.Name, ruleset.Patterns)
+	}
+	if strings.Join(ruleset.Versions, ",") != "abc123,def456" {
+		t.Errorf("Expected both versions, got %v", ruleset.Versions)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://rules.example.com", "go", "1.0.0", nil)
+
+	problems, err := manager.Verify()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("Expected healthy cache, got %v", problems)
+	}
+
+	cachePath, _ := manager.GetCachePath("https", "https://rules.example.com")
+	if err := os.WriteFile(filepath.Join(cachePath, "versions.json"), []byte("{broken"), 0o644); err != nil {
+		t.Fatalf("Failed to corrupt versions.json: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(manager.cacheRoot, "registries", "orphan"), 0o755); err != nil {
+		t.Fatalf("Failed to create orphan: %v", err)
+	}
+	versionPath, _ := manager.GetRulesetStorage().GetRulesetVersionPath("https", "https://rules.example.com", "go", "2.0.0")
+	if err := os.MkdirAll(versionPath, 0o755); err != nil {
+		t.Fatalf("Failed to create empty version: %v", err)
+	}
+
+	problems, err = manager.Verify()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	issues := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		issues = append(issues, problem.Issue)
+	}
+	for _, want := range []string{"unreadable versions.json", "cached files without registry mapping", "no files in version 2.0.0"} {
+		if !strings.Contains(strings.Join(issues, "\n"), want) {
+			t.Errorf("Expected problem %q, got %v", want, issues)
+		}
+	}
+
+	for _, problem := range problems {
+		if err := problem.Repair(); err != nil {
+			t.Fatalf("Failed to repair %s: %v", problem.Path, err)
+		}
+	}
+	problems, err = manager.Verify()
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected repaired cache, got %v (%v)", problems, err)
+	}
+}
+
+func TestRemoveRegistryAndClear(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://a.example.com", "go", "1.0.0", nil)
+	cacheRegistry(t, manager, "https", "https://b.example.com", "go", "1.0.0", nil)
+
+	if err := manager.RemoveRegistry("https", "https://a.example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	entries, _ := manager.List()
+	if len(entries) != 1 || entries[0].URL != "https://b.example.com" {
+		t.Fatalf("Expected only registry b to remain, got %v", entries)
+	}
+	if mappings, _ := manager.rulesetMapper.ListMappings(); len(mappings) != 1 {
+		t.Errorf("Expected ruleset mappings of registry a to be removed, got %d", len(mappings))
+	}
+
+	if err := manager.Clear(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 0 {
+		t.Errorf("Expected empty cache, got %v", entries)
+	}
+}
+
+func TestExpiredAndOversizedEntries(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://old.example.com", "go", "1.0.0", nil)
+	cacheRegistry(t, manager, "https", "https://new.example.com", "go", "1.0.0", nil)
+	oldKey, _ := manager.GetCacheKey("https", "https://old.example.com")
+	setLastAccessed(t, manager, oldKey, time.Now().Add(-48*time.Hour))
+
+	expired, err := manager.ExpiredEntries(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(expired) != 1 || expired[0].CacheKey != oldKey {
+		t.Errorf("Expected only the old registry to expire, got %v", expired)
+	}
+
+	size, _ := manager.GetCacheSize()
+	oversized, err := manager.EvictionCandidates(size-1, EvictionLRU)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(oversized) != 1 || oversized[0].CacheKey != oldKey {
+		t.Errorf("Expected the least recently accessed registry to be evicted, got %v", oversized)
+	}
+
+	if err := manager.CleanupOversized(size - 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 1 || entries[0].URL != "https://new.example.com" {
+		t.Errorf("Expected only the new registry to remain, got %v", entries)
+	}
+}
+
+func TestUnreferencedVersions(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		cacheRegistry(t, manager, "https", "https://rules.example.com", "go", version, nil)
+	}
+
+	storage := manager.GetRulesetStorage()
+	unreferenced, err := storage.UnreferencedVersions("https", "https://rules.example.com", "go", []string{"1.1.0"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(unreferenced, ",") != "1.0.0,2.0.0" {
+		t.Errorf("Expected 1.0.0 and 2.0.0 to be unreferenced, got %v", unreferenced)
+	}
+}
@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EvictionPolicy selects which registries are removed first when the cache exceeds its size limit
+type EvictionPolicy string
+
+const (
+	// EvictionLRU removes the least recently accessed registries first
+	EvictionLRU EvictionPolicy = "lru"
+	// EvictionLFU removes the least frequently accessed registries first
+	EvictionLFU EvictionPolicy = "lfu"
+)
+
+// ParseEvictionPolicy parses an eviction policy name, defaulting to LRU when empty
+func ParseEvictionPolicy(name string) (EvictionPolicy, error) {
+	switch EvictionPolicy(name) {
+	case "", EvictionLRU:
+		return EvictionLRU, nil
+	case EvictionLFU:
+		return EvictionLFU, nil
+	default:
+		return "", fmt.Errorf("unknown eviction policy %q (use lru or lfu)", name)
+	}
+}
+
+// MaintenanceOptions configures scheduled cache maintenance
+type MaintenanceOptions struct {
+	TTL      time.Duration  // Remove registries not accessed within TTL (0 = keep)
+	MaxSize  int64          // Evict registries until the cache fits (0 = unlimited)
+	Interval time.Duration  // Minimum time between runs (0 = every run)
+	Policy   EvictionPolicy // Order in which registries are evicted
+}
+
+// MaintenanceState is stored in maintenance.json at the cache root
+type MaintenanceState struct {
+	LastCleanup time.Time `json:"last_cleanup"`
+}
+
+// staleLockAge is how old a maintenance lock may get before another process takes over
+const staleLockAge = time.Hour
+
+var (
+	// inUseMu guards inUse and serializes entry removal against MarkInUse
+	inUseMu sync.Mutex
+	// inUse holds the registry cache paths used by this process, which are never removed
+	inUse = make(map[string]bool)
+	// background tracks maintenance running in the background
+	background sync.WaitGroup
+)
+
+// MarkInUse protects a registry's cache from removal for the rest of the process,
+// so background maintenance cannot evict content the current command relies on
+func (m *DefaultManager) MarkInUse(registryType, registryURL string) {
+	cachePath, err := m.GetCachePath(registryType, registryURL)
+	if err != nil {
+		return
+	}
+	inUseMu.Lock()
+	defer inUseMu.Unlock()
+	inUse[cachePath] = true
+}
+
+// LoadMaintenanceState returns the recorded maintenance state, zero if maintenance never ran
+func (m *DefaultManager) LoadMaintenanceState() (*MaintenanceState, error) {
+	data, err := os.ReadFile(m.maintenanceStatePath())
+	if os.IsNotExist(err) {
+		return &MaintenanceState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance state: %w", err)
+	}
+	var state MaintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance state: %w", err)
+	}
+	return &state, nil
+}
+
+// MaintenanceDue reports whether the interval has elapsed since the last maintenance run
+func (m *DefaultManager) MaintenanceDue(interval time.Duration) bool {
+	state, err := m.LoadMaintenanceState()
+	if err != nil {
+		return true // Unreadable state is rewritten by the next run
+	}
+	return time.Since(state.LastCleanup) >= interval
+}
+
+// RunMaintenance removes expired registries, evicts registries over the size
+// limit and collects unreferenced blobs, then records the run. It returns without doing anything when another
+// process holds the maintenance lock.
+func (m *DefaultManager) RunMaintenance(opts MaintenanceOptions) error {
+	unlock, err := m.lockMaintenance()
+	if err != nil {
+		return err
+	}
+	if unlock == nil {
+		return nil // Another process is running maintenance
+	}
+	defer unlock()
+
+	// Leftovers of removals interrupted by an exiting process
+	_ = os.RemoveAll(filepath.Join(m.cacheRoot, "temp", "removed"))
+
+	if err := m.CleanupExpired(opts.TTL); err != nil {
+		return fmt.Errorf("failed to remove expired entries: %w", err)
+	}
+	if err := m.Evict(opts.MaxSize, opts.Policy); err != nil {
+		return fmt.Errorf("failed to evict entries: %w", err)
+	}
+	if _, _, err := m.CollectGarbage(); err != nil {
+		return fmt.Errorf("failed to remove unreferenced blobs: %w", err)
+	}
+
+	return m.saveMaintenanceState(&MaintenanceState{LastCleanup: time.Now()})
+}
+
+// StartMaintenance runs maintenance in the background when the interval has
+// elapsed and reports whether it was started. Call WaitForMaintenance before
+// the process exits.
+func (m *DefaultManager) StartMaintenance(opts MaintenanceOptions) bool {
+	if !m.MaintenanceDue(opts.Interval) {
+		return false
+	}
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		_ = m.RunMaintenance(opts)
+	}()
+	return true
+}
+
+// WaitForMaintenance blocks until background maintenance has finished
+func WaitForMaintenance() {
+	background.Wait()
+}
+
+// Evict removes registries in policy order until the cache is under maxSize
+func (m *DefaultManager) Evict(maxSize int64, policy EvictionPolicy) error {
+	candidates, err := m.EvictionCandidates(maxSize, policy)
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range candidates {
+		m.removeEntry(mapping.CacheKey)
+	}
+
+	return nil
+}
+
+// EvictionCandidates returns the registry entries Evict would remove to bring
+// the cache under maxSize
+func (m *DefaultManager) EvictionCandidates(maxSize int64, policy EvictionPolicy) ([]RegistryMapping, error) {
+	if maxSize <= 0 {
+		return nil, nil // No size limit
+	}
+
+	currentSize, err := m.GetCacheSize()
+	if err != nil || currentSize <= maxSize {
+		return nil, err
+	}
+
+	mappings, err := m.mapper.ListMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	// Least recently accessed first; LFU orders by access count and breaks ties by recency
+	sort.SliceStable(mappings, func(i, j int) bool {
+		if policy == EvictionLFU && mappings[i].AccessCount != mappings[j].AccessCount {
+			return mappings[i].AccessCount < mappings[j].AccessCount
+		}
+		return mappings[i].LastAccessed.Before(mappings[j].LastAccessed)
+	})
+
+	// Select entries until under size limit
+	var candidates []RegistryMapping
+	for _, mapping := range mappings {
+		if currentSize <= maxSize {
+			break
+		}
+		cachePath := filepath.Join(m.cacheRoot, "registries", mapping.CacheKey)
+		if _, err := os.Stat(cachePath); err == nil {
+			currentSize -= getStoredSize(cachePath)
+			candidates = append(candidates, mapping)
+		}
+	}
+
+	return candidates, nil
+}
+
+// CollectGarbage removes blobs no version manifest references and returns the
+// number of blobs removed and their total size
+func (m *DefaultManager) CollectGarbage() (int, int64, error) {
+	unreferenced, err := m.UnreferencedBlobs()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var freed int64
+	for _, digest := range unreferenced {
+		path, _ := m.blobStore.Path(digest)
+		if info, err := os.Stat(path); err == nil {
+			freed += info.Size()
+		}
+		if err := m.blobStore.Remove(digest); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(unreferenced), freed, nil
+}
+
+// UnreferencedBlobs returns the blobs CollectGarbage would remove
+func (m *DefaultManager) UnreferencedBlobs() ([]string, error) {
+	referenced, err := m.referencedBlobs()
+	if err != nil {
+		return nil, err
+	}
+	return m.blobStore.UnreferencedBlobs(referenced)
+}
+
+// referencedBlobs returns the digests referenced by any version manifest
+func (m *DefaultManager) referencedBlobs() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	pattern := filepath.Join(m.cacheRoot, "registries", "*", "rulesets", "*", "*", ManifestFileName)
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find manifests: %w", err)
+	}
+	for _, path := range paths {
+		manifest, err := readManifest(filepath.Dir(path))
+		if err != nil {
+			// An unreadable manifest may reference any blob; keep them all
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for _, file := range manifest.Files {
+			referenced[file.SHA256] = true
+		}
+	}
+	return referenced, nil
+}
+
+// lockMaintenance takes the cross-process maintenance lock, returning a nil
+// release function when another process holds it
+func (m *DefaultManager) lockMaintenance() (func(), error) {
+	if err := os.MkdirAll(m.cacheRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	lockPath := filepath.Join(m.cacheRoot, "maintenance.lock")
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		info, statErr := os.Stat(lockPath)
+		if statErr != nil || time.Since(info.ModTime()) < staleLockAge {
+			return nil, nil
+		}
+		// Take over the lock of a process that died during maintenance
+		_ = os.Remove(lockPath)
+		file, err = os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create maintenance lock: %w", err)
+	}
+	_ = file.Close()
+
+	return func() { _ = os.Remove(lockPath) }, nil
+}
+
+// saveMaintenanceState writes maintenance.json
+func (m *DefaultManager) saveMaintenanceState(state *MaintenanceState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance state: %w", err)
+	}
+	if err := os.WriteFile(m.maintenanceStatePath(), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write maintenance state: %w", err)
+	}
+	return nil
+}
+
+// maintenanceStatePath returns the path of maintenance.json
+func (m *DefaultManager) maintenanceStatePath() string {
+	return filepath.Join(m.cacheRoot, "maintenance.json")
+}
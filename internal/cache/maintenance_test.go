@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunMaintenance_RespectsInterval(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://old.example.com", "go", "1.0.0", nil)
+	oldKey, _ := manager.GetCacheKey("https", "https://old.example.com")
+	setLastAccessed(t, manager, oldKey, time.Now().Add(-48*time.Hour))
+
+	if !manager.MaintenanceDue(time.Hour) {
+		t.Fatal("Expected maintenance to be due before the first run")
+	}
+	if err := manager.RunMaintenance(MaintenanceOptions{TTL: 24 * time.Hour, Interval: time.Hour}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 0 {
+		t.Errorf("Expected expired registry to be removed, got %v", entries)
+	}
+
+	state, err := manager.LoadMaintenanceState()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if time.Since(state.LastCleanup) > time.Minute {
+		t.Errorf("Expected last cleanup to be recorded, got %v", state.LastCleanup)
+	}
+	if manager.MaintenanceDue(time.Hour) {
+		t.Error("Expected maintenance not to be due within the interval")
+	}
+	if !manager.MaintenanceDue(0) {
+		t.Error("Expected a zero interval to run maintenance every time")
+	}
+}
+
+func TestRunMaintenance_SkipsWhileLocked(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://old.example.com", "go", "1.0.0", nil)
+	oldKey, _ := manager.GetCacheKey("https", "https://old.example.com")
+	setLastAccessed(t, manager, oldKey, time.Now().Add(-48*time.Hour))
+
+	lockPath := filepath.Join(manager.cacheRoot, "maintenance.lock")
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatalf("Failed to create lock: %v", err)
+	}
+	if err := manager.RunMaintenance(MaintenanceOptions{TTL: 24 * time.Hour}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 1 {
+		t.Errorf("Expected maintenance to be skipped while another process holds the lock, got %v", entries)
+	}
+
+	// A stale lock is taken over
+	stale := time.Now().Add(-2 * staleLockAge)
+	_ = os.Chtimes(lockPath, stale, stale)
+	if err := manager.RunMaintenance(MaintenanceOptions{TTL: 24 * time.Hour}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 0 {
+		t.Errorf("Expected stale lock to be taken over, got %v", entries)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("Expected lock to be released, got %v", err)
+	}
+}
+
+func TestStartMaintenance(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://old.example.com", "go", "1.0.0", nil)
+	oldKey, _ := manager.GetCacheKey("https", "https://old.example.com")
+	setLastAccessed(t, manager, oldKey, time.Now().Add(-48*time.Hour))
+
+	opts := MaintenanceOptions{TTL: 24 * time.Hour, Interval: time.Hour}
+	if !manager.StartMaintenance(opts) {
+		t.Fatal("Expected maintenance to start")
+	}
+	WaitForMaintenance()
+	if entries, _ := manager.List(); len(entries) != 0 {
+		t.Errorf("Expected expired registry to be removed, got %v", entries)
+	}
+	if manager.StartMaintenance(opts) {
+		t.Error("Expected maintenance not to start again within the interval")
+	}
+}
+
+func TestEvictionCandidates_Policies(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://frequent.example.com", "go", "1.0.0", nil)
+	cacheRegistry(t, manager, "https", "https://recent.example.com", "go", "1.0.0", nil)
+	frequentKey, _ := manager.GetCacheKey("https", "https://frequent.example.com")
+	recentKey, _ := manager.GetCacheKey("https", "https://recent.example.com")
+
+	// The frequent registry is used often but not lately
+	for i := 0; i < 5; i++ {
+		_ = manager.mapper.UpdateLastAccessed(frequentKey)
+	}
+	setLastAccessed(t, manager, frequentKey, time.Now().Add(-time.Hour))
+
+	size, _ := manager.GetCacheSize()
+	tests := []struct {
+		policy   EvictionPolicy
+		expected string
+	}{
+		{EvictionLRU, frequentKey},
+		{EvictionLFU, recentKey},
+	}
+	for _, tt := range tests {
+		candidates, err := manager.EvictionCandidates(size-1, tt.policy)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(candidates) != 1 || candidates[0].CacheKey != tt.expected {
+			t.Errorf("Expected %s to evict %s first, got %v", tt.policy, tt.expected, candidates)
+		}
+	}
+}
+
+func TestMarkInUse_ProtectsEntry(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://used.example.com", "go", "1.0.0", nil)
+	key, _ := manager.GetCacheKey("https", "https://used.example.com")
+	setLastAccessed(t, manager, key, time.Now().Add(-48*time.Hour))
+
+	manager.MarkInUse("https", "https://used.example.com")
+	if err := manager.CleanupExpired(24 * time.Hour); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 1 {
+		t.Errorf("Expected registry in use to be kept, got %v", entries)
+	}
+}
+
+func TestParseEvictionPolicy(t *testing.T) {
+	for input, expected := range map[string]EvictionPolicy{"": EvictionLRU, "lru": EvictionLRU, "lfu": EvictionLFU} {
+		policy, err := ParseEvictionPolicy(input)
+		if err != nil || policy != expected {
+			t.Errorf("ParseEvictionPolicy(%q) = %s, %v; expected %s", input, policy, err, expected)
+		}
+	}
+	if _, err := ParseEvictionPolicy("fifo"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
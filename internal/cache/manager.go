@@ -43,7 +43,7 @@ type Manager interface {
 	// CleanupExpired removes expired cache entries based on TTL
 	CleanupExpired(ttl time.Duration) error
 
-	// CleanupOversized removes oldest cache entries to stay under size limit
+	// CleanupOversized removes least recently accessed cache entries to stay under size limit
 	CleanupOversized(maxSize int64) error
 
 	// GetRulesetVersionPath returns the path for a specific ruleset version
@@ -63,6 +63,12 @@ type Manager interface {
 
 	// GetRulesetMapper returns the ruleset mapper for this cache
 	GetRulesetMapper() *RulesetMapper
+
+	// GetBlobStore returns the content-addressable blob store for this cache
+	GetBlobStore() *BlobStore
+
+	// MarkInUse protects a registry's cache from removal for the rest of the process
+	MarkInUse(registryType, registryURL string)
 }
 
 // CacheInfo represents metadata stored in cache-info.json
@@ -85,6 +91,7 @@ type DefaultManager struct {
 	rulesetMapper   *RulesetMapper
 	metadataManager *MetadataManager
 	rulesetStorage  *RulesetStorage
+	blobStore       *BlobStore
 }
 
 // NewManager creates a new cache manager with the specified cache root directory
@@ -94,6 +101,7 @@ func NewManager(cacheRoot string) *DefaultManager {
 		normalizer:    NewURLNormalizer(),
 		mapper:        NewRegistryMapper(cacheRoot),
 		rulesetMapper: NewRulesetMapper(cacheRoot),
+		blobStore:     NewBlobStore(cacheRoot),
 	}
 	manager.metadataManager = NewMetadataManager(manager)
 	manager.rulesetStorage = NewRulesetStorage(manager)
@@ -270,85 +278,92 @@ func (m *DefaultManager) IsCacheValid(registryType, registryURL string, ttl time
 	return time.Since(cacheInfo.LastUpdated) < ttl, nil
 }
 
-// GetCacheSize returns the total size of cache directory in bytes
+// GetCacheSize returns the total size of cache directory in bytes, including the blob store
 func (m *DefaultManager) GetCacheSize() (int64, error) {
-	cacheDir := filepath.Join(m.cacheRoot, "registries")
 	var totalSize int64
 
-	err := filepath.Walk(cacheDir, func(path string, info os.FileInfo, err error) error {
+	for _, dir := range []string{filepath.Join(m.cacheRoot, "registries"), m.blobStore.root} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip inaccessible files
+			}
+			if !info.IsDir() {
+				totalSize += info.Size()
+			}
+			return nil
+		})
 		if err != nil {
-			return nil // Skip inaccessible files
-		}
-		if !info.IsDir() {
-			totalSize += info.Size()
+			return 0, err
 		}
-		return nil
-	})
+	}
 
-	return totalSize, err
+	return totalSize, nil
 }
 
 // CleanupExpired removes expired cache entries based on TTL
 func (m *DefaultManager) CleanupExpired(ttl time.Duration) error {
-	if ttl <= 0 {
-		return nil // No cleanup if TTL is 0 or negative
-	}
-
-	mappings, err := m.mapper.ListMappings()
+	expired, err := m.ExpiredEntries(ttl)
 	if err != nil {
 		return err
 	}
 
-	for _, mapping := range mappings {
-		if time.Since(mapping.LastAccessed) > ttl {
-			cachePath := filepath.Join(m.cacheRoot, "registries", mapping.CacheKey)
-			_ = os.RemoveAll(cachePath)
-			_ = m.mapper.RemoveMapping(mapping.CacheKey)
-		}
+	for _, mapping := range expired {
+		m.removeEntry(mapping.CacheKey)
 	}
 
 	return nil
 }
 
-// CleanupOversized removes oldest cache entries to stay under size limit
-func (m *DefaultManager) CleanupOversized(maxSize int64) error {
-	if maxSize <= 0 {
-		return nil // No size limit
-	}
-
-	currentSize, err := m.GetCacheSize()
-	if err != nil || currentSize <= maxSize {
-		return err
+// ExpiredEntries returns the registry entries CleanupExpired would remove
+func (m *DefaultManager) ExpiredEntries(ttl time.Duration) ([]RegistryMapping, error) {
+	if ttl <= 0 {
+		return nil, nil // No cleanup if TTL is 0 or negative
 	}
 
 	mappings, err := m.mapper.ListMappings()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Sort by last accessed time (oldest first)
-	for i := 0; i < len(mappings)-1; i++ {
-		for j := i + 1; j < len(mappings); j++ {
-			if mappings[i].LastAccessed.After(mappings[j].LastAccessed) {
-				mappings[i], mappings[j] = mappings[j], mappings[i]
-			}
+	var expired []RegistryMapping
+	for _, mapping := range mappings {
+		if time.Since(mapping.LastAccessed) > ttl {
+			expired = append(expired, mapping)
 		}
 	}
 
-	// Remove oldest entries until under size limit
-	for _, mapping := range mappings {
-		if currentSize <= maxSize {
-			break
-		}
-		cachePath := filepath.Join(m.cacheRoot, "registries", mapping.CacheKey)
-		if _, err := os.Stat(cachePath); err == nil {
-			currentSize -= getDirSize(cachePath)
-			_ = os.RemoveAll(cachePath)
-			_ = m.mapper.RemoveMapping(mapping.CacheKey)
+	return expired, nil
+}
+
+// CleanupOversized removes least recently accessed cache entries to stay under size limit
+func (m *DefaultManager) CleanupOversized(maxSize int64) error {
+	return m.Evict(maxSize, EvictionLRU)
+}
+
+// removeEntry deletes a registry's cache directory and mappings unless this
+// process uses the registry. The directory is moved aside first so that an
+// interrupted removal never leaves a partial entry behind.
+func (m *DefaultManager) removeEntry(cacheKey string) {
+	registryPath := filepath.Join(m.cacheRoot, "registries", cacheKey)
+
+	inUseMu.Lock()
+	if inUse[registryPath] {
+		inUseMu.Unlock()
+		return
+	}
+	removedPath := filepath.Join(m.cacheRoot, "temp", "removed", fmt.Sprintf("%s-%d", cacheKey, time.Now().UnixNano()))
+	if err := os.MkdirAll(filepath.Dir(removedPath), 0o755); err != nil || os.Rename(registryPath, removedPath) != nil {
+		removedPath = registryPath
+	}
+	_ = m.mapper.RemoveMapping(cacheKey)
+	if rulesets, err := m.rulesetMapper.ListMappingsByRegistry(cacheKey); err == nil {
+		for _, ruleset := range rulesets {
+			_ = m.rulesetMapper.RemoveMapping(ruleset.CacheKey)
 		}
 	}
+	inUseMu.Unlock()
 
-	return nil
+	_ = os.RemoveAll(removedPath)
 }
 
 // getDirSize calculates the total size of a directory
@@ -363,6 +378,27 @@ func getDirSize(path string) int64 {
 	return size
 }
 
+// getStoredSize calculates the size of a cache directory including the blobs
+// its version manifests reference, which may be shared with other entries
+func getStoredSize(path string) int64 {
+	var size int64
+	_ = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		size += info.Size()
+		if info.Name() == ManifestFileName {
+			if manifest, err := readManifest(filepath.Dir(filePath)); err == nil {
+				for _, file := range manifest.Files {
+					size += file.Size
+				}
+			}
+		}
+		return nil
+	})
+	return size
+}
+
 // GetRulesetVersionPath returns the path for a specific ruleset version (legacy method)
 func (m *DefaultManager) GetRulesetVersionPath(registryType, registryURL, rulesetName, version string) (string, error) {
 	// Use empty patterns for backward compatibility
@@ -383,6 +419,11 @@ func (m *DefaultManager) GetRulesetStorage() *RulesetStorage {
 	return m.rulesetStorage
 }
 
+// GetBlobStore returns the content-addressable blob store for this cache
+func (m *DefaultManager) GetBlobStore() *BlobStore {
+	return m.blobStore
+}
+
 // GetRulesetMapper returns the ruleset mapper for this cache
 func (m *DefaultManager) GetRulesetMapper() *RulesetMapper {
 	return m.rulesetMapper
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -66,8 +67,8 @@ func (mm *MetadataManager) UpdateVersions(registryType, registryURL, rulesetName
 	versionsFile.Rulesets[rulesetName] = versions
 	versionsFile.CachedAt = time.Now()
 
-	// Update mappings for git registries
-	if registryType == "git" && mappings != nil {
+	// Update version spec mappings (git commits, resolved tarball versions)
+	if mappings != nil {
 		if versionsFile.Mappings == nil {
 			versionsFile.Mappings = make(map[string]map[string]string)
 		}
@@ -133,6 +134,26 @@ func (mm *MetadataManager) GetVersions(registryType, registryURL, rulesetName st
 	return versions, rulesetMappings, nil
 }
 
+// ListRulesets returns the names of rulesets with cached versions for a registry
+func (mm *MetadataManager) ListRulesets(registryType, registryURL string) ([]string, error) {
+	cachePath, err := mm.cacheManager.GetCachePath(registryType, registryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache path: %w", err)
+	}
+
+	versionsFile, err := mm.loadVersionsFile(filepath.Join(cachePath, "versions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load versions file: %w", err)
+	}
+
+	names := make([]string, 0, len(versionsFile.Rulesets))
+	for name := range versionsFile.Rulesets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // GetMetadata retrieves metadata for a ruleset from cache
 func (mm *MetadataManager) GetMetadata(registryType, registryURL, rulesetName string) (*RulesetMetadata, error) {
 	cachePath, err := mm.cacheManager.GetCachePath(registryType, registryURL)
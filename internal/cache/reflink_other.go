@@ -0,0 +1,8 @@
+//go:build !linux
+
+package cache
+
+// reflink is only implemented on Linux; other platforms copy instead
+func reflink(_, _ string) error {
+	return errReflinkUnsupported
+}
@@ -17,6 +17,7 @@ type RegistryMapping struct {
 	NormalizedURL string    `json:"normalized_url"`
 	CreatedAt     time.Time `json:"created_at"`
 	LastAccessed  time.Time `json:"last_accessed"`
+	AccessCount   int64     `json:"access_count,omitempty"`
 }
 
 // RegistryMapFile represents the structure of the registry mapping file
@@ -56,6 +57,7 @@ func (rm *RegistryMapper) AddMapping(cacheKey, registryType, registryURL, normal
 		NormalizedURL: normalizedURL,
 		CreatedAt:     now,
 		LastAccessed:  now,
+		AccessCount:   1,
 	}
 
 	// Update existing mapping or add new one
@@ -63,6 +65,7 @@ func (rm *RegistryMapper) AddMapping(cacheKey, registryType, registryURL, normal
 	for i, existing := range mapFile.Mappings {
 		if existing.CacheKey == cacheKey {
 			mapping.CreatedAt = existing.CreatedAt // Preserve original creation time
+			mapping.AccessCount = existing.AccessCount + 1
 			mapFile.Mappings[i] = mapping
 			found = true
 			break
@@ -127,6 +130,7 @@ func (rm *RegistryMapper) UpdateLastAccessed(cacheKey string) error {
 	for i, mapping := range mapFile.Mappings {
 		if mapping.CacheKey == cacheKey {
 			mapFile.Mappings[i].LastAccessed = time.Now()
+			mapFile.Mappings[i].AccessCount++
 			return rm.saveMapFile(mapFile)
 		}
 	}
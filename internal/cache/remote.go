@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
+)
+
+// The remote cache protocol serves the content-addressable cache over HTTP:
+//
+//	GET|HEAD /v1/blobs/sha256/<digest>                               blob content
+//	PUT      /v1/blobs/sha256/<digest>                               upload a blob
+//	GET      /v1/rulesets/<registryKey>/<rulesetKey>/<version>       VersionManifest
+//	PUT      /v1/rulesets/<registryKey>/<rulesetKey>/<version>       upload a RemoteManifest
+//
+// registryKey and rulesetKey are the keys of GetCacheKey and GetRulesetCacheKey,
+// so a version is addressed exactly as in the local cache. Uploads are optional
+// and a manifest is only accepted once the server holds all of its blobs.
+
+// ErrRemoteMiss is returned when the remote cache does not hold the requested content
+var ErrRemoteMiss = errors.New("not in remote cache")
+
+const (
+	// remoteAPIPrefix is the path prefix of protocol version 1
+	remoteAPIPrefix = "/v1"
+	// maxRemoteBlobSize limits the size of a single blob transferred
+	maxRemoteBlobSize = 64 << 20
+	// maxRemoteManifestSize limits the size of a manifest transferred
+	maxRemoteManifestSize = 8 << 20
+)
+
+// RemoteManifest is the body of a manifest upload. It names the registry and
+// ruleset the keys were derived from so the server can record the version
+// like a locally cached one.
+type RemoteManifest struct {
+	RegistryType string   `json:"registry_type"`
+	RegistryURL  string   `json:"registry_url"`
+	Ruleset      string   `json:"ruleset"`
+	Patterns     []string `json:"patterns,omitempty"`
+	VersionManifest
+}
+
+// RemoteClient talks to a remote cache server
+type RemoteClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewRemoteClient creates a client for the remote cache at baseURL, sending
+// token as a bearer token when set
+func NewRemoteClient(baseURL, token string) *RemoteClient {
+	return &RemoteClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Fetch downloads the manifest of a version and the blobs missing from blobs,
+// storing them, and returns the version's files keyed by path. Blobs are
+// verified against their digest before use.
+func (c *RemoteClient) Fetch(ctx context.Context, blobs *BlobStore, registryKey, rulesetKey, version string) (map[string][]byte, error) {
+	body, err := c.get(ctx, rulesetPath(registryKey, rulesetKey, version), maxRemoteManifestSize)
+	if err != nil {
+		return nil, err
+	}
+	var manifest VersionManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse remote manifest: %w", err)
+	}
+	if err := validateManifest(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid remote manifest: %w", err)
+	}
+
+	files := make(map[string][]byte, len(manifest.Files))
+	for _, file := range manifest.Files {
+		content, err := blobs.Get(file.SHA256)
+		if err != nil {
+			if content, err = c.fetchBlob(ctx, file.SHA256); err != nil {
+				return nil, fmt.Errorf("failed to fetch %s: %w", file.Path, err)
+			}
+			if _, err := blobs.Put(content); err != nil {
+				return nil, err
+			}
+		}
+		files[file.Path] = content
+	}
+	return files, nil
+}
+
+// Push uploads a locally cached version and the blobs the server does not hold yet
+func (c *RemoteClient) Push(ctx context.Context, manager Manager, registryType, registryURL, rulesetName, version string, patterns []string) error {
+	manifest, err := manager.GetRulesetStorage().GetManifest(registryType, registryURL, rulesetName, version, patterns)
+	if err != nil {
+		return fmt.Errorf("failed to read cached version: %w", err)
+	}
+
+	blobs := manager.GetBlobStore()
+	for _, file := range manifest.Files {
+		exists, err := c.hasBlob(ctx, file.SHA256)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		content, err := blobs.Get(file.SHA256)
+		if err != nil {
+			return err
+		}
+		if err := c.put(ctx, blobPath(file.SHA256), content); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", file.Path, err)
+		}
+	}
+
+	registryKey, err := manager.GetCacheKey(registryType, registryURL)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(&RemoteManifest{
+		RegistryType:    registryType,
+		RegistryURL:     registryURL,
+		Ruleset:         rulesetName,
+		Patterns:        patterns,
+		VersionManifest: *manifest,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := c.put(ctx, rulesetPath(registryKey, manager.GetRulesetCacheKey(rulesetName, patterns), version), body); err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
+
+// fetchBlob downloads a blob and checks it against its digest
+func (c *RemoteClient) fetchBlob(ctx context.Context, digest string) ([]byte, error) {
+	content, err := c.get(ctx, blobPath(digest), maxRemoteBlobSize)
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != digest {
+		return nil, fmt.Errorf("remote blob %s does not match its digest", digest)
+	}
+	return content, nil
+}
+
+// hasBlob reports whether the server holds a blob
+func (c *RemoteClient) hasBlob(ctx context.Context, digest string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, blobPath(digest), nil)
+	if err != nil {
+		return false, err
+	}
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("remote cache returned %s", resp.Status)
+	}
+}
+
+// get fetches a resource, returning ErrRemoteMiss when it does not exist
+func (c *RemoteClient) get(ctx context.Context, resource string, limit int64) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, resource, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrRemoteMiss
+	default:
+		return nil, fmt.Errorf("remote cache returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(progress.NewReader(ctx, resp.Body, resp.ContentLength), limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote cache response: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("remote cache response exceeds %d bytes", limit)
+	}
+	return body, nil
+}
+
+// put uploads a resource
+func (c *RemoteClient) put(ctx context.Context, resource string, body []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, resource, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote cache returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// do sends an authenticated request to the server
+func (c *RemoteClient) do(ctx context.Context, method, resource string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+resource, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote cache request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote cache request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// blobPath returns the protocol path of a blob
+func blobPath(digest string) string {
+	return remoteAPIPrefix + "/blobs/sha256/" + digest
+}
+
+// rulesetPath returns the protocol path of a version manifest
+func rulesetPath(registryKey, rulesetKey, version string) string {
+	return remoteAPIPrefix + "/rulesets/" + registryKey + "/" + rulesetKey + "/" + url.PathEscape(version)
+}
+
+// validateManifest rejects manifests with invalid digests or paths escaping the version directory
+func validateManifest(manifest *VersionManifest) error {
+	for _, file := range manifest.Files {
+		if !isDigest(file.SHA256) {
+			return fmt.Errorf("invalid digest %q for %s", file.SHA256, file.Path)
+		}
+		clean := path.Clean(file.Path)
+		if file.Path == "" || clean != file.Path || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(file.Path, `\`) {
+			return fmt.Errorf("invalid file path %q", file.Path)
+		}
+	}
+	return nil
+}
+
+// isDigest reports whether s is a hex SHA-256 digest or cache key
+func isDigest(s string) bool {
+	return len(s) == sha256.Size*2 && strings.Trim(s, "0123456789abcdef") == ""
+}
@@ -1,14 +1,33 @@
 package cache
 
 import (
+	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
 )
 
-// RulesetStorage handles storing individual files in the cache with ruleset/version structure
+// ManifestFileName is the file in a cached version directory listing its files
+const ManifestFileName = ".arm-manifest.json"
+
+// VersionManifest lists the files of a cached ruleset version and the blobs holding their content
+type VersionManifest struct {
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file of a cached ruleset version
+type ManifestFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// RulesetStorage handles storing individual files in the cache with ruleset/version structure.
+// File contents live in the blob store; each version directory holds a manifest referencing them.
 type RulesetStorage struct {
 	cacheManager Manager
 }
@@ -22,60 +41,114 @@ func NewRulesetStorage(cacheManager Manager) *RulesetStorage {
 
 // StoreRulesetFiles stores individual files for a ruleset version with patterns
 func (rs *RulesetStorage) StoreRulesetFiles(registryType, registryURL, rulesetName, version string, files map[string][]byte, patterns []string) error {
-	rulesetPath, err := rs.GetRulesetVersionPathWithPatterns(registryType, registryURL, rulesetName, version, patterns)
-	if err != nil {
-		return fmt.Errorf("failed to get ruleset path: %w", err)
+	blobs := rs.cacheManager.GetBlobStore()
+	manifest := &VersionManifest{Files: make([]ManifestFile, 0, len(files))}
+	for filename, content := range files {
+		digest, err := blobs.Put(content)
+		if err != nil {
+			return fmt.Errorf("failed to store file %s: %w", filename, err)
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:   rs.NormalizeFilePath(filename),
+			SHA256: digest,
+			Size:   int64(len(content)),
+		})
 	}
 
-	// Create the version directory
-	if err := os.MkdirAll(rulesetPath, 0o755); err != nil {
-		return fmt.Errorf("failed to create ruleset directory: %w", err)
+	return rs.writeManifest(registryType, registryURL, rulesetName, version, patterns, manifest)
+}
+
+// StoreRulesetFilesFromPaths copies files from source paths to cache with patterns
+func (rs *RulesetStorage) StoreRulesetFilesFromPaths(registryType, registryURL, rulesetName, version string, filePaths []string, sourceDir string, patterns []string) error {
+	blobs := rs.cacheManager.GetBlobStore()
+	manifest := &VersionManifest{Files: make([]ManifestFile, 0, len(filePaths))}
+	for _, filePath := range filePaths {
+		digest, size, err := blobs.PutFile(filepath.Join(sourceDir, filePath))
+		if err != nil {
+			return fmt.Errorf("failed to store file %s: %w", filePath, err)
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:   rs.NormalizeFilePath(filePath),
+			SHA256: digest,
+			Size:   size,
+		})
 	}
 
-	// Store each file
-	for filename, content := range files {
-		filePath := filepath.Join(rulesetPath, filename)
+	return rs.writeManifest(registryType, registryURL, rulesetName, version, patterns, manifest)
+}
 
-		// Create parent directories if needed
-		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
-			return fmt.Errorf("failed to create file directory: %w", err)
+// StoreManifest records a version whose file contents are already in the blob store
+func (rs *RulesetStorage) StoreManifest(registryType, registryURL, rulesetName, version string, manifest *VersionManifest, patterns []string) error {
+	blobs := rs.cacheManager.GetBlobStore()
+	for _, file := range manifest.Files {
+		if !blobs.Has(file.SHA256) {
+			return fmt.Errorf("content of %s is not in the blob store", file.Path)
 		}
+	}
+	return rs.writeManifest(registryType, registryURL, rulesetName, version, patterns, manifest)
+}
 
-		if err := os.WriteFile(filePath, content, 0o644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", filename, err)
-		}
+// GetManifest returns the manifest of a cached ruleset version. Versions cached
+// before the blob store hold plain files and have no manifest.
+func (rs *RulesetStorage) GetManifest(registryType, registryURL, rulesetName, version string, patterns []string) (*VersionManifest, error) {
+	rulesetPath, err := rs.GetRulesetVersionPathWithPatterns(registryType, registryURL, rulesetName, version, patterns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ruleset path: %w", err)
 	}
+	return readManifest(rulesetPath)
+}
 
-	return nil
+// LinkRulesetFiles places the files of a cached ruleset version into destDir
+// using the link mode and returns the number of files placed
+func (rs *RulesetStorage) LinkRulesetFiles(registryType, registryURL, rulesetName, version string, patterns []string, destDir string, mode LinkMode) (int, error) {
+	manifest, err := rs.GetManifest(registryType, registryURL, rulesetName, version, patterns)
+	if err != nil {
+		return 0, err
+	}
+
+	blobs := rs.cacheManager.GetBlobStore()
+	for _, file := range manifest.Files {
+		destPath := filepath.Join(destDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+			return 0, fmt.Errorf("failed to create file directory: %w", err)
+		}
+		if err := blobs.Link(file.SHA256, destPath, mode); err != nil {
+			return 0, fmt.Errorf("failed to place file %s: %w", file.Path, err)
+		}
+	}
+	return len(manifest.Files), nil
 }
 
-// StoreRulesetFilesFromPaths copies files from source paths to cache with patterns
-func (rs *RulesetStorage) StoreRulesetFilesFromPaths(registryType, registryURL, rulesetName, version string, filePaths []string, sourceDir string, patterns []string) error {
+// writeManifest replaces a version directory with one holding only the manifest
+func (rs *RulesetStorage) writeManifest(registryType, registryURL, rulesetName, version string, patterns []string, manifest *VersionManifest) error {
 	rulesetPath, err := rs.GetRulesetVersionPathWithPatterns(registryType, registryURL, rulesetName, version, patterns)
 	if err != nil {
 		return fmt.Errorf("failed to get ruleset path: %w", err)
 	}
 
-	// Create the version directory
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	// Drop plain files left by earlier cache layouts
+	if err := os.RemoveAll(rulesetPath); err != nil {
+		return fmt.Errorf("failed to clear ruleset directory: %w", err)
+	}
 	if err := os.MkdirAll(rulesetPath, 0o755); err != nil {
 		return fmt.Errorf("failed to create ruleset directory: %w", err)
 	}
 
-	// Copy each file
-	for _, filePath := range filePaths {
-		srcPath := filepath.Join(sourceDir, filePath)
-		destPath := filepath.Join(rulesetPath, filePath)
-
-		// Create parent directories if needed
-		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
-			return fmt.Errorf("failed to create file directory: %w", err)
-		}
-
-		if err := rs.copyFile(srcPath, destPath); err != nil {
-			return fmt.Errorf("failed to copy file %s: %w", filePath, err)
-		}
+	manifestPath := filepath.Join(rulesetPath, ManifestFileName)
+	tempPath := manifestPath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tempPath, manifestPath); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write manifest: %w", err)
 	}
-
 	return nil
 }
 
@@ -102,11 +175,28 @@ func (rs *RulesetStorage) GetRulesetFiles(registryType, registryURL, rulesetName
 	}
 
 	if _, err := os.Stat(rulesetPath); os.IsNotExist(err) {
+		logger.Debug("Cache miss: %s %s@%s", registryURL, rulesetName, version)
 		return nil, fmt.Errorf("ruleset version not found in cache")
 	}
+	logger.Debug("Cache hit: %s %s@%s", registryURL, rulesetName, version)
 
 	files := make(map[string][]byte)
 
+	if manifest, err := readManifest(rulesetPath); err == nil {
+		blobs := rs.cacheManager.GetBlobStore()
+		for _, file := range manifest.Files {
+			content, err := blobs.Get(file.SHA256)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", file.Path, err)
+			}
+			files[file.Path] = content
+		}
+		return files, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Versions cached before the blob store hold plain files
 	err = filepath.Walk(rulesetPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -193,36 +283,54 @@ func (rs *RulesetStorage) ListRulesets(registryType, registryURL string) ([]stri
 
 // GetRulesetStats returns file count and total size for a ruleset version with patterns
 func (rs *RulesetStorage) GetRulesetStats(registryType, registryURL, rulesetName, version string, patterns []string) (fileCount int, totalSize int64, err error) {
+	sizes, err := rs.ListRulesetFileSizes(registryType, registryURL, rulesetName, version, patterns)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, size := range sizes {
+		totalSize += size
+	}
+	return len(sizes), totalSize, nil
+}
+
+// ListRulesetFileSizes returns the size of each cached file of a ruleset version, keyed by relative path
+func (rs *RulesetStorage) ListRulesetFileSizes(registryType, registryURL, rulesetName, version string, patterns []string) (map[string]int64, error) {
 	rulesetPath, err := rs.GetRulesetVersionPathWithPatterns(registryType, registryURL, rulesetName, version, patterns)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get ruleset path: %w", err)
+		return nil, fmt.Errorf("failed to get ruleset path: %w", err)
 	}
 
 	if _, err := os.Stat(rulesetPath); os.IsNotExist(err) {
-		return 0, 0, fmt.Errorf("ruleset version not found in cache")
+		return nil, fmt.Errorf("ruleset version not found in cache")
 	}
 
-	var count int
-	var size int64
+	sizes := make(map[string]int64)
+	if manifest, err := readManifest(rulesetPath); err == nil {
+		for _, file := range manifest.Files {
+			sizes[file.Path] = file.Size
+		}
+		return sizes, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
 
 	err = filepath.Walk(rulesetPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+		if err != nil || info.IsDir() {
 			return err
 		}
-
-		if !info.IsDir() {
-			count++
-			size += info.Size()
+		relPath, err := filepath.Rel(rulesetPath, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
 		}
-
+		sizes[filepath.ToSlash(relPath)] = info.Size()
 		return nil
 	})
-
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to walk ruleset directory: %w", err)
+		return nil, fmt.Errorf("failed to walk ruleset directory: %w", err)
 	}
 
-	return count, size, nil
+	return sizes, nil
 }
 
 // RemoveRulesetVersion removes a specific version of a ruleset from cache with patterns
@@ -241,59 +349,43 @@ func (rs *RulesetStorage) RemoveRulesetVersion(registryType, registryURL, rulese
 
 // CleanupUnreferencedVersions removes version directories that are no longer referenced
 func (rs *RulesetStorage) CleanupUnreferencedVersions(registryType, registryURL, rulesetName string, referencedVersions, patterns []string) error {
-	cachedVersions, err := rs.ListRulesetVersions(registryType, registryURL, rulesetName, patterns)
+	unreferenced, err := rs.UnreferencedVersions(registryType, registryURL, rulesetName, referencedVersions, patterns)
 	if err != nil {
-		return fmt.Errorf("failed to list cached versions: %w", err)
-	}
-
-	// Create a set of referenced versions for quick lookup
-	referencedSet := make(map[string]bool)
-	for _, version := range referencedVersions {
-		referencedSet[version] = true
+		return err
 	}
 
 	// Remove unreferenced versions
-	for _, cachedVersion := range cachedVersions {
-		if !referencedSet[cachedVersion] {
-			if err := rs.RemoveRulesetVersion(registryType, registryURL, rulesetName, cachedVersion, patterns); err != nil {
-				// Log error but continue cleanup
-				continue
-			}
+	for _, cachedVersion := range unreferenced {
+		if err := rs.RemoveRulesetVersion(registryType, registryURL, rulesetName, cachedVersion, patterns); err != nil {
+			// Log error but continue cleanup
+			continue
 		}
 	}
 
 	return nil
 }
 
-// copyFile copies a file from src to dst
-func (rs *RulesetStorage) copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer func() { _ = srcFile.Close() }()
-
-	dstFile, err := os.Create(dst)
+// UnreferencedVersions returns the cached versions CleanupUnreferencedVersions would remove
+func (rs *RulesetStorage) UnreferencedVersions(registryType, registryURL, rulesetName string, referencedVersions, patterns []string) ([]string, error) {
+	cachedVersions, err := rs.ListRulesetVersions(registryType, registryURL, rulesetName, patterns)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return nil, fmt.Errorf("failed to list cached versions: %w", err)
 	}
-	defer func() { _ = dstFile.Close() }()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("failed to copy file content: %w", err)
-	}
-
-	// Copy file permissions
-	srcInfo, err := srcFile.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to get source file info: %w", err)
+	// Create a set of referenced versions for quick lookup
+	referencedSet := make(map[string]bool)
+	for _, version := range referencedVersions {
+		referencedSet[version] = true
 	}
 
-	if err := dstFile.Chmod(srcInfo.Mode()); err != nil {
-		return fmt.Errorf("failed to set file permissions: %w", err)
+	var unreferenced []string
+	for _, cachedVersion := range cachedVersions {
+		if !referencedSet[cachedVersion] {
+			unreferenced = append(unreferenced, cachedVersion)
+		}
 	}
 
-	return nil
+	return unreferenced, nil
 }
 
 // NormalizeFilePath normalizes file paths for consistent storage
@@ -306,3 +398,16 @@ func (rs *RulesetStorage) NormalizeFilePath(path string) string {
 
 	return normalized
 }
+
+// readManifest reads the manifest of a version directory
+func readManifest(versionPath string) (*VersionManifest, error) {
+	data, err := os.ReadFile(filepath.Join(versionPath, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest VersionManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
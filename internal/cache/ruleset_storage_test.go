@@ -41,12 +41,22 @@ func TestRulesetStorage_StoreAndRetrieveFiles(t *testing.T) {
 		t.Fatalf("Failed to get ruleset path: %v", err)
 	}
 
-	for filename := range files {
-		filePath := filepath.Join(rulesetPath, filename)
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			t.Errorf("File %s was not stored", filename)
+	if _, err := os.Stat(filepath.Join(rulesetPath, ManifestFileName)); err != nil {
+		t.Errorf("Version manifest was not written: %v", err)
+	}
+
+	manifest, err := rulesetStorage.GetManifest(registryType, registryURL, rulesetName, version, nil)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	for _, file := range manifest.Files {
+		if !cacheManager.GetBlobStore().Has(file.SHA256) {
+			t.Errorf("File %s was not stored in the blob store", file.Path)
 		}
 	}
+	if len(manifest.Files) != len(files) {
+		t.Errorf("Expected %d manifest entries, got %d", len(files), len(manifest.Files))
+	}
 
 	// Retrieve files
 	retrievedFiles, err := rulesetStorage.GetRulesetFiles(registryType, registryURL, rulesetName, version, nil)
@@ -161,6 +171,22 @@ func TestRulesetStorage_GetRulesetStats(t *testing.T) {
 	if totalSize != expectedTotalSize {
 		t.Errorf("Expected total size %d, got %d", expectedTotalSize, totalSize)
 	}
+
+	// Per-file sizes add up to the total
+	sizes, err := rulesetStorage.ListRulesetFileSizes(registryType, registryURL, rulesetName, version, nil)
+	if err != nil {
+		t.Fatalf("Failed to list file sizes: %v", err)
+	}
+	var sum int64
+	for path, size := range sizes {
+		if int64(len(files[path])) != size {
+			t.Errorf("Expected %s to be %d bytes, got %d", path, len(files[path]), size)
+		}
+		sum += size
+	}
+	if len(sizes) != expectedFileCount || sum != expectedTotalSize {
+		t.Errorf("Expected %d files totalling %d bytes, got %v", expectedFileCount, expectedTotalSize, sizes)
+	}
 }
 
 func TestRulesetStorage_RemoveRulesetVersion(t *testing.T) {
@@ -269,3 +295,29 @@ func TestRulesetStorage_CleanupUnreferencedVersions(t *testing.T) {
 		t.Errorf("Unreferenced version def456 was not removed")
 	}
 }
+
+func TestRulesetStorage_ReadsPlainFileVersions(t *testing.T) {
+	cacheManager := NewManager(t.TempDir())
+	rulesetStorage := NewRulesetStorage(cacheManager)
+
+	// Versions cached before the blob store hold the files themselves
+	versionPath, err := rulesetStorage.GetRulesetVersionPath("https", "https://rules.example.com", "go", "1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to get ruleset path: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(versionPath, "rules"), 0o755); err != nil {
+		t.Fatalf("Failed to create version directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(versionPath, "rules", "go.md"), []byte("go"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	files, err := rulesetStorage.GetRulesetFiles("https", "https://rules.example.com", "go", "1.0.0", nil)
+	if err != nil || string(files[filepath.Join("rules", "go.md")]) != "go" {
+		t.Errorf("Expected plain files to be read, got %v (%v)", files, err)
+	}
+	count, size, err := rulesetStorage.GetRulesetStats("https", "https://rules.example.com", "go", "1.0.0", nil)
+	if err != nil || count != 1 || size != 2 {
+		t.Errorf("Expected 1 file of 2 bytes, got %d files, %d bytes (%v)", count, size, err)
+	}
+}
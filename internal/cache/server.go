@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ServerOptions configures a remote cache server
+type ServerOptions struct {
+	Token       string // Bearer token required on every request (empty = no authentication)
+	AllowUpload bool   // Accept blob and manifest uploads from clients
+}
+
+// Server exposes a cache directory over the remote cache protocol
+type Server struct {
+	manager *DefaultManager
+	opts    ServerOptions
+	mux     *http.ServeMux
+}
+
+// NewServer creates a remote cache server for manager's cache directory
+func NewServer(manager *DefaultManager, opts ServerOptions) *Server {
+	s := &Server{manager: manager, opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET "+remoteAPIPrefix+"/blobs/sha256/{digest}", s.getBlob)
+	s.mux.HandleFunc("PUT "+remoteAPIPrefix+"/blobs/sha256/{digest}", s.putBlob)
+	s.mux.HandleFunc("GET "+remoteAPIPrefix+"/rulesets/{registryKey}/{rulesetKey}/{version}", s.getManifest)
+	s.mux.HandleFunc("PUT "+remoteAPIPrefix+"/rulesets/{registryKey}/{rulesetKey}/{version}", s.putManifest)
+	return s
+}
+
+// ServeHTTP authenticates the request and dispatches it
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Token != "" {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	if r.Method == http.MethodPut && !s.opts.AllowUpload {
+		http.Error(w, "uploads are disabled", http.StatusForbidden)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// getBlob serves blob content; HEAD requests are answered by the same handler
+func (s *Server) getBlob(w http.ResponseWriter, r *http.Request) {
+	blobPath, err := s.manager.blobStore.Path(r.PathValue("digest"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, err := os.Open(blobPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "failed to read blob", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
+
+// putBlob stores an uploaded blob after checking it against its digest
+func (s *Server) putBlob(w http.ResponseWriter, r *http.Request) {
+	digest := r.PathValue("digest")
+	if !isDigest(digest) {
+		http.Error(w, fmt.Sprintf("invalid blob digest %q", digest), http.StatusBadRequest)
+		return
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRemoteBlobSize))
+	if err != nil {
+		http.Error(w, "failed to read blob: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != digest {
+		http.Error(w, "content does not match digest", http.StatusBadRequest)
+		return
+	}
+	if _, err := s.manager.blobStore.Put(content); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// getManifest serves the manifest of a cached version
+func (s *Server) getManifest(w http.ResponseWriter, r *http.Request) {
+	versionPath, err := s.versionPath(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, err := os.Open(filepath.Join(versionPath, ManifestFileName))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "failed to read manifest", http.StatusInternalServerError)
+		return
+	}
+
+	// Served versions count as accessed for the server's own eviction
+	_ = s.manager.mapper.UpdateLastAccessed(r.PathValue("registryKey"))
+
+	w.Header().Set("Content-Type", "application/json")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
+
+// putManifest records an uploaded version once all its blobs are stored
+func (s *Server) putManifest(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.versionPath(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var upload RemoteManifest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRemoteManifestSize)).Decode(&upload); err != nil {
+		http.Error(w, "failed to parse manifest: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateManifest(&upload.VersionManifest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The keys in the path must be the ones the body's registry and ruleset produce
+	registryKey, _ := s.manager.GetCacheKey(upload.RegistryType, upload.RegistryURL)
+	rulesetKey := s.manager.GetRulesetCacheKey(upload.Ruleset, upload.Patterns)
+	if upload.RegistryType == "" || upload.Ruleset == "" || registryKey != r.PathValue("registryKey") || rulesetKey != r.PathValue("rulesetKey") {
+		http.Error(w, "manifest does not match the registry and ruleset keys", http.StatusBadRequest)
+		return
+	}
+
+	var missing []string
+	for _, file := range upload.Files {
+		if !s.manager.blobStore.Has(file.SHA256) {
+			missing = append(missing, file.SHA256)
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, "missing blobs: "+strings.Join(missing, ", "), http.StatusConflict)
+		return
+	}
+
+	version := r.PathValue("version")
+	if err := s.manager.EnsureCacheDir(upload.RegistryType, upload.RegistryURL); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.manager.rulesetStorage.StoreManifest(upload.RegistryType, upload.RegistryURL, upload.Ruleset, version, &upload.VersionManifest, upload.Patterns); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.manager.UpdateCacheInfo(upload.RegistryType, upload.RegistryURL, version); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// versionPath returns the version directory addressed by a request's keys
+func (s *Server) versionPath(r *http.Request) (string, error) {
+	registryKey := r.PathValue("registryKey")
+	rulesetKey := r.PathValue("rulesetKey")
+	version := r.PathValue("version")
+	if !isDigest(registryKey) || !isDigest(rulesetKey) {
+		return "", fmt.Errorf("invalid cache key")
+	}
+	if version == "" || strings.HasPrefix(version, ".") || strings.ContainsAny(version, `/\`) {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	return filepath.Join(s.manager.cacheRoot, "registries", registryKey, "rulesets", rulesetKey, version), nil
+}
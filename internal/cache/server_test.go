@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRemoteCache_PushAndFetch(t *testing.T) {
+	serverManager := NewManager(t.TempDir())
+	server := httptest.NewServer(NewServer(serverManager, ServerOptions{Token: "secret", AllowUpload: true}))
+	defer server.Close()
+
+	local := NewManager(t.TempDir())
+	cacheRegistry(t, local, "git", "https://github.com/org/rules", "python", "abc123", []string{"**/*.md"})
+
+	client := NewRemoteClient(server.URL, "secret")
+	if err := client.Push(context.Background(), local, "git", "https://github.com/org/rules", "python", "abc123", []string{"**/*.md"}); err != nil {
+		t.Fatalf("Expected push to succeed, got %v", err)
+	}
+	if entries, _ := serverManager.List(); len(entries) != 1 || entries[0].URL != "https://github.com/org/rules" {
+		t.Errorf("Expected the server to record the registry, got %v", entries)
+	}
+
+	other := NewManager(t.TempDir())
+	registryKey, _ := other.GetCacheKey("git", "https://github.com/org/rules")
+	rulesetKey := other.GetRulesetCacheKey("python", []string{"**/*.md"})
+	files, err := client.Fetch(context.Background(), other.GetBlobStore(), registryKey, rulesetKey, "abc123")
+	if err != nil {
+		t.Fatalf("Expected fetch to succeed, got %v", err)
+	}
+	if string(files["rules.md"]) != "python@abc123" {
+		t.Errorf("Expected fetched content, got %v", files)
+	}
+	if blobs, _ := other.GetBlobStore().List(); len(blobs) != 1 {
+		t.Errorf("Expected the fetched blob to be stored locally, got %v", blobs)
+	}
+
+	if _, err := client.Fetch(context.Background(), other.GetBlobStore(), registryKey, rulesetKey, "def456"); !errors.Is(err, ErrRemoteMiss) {
+		t.Errorf("Expected ErrRemoteMiss for an uncached version, got %v", err)
+	}
+}
+
+func TestRemoteCache_Authorization(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	cacheRegistry(t, manager, "https", "https://rules.example.com", "go", "1.0.0", nil)
+	server := httptest.NewServer(NewServer(manager, ServerOptions{Token: "secret"}))
+	defer server.Close()
+
+	registryKey, _ := manager.GetCacheKey("https", "https://rules.example.com")
+	rulesetKey := manager.GetRulesetCacheKey("go", nil)
+
+	if _, err := NewRemoteClient(server.URL, "wrong").Fetch(context.Background(), NewBlobStore(t.TempDir()), registryKey, rulesetKey, "1.0.0"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected unauthorized error, got %v", err)
+	}
+
+	err := NewRemoteClient(server.URL, "secret").Push(context.Background(), manager, "https", "https://rules.example.com", "go", "1.0.0", nil)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected uploads to be rejected without --allow-upload, got %v", err)
+	}
+}
+
+func TestRemoteCache_RejectsInvalidUploads(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	server := httptest.NewServer(NewServer(manager, ServerOptions{AllowUpload: true}))
+	defer server.Close()
+	client := NewRemoteClient(server.URL, "")
+
+	registryKey, _ := manager.GetCacheKey("https", "https://rules.example.com")
+	rulesetKey := manager.GetRulesetCacheKey("go", nil)
+	digest, _ := NewBlobStore(t.TempDir()).Put([]byte("rules"))
+
+	if err := client.put(context.Background(), blobPath(digest), []byte("other content")); err == nil {
+		t.Error("Expected a blob not matching its digest to be rejected")
+	}
+
+	manifest := `{"registry_type":"https","registry_url":"https://rules.example.com","ruleset":"go","files":[{"path":"rules.md","sha256":"` + digest + `","size":5}]}`
+	if err := client.put(context.Background(), rulesetPath(registryKey, rulesetKey, "1.0.0"), []byte(manifest)); err == nil || !strings.Contains(err.Error(), "missing blobs") {
+		t.Errorf("Expected a manifest with missing blobs to be rejected, got %v", err)
+	}
+	if err := client.put(context.Background(), rulesetPath(registryKey, manager.GetRulesetCacheKey("python", nil), "1.0.0"), []byte(manifest)); err == nil {
+		t.Error("Expected a manifest not matching its keys to be rejected")
+	}
+
+	escaping := strings.Replace(manifest, `"rules.md"`, `"../rules.md"`, 1)
+	if err := client.put(context.Background(), blobPath(digest), []byte("rules")); err != nil {
+		t.Fatalf("Expected blob upload to succeed, got %v", err)
+	}
+	if err := client.put(context.Background(), rulesetPath(registryKey, rulesetKey, "1.0.0"), []byte(escaping)); err == nil {
+		t.Error("Expected a manifest with an escaping path to be rejected")
+	}
+
+	resp, err := http.Get(server.URL + "/v1/rulesets/" + registryKey + "/" + rulesetKey + "/..")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("Expected an invalid version to be rejected")
+	}
+}
@@ -0,0 +1,677 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// newCacheCommand creates the cache command group
+func newCacheCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local registry cache",
+	}
+
+	lsCmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List cached registries and rulesets",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			return handleCacheList(cmd.Context(), jsonOutput)
+		},
+	}
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove expired, oversized or unreferenced cache entries",
+		Long: `Remove cache entries by policy. Without flags, the configured cache TTL and
+maximum size apply, evicting by the configured eviction policy. --unreferenced removes cached versions of locked rulesets
+other than the version recorded in arm.lock.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := cachePruneOptions{}
+			opts.TTL, _ = cmd.Flags().GetDuration("ttl")
+			opts.MaxSize, _ = cmd.Flags().GetInt64("max-size")
+			opts.Unreferenced, _ = cmd.Flags().GetBool("unreferenced")
+			opts.Policy, _ = cmd.Flags().GetString("policy")
+			opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.Configured = !cmd.Flags().Changed("ttl") && !cmd.Flags().Changed("max-size") && !opts.Unreferenced
+			return handleCachePrune(cmd.Context(), opts)
+		},
+	}
+	pruneCmd.Flags().Duration("ttl", 0, "Remove registries not accessed within this duration")
+	pruneCmd.Flags().Int64("max-size", 0, "Remove registries in eviction policy order until the cache is below this size in bytes")
+	pruneCmd.Flags().String("policy", "", "Eviction policy for --max-size: lru or lfu (default from configuration)")
+	pruneCmd.Flags().Bool("unreferenced", false, "Remove cached versions not locked in arm.lock")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the cache for inconsistent or corrupted entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fix, _ := cmd.Flags().GetBool("fix")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			return handleCacheVerify(cmd.Context(), fix, jsonOutput)
+		},
+	}
+	verifyCmd.Flags().Bool("fix", false, "Remove the entries that fail verification")
+
+	clearCmd := &cobra.Command{
+		Use:   "clear [registry...]",
+		Short: "Remove the cached content of registries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			force, _ := cmd.Flags().GetBool("force")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return handleCacheClear(cmd.Context(), args, all, force, dryRun)
+		},
+	}
+	clearCmd.Flags().Bool("all", false, "Clear the entire cache")
+	clearCmd.Flags().Bool("force", false, "Skip confirmation prompts")
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a cache directory as a shared remote cache",
+		Long: `Expose a cache directory over HTTP so that other ARM installations can use it
+as a remote cache with [cache] remote = <url>. Clients only download content the
+server holds; with --allow-upload they also push content they fetched from
+origin registries.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{noTimeoutAnnotation: "true", pathFlagsAnnotation: "dir"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := cacheServeOptions{}
+			opts.Addr, _ = cmd.Flags().GetString("addr")
+			opts.Dir, _ = cmd.Flags().GetString("dir")
+			opts.Token, _ = cmd.Flags().GetString("token")
+			if opts.Token == "" {
+				opts.Token = os.Getenv("ARM_CACHE_TOKEN")
+			}
+			opts.AllowUpload, _ = cmd.Flags().GetBool("allow-upload")
+			return handleCacheServe(cmd.Context(), opts)
+		},
+	}
+	serveCmd.Flags().String("addr", ":7878", "Address to listen on")
+	serveCmd.Flags().String("dir", "", "Cache directory to serve (default: the configured cache path)")
+	serveCmd.Flags().String("token", "", "Bearer token clients must send (default: $ARM_CACHE_TOKEN)")
+	serveCmd.Flags().Bool("allow-upload", false, "Accept content uploaded by clients")
+
+	cmd.AddCommand(lsCmd, pruneCmd, verifyCmd, clearCmd, serveCmd)
+	return cmd
+}
+
+// startCacheMaintenance starts background cache maintenance when the configured
+// cleanup interval has elapsed. It runs after a successful command, whose
+// registries are then marked in use. The cache commands manage the cache
+// explicitly and offline mode keeps everything, so neither triggers it.
+func startCacheMaintenance(cmd *cobra.Command, cfg *config.Config) {
+	if cfg == nil || cfg.CacheConfig == nil || cfg.Offline() {
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "cache" || c.Name() == "clean" {
+			return
+		}
+	}
+
+	policy, err := cache.ParseEvictionPolicy(cfg.CacheConfig.EvictionPolicy)
+	if err != nil {
+		policy = cache.EvictionLRU
+	}
+	cache.NewManager(cfg.CacheConfig.Path).StartMaintenance(cache.MaintenanceOptions{
+		TTL:      cfg.CacheConfig.TTL,
+		MaxSize:  cfg.CacheConfig.MaxSize,
+		Interval: cfg.CacheConfig.CleanupInterval,
+		Policy:   policy,
+	})
+}
+
+// cacheListEntry is a cached registry with the configured registry name it belongs to
+type cacheListEntry struct {
+	Name string `json:"name,omitempty"`
+	cache.RegistryEntry
+}
+
+// cachePruneOptions selects the policies applied by arm cache prune
+type cachePruneOptions struct {
+	TTL          time.Duration
+	MaxSize      int64
+	Unreferenced bool
+	Policy       string
+	Configured   bool // Apply the configured TTL and maximum size
+	DryRun       bool
+}
+
+// cacheIssue is a cache problem reported by arm cache verify
+type cacheIssue struct {
+	Registry string `json:"registry,omitempty"`
+	Path     string `json:"path"`
+	Issue    string `json:"issue"`
+	repair   func() error
+}
+
+func handleCacheList(ctx context.Context, jsonOutput bool) error {
+	out := textOutput(ctx)
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	manager := cache.NewManager(cfg.CacheConfig.Path)
+	entries, err := manager.List()
+	if err != nil {
+		return fmt.Errorf("failed to list cache: %w", err)
+	}
+
+	names := cachedRegistryNames(cfg, manager)
+	total, _ := manager.GetCacheSize()
+	listed := make([]cacheListEntry, 0, len(entries))
+	for _, entry := range entries {
+		listed = append(listed, cacheListEntry{Name: names[entry.CacheKey], RegistryEntry: entry})
+	}
+
+	setResult(ctx, map[string]interface{}{
+		"path":       cfg.CacheConfig.Path,
+		"size":       total,
+		"registries": listed,
+	})
+	if jsonOutput {
+		return nil
+	}
+
+	fmt.Fprintf(out, "Cache: %s (%s)\n", cfg.CacheConfig.Path, formatSize(total))
+	if len(listed) == 0 {
+		fmt.Fprintln(out, "\nCache is empty")
+		return nil
+	}
+
+	for _, entry := range listed {
+		name := entry.Name
+		if name == "" {
+			name = "(unconfigured)"
+		}
+		location := strings.TrimSpace(entry.Type + " " + entry.URL)
+		if location == "" {
+			location = "unmapped " + entry.CacheKey
+		}
+		fmt.Fprintf(out, "\n%s (%s) - %s, last access %s\n", name, location, formatSize(entry.Size), formatTime(entry.LastAccessed))
+		if len(entry.Rulesets) == 0 {
+			continue
+		}
+
+		writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "  RULESET\tPATTERNS\tVERSIONS\tSIZE\tLAST ACCESS")
+		for _, ruleset := range entry.Rulesets {
+			versions := make([]string, 0, len(ruleset.Versions))
+			for _, version := range ruleset.Versions {
+				versions = append(versions, shortRevision(version))
+			}
+			_, _ = fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\t%s\n",
+				valueOrDash(ruleset.Name), valueOrDash(strings.Join(ruleset.Patterns, ", ")),
+				valueOrDash(strings.Join(versions, ", ")), formatSize(ruleset.Size), formatTime(ruleset.LastAccessed))
+		}
+		_ = writer.Flush()
+	}
+	return nil
+}
+
+// cachePruneOutput is the result of arm cache prune in JSON output
+type cachePruneOutput struct {
+	DryRun  bool          `json:"dry_run"`
+	Removed []prunedEntry `json:"removed"`
+	Blobs   int           `json:"blobs"`
+	Freed   int64         `json:"freed,omitempty"`
+	Size    int64         `json:"size,omitempty"`
+}
+
+// prunedEntry is a cached registry or ruleset version removed by arm cache prune
+type prunedEntry struct {
+	Target string `json:"target"`
+	Reason string `json:"reason"` // expired, evicted or unreferenced
+}
+
+func handleCachePrune(ctx context.Context, opts cachePruneOptions) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if opts.Configured {
+		opts.TTL = cfg.CacheConfig.TTL
+		opts.MaxSize = cfg.CacheConfig.MaxSize
+	}
+	if opts.Policy == "" {
+		opts.Policy = cfg.CacheConfig.EvictionPolicy
+	}
+	policy, err := cache.ParseEvictionPolicy(opts.Policy)
+	if err != nil {
+		return err
+	}
+
+	manager := cache.NewManager(cfg.CacheConfig.Path)
+	names := cachedRegistryNames(cfg, manager)
+	sizeBefore, _ := manager.GetCacheSize()
+	verb := "Removed"
+	if opts.DryRun {
+		verb = "Would remove"
+	}
+	output := &cachePruneOutput{DryRun: opts.DryRun, Removed: []prunedEntry{}}
+	setResult(ctx, output)
+
+	expired, err := manager.ExpiredEntries(opts.TTL)
+	if err != nil {
+		return fmt.Errorf("failed to find expired entries: %w", err)
+	}
+	for _, mapping := range expired {
+		output.Removed = append(output.Removed, prunedEntry{Target: cacheEntryName(names, mapping), Reason: "expired"})
+		logger.Info("%s %s: not accessed since %s", verb, cacheEntryName(names, mapping), formatTime(mapping.LastAccessed))
+	}
+	if !opts.DryRun {
+		if err := manager.CleanupExpired(opts.TTL); err != nil {
+			return fmt.Errorf("failed to remove expired entries: %w", err)
+		}
+	}
+
+	oversized, err := manager.EvictionCandidates(opts.MaxSize, policy)
+	if err != nil {
+		return fmt.Errorf("failed to find oversized entries: %w", err)
+	}
+	for _, mapping := range oversized {
+		output.Removed = append(output.Removed, prunedEntry{Target: cacheEntryName(names, mapping), Reason: "evicted"})
+		logger.Info("%s %s: cache exceeds %s (%s)", verb, cacheEntryName(names, mapping), formatSize(opts.MaxSize), policy)
+	}
+	if !opts.DryRun {
+		if err := manager.Evict(opts.MaxSize, policy); err != nil {
+			return fmt.Errorf("failed to remove oversized entries: %w", err)
+		}
+	}
+
+	if opts.Unreferenced {
+		// Versions locked by either scope are referenced
+		configs, err := loadScopes(ctx, config.Scopes(false, false))
+		if err != nil {
+			return err
+		}
+		unreferenced, err := pruneUnreferenced(configs, manager, verb, opts.DryRun)
+		if err != nil {
+			return err
+		}
+		output.Removed = append(output.Removed, unreferenced...)
+	}
+
+	// Blobs are shared between versions and removed once nothing references them
+	if opts.DryRun {
+		blobs, err := manager.UnreferencedBlobs()
+		if err != nil {
+			return fmt.Errorf("failed to find unreferenced blobs: %w", err)
+		}
+		output.Blobs = len(blobs)
+		if len(blobs) > 0 {
+			logger.Info("%s %d unreferenced blob(s)", verb, len(blobs))
+		}
+	} else {
+		count, freed, err := manager.CollectGarbage()
+		if err != nil {
+			return fmt.Errorf("failed to remove unreferenced blobs: %w", err)
+		}
+		output.Blobs = count
+		if count > 0 {
+			logger.Info("%s %d unreferenced blob(s), %s", verb, count, formatSize(freed))
+		}
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	sizeAfter, _ := manager.GetCacheSize()
+	output.Freed, output.Size = sizeBefore-sizeAfter, sizeAfter
+	logger.Success("Freed %s, cache is now %s", formatSize(sizeBefore-sizeAfter), formatSize(sizeAfter))
+	return nil
+}
+
+// cachedLock is a cached ruleset with the versions locked for it by any scope
+type cachedLock struct {
+	registry     string
+	registryType string
+	registryURL  string
+	name         string
+	patterns     []string
+	versions     []string
+}
+
+// cachedLocks groups the locked versions of every configuration by the
+// cache location of their ruleset, in lock file order
+func cachedLocks(configs []*config.Config) []*cachedLock {
+	var rulesets []*cachedLock
+	byLocation := make(map[string]*cachedLock)
+	for _, cfg := range configs {
+		if cfg.LockFile == nil {
+			continue
+		}
+		for _, registryName := range sortedKeys(cfg.LockFile.Rulesets) {
+			registryType := cfg.RegistryConfigs[registryName]["type"]
+			registryURL := cfg.Registries[registryName]
+			for _, name := range sortedKeys(cfg.LockFile.Rulesets[registryName]) {
+				var patterns []string
+				if registryType == "git" {
+					patterns = cfg.Rulesets[registryName][name].Patterns
+				}
+				location := strings.Join([]string{registryType, registryURL, name, strings.Join(patterns, ",")}, "\x00")
+				ruleset := byLocation[location]
+				if ruleset == nil {
+					ruleset = &cachedLock{registry: registryName, registryType: registryType, registryURL: registryURL, name: name, patterns: patterns}
+					byLocation[location] = ruleset
+					rulesets = append(rulesets, ruleset)
+				}
+				if resolved := cfg.LockFile.Rulesets[registryName][name].Resolved; !contains(ruleset.versions, resolved) {
+					ruleset.versions = append(ruleset.versions, resolved)
+				}
+			}
+		}
+	}
+	return rulesets
+}
+
+// pruneUnreferenced removes cached versions of locked rulesets that no lock
+// file references and returns what it removed
+func pruneUnreferenced(configs []*config.Config, manager *cache.DefaultManager, verb string, dryRun bool) ([]prunedEntry, error) {
+	cached := make(map[string]bool)
+	entries, err := manager.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache: %w", err)
+	}
+	for _, entry := range entries {
+		cached[entry.CacheKey] = true
+	}
+
+	var pruned []prunedEntry
+	storage := manager.GetRulesetStorage()
+	for _, ruleset := range cachedLocks(configs) {
+		if key, err := manager.GetCacheKey(ruleset.registryType, ruleset.registryURL); err != nil || !cached[key] {
+			continue // Never cached, avoid creating mappings for it
+		}
+
+		registryName, name := ruleset.registry, ruleset.name
+		unreferenced, err := storage.UnreferencedVersions(ruleset.registryType, ruleset.registryURL, name, ruleset.versions, ruleset.patterns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find unreferenced versions of %s/%s: %w", registryName, name, err)
+		}
+		for _, version := range unreferenced {
+			pruned = append(pruned, prunedEntry{Target: fmt.Sprintf("%s/%s@%s", registryName, name, version), Reason: "unreferenced"})
+			logger.Info("%s %s/%s@%s: not locked", verb, registryName, name, shortRevision(version))
+		}
+		if dryRun {
+			continue
+		}
+		if err := storage.CleanupUnreferencedVersions(ruleset.registryType, ruleset.registryURL, name, ruleset.versions, ruleset.patterns); err != nil {
+			return nil, fmt.Errorf("failed to remove unreferenced versions of %s/%s: %w", registryName, name, err)
+		}
+	}
+	return pruned, nil
+}
+
+func handleCacheVerify(ctx context.Context, fix, jsonOutput bool) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	manager := cache.NewManager(cfg.CacheConfig.Path)
+	problems, err := manager.Verify()
+	if err != nil {
+		return fmt.Errorf("failed to verify cache: %w", err)
+	}
+
+	names := cachedRegistryNames(cfg, manager)
+	issues := make([]cacheIssue, 0, len(problems))
+	for _, problem := range problems {
+		issues = append(issues, cacheIssue{Registry: names[problem.CacheKey], Path: problem.Path, Issue: problem.Issue, repair: problem.Repair})
+	}
+	configs, err := loadScopes(ctx, config.Scopes(false, false))
+	if err != nil {
+		return err
+	}
+	issues = append(issues, lockIntegrityIssues(configs, manager)...)
+
+	repaired := 0
+	if fix {
+		for _, issue := range issues {
+			if err := issue.repair(); err != nil {
+				return fmt.Errorf("failed to repair %s: %w", issue.Path, err)
+			}
+			repaired++
+		}
+	}
+
+	setResult(ctx, map[string]interface{}{
+		"issues":   issues,
+		"repaired": repaired,
+	})
+	if !jsonOutput {
+		for _, issue := range issues {
+			prefix := ""
+			if issue.Registry != "" {
+				prefix = issue.Registry + ": "
+			}
+			logger.Error("%s%s (%s)", prefix, issue.Issue, issue.Path)
+		}
+		switch {
+		case len(issues) == 0:
+			logger.Success("Cache verified, no problems found")
+		case fix:
+			logger.Success("Repaired %d problem(s)", repaired)
+		}
+	}
+
+	if len(issues) > 0 && !fix {
+		return fmt.Errorf("cache verification found %d problem(s); run 'arm cache verify --fix' to repair", len(issues))
+	}
+	return nil
+}
+
+// lockIntegrityIssues reports cached versions of locked rulesets whose files no
+// longer match the integrity recorded in the lock file of any scope
+func lockIntegrityIssues(configs []*config.Config, manager *cache.DefaultManager) []cacheIssue {
+	var issues []cacheIssue
+	reported := make(map[string]bool)
+	storage := manager.GetRulesetStorage()
+	for _, cfg := range configs {
+		if cfg.LockFile == nil {
+			continue
+		}
+		for _, registryName := range sortedKeys(cfg.LockFile.Rulesets) {
+			registryType := cfg.RegistryConfigs[registryName]["type"]
+			registryURL := cfg.Registries[registryName]
+			for _, name := range sortedKeys(cfg.LockFile.Rulesets[registryName]) {
+				locked := cfg.LockFile.Rulesets[registryName][name]
+				if locked.Integrity == "" {
+					continue
+				}
+				var patterns []string
+				if registryType == "git" {
+					patterns = cfg.Rulesets[registryName][name].Patterns
+				}
+
+				versions, err := storage.ListRulesetVersions(registryType, registryURL, name, patterns)
+				if err != nil || !contains(versions, locked.Resolved) {
+					continue
+				}
+				versionPath, err := storage.GetRulesetVersionPathWithPatterns(registryType, registryURL, name, locked.Resolved, patterns)
+				if err != nil || reported[versionPath] {
+					continue
+				}
+				files, err := storage.GetRulesetFiles(registryType, registryURL, name, locked.Resolved, patterns)
+				if err != nil {
+					continue // Missing or corrupted blobs are reported by the cache itself
+				}
+				if registry.ComputeFilesIntegrity(files) != locked.Integrity {
+					reported[versionPath] = true
+					resolved := locked.Resolved
+					issues = append(issues, cacheIssue{
+						Registry: registryName,
+						Path:     versionPath,
+						Issue:    fmt.Sprintf("cached %s@%s does not match the %s integrity", name, shortRevision(resolved), cfg.LockPath()),
+						repair: func() error {
+							return storage.RemoveRulesetVersion(registryType, registryURL, name, resolved, patterns)
+						},
+					})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// cacheServeOptions holds the flags of arm cache serve
+type cacheServeOptions struct {
+	Addr        string
+	Dir         string
+	Token       string
+	AllowUpload bool
+}
+
+func handleCacheServe(ctx context.Context, opts cacheServeOptions) error {
+	if opts.Dir == "" {
+		cfg, err := loadConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		opts.Dir = cfg.CacheConfig.Path
+	}
+
+	server := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           cache.NewServer(cache.NewManager(opts.Dir), cache.ServerOptions{Token: opts.Token, AllowUpload: opts.AllowUpload}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// The root context is canceled on Ctrl-C or SIGTERM
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	mode := "read-only"
+	if opts.AllowUpload {
+		mode = "uploads enabled"
+	}
+	logger.Info("Serving cache %s on %s (%s)", opts.Dir, opts.Addr, mode)
+	if opts.Token == "" {
+		warn(ctx, "", "no token set; any client can read the cache")
+	}
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("cache server failed: %w", err)
+	}
+	return nil
+}
+
+func handleCacheClear(ctx context.Context, registries []string, all, force, dryRun bool) error {
+	out := textOutput(ctx)
+	if len(registries) == 0 && !all {
+		return fmt.Errorf("specify the registries to clear, or --all to clear the entire cache")
+	}
+	if len(registries) > 0 && all {
+		return fmt.Errorf("cannot combine registry names with --all")
+	}
+
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	manager := cache.NewManager(cfg.CacheConfig.Path)
+
+	for _, name := range registries {
+		if _, exists := cfg.Registries[name]; !exists {
+			return registryNotFoundError(name)
+		}
+	}
+
+	target := strings.Join(registries, ", ")
+	if all {
+		target = "all registries"
+	}
+	setResult(ctx, map[string]interface{}{"target": target, "dry_run": dryRun})
+	if dryRun {
+		logger.Info("Would clear the cache of %s", target)
+		return nil
+	}
+	if !force {
+		fmt.Fprintf(out, "This will clear the cache of %s. Continue? (y/N): ", target)
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
+			logger.Info("Operation cancelled")
+			return nil
+		}
+	}
+
+	if all {
+		if err := manager.Clear(); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	for _, name := range registries {
+		if err := manager.RemoveRegistry(cfg.RegistryConfigs[name]["type"], cfg.Registries[name]); err != nil {
+			return fmt.Errorf("failed to clear cache of %s: %w", name, err)
+		}
+	}
+
+	logger.Success("Cleared the cache of %s", target)
+	return nil
+}
+
+// cachedRegistryNames maps cache keys to the names of the configured registries
+func cachedRegistryNames(cfg *config.Config, manager cache.Manager) map[string]string {
+	names := make(map[string]string)
+	for name, url := range cfg.Registries {
+		if key, err := manager.GetCacheKey(cfg.RegistryConfigs[name]["type"], url); err == nil {
+			names[key] = name
+		}
+	}
+	return names
+}
+
+// cacheEntryName returns a display name for a cached registry
+func cacheEntryName(names map[string]string, mapping cache.RegistryMapping) string {
+	if name, exists := names[mapping.CacheKey]; exists {
+		return name
+	}
+	return mapping.RegistryURL
+}
+
+// formatSize formats a byte count for display
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// formatTime formats a timestamp for display, or "-" when unknown
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+func TestHandleCache(t *testing.T) {
+	tempDir := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	url := "https://rules.example.com"
+	manager := cache.NewManager(filepath.Join(home, ".arm", "cache"))
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		if err := manager.UpdateCacheInfo("https", url, version); err != nil {
+			t.Fatalf("Failed to update cache info: %v", err)
+		}
+		files := map[string][]byte{"rules.md": []byte(version)}
+		if err := manager.GetRulesetStorage().StoreRulesetFiles("https", url, "rules", version, files, nil); err != nil {
+			t.Fatalf("Failed to seed cache: %v", err)
+		}
+	}
+	versionPath, _ := manager.GetRulesetStorage().GetRulesetVersionPath("https", url, "rules", "1.1.0")
+	integrity := registry.ComputeFilesIntegrity(map[string][]byte{"rules.md": []byte("1.1.0")})
+
+	armrc := fmt.Sprintf("[registries]\nteam = %s\n\n[registries.team]\ntype = https\n", url)
+	lock := fmt.Sprintf(`{"rulesets":{"team":{"rules":{"version":"^1.0.0","resolved":"1.1.0","registry":"","type":"https","integrity":%q}}}}`, integrity)
+	_ = os.WriteFile(".armrc", []byte(armrc), 0o600)
+	_ = os.WriteFile("arm.json", []byte(`{"engines":{"arm":"^1.0.0"},"channels":{},"rulesets":{}}`), 0o600)
+	_ = os.WriteFile("arm.lock", []byte(lock), 0o600)
+
+	var listed struct {
+		Registries []cacheListEntry `json:"registries"`
+	}
+	if doc := captureJSON(t, "cache list", &listed, func(ctx context.Context) error {
+		return handleCacheList(ctx, true)
+	}); !doc.Success {
+		t.Fatalf("Expected success, got %+v", doc.Errors)
+	}
+	if len(listed.Registries) != 1 || listed.Registries[0].Name != "team" {
+		t.Fatalf("Expected cached registry team, got %+v", listed.Registries)
+	}
+	if versions := listed.Registries[0].Rulesets[0].Versions; strings.Join(versions, ",") != "1.0.0,1.1.0" {
+		t.Errorf("Expected both cached versions, got %v", versions)
+	}
+
+	// Only the locked version survives an unreferenced prune
+	if err := handleCachePrune(context.Background(), cachePruneOptions{Unreferenced: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	versions, _ := manager.GetRulesetStorage().ListRulesetVersions("https", url, "rules", nil)
+	if strings.Join(versions, ",") != "1.1.0" {
+		t.Errorf("Expected only the locked version to remain, got %v", versions)
+	}
+
+	if err := handleCacheVerify(context.Background(), false, false); err != nil {
+		t.Fatalf("Expected healthy cache, got %v", err)
+	}
+
+	// A locked version whose content differs from arm.lock fails verification
+	_ = os.WriteFile("arm.lock", []byte(strings.Replace(lock, integrity, "sha256-other", 1)), 0o600)
+	if err := handleCacheVerify(context.Background(), false, false); err == nil {
+		t.Fatal("Expected integrity mismatch to fail verification")
+	}
+	_ = os.WriteFile("arm.lock", []byte(lock), 0o600)
+
+	// So does a blob modified in place, for example through a hardlinked install
+	manifest, err := manager.GetRulesetStorage().GetManifest("https", url, "rules", "1.1.0", nil)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	blobPath, _ := manager.GetBlobStore().Path(manifest.Files[0].SHA256)
+	_ = os.Chmod(blobPath, 0o644)
+	_ = os.WriteFile(blobPath, []byte("tampered"), 0o644)
+	if err := handleCacheVerify(context.Background(), false, false); err == nil {
+		t.Fatal("Expected tampered cache to fail verification")
+	}
+	if err := handleCacheVerify(context.Background(), true, false); err != nil {
+		t.Fatalf("Expected repair to succeed, got %v", err)
+	}
+	if _, err := os.Stat(versionPath); !os.IsNotExist(err) {
+		t.Errorf("Expected tampered version to be removed, got %v", err)
+	}
+
+	if err := handleCacheClear(context.Background(), []string{"missing"}, false, true, false); err == nil {
+		t.Error("Expected error for unknown registry")
+	}
+	if err := handleCacheClear(context.Background(), []string{"team"}, false, true, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := manager.List(); len(entries) != 0 {
+		t.Errorf("Expected empty cache, got %+v", entries)
+	}
+}
+
+func TestCacheMaintenanceStartsAfterCommand(t *testing.T) {
+	cacheDir := t.TempDir()
+	statePath := filepath.Join(cacheDir, "maintenance.json")
+	cfg := &config.Config{CacheConfig: &config.CacheConfig{Path: cacheDir}}
+
+	// Maintenance must not run while the command is still marking registries in use
+	root := NewRootCommand(cfg, &VersionInfo{})
+	root.AddCommand(&cobra.Command{
+		Use: "probe",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache.WaitForMaintenance()
+			if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+				t.Errorf("Expected maintenance not to have run during the command, got %v", err)
+			}
+			return nil
+		},
+	})
+	root.SetArgs([]string{"probe"})
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	cache.WaitForMaintenance()
+
+	if _, err := os.Stat(statePath); err != nil {
+		t.Errorf("Expected maintenance to run after the command, got %v", err)
+	}
+}
@@ -4,25 +4,171 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 	"github.com/max-dunn/ai-rules-manager/internal/cache"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
 	"github.com/max-dunn/ai-rules-manager/internal/install"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 	"github.com/max-dunn/ai-rules-manager/internal/registry"
-	"github.com/max-dunn/ai-rules-manager/internal/update"
 	"github.com/spf13/cobra"
-	"gopkg.in/ini.v1"
 )
 
 // VersionInfo contains build version information
 type VersionInfo struct {
-	Version   string
-	Commit    string
-	BuildTime string
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// pathFlagsAnnotation lists the flags of a command, comma-separated, whose
+// values are paths relative to the directory arm was started in
+const pathFlagsAnnotation = "arm/path-flags"
+
+// workingDirKey is the context key of the directory arm was started in
+type workingDirKey struct{}
+
+// WithWorkingDir returns a context recording the directory arm was started
+// in, before changing to the project root, against which relative path flags
+// resolve
+func WithWorkingDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, workingDirKey{}, dir)
+}
+
+// resolvePathFlags makes the relative values of the path flags of cmd
+// absolute, taking them from the directory arm was started in
+func resolvePathFlags(cmd *cobra.Command) error {
+	dir, ok := cmd.Context().Value(workingDirKey{}).(string)
+	if !ok || cmd.Annotations[pathFlagsAnnotation] == "" {
+		return nil
+	}
+	for _, name := range strings.Split(cmd.Annotations[pathFlagsAnnotation], ",") {
+		path, err := cmd.Flags().GetString(name)
+		if err != nil || path == "" || filepath.IsAbs(path) {
+			continue
+		}
+		if err := cmd.Flags().Set(name, filepath.Join(dir, path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// optionsKey is the context key of the configuration options of the running
+// command
+type optionsKey struct{}
+
+// newConfigOptions returns the options set by --offline, --workspace and
+// --profile, which default to ARM_OFFLINE, ARM_WORKSPACE and ARM_PROFILE
+func newConfigOptions(cmd *cobra.Command) config.Options {
+	flags := cmd.Flags()
+	opts := config.OptionsFromEnv()
+	if flags.Changed("offline") {
+		offline, _ := flags.GetBool("offline")
+		opts.Offline = &offline
+	}
+	if flags.Changed("workspace") {
+		opts.Workspaces, _ = flags.GetStringSlice("workspace")
+	}
+	// config add registry keeps a deprecated --profile for the AWS profile
+	if flags.Changed("profile") && cmd.LocalNonPersistentFlags().Lookup("profile") == nil {
+		opts.Profile, _ = flags.GetString("profile")
+	}
+	return opts
+}
+
+// configOptions returns the configuration options of the command running
+// with ctx, those of the environment when none are set
+func configOptions(ctx context.Context) config.Options {
+	if opts, ok := ctx.Value(optionsKey{}).(config.Options); ok {
+		return opts
+	}
+	return config.OptionsFromEnv()
+}
+
+// loadConfig loads the merged configuration with the options of the command
+func loadConfig(ctx context.Context) (*config.Config, error) {
+	return config.Load(configOptions(ctx))
+}
+
+// reloadConfig reloads the configuration loaded before flags were parsed
+// with the options they set
+func reloadConfig(cfg *config.Config, opts config.Options) error {
+	loaded, err := config.Load(opts)
+	if err != nil {
+		return err
+	}
+	*cfg = *loaded
+	if opts.Profile != "" {
+		logger.Debug("Profile: %s", opts.Profile)
+	}
+	return nil
+}
+
+// configureLogger applies --quiet, --verbose and --no-color
+func configureLogger(cmd *cobra.Command) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if quiet && verbose {
+		return armerr.New(armerr.Config, "--quiet and --verbose cannot be used together")
+	}
+
+	level := logger.LevelNormal
+	switch {
+	case quiet:
+		level = logger.LevelQuiet
+	case verbose:
+		level = logger.LevelVerbose
+	}
+	// Human-readable output goes to standard error in JSON mode
+	logger.Configure(level, logger.ColorSupported(os.Stdout, noColor || jsonOutput))
+	if jsonOutput {
+		logger.SetOutput(os.Stderr)
+	} else {
+		logger.SetOutput(nil)
+	}
+	return nil
+}
+
+// errNoRegistries is returned by commands that need at least one registry
+var errNoRegistries = armerr.New(armerr.Config, "no registries configured").
+	WithHint("Add a registry with 'arm config add registry <name> <url> --type=<type>'")
+
+// registryNotFoundError reports a registry missing from the configuration
+func registryNotFoundError(name string) error {
+	return armerr.Errorf(armerr.Config, "registry '%s' not found", name).
+		WithHint(fmt.Sprintf("Add it with 'arm config add registry %s <url> --type=<type>' or check 'arm config list'", name))
+}
+
+// prepareCommand applies the global flags before a command runs: output
+// levels, path flags and the options of configuration loading
+func prepareCommand(cmd *cobra.Command, cfg *config.Config) error {
+	if err := configureLogger(cmd); err != nil {
+		return err
+	}
+	if root, err := os.Getwd(); err == nil {
+		logger.Debug("Project root: %s", root)
+	}
+	if err := resolvePathFlags(cmd); err != nil {
+		return err
+	}
+	opts := newConfigOptions(cmd)
+	cmd.SetContext(context.WithValue(cmd.Context(), optionsKey{}, opts))
+	flags := cmd.Flags()
+	if cfg != nil && (flags.Changed("offline") || flags.Changed("workspace") || flags.Changed("profile")) {
+		return reloadConfig(cfg, opts)
+	}
+	return nil
 }
 
 // NewRootCommand creates the root ARM command
@@ -33,17 +179,36 @@ func NewRootCommand(cfg *config.Config, versionInfo *VersionInfo) *cobra.Command
 		Long: `ARM is a package manager for AI coding assistant rulesets that enables
 developers and teams to install, update, and manage coding rules across
 different AI tools like Cursor and Amazon Q Developer.`,
-		SilenceUsage: true,
+		SilenceUsage:  true,
+		SilenceErrors: true, // Reported by main along with a hint and exit code
 	}
 
 	// Add global flags
 	rootCmd.PersistentFlags().Bool("global", false, "Operate on global configuration")
-	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress non-essential output")
-	rootCmd.PersistentFlags().Bool("verbose", false, "Show detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Print only errors and requested data")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Show HTTP requests, git operations, cache activity and timings")
 	rootCmd.PersistentFlags().Bool("dry-run", false, "Show what would be done without executing")
 	rootCmd.PersistentFlags().Bool("json", false, "Output machine-readable JSON format")
-	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output (also off with NO_COLOR or when not a terminal)")
 	rootCmd.PersistentFlags().Bool("insecure", false, "Allow insecure HTTP connections")
+	rootCmd.PersistentFlags().Bool("offline", false, "Serve registries from the cache only, without network access")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort the command after this duration, e.g. 10m (default: commandTimeout from .armrc)")
+	rootCmd.PersistentFlags().StringSlice("workspace", nil, "Operate on these workspace members, '.' for the root project (default: all for install, list, outdated and update)")
+	rootCmd.PersistentFlags().String("profile", "", "Apply this .armrc profile, such as ci, over the configuration (default: ARM_PROFILE)")
+
+	// Offline mode, workspaces and profiles apply wherever the command loads
+	// configuration; failures before the command runs are reported with --json too
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return failWithJSONOutput(cmd, prepareCommand(cmd, cfg))
+	}
+	rootCmd.SetFlagErrorFunc(failWithJSONOutput)
+
+	// Maintenance starts once the command has created its registries, which
+	// marks their caches in use so eviction cannot remove them
+	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		startCacheMaintenance(cmd, cfg)
+		return nil
+	}
 
 	// Add subcommands
 	rootCmd.AddCommand(newConfigCommand(cfg))
@@ -53,147 +218,25 @@ different AI tools like Cursor and Amazon Q Developer.`,
 	rootCmd.AddCommand(newInfoCommand(cfg))
 	rootCmd.AddCommand(newOutdatedCommand(cfg))
 	rootCmd.AddCommand(newUpdateCommand(cfg))
+	rootCmd.AddCommand(newDiffCommand(cfg))
 	rootCmd.AddCommand(newCleanCommand(cfg))
 	rootCmd.AddCommand(newListCommand(cfg))
+	rootCmd.AddCommand(newLoginCommand(cfg))
+	rootCmd.AddCommand(newLogoutCommand(cfg))
+	rootCmd.AddCommand(newMirrorCommand(cfg))
+	rootCmd.AddCommand(newCacheCommand(cfg))
 	rootCmd.AddCommand(newVersionCommand(versionInfo))
 
-	return rootCmd
-}
-
-// newConfigCommand creates the config command
-func newConfigCommand(_ *config.Config) *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "config",
-		Short: "Manage ARM configuration",
-		Long:  "Configure registries, channels, and other ARM settings",
-	}
-
-	// Set command
-	setCmd := &cobra.Command{
-		Use:   "set <key> <value>",
-		Short: "Set configuration value",
-		Args:  cobra.ExactArgs(2),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			return handleConfigSet(args[0], args[1], global)
-		},
-	}
-	cmd.AddCommand(setCmd)
-
-	// Get command
-	getCmd := &cobra.Command{
-		Use:   "get <key>",
-		Short: "Get configuration value",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			return handleConfigGet(args[0], global)
-		},
-	}
-	cmd.AddCommand(getCmd)
-
-	// List command
-	listCmd := &cobra.Command{
-		Use:   "list",
-		Short: "List configuration",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			return handleConfigList(global)
-		},
-	}
-	cmd.AddCommand(listCmd)
-
-	// Add command
-	addCmd := &cobra.Command{
-		Use:   "add",
-		Short: "Add registry or channel",
-	}
-
-	// Add registry subcommand
-	addRegistryCmd := &cobra.Command{
-		Use:   "registry <name> <value>",
-		Short: "Add registry",
-		Args:  cobra.ExactArgs(2),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			registryType, _ := cmd.Flags().GetString("type")
-			authToken, _ := cmd.Flags().GetString("authToken")
-			region, _ := cmd.Flags().GetString("region")
-			profile, _ := cmd.Flags().GetString("profile")
-			prefix, _ := cmd.Flags().GetString("prefix")
-			apiType, _ := cmd.Flags().GetString("apiType")
-			apiVersion, _ := cmd.Flags().GetString("apiVersion")
-
-			return handleAddRegistry(args[0], args[1], registryType, global, map[string]string{
-				"authToken":  authToken,
-				"region":     region,
-				"profile":    profile,
-				"prefix":     prefix,
-				"apiType":    apiType,
-				"apiVersion": apiVersion,
-			})
-		},
-	}
-	addRegistryCmd.Flags().String("type", "", "Registry type (required)")
-	addRegistryCmd.Flags().String("authToken", "", "Authentication token")
-	addRegistryCmd.Flags().String("region", "", "AWS region (for S3 registries)")
-	addRegistryCmd.Flags().String("profile", "", "AWS profile (for S3 registries)")
-	addRegistryCmd.Flags().String("prefix", "", "Path prefix")
-	addRegistryCmd.Flags().String("apiType", "", "API type (for Git registries)")
-	addRegistryCmd.Flags().String("apiVersion", "", "API version")
-	_ = addRegistryCmd.MarkFlagRequired("type")
-	addCmd.AddCommand(addRegistryCmd)
-
-	// Add channel subcommand
-	addChannelCmd := &cobra.Command{
-		Use:   "channel <name>",
-		Short: "Add channel",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			directories, _ := cmd.Flags().GetString("directories")
-			return handleAddChannel(args[0], directories, global)
-		},
-	}
-	addChannelCmd.Flags().String("directories", "", "Comma-separated list of directories (required)")
-	_ = addChannelCmd.MarkFlagRequired("directories")
-	addCmd.AddCommand(addChannelCmd)
-
-	cmd.AddCommand(addCmd)
-
-	// Remove command
-	removeCmd := &cobra.Command{
-		Use:   "remove",
-		Short: "Remove registry or channel",
-	}
-
-	// Remove registry subcommand
-	removeRegistryCmd := &cobra.Command{
-		Use:   "registry <name>",
-		Short: "Remove registry",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			return handleRemoveRegistry(args[0], global)
-		},
-	}
-	removeCmd.AddCommand(removeRegistryCmd)
+	// Every command reports the progress of downloads and rulesets
+	enableProgress(rootCmd)
 
-	// Remove channel subcommand
-	removeChannelCmd := &cobra.Command{
-		Use:   "channel <name>",
-		Short: "Remove channel",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			return handleRemoveChannel(args[0], global)
-		},
-	}
-	removeCmd.AddCommand(removeChannelCmd)
+	// Every command runs with a context canceled on timeout, Ctrl-C or SIGTERM
+	enableTimeouts(rootCmd, cfg)
 
-	cmd.AddCommand(removeCmd)
+	// Every command prints a versioned JSON document with --json
+	enableJSONOutput(rootCmd)
 
-	return cmd
+	return rootCmd
 }
 
 // newInstallCommand creates the install command
@@ -209,9 +252,9 @@ func newInstallCommand(_ *config.Config) *cobra.Command {
 			patterns, _ := cmd.Flags().GetString("patterns")
 
 			if len(args) == 0 {
-				return handleInstallFromManifest(global, dryRun, channels)
+				return handleInstallFromManifest(cmd.Context(), global, dryRun, channels)
 			} else {
-				return handleInstallRuleset(args[0], global, dryRun, channels, patterns)
+				return handleInstallRuleset(cmd.Context(), args[0], global, dryRun, channels, patterns)
 			}
 		},
 	}
@@ -232,7 +275,7 @@ func newUninstallCommand(_ *config.Config) *cobra.Command {
 			global, _ := cmd.Flags().GetBool("global")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			channels, _ := cmd.Flags().GetString("channels")
-			return handleUninstall(args[0], global, dryRun, channels)
+			return handleUninstall(cmd.Context(), args[0], global, dryRun, channels)
 		},
 	}
 
@@ -251,7 +294,7 @@ func newSearchCommand(_ *config.Config) *cobra.Command {
 			registries, _ := cmd.Flags().GetString("registries")
 			jsonOutput, _ := cmd.Flags().GetBool("json")
 			limit, _ := cmd.Flags().GetInt("limit")
-			return handleSearch(args[0], registries, jsonOutput, limit)
+			return handleSearch(cmd.Context(), args[0], registries, jsonOutput, limit)
 		},
 	}
 
@@ -270,7 +313,7 @@ func newInfoCommand(_ *config.Config) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			jsonOutput, _ := cmd.Flags().GetBool("json")
 			versions, _ := cmd.Flags().GetBool("versions")
-			return handleInfo(args[0], jsonOutput, versions)
+			return handleInfo(cmd.Context(), args[0], jsonOutput, versions)
 		},
 	}
 
@@ -279,46 +322,12 @@ func newInfoCommand(_ *config.Config) *cobra.Command {
 	return cmd
 }
 
-// newOutdatedCommand creates the outdated command
-func newOutdatedCommand(_ *config.Config) *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "outdated",
-		Short: "Show outdated rulesets",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			jsonOutput, _ := cmd.Flags().GetBool("json")
-			return handleOutdated(global, jsonOutput)
-		},
-	}
-
-	return cmd
-}
-
-// newUpdateCommand creates the update command
-func newUpdateCommand(_ *config.Config) *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "update [ruleset-name]",
-		Short: "Update rulesets",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			global, _ := cmd.Flags().GetBool("global")
-			dryRun, _ := cmd.Flags().GetBool("dry-run")
-			if len(args) == 0 {
-				return handleUpdateAll(global, dryRun)
-			} else {
-				return handleUpdateRuleset(args[0], global, dryRun)
-			}
-		},
-	}
-
-	return cmd
-}
-
 // newCleanCommand creates the clean command
 func newCleanCommand(_ *config.Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "clean [target]",
 		Short: "Clean unused rulesets",
-		Long:  "Clean unused rulesets. Targets: cache (no-op), unused, all",
+		Long:  "Clean unused rulesets and the registry cache. Targets: cache, unused, all",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			global, _ := cmd.Flags().GetBool("global")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
@@ -329,7 +338,7 @@ func newCleanCommand(_ *config.Config) *cobra.Command {
 				target = args[0]
 			}
 
-			return handleClean(target, global, dryRun, force)
+			return handleClean(cmd.Context(), target, global, dryRun, force)
 		},
 	}
 
@@ -348,7 +357,7 @@ func newListCommand(_ *config.Config) *cobra.Command {
 			local, _ := cmd.Flags().GetBool("local")
 			jsonOutput, _ := cmd.Flags().GetBool("json")
 			channels, _ := cmd.Flags().GetString("channels")
-			return handleList(global, local, jsonOutput, channels)
+			return handleList(cmd.Context(), global, local, jsonOutput, channels)
 		},
 	}
 
@@ -364,324 +373,213 @@ func newVersionCommand(versionInfo *VersionInfo) *cobra.Command {
 		Use:   "version",
 		Short: "Show ARM version",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Printf("ARM version %s\n", versionInfo.Version)
-			fmt.Printf("Commit: %s\n", versionInfo.Commit)
-			fmt.Printf("Built: %s\n", versionInfo.BuildTime)
+			setResult(cmd.Context(), versionInfo)
+			out := textOutput(cmd.Context())
+			fmt.Fprintf(out, "ARM version %s\n", versionInfo.Version)
+			fmt.Fprintf(out, "Commit: %s\n", versionInfo.Commit)
+			fmt.Fprintf(out, "Built: %s\n", versionInfo.BuildTime)
 			return nil
 		},
 	}
 }
 
-// Config command handlers
-
-func handleConfigSet(key, value string, global bool) error {
-	parts := strings.Split(key, ".")
-	if len(parts) < 2 {
-		return fmt.Errorf("invalid key format. Use section.key (e.g., git.concurrency)")
-	}
-
-	path := getConfigPath(".armrc", global)
-	cfg, err := loadOrCreateINI(path)
-	if err != nil {
-		return err
-	}
-
-	section := cfg.Section(parts[0])
-	section.Key(parts[1]).SetValue(value)
+// Install command handlers
 
-	return cfg.SaveTo(path)
+// installOutput is the result of arm install in JSON output
+type installOutput struct {
+	DryRun   bool               `json:"dry_run"`
+	Scope    config.Scope       `json:"scope"`
+	Rulesets []installedRuleset `json:"rulesets"`
 }
 
-func handleConfigGet(key string, _ bool) error {
-	cfg, err := config.Load()
-	if err != nil {
-		return err
-	}
-
-	value := getConfigValue(cfg, key)
-	if value == "" {
-		return fmt.Errorf("key '%s' not found", key)
-	}
-
-	fmt.Println(value)
-	return nil
+// installedRuleset is a ruleset installed, or to be installed, by arm install
+type installedRuleset struct {
+	Workspace string   `json:"workspace,omitempty"` // Workspace member, empty for the root project
+	Registry  string   `json:"registry"`
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Resolved  string   `json:"resolved,omitempty"`
+	Integrity string   `json:"integrity,omitempty"`
+	Source    string   `json:"source,omitempty"` // Mirror that served the files
+	Patterns  []string `json:"patterns,omitempty"`
+	Files     int      `json:"files"`
+	Channels  []string `json:"channels"`
 }
 
-func handleConfigList(_ bool) error {
-	cfg, err := config.Load()
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("[registries]")
-	for name, url := range cfg.Registries {
-		fmt.Printf("%s = %s\n", name, url)
+// recordInstalled adds an installed ruleset to the JSON output of arm install
+func recordInstalled(ctx context.Context, installed installedRuleset) {
+	report := outputOf(ctx).report
+	if report == nil {
+		return
 	}
-
-	for name, regConfig := range cfg.RegistryConfigs {
-		fmt.Printf("\n[registries.%s]\n", name)
-		for key, value := range regConfig {
-			fmt.Printf("%s = %s\n", key, value)
-		}
-	}
-
-	for typeName, typeConfig := range cfg.TypeDefaults {
-		fmt.Printf("\n[%s]\n", typeName)
-		for key, value := range typeConfig {
-			fmt.Printf("%s = %s\n", key, value)
-		}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	output, ok := report.result.(*installOutput)
+	if !ok {
+		output = &installOutput{}
+		report.result = output
 	}
-
-	if len(cfg.NetworkConfig) > 0 {
-		fmt.Println("\n[network]")
-		for key, value := range cfg.NetworkConfig {
-			fmt.Printf("%s = %s\n", key, value)
-		}
+	if installed.Channels == nil {
+		installed.Channels = []string{}
 	}
-
-	return nil
+	output.Rulesets = append(output.Rulesets, installed)
 }
 
-func handleAddRegistry(name, url, registryType string, global bool, options map[string]string) error {
-	if registryType == "" {
-		return fmt.Errorf("registry type is required")
-	}
-
-	// Validate git-local registry path immediately
-	if registryType == "git-local" {
-		if url == "" {
-			return fmt.Errorf("git-local registry requires a local path")
-		}
-
-		// Resolve and validate the path
-		resolvedPath, err := config.ResolvePath(url)
-		if err != nil {
-			return fmt.Errorf("invalid path for git-local registry: %w", err)
-		}
-
-		// Check if it's a Git repository
-		gitDir := filepath.Join(resolvedPath, ".git")
-		if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-			return fmt.Errorf("path is not a Git repository (no .git directory found): %s", resolvedPath)
-		}
-
-		// Use the resolved path for storage
-		url = resolvedPath
-	}
-
-	// Add to registries section
-	path := getConfigPath(".armrc", global)
-	cfg, err := loadOrCreateINI(path)
-	if err != nil {
-		return err
-	}
-
-	cfg.Section("registries").Key(name).SetValue(url)
-
-	// Add registry config section
-	sectionName := fmt.Sprintf("registries.%s", name)
-	section := cfg.Section(sectionName)
-	section.Key("type").SetValue(registryType)
-
-	// Add optional parameters
-	for key, value := range options {
-		if value != "" {
-			section.Key(key).SetValue(value)
-		}
-	}
-
-	return cfg.SaveTo(path)
-}
+func handleInstallFromManifest(ctx context.Context, global, dryRun bool, channels string) error {
+	scope := config.ScopeFor(global)
+	setResult(ctx, &installOutput{DryRun: dryRun, Scope: scope, Rulesets: []installedRuleset{}})
 
-func handleRemoveRegistry(name string, global bool) error {
-	path := getConfigPath(".armrc", global)
-	cfg, err := loadOrCreateINI(path)
+	// Load configuration to check for existing manifests, every workspace
+	// member's included
+	configs, err := loadScopes(ctx, []config.Scope{scope})
 	if err != nil {
 		return err
 	}
 
-	// Remove from registries section
-	cfg.Section("registries").DeleteKey(name)
-
-	// Remove registry config section
-	sectionName := fmt.Sprintf("registries.%s", name)
-	cfg.DeleteSection(sectionName)
-
-	return cfg.SaveTo(path)
-}
-
-func handleAddChannel(name, directories string, global bool) error {
-	if directories == "" {
-		return fmt.Errorf("directories are required")
+	// Check if we have any rulesets configured
+	configured := false
+	for _, cfg := range configs {
+		configured = configured || len(cfg.Rulesets) > 0
 	}
-
-	path := getConfigPath("arm.json", global)
-	armConfig, err := loadOrCreateJSON(path)
-	if err != nil {
-		return err
+	if !configured {
+		// Generate stub files if they don't exist
+		if err := ensureConfigFiles(global); err != nil {
+			return err
+		}
+		logger.Info("No rulesets configured. Generated stub configuration files.")
+		logger.Info("Configure registries and rulesets in .armrc and arm.json, then run 'arm install' again.")
+		return nil
 	}
 
-	dirList := strings.Split(directories, ",")
-	for i, dir := range dirList {
-		dirList[i] = strings.TrimSpace(dir)
+	if dryRun {
+		logger.Info("Would install the following rulesets:")
+	}
+	var failed []string
+	var failures []error
+	// Members share the lock file, so they are installed one after another,
+	// and each ruleset version they require is fetched once
+	if len(configs) > 1 {
+		var release func()
+		ctx, release = withFetchCache(ctx)
+		defer release()
+	}
+	for _, cfg := range configs {
+		targets, errs, err := installManifest(ctx, cfg, dryRun, channels)
+		if err != nil {
+			return err
+		}
+		failed, failures = append(failed, targets...), append(failures, errs...)
 	}
 
-	armConfig.Channels[name] = config.ChannelConfig{
-		Directories: dirList,
+	if len(failed) > 0 {
+		return batchError("install", failed, failures)
 	}
-
-	return saveJSON(path, armConfig)
+	return nil
 }
 
-func handleRemoveChannel(name string, global bool) error {
-	path := getConfigPath("arm.json", global)
-	armConfig, err := loadOrCreateJSON(path)
-	if err != nil {
-		return err
+// installManifest installs the rulesets of a manifest and returns those that
+// failed with their errors. It stops when the command is interrupted.
+func installManifest(ctx context.Context, cfg *config.Config, dryRun bool, channels string) (failed []string, failures []error, err error) {
+	registries := make([]string, 0, len(cfg.Rulesets))
+	for registryName := range cfg.Rulesets {
+		registries = append(registries, registryName)
 	}
+	sort.Strings(registries)
 
-	delete(armConfig.Channels, name)
-
-	return saveJSON(path, armConfig)
-}
-
-// Helper functions
-
-func getConfigPath(filename string, global bool) string {
-	if global {
-		return filepath.Join(os.Getenv("HOME"), ".arm", filename)
-	}
-	return filename
-}
-
-func loadOrCreateINI(path string) (*ini.File, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Create parent directory if needed
-		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-			return nil, err
+	if dryRun {
+		for _, registryName := range registries {
+			for _, name := range sortedRulesetNames(cfg.Rulesets[registryName]) {
+				spec := cfg.Rulesets[registryName][name]
+				recordInstalled(ctx, installedRuleset{Workspace: cfg.Workspace, Registry: registryName, Name: name, Version: spec.Version, Patterns: spec.Patterns})
+				logger.Info("  %s", scopedName(cfg, registryName+"/"+name+"@"+spec.Version))
+			}
 		}
-		return ini.Empty(), nil
+		return nil, nil, nil
 	}
-	return ini.Load(path)
-}
 
-func loadOrCreateJSON(path string) (*config.ARMConfig, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Create parent directory if needed
-		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-			return nil, err
+	for _, registryName := range registries {
+		if _, exists := cfg.Registries[registryName]; !exists {
+			err = registryNotFoundError(registryName)
+			logger.Error("Failed to install rulesets from %s: %v", scopedName(cfg, registryName), err)
+			reportError(ctx, scopedName(cfg, registryName), err)
+			failed = append(failed, scopedName(cfg, registryName))
+			failures = append(failures, err)
+			continue
 		}
-		return &config.ARMConfig{
-			Engines:  make(map[string]string),
-			Channels: make(map[string]config.ChannelConfig),
-			Rulesets: make(map[string]map[string]config.RulesetSpec),
-		}, nil
-	}
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var armConfig config.ARMConfig
-	if err := json.Unmarshal(data, &armConfig); err != nil {
-		return nil, err
-	}
-
-	// Initialize maps if nil
-	if armConfig.Engines == nil {
-		armConfig.Engines = make(map[string]string)
-	}
-	if armConfig.Channels == nil {
-		armConfig.Channels = make(map[string]config.ChannelConfig)
-	}
-	if armConfig.Rulesets == nil {
-		armConfig.Rulesets = make(map[string]map[string]config.RulesetSpec)
-	}
-
-	return &armConfig, nil
-}
-
-func saveJSON(path string, armConfig *config.ARMConfig) error {
-	data, err := json.MarshalIndent(armConfig, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0o600)
-}
 
-func getConfigValue(cfg *config.Config, key string) string {
-	parts := strings.Split(key, ".")
-	if len(parts) < 2 {
-		return ""
-	}
+		for _, name := range sortedRulesetNames(cfg.Rulesets[registryName]) {
+			spec := cfg.Rulesets[registryName][name]
+			version := spec.Version
+			if version == "" {
+				version = "latest"
+			}
 
-	section := parts[0]
-	field := parts[1]
+			// Reinstall the locked version while the manifest spec is unchanged
+			var lockedVersion string
+			if cfg.LockFile != nil {
+				if locked, exists := cfg.LockFile.Rulesets[registryName][name]; exists && locked.Version == version {
+					lockedVersion = locked.Resolved
+				}
+			}
 
-	switch section {
-	case "registries":
-		if len(parts) == 2 {
-			return cfg.Registries[field]
-		} else if len(parts) == 3 {
-			if regConfig, exists := cfg.RegistryConfigs[field]; exists {
-				return regConfig[parts[2]]
+			err = progress.Track(ctx, scopedName(cfg, registryName+"/"+name), func(ctx context.Context) error {
+				return install.WithOperationTimeout(ctx, cfg, registryName, func(ctx context.Context) error {
+					return performInstallation(ctx, cfg, registryName, name, version, lockedVersion, channels, strings.Join(spec.Patterns, ","))
+				})
+			})
+			if ctx.Err() != nil {
+				// Interrupted; the rulesets not yet installed are left as they were
+				return nil, nil, ctx.Err()
+			}
+			if err != nil {
+				logger.Error("Failed to install %s: %v", scopedName(cfg, registryName+"/"+name), err)
+				reportError(ctx, scopedName(cfg, registryName+"/"+name), err)
+				failed = append(failed, scopedName(cfg, registryName+"/"+name))
+				failures = append(failures, err)
 			}
-		}
-	case "network":
-		return cfg.NetworkConfig[field]
-	default:
-		if typeConfig, exists := cfg.TypeDefaults[section]; exists {
-			return typeConfig[field]
 		}
 	}
 
-	return ""
+	return failed, failures, nil
 }
 
-// Install command handlers
-
-func handleInstallFromManifest(global, dryRun bool, _ string) error {
-	// Load configuration to check for existing manifest
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
-	}
-
-	// Check if we have any rulesets configured
-	if len(cfg.Rulesets) == 0 {
-		// Generate stub files if they don't exist
-		if err := ensureConfigFiles(global); err != nil {
+// batchError reports the targets a command failed for. It takes the kind of
+// the individual failures when they all share one, so that the exit code
+// still tells what went wrong.
+func batchError(action string, failed []string, failures []error) error {
+	err := fmt.Errorf("failed to %s: %s", action, strings.Join(failed, ", "))
+	kind := armerr.KindOf(failures[0])
+	for _, failure := range failures[1:] {
+		if armerr.KindOf(failure) != kind {
 			return err
 		}
-		fmt.Println("No rulesets configured. Generated stub configuration files.")
-		fmt.Println("Configure registries and rulesets in .armrc and arm.json, then run 'arm install' again.")
-		return nil
 	}
+	return armerr.Wrap(kind, err)
+}
 
-	if dryRun {
-		fmt.Println("Would install the following rulesets:")
-		for registry, rulesets := range cfg.Rulesets {
-			for name, spec := range rulesets {
-				fmt.Printf("  %s/%s@%s\n", registry, name, spec.Version)
-			}
-		}
-		return nil
+// sortedRulesetNames returns the ruleset names of a manifest registry in sorted order
+func sortedRulesetNames(rulesets map[string]config.RulesetSpec) []string {
+	names := make([]string, 0, len(rulesets))
+	for name := range rulesets {
+		names = append(names, name)
 	}
-
-	// TODO: Implement actual installation from manifest
-	return fmt.Errorf("manifest installation not yet implemented")
+	sort.Strings(names)
+	return names
 }
 
-func handleInstallRuleset(rulesetSpec string, global, dryRun bool, channels, patterns string) error {
+func handleInstallRuleset(ctx context.Context, rulesetSpec string, global, dryRun bool, channels, patterns string) error {
+	scope := config.ScopeFor(global)
+	setResult(ctx, &installOutput{DryRun: dryRun, Scope: scope, Rulesets: []installedRuleset{}})
+
 	// Parse ruleset specification
 	registry, name, version := parseRulesetSpec(rulesetSpec)
 
 	// Load configuration
-	cfg, err := config.Load()
+	targets, err := loadTargets(ctx, scope)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
 	}
+	cfg := targets[0]
 
 	// Check if we have registries configured
 	if len(cfg.Registries) == 0 {
@@ -689,7 +587,7 @@ func handleInstallRuleset(rulesetSpec string, global, dryRun bool, channels, pat
 		if err := ensureConfigFiles(global); err != nil {
 			return err
 		}
-		return fmt.Errorf("no registries configured. Add a registry with 'arm config add registry'")
+		return errNoRegistries
 	}
 
 	// Determine target registry
@@ -704,7 +602,7 @@ func handleInstallRuleset(rulesetSpec string, global, dryRun bool, channels, pat
 
 	// Check if registry exists
 	if _, exists := cfg.Registries[registry]; !exists {
-		return fmt.Errorf("registry '%s' not found", registry)
+		return registryNotFoundError(registry)
 	}
 
 	// Check if it's a Git registry and patterns are required
@@ -715,18 +613,58 @@ func handleInstallRuleset(rulesetSpec string, global, dryRun bool, channels, pat
 	}
 
 	if dryRun {
-		fmt.Printf("Would install: %s/%s@%s\n", registry, name, version)
+		var patternList []string
+		for _, pattern := range strings.Split(patterns, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patternList = append(patternList, pattern)
+			}
+		}
+		for _, cfg := range targets {
+			recordInstalled(ctx, installedRuleset{Workspace: cfg.Workspace, Registry: registry, Name: name, Version: version, Patterns: patternList})
+			logger.Info("Would install: %s", scopedName(cfg, registry+"/"+name+"@"+version))
+		}
 		if patterns != "" {
-			fmt.Printf("  Patterns: %s\n", patterns)
+			logger.Info("  Patterns: %s", patterns)
 		}
 		if channels != "" {
-			fmt.Printf("  Channels: %s\n", channels)
+			logger.Info("  Channels: %s", channels)
 		}
 		return nil
 	}
 
 	// Implement actual ruleset installation
-	return performInstallation(cfg, registry, name, version, channels, patterns)
+	if len(targets) == 1 {
+		return installRuleset(ctx, cfg, registry, name, version, channels, patterns)
+	}
+	// Workspace members share one download of the ruleset
+	ctx, release := withFetchCache(ctx)
+	defer release()
+	var failed []string
+	var failures []error
+	for _, cfg := range targets {
+		if err := installRuleset(ctx, cfg, registry, name, version, channels, patterns); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Error("Failed to install %s: %v", scopedName(cfg, registry+"/"+name), err)
+			reportError(ctx, scopedName(cfg, registry+"/"+name), err)
+			failed = append(failed, scopedName(cfg, registry+"/"+name))
+			failures = append(failures, err)
+		}
+	}
+	if len(failed) > 0 {
+		return batchError("install", failed, failures)
+	}
+	return nil
+}
+
+// installRuleset installs a ruleset into the channels of a configuration
+func installRuleset(ctx context.Context, cfg *config.Config, registry, name, version, channels, patterns string) error {
+	return progress.Track(ctx, scopedName(cfg, registry+"/"+name), func(ctx context.Context) error {
+		return install.WithOperationTimeout(ctx, cfg, registry, func(ctx context.Context) error {
+			return performInstallation(ctx, cfg, registry, name, version, "", channels, patterns)
+		})
+	})
 }
 
 func parseRulesetSpec(spec string) (registry, name, version string) {
@@ -792,18 +730,27 @@ func ensureConfigFiles(global bool) error {
 	return nil
 }
 
-// Search, info, and list command handlers
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-func handleSearch(query, registries string, jsonOutput bool, limit int) error {
+func handleSearch(ctx context.Context, query, registries string, jsonOutput bool, limit int) error {
+	out := textOutput(ctx)
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Check if we have registries configured
 	if len(cfg.Registries) == 0 {
-		return fmt.Errorf("no registries configured. Add a registry with 'arm config add registry'")
+		return errNoRegistries
 	}
 
 	// Determine which registries to search
@@ -813,103 +760,306 @@ func handleSearch(query, registries string, jsonOutput bool, limit int) error {
 	}
 
 	// Perform search across registries
-	allResults, searchErrors := performSearch(cfg, targetRegistries, query, limit)
+	allResults, searchErrors := performSearch(ctx, cfg, targetRegistries, query, limit)
+
+	if allResults == nil {
+		allResults = []registry.SearchResult{}
+	}
+	setResult(ctx, map[string]interface{}{
+		"query":      query,
+		"registries": targetRegistries,
+		"limit":      limit,
+		"results":    allResults,
+	})
 
-	// Handle JSON output
+	// Failed registries are reported as warnings; the others still return results
 	if jsonOutput {
-		data, _ := json.MarshalIndent(map[string]interface{}{
-			"query":      query,
-			"registries": targetRegistries,
-			"limit":      limit,
-			"results":    allResults,
-			"errors":     searchErrors,
-		}, "", "  ")
-		fmt.Println(string(data))
+		for _, registryName := range sortedKeys(searchErrors) {
+			reportWarning(ctx, registryName, searchErrors[registryName])
+		}
 		return nil
 	}
 
 	// Display search results in table format
-	fmt.Printf("Searching for '%s' in registries: %s\n", query, strings.Join(targetRegistries, ", "))
-	fmt.Printf("Limit: %d results\n\n", limit)
+	logger.Info("Searching for '%s' in registries: %s", query, strings.Join(targetRegistries, ", "))
+	logger.Info("Limit: %d results\n", limit)
 
 	if len(allResults) == 0 {
-		fmt.Println("No results found")
+		fmt.Fprintln(out, "No results found")
 	} else {
-		fmt.Printf("%-20s %-30s %s\n", "REGISTRY", "RULESET", "MATCH")
-		fmt.Printf("%-20s %-30s %s\n", "--------", "-------", "-----")
+		fmt.Fprintf(out, "%-20s %-30s %s\n", "REGISTRY", "RULESET", "MATCH")
+		fmt.Fprintf(out, "%-20s %-30s %s\n", "--------", "-------", "-----")
 		for _, result := range allResults {
-			fmt.Printf("%-20s %-30s %s\n", result.RegistryName, result.RulesetName, result.Match)
+			fmt.Fprintf(out, "%-20s %-30s %s\n", result.RegistryName, result.RulesetName, result.Match)
 		}
 	}
 
 	// Show warnings for failed registries
-	if len(searchErrors) > 0 {
-		fmt.Printf("\nWarnings:\n")
-		for registry, errMsg := range searchErrors {
-			fmt.Printf("  %s: %s\n", registry, errMsg)
-		}
+	for _, registry := range sortedKeys(searchErrors) {
+		logger.Warn("%s: %s", registry, searchErrors[registry])
 	}
 
 	return nil
 }
 
-func handleInfo(rulesetSpec string, jsonOutput, versions bool) error {
+// rulesetDetails is the information reported by arm info
+type rulesetDetails struct {
+	Registry    string            `json:"registry"`
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Type        string            `json:"type"`
+	URL         string            `json:"url"`
+	Mirrors     []string          `json:"mirrors,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Author      string            `json:"author,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Resolved    string            `json:"resolved"`
+	Latest      string            `json:"latest,omitempty"`
+	Matching    []string          `json:"matching,omitempty"`
+	Versions    []string          `json:"versions,omitempty"`
+	Installed   *installedDetails `json:"installed,omitempty"`
+	Cache       *cacheDetails     `json:"cache,omitempty"`
+}
+
+// installedDetails describes the lock file entry of an installed ruleset
+type installedDetails struct {
+	Version   string `json:"version"`
+	Resolved  string `json:"resolved"`
+	Integrity string `json:"integrity,omitempty"`
+	Source    string `json:"source"`
+}
+
+// cacheDetails describes the cached files of the resolved version
+type cacheDetails struct {
+	FileCount int          `json:"file_count"`
+	TotalSize int64        `json:"total_size"`
+	Files     []cachedFile `json:"files"`
+}
+
+// cachedFile is a single cached ruleset file
+type cachedFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func handleInfo(ctx context.Context, rulesetSpec string, jsonOutput, versions bool) error {
 	// Parse ruleset specification
-	registry, name, version := parseRulesetSpec(rulesetSpec)
+	registryName, name, version := parseRulesetSpec(rulesetSpec)
 
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Determine target registry
-	if registry == "" {
+	if registryName == "" {
 		if _, exists := cfg.Registries["default"]; exists {
-			registry = "default"
+			registryName = "default"
 		} else {
 			return fmt.Errorf("no default registry configured and no registry specified")
 		}
 	}
 
 	// Check if registry exists
-	if _, exists := cfg.Registries[registry]; !exists {
-		return fmt.Errorf("registry '%s' not found", registry)
+	if _, exists := cfg.Registries[registryName]; !exists {
+		return registryNotFoundError(registryName)
 	}
 
-	if jsonOutput {
-		fmt.Printf(`{"registry":"%s","name":"%s","version":"%s","versions_requested":%t}`, registry, name, version, versions)
-		return nil
+	builder := newRegistryBuilder(cfg)
+	registryConfig, err := builder.Config(registryName)
+	if err != nil {
+		return err
+	}
+	reg, err := builder.Create(registryConfig)
+	if err != nil {
+		return err
 	}
+	defer func() { _ = reg.Close() }()
 
-	fmt.Printf("Ruleset: %s/%s@%s\n", registry, name, version)
-	fmt.Printf("Registry: %s (%s)\n", registry, cfg.Registries[registry])
-	if regConfig, exists := cfg.RegistryConfigs[registry]; exists {
-		fmt.Printf("Type: %s\n", regConfig["type"])
+	patterns := cfg.Rulesets[registryName][name].Patterns
+
+	// Offline mode fails unless the files for the requested version are cached
+	if offline, ok := reg.(*registry.OfflineRegistry); ok {
+		if _, err := offline.Lookup(name, version, patterns); err != nil {
+			return err
+		}
+	}
+
+	available, err := reg.GetVersions(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get versions of %s/%s: %w", registryName, name, err)
 	}
 
+	details := &rulesetDetails{
+		Registry: registryName,
+		Name:     name,
+		Version:  version,
+		Type:     registryConfig.Type,
+		URL:      cfg.Registries[registryName],
+		Mirrors:  config.ParseMirrors(cfg.RegistryConfigs[registryName]["mirrors"]),
+		Resolved: resolveRulesetVersion(ctx, reg, name, version),
+		Latest:   resolveRulesetVersion(ctx, reg, name, "latest"),
+	}
+	if registry.IsSemverPattern(version) {
+		details.Matching, _ = registry.MatchingVersions(version, available)
+	}
 	if versions {
-		fmt.Println("\nAvailable versions: (not yet implemented)")
+		details.Versions = available
+	}
+
+	// Git rulesets are named in arm.json rather than in the repository, so
+	// registry metadata is only required for the other registry types
+	info, err := reg.GetRuleset(ctx, name, details.Resolved)
+	switch {
+	case err == nil:
+		details.Description = info.Description
+		details.Author = info.Author
+		details.Tags = info.Tags
+		details.Metadata = info.Metadata
+		if !info.UpdatedAt.IsZero() {
+			details.UpdatedAt = &info.UpdatedAt
+		}
+	case registryConfig.Type != "git":
+		return fmt.Errorf("failed to get ruleset %s/%s: %w", registryName, name, err)
 	}
 
-	fmt.Println("\nDetailed information: (not yet implemented)")
+	// Installed state from the lock file, including which source served it
+	if locked := lockedRuleset(cfg, registryName, name); locked != nil {
+		details.Installed = &installedDetails{
+			Version:   locked.Version,
+			Resolved:  locked.Resolved,
+			Integrity: locked.Integrity,
+			Source:    registryName,
+		}
+		if locked.Source != "" {
+			details.Installed.Source = locked.Source
+		}
+	}
+
+	details.Cache = cachedDetails(builder.CacheManager(), registryConfig, name, details.Resolved, patterns)
+
+	setResult(ctx, details)
+	if jsonOutput {
+		return nil
+	}
 
+	printRulesetDetails(textOutput(ctx), details)
 	return nil
 }
 
-func handleList(global, local, jsonOutput bool, channels string) error {
+// resolveRulesetVersion resolves a version spec to the concrete version it
+// currently selects, returning the spec when it cannot be resolved
+func resolveRulesetVersion(ctx context.Context, reg registry.Registry, name, version string) string {
+	if resolver, ok := reg.(registry.VersionSpecResolver); ok && reg.GetType() == "git" {
+		if resolved, err := resolver.ResolveVersion(ctx, version); err == nil {
+			return resolved
+		}
+	}
+	return registry.ResolveVersionSpec(ctx, reg, name, version)
+}
+
+// cachedDetails returns the cached files of a ruleset version, or nil when it is not cached
+func cachedDetails(cacheManager cache.Manager, registryConfig *registry.RegistryConfig, name, version string, patterns []string) *cacheDetails {
+	// Only Git content is cached per pattern set
+	if registryConfig.Type != "git" {
+		patterns = nil
+	}
+
+	storage := cacheManager.GetRulesetStorage()
+	fileCount, totalSize, err := storage.GetRulesetStats(registryConfig.Type, registryConfig.URL, name, version, patterns)
+	if err != nil {
+		return nil
+	}
+	sizes, err := storage.ListRulesetFileSizes(registryConfig.Type, registryConfig.URL, name, version, patterns)
+	if err != nil {
+		return nil
+	}
+
+	details := &cacheDetails{FileCount: fileCount, TotalSize: totalSize}
+	for path, size := range sizes {
+		details.Files = append(details.Files, cachedFile{Path: path, Size: size})
+	}
+	sort.Slice(details.Files, func(i, j int) bool { return details.Files[i].Path < details.Files[j].Path })
+	return details
+}
+
+// printRulesetDetails prints the human-readable output of arm info
+func printRulesetDetails(out io.Writer, details *rulesetDetails) {
+	fmt.Fprintf(out, "Ruleset: %s/%s@%s\n", details.Registry, details.Name, details.Version)
+	fmt.Fprintf(out, "Registry: %s (%s)\n", details.Registry, details.URL)
+	fmt.Fprintf(out, "Type: %s\n", details.Type)
+	if len(details.Mirrors) > 0 {
+		fmt.Fprintf(out, "Mirrors: %s\n", strings.Join(details.Mirrors, ", "))
+	}
+	if details.Description != "" {
+		fmt.Fprintf(out, "Description: %s\n", details.Description)
+	}
+	if details.Author != "" {
+		fmt.Fprintf(out, "Author: %s\n", details.Author)
+	}
+	if len(details.Tags) > 0 {
+		fmt.Fprintf(out, "Tags: %s\n", strings.Join(details.Tags, ", "))
+	}
+	if details.UpdatedAt != nil {
+		fmt.Fprintf(out, "Updated: %s\n", details.UpdatedAt.Format(time.RFC3339))
+	}
+
+	fmt.Fprintln(out, "\nVersions:")
+	if details.Installed != nil {
+		fmt.Fprintf(out, "  Locked: %s (resolved %s from %s)\n", details.Installed.Version, details.Installed.Resolved, details.Installed.Source)
+		if details.Installed.Integrity != "" {
+			fmt.Fprintf(out, "  Integrity: %s\n", details.Installed.Integrity)
+		}
+	}
+	if details.Resolved != details.Version {
+		fmt.Fprintf(out, "  Requested: %s (resolves to %s)\n", details.Version, details.Resolved)
+	}
+	if details.Latest != "" {
+		fmt.Fprintf(out, "  Latest: %s\n", details.Latest)
+	}
+	if len(details.Matching) > 0 {
+		fmt.Fprintf(out, "  Satisfying %s: %s\n", details.Version, strings.Join(details.Matching, ", "))
+	}
+	if len(details.Versions) > 0 {
+		fmt.Fprintf(out, "  Available: %s\n", strings.Join(details.Versions, ", "))
+	}
+
+	if details.Cache != nil {
+		fmt.Fprintf(out, "\nFiles (%d, %d bytes):\n", details.Cache.FileCount, details.Cache.TotalSize)
+		for _, file := range details.Cache.Files {
+			fmt.Fprintf(out, "  %s (%d bytes)\n", file.Path, file.Size)
+		}
+	} else {
+		fmt.Fprintln(out, "\nFiles: not cached")
+	}
+}
+
+// listOutput is the JSON document printed by arm list
+type listOutput struct {
+	Scope    string                  `json:"scope"`
+	Channels []string                `json:"channels"`
+	Rulesets []install.RulesetStatus `json:"rulesets"`
+}
+
+// statusNotCached flags manifest entries missing from the cache in offline mode
+const statusNotCached = "not cached"
+
+func handleList(ctx context.Context, global, local, jsonOutput bool, channels string) error {
 	// Load configuration
-	cfg, err := config.Load()
+	configs, err := loadScopes(ctx, config.Scopes(global, local))
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
 	}
 
-	// Determine scope
-	scope := "both"
-	if global && !local {
-		scope = "global"
-	} else if local && !global {
-		scope = "local"
+	// Determine scope, which selecting workspace members narrows to local
+	scope := string(configs[0].Scope)
+	for _, cfg := range configs {
+		if cfg.Scope != configs[0].Scope {
+			scope = "both"
+		}
 	}
 
 	// Parse channel filter
@@ -921,68 +1071,192 @@ func handleList(global, local, jsonOutput bool, channels string) error {
 		}
 	}
 
-	if jsonOutput {
-		fmt.Printf(`{"scope":"%s","channels":%v,"rulesets":[]}`, scope, channelFilter)
-		return nil
+	statuses := []install.RulesetStatus{}
+	var notCached []error
+	for _, cfg := range configs {
+		scoped, err := install.New(cfg).Status(channelFilter)
+		if err != nil {
+			return fmt.Errorf("failed to read %s installation state: %w", cfg.Scope, err)
+		}
+
+		// Offline mode also reports declared rulesets whose files are not cached
+		for i := range scoped {
+			status := &scoped[i]
+			if status.Version == "" {
+				continue
+			}
+			offline, err := offlineRegistry(cfg, status.Registry)
+			if err != nil {
+				return err
+			}
+			if offline == nil {
+				continue
+			}
+			spec := config.RulesetSpec{Version: status.Version, Patterns: status.Patterns}
+			if err := checkCached(cfg, offline, status.Registry, status.Name, spec); err != nil {
+				notCached = append(notCached, err)
+				status.Flags = append(status.Flags, statusNotCached)
+			}
+		}
+		statuses = append(statuses, scoped...)
+	}
+
+	setResult(ctx, listOutput{Scope: scope, Channels: channelFilter, Rulesets: statuses})
+	if !jsonOutput {
+		printRulesetStatuses(textOutput(ctx), scope, channelFilter, statuses)
+	}
+
+	if len(notCached) > 0 {
+		return notCachedError(notCached)
+	}
+	return nil
+}
+
+// loadScopes loads the configuration of each scope, in order, followed in
+// local scope by the selected workspace members, by default all of them
+func loadScopes(ctx context.Context, scopes []config.Scope) ([]*config.Config, error) {
+	opts := configOptions(ctx)
+	configs := make([]*config.Config, 0, len(scopes))
+	for _, scope := range scopes {
+		// Selecting workspace members narrows both scopes to the project
+		if scope == config.ScopeGlobal && len(scopes) > 1 && len(opts.Workspaces) > 0 {
+			continue
+		}
+		cfg, err := config.LoadScope(scope, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s configuration: %w", scope, err)
+		}
+		selected, err := config.SelectWorkspaces(cfg, true)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, selected...)
 	}
+	return configs, nil
+}
 
-	fmt.Printf("Installed rulesets (scope: %s):\n", scope)
+// loadTargets loads the configuration of scope, or of the workspace members
+// selected with --workspace
+func loadTargets(ctx context.Context, scope config.Scope) ([]*config.Config, error) {
+	cfg, err := config.LoadScope(scope, configOptions(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return config.SelectWorkspaces(cfg, false)
+}
+
+// printRulesetStatuses prints the table output of arm list
+func printRulesetStatuses(out io.Writer, scope string, channelFilter []string, statuses []install.RulesetStatus) {
+	fmt.Fprintf(out, "Installed rulesets (scope: %s):\n", scope)
 	if len(channelFilter) > 0 {
-		fmt.Printf("Channels: %s\n", strings.Join(channelFilter, ", "))
+		fmt.Fprintf(out, "Channels: %s\n", strings.Join(channelFilter, ", "))
+	}
+	fmt.Fprintln(out)
+
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No rulesets installed")
+		fmt.Fprintln(out, "Install rulesets with 'arm install <ruleset-name>'")
+		return
+	}
+
+	// Workspaces get a column of their own when any member is listed
+	workspaces := false
+	for _, status := range statuses {
+		workspaces = workspaces || status.Workspace != ""
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if workspaces {
+		_, _ = fmt.Fprint(writer, "WORKSPACE\t")
+	}
+	_, _ = fmt.Fprintln(writer, "SCOPE\tRULESET\tVERSION\tLOCKED\tRESOLVED\tINSTALLED IN\tSTATUS")
+	for _, status := range statuses {
+		if workspaces {
+			workspace := status.Workspace
+			if workspace == "" && status.Scope != config.ScopeGlobal {
+				workspace = "."
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t", valueOrDash(workspace))
+		}
+		var locations []string
+		for _, location := range status.Installed {
+			locations = append(locations, fmt.Sprintf("%s:%s", location.Channel, location.Directory))
+		}
+		flags := "ok"
+		if len(status.Flags) > 0 {
+			flags = strings.Join(status.Flags, ", ")
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\n",
+			status.Scope, status.Registry, status.Name,
+			valueOrDash(status.Version), valueOrDash(status.Locked), valueOrDash(shortRevision(status.Resolved)),
+			valueOrDash(strings.Join(locations, ", ")), flags)
+	}
+	_ = writer.Flush()
+}
+
+// shortRevision abbreviates full Git commit hashes for display
+func shortRevision(version string) string {
+	if len(version) != 40 {
+		return version
+	}
+	for _, c := range version {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return version
+		}
 	}
-	fmt.Println()
+	return version[:12]
+}
 
-	// Check if we have any rulesets configured
-	if len(cfg.Rulesets) == 0 {
-		fmt.Println("No rulesets installed")
-		fmt.Println("Install rulesets with 'arm install <ruleset-name>'")
-		return nil
+// valueOrDash returns "-" for empty table cells
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
 	}
+	return value
+}
 
-	// List configured rulesets (from manifest)
-	fmt.Println("Configured rulesets:")
-	for registry, rulesets := range cfg.Rulesets {
-		for name, spec := range rulesets {
-			fmt.Printf("  %s/%s@%s\n", registry, name, spec.Version)
-			if len(spec.Patterns) > 0 {
-				fmt.Printf("    Patterns: %s\n", strings.Join(spec.Patterns, ", "))
-			}
-		}
-	}
+// newRegistryBuilder returns the builder all commands create registries with
+func newRegistryBuilder(cfg *config.Config) *registry.Builder {
+	return registry.NewBuilder(cfg, cache.NewManager(cfg.CacheConfig.Path))
+}
 
-	fmt.Println("\nActual installation status: (not yet implemented)")
+// offlineRegistry returns the cache-backed registry used in offline mode, or
+// nil when the registry is served as usual
+func offlineRegistry(cfg *config.Config, registryName string) (*registry.OfflineRegistry, error) {
+	registryType := cfg.RegistryConfigs[registryName]["type"]
+	if !cfg.Offline() || !registry.SupportsOffline(registryType) {
+		return nil, nil
+	}
+	builder := newRegistryBuilder(cfg)
+	registryConfig, err := builder.Config(registryName)
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewOfflineRegistry(registryConfig, builder.CacheManager())
+}
 
-	return nil
+// checkCached verifies that the version of a manifest entry that install
+// would use is available in the cache
+func checkCached(cfg *config.Config, offline *registry.OfflineRegistry, registryName, name string, spec config.RulesetSpec) error {
+	version := spec.Version
+	if locked := lockedRuleset(cfg, registryName, name); locked != nil && locked.Version == spec.Version && locked.Resolved != "" {
+		version = locked.Resolved
+	}
+	_, err := offline.Lookup(name, version, spec.Patterns)
+	return err
 }
 
 // performSearch executes search across multiple registries
-func performSearch(cfg *config.Config, targetRegistries []string, query string, limit int) (results []registry.SearchResult, errors map[string]string) {
+func performSearch(ctx context.Context, cfg *config.Config, targetRegistries []string, query string, limit int) (results []registry.SearchResult, errors map[string]string) {
 	var allResults []registry.SearchResult
 	searchErrors := make(map[string]string)
 
-	// Create cache manager
-	cacheManager := cache.NewManager(cfg.CacheConfig.Path)
+	builder := newRegistryBuilder(cfg)
 
 	for _, registryName := range targetRegistries {
-		// Create registry instance
-		registryConfig := &registry.RegistryConfig{
-			Name: registryName,
-			Type: cfg.RegistryConfigs[registryName]["type"],
-			URL:  cfg.Registries[registryName],
-		}
-
-		// Create auth configuration
-		authConfig := &registry.AuthConfig{}
-		if regConfig := cfg.RegistryConfigs[registryName]; regConfig != nil {
-			authConfig.Token = regConfig["authToken"]
-			authConfig.Region = regConfig["region"]
-			authConfig.Profile = regConfig["profile"]
-		}
-
-		// Create registry instance
-		reg, err := registry.CreateRegistryWithCacheConfig(registryConfig, authConfig, cacheManager, cfg.CacheConfig, registryName)
+		reg, err := builder.Build(registryName)
 		if err != nil {
-			searchErrors[registryName] = fmt.Sprintf("failed to create registry: %v", err)
+			searchErrors[registryName] = err.Error()
 			continue
 		}
 
@@ -995,7 +1269,7 @@ func performSearch(cfg *config.Config, targetRegistries []string, query string,
 		}
 
 		// Perform search
-		results, err := searcher.Search(context.Background(), query)
+		results, err := searcher.Search(ctx, query)
 		if err != nil {
 			searchErrors[registryName] = fmt.Sprintf("search failed: %v", err)
 		} else {
@@ -1050,62 +1324,144 @@ func getTargetRegistries(allRegistries map[string]string, filter string) []strin
 	return result
 }
 
-// Update, outdated, and uninstall command handlers
+// Uninstall and clean command handlers
+
+// uninstallOutput is the result of arm uninstall in JSON output
+type uninstallOutput struct {
+	DryRun    bool         `json:"dry_run"`
+	Scope     config.Scope `json:"scope"`
+	Workspace string       `json:"workspace,omitempty"` // Workspace member, empty for the root project
+	Registry  string       `json:"registry"`
+	Name      string       `json:"name"`
+	Version   string       `json:"version"`
+	Channels  []string     `json:"channels,omitempty"` // Channels removed from, all when empty
+}
+
+// lockedRegistry returns the registry of the first locked ruleset called
+// name, in sorted registry order, or "" when none is locked
+func lockedRegistry(cfg *config.Config, name string) string {
+	if cfg.LockFile == nil {
+		return ""
+	}
+	for _, registryName := range sortedKeys(cfg.LockFile.Rulesets) {
+		if _, exists := cfg.LockFile.Rulesets[registryName][name]; exists {
+			return registryName
+		}
+	}
+	return ""
+}
+
+// otherScopeHint suggests the scope flag for a ruleset missing from cfg that
+// is locked in the other scope only, or returns "" when it is not
+func otherScopeHint(cfg *config.Config, registry, name string) string {
+	other, flag := config.ScopeGlobal, "with --global"
+	if cfg.Scope == config.ScopeGlobal {
+		other, flag = config.ScopeLocal, "without --global"
+	}
+	cfg, err := config.LoadScope(other, cfg.Options)
+	if err != nil {
+		return ""
+	}
+	if registry == "" {
+		registry = lockedRegistry(cfg, name)
+	}
+	if cfg.LockFile == nil || cfg.LockFile.Rulesets[registry][name].Version == "" {
+		return ""
+	}
+	return fmt.Sprintf("'%s/%s' is installed in %s scope; run the command %s", registry, name, other, flag)
+}
+
+// notInstalledHint suggests where else a ruleset missing from cfg is
+// installed: in a workspace member of the project or in the other scope
+func notInstalledHint(cfg *config.Config, registry, name string) string {
+	if cfg.Workspace == "" && cfg.LockFile != nil {
+		var members []string
+		for _, member := range sortedKeys(cfg.LockFile.Workspaces) {
+			for registryName, rulesets := range cfg.LockFile.Workspaces[member].Rulesets {
+				if (registry == "" || registry == registryName) && rulesets[name].Version != "" {
+					members = append(members, member)
+					break
+				}
+			}
+		}
+		if len(members) > 0 {
+			return fmt.Sprintf("'%s' is installed in workspace %s; select it with --workspace", name, strings.Join(members, ", "))
+		}
+	}
+	return otherScopeHint(cfg, registry, name)
+}
 
-func handleUninstall(rulesetName string, global, dryRun bool, channels string) error {
+func handleUninstall(ctx context.Context, rulesetName string, global, dryRun bool, channels string) error {
 	// Parse ruleset specification
 	registry, name, _ := parseRulesetSpec(rulesetName)
+	scope := config.ScopeFor(global)
 
 	// Load configuration
-	cfg, err := config.Load()
+	targets, err := loadTargets(ctx, scope)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
+	}
+	if len(targets) > 1 {
+		return armerr.New(armerr.Config, "uninstall takes a single workspace member").
+			WithHint("Run it once per member with --workspace")
+	}
+	cfg := targets[0]
+	where := string(scope) + " scope"
+	if cfg.Workspace != "" {
+		where = "workspace " + cfg.Workspace
 	}
 
 	// Check if we have a lock file
 	if cfg.LockFile == nil {
-		return fmt.Errorf("no lock file found - no rulesets installed")
+		if cfg.Workspace != "" {
+			return armerr.Errorf(armerr.NotFound, "no rulesets installed in %s", where)
+		}
+		return armerr.Errorf(armerr.NotFound, "no %s lock file found - no rulesets installed", scope).
+			WithHint(otherScopeHint(cfg, registry, name))
 	}
 
 	// Determine target registry
 	if registry == "" {
-		// Find the registry from lock file
-		for reg, rulesets := range cfg.LockFile.Rulesets {
-			if _, exists := rulesets[name]; exists {
-				registry = reg
-				break
-			}
-		}
+		registry = lockedRegistry(cfg, name)
 		if registry == "" {
-			return fmt.Errorf("ruleset '%s' not found in installed rulesets", name)
+			return armerr.Errorf(armerr.NotFound, "ruleset '%s' not found in %s installed rulesets", name, where).
+				WithHint(notInstalledHint(cfg, registry, name))
 		}
 	}
 
 	// Check if ruleset is installed
 	if cfg.LockFile.Rulesets[registry] == nil || cfg.LockFile.Rulesets[registry][name].Version == "" {
-		return fmt.Errorf("ruleset '%s/%s' is not installed", registry, name)
+		return armerr.Errorf(armerr.NotFound, "ruleset '%s/%s' is not installed in %s", registry, name, where).
+			WithHint(notInstalledHint(cfg, registry, name))
 	}
 
 	lockedRuleset := cfg.LockFile.Rulesets[registry][name]
+	output := &uninstallOutput{DryRun: dryRun, Scope: scope, Workspace: cfg.Workspace, Registry: registry, Name: name, Version: lockedRuleset.Version}
+	for _, channel := range strings.Split(channels, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			output.Channels = append(output.Channels, channel)
+		}
+	}
+	setResult(ctx, output)
 
 	if dryRun {
-		fmt.Printf("Would uninstall: %s/%s@%s\n", registry, name, lockedRuleset.Version)
+		logger.Info("Would uninstall: %s/%s@%s", registry, name, lockedRuleset.Version)
 		if channels != "" {
-			fmt.Printf("  Channels: %s\n", channels)
+			logger.Info("  Channels: %s", channels)
 		}
-		fmt.Println("  Files would be removed from ARM namespace directories")
+		logger.Info("  Files would be removed from ARM namespace directories")
 		return nil
 	}
 
-	fmt.Printf("Uninstalling %s/%s@%s...\n", registry, name, lockedRuleset.Version)
+	logger.Info("Uninstalling %s...", scopedName(cfg, registry+"/"+name+"@"+lockedRuleset.Version))
 
 	// Remove from manifest (arm.json)
-	if err := removeFromManifest(registry, name, global); err != nil {
+	if err := removeFromManifest(cfg, registry, name); err != nil {
 		return fmt.Errorf("failed to update manifest: %w", err)
 	}
 
 	// Remove from lock file
-	if err := removeFromLockFile(registry, name); err != nil {
+	if err := removeFromLockFile(cfg, registry, name); err != nil {
 		return fmt.Errorf("failed to update lock file: %w", err)
 	}
 
@@ -1114,147 +1470,54 @@ func handleUninstall(rulesetName string, global, dryRun bool, channels string) e
 		return fmt.Errorf("failed to remove files: %w", err)
 	}
 
-	fmt.Printf("✓ Uninstalled %s/%s\n", registry, name)
+	logger.Success("Uninstalled %s", scopedName(cfg, registry+"/"+name))
 	return nil
 }
 
-func handleOutdated(_, jsonOutput bool) error {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
-	}
-
-	// Check if we have a lock file
-	if cfg.LockFile == nil {
-		return fmt.Errorf("no lock file found - no rulesets installed")
-	}
-
-	type outdatedInfo struct {
-		Registry       string `json:"registry"`
-		Name           string `json:"name"`
-		CurrentVersion string `json:"current_version"`
-		LatestVersion  string `json:"latest_version"`
-		UpdateCommand  string `json:"update_command"`
+// scopeFlag returns the flags selecting the configuration's scope or
+// workspace member in suggested commands, which look in local scope first
+func scopeFlag(cfg *config.Config) string {
+	switch {
+	case cfg.Scope == config.ScopeGlobal:
+		return " --global"
+	case cfg.Workspace != "":
+		return " --workspace " + cfg.Workspace
 	}
-
-	var outdatedRulesets []outdatedInfo
-	updateService := update.New(cfg)
-
-	// Check each installed ruleset
-	for registry, rulesets := range cfg.LockFile.Rulesets {
-		for name := range rulesets {
-			rulesetSpec := fmt.Sprintf("%s/%s", registry, name)
-			result, err := updateService.CheckOutdated(context.Background(), rulesetSpec)
-			if err != nil {
-				continue // Skip failed resolutions
-			}
-			if result.Updated {
-				outdatedRulesets = append(outdatedRulesets, outdatedInfo{
-					Registry:       result.Registry,
-					Name:           result.Ruleset,
-					CurrentVersion: result.PreviousVersion,
-					LatestVersion:  result.Version,
-					UpdateCommand:  fmt.Sprintf("arm update %s/%s", result.Registry, result.Ruleset),
-				})
-			}
-		}
-	}
-
-	if jsonOutput {
-		data, _ := json.MarshalIndent(map[string]interface{}{
-			"outdated": outdatedRulesets,
-		}, "", "  ")
-		fmt.Println(string(data))
-		return nil
-	}
-
-	if len(outdatedRulesets) == 0 {
-		fmt.Println("All rulesets are up to date")
-		return nil
-	}
-
-	fmt.Printf("Found %d outdated ruleset(s):\n\n", len(outdatedRulesets))
-	for _, info := range outdatedRulesets {
-		fmt.Printf("%s/%s\n", info.Registry, info.Name)
-		fmt.Printf("  Current: %s\n", info.CurrentVersion)
-		fmt.Printf("  Latest:  %s\n", info.LatestVersion)
-		fmt.Printf("  Update:  %s\n\n", info.UpdateCommand)
-	}
-
-	return nil
+	return ""
 }
 
-func handleUpdateAll(_, dryRun bool) error {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
-	}
-
-	if dryRun {
-		fmt.Println("Would update all rulesets")
-		return nil
-	}
-
-	updateService := update.New(cfg)
-	var updatedCount int
-
-	// Update each installed ruleset
-	for registry, rulesets := range cfg.LockFile.Rulesets {
-		for name := range rulesets {
-			rulesetSpec := fmt.Sprintf("%s/%s", registry, name)
-			result, err := updateService.UpdateRuleset(context.Background(), rulesetSpec)
-			if err != nil {
-				fmt.Printf("Failed to update %s: %v\n", rulesetSpec, err)
-				continue
-			}
-			if result.Updated {
-				fmt.Printf("Updated %s/%s %s → %s\n", result.Registry, result.Ruleset, result.PreviousVersion, result.Version)
-				updatedCount++
-			} else {
-				fmt.Printf("%s/%s is already up to date (%s)\n", result.Registry, result.Ruleset, result.Version)
-			}
-		}
+// scopedName names a ruleset or registry for display, marking those of global
+// scope and workspace members
+func scopedName(cfg *config.Config, target string) string {
+	switch {
+	case cfg.Scope == config.ScopeGlobal:
+		return target + " (global)"
+	case cfg.Workspace != "":
+		return fmt.Sprintf("%s (%s)", target, cfg.Workspace)
 	}
-
-	fmt.Printf("Updated %d ruleset(s)\n", updatedCount)
-	return nil
+	return target
 }
 
-func handleUpdateRuleset(rulesetSpec string, _, dryRun bool) error {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
-	}
-
-	if dryRun {
-		fmt.Printf("Would update: %s\n", rulesetSpec)
-		return nil
-	}
-
-	updateService := update.New(cfg)
-	result, err := updateService.UpdateRuleset(context.Background(), rulesetSpec)
+// rulesetScope returns the configuration of the first scope whose lock file
+// contains the ruleset, or of the first scope when none does
+func rulesetScope(ctx context.Context, scopes []config.Scope, rulesetSpec string) (*config.Config, error) {
+	configs, err := loadScopes(ctx, scopes)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if result.Updated {
-		fmt.Printf("Updating %s/%s %s → %s...\n", result.Registry, result.Ruleset, result.PreviousVersion, result.Version)
-		fmt.Printf("  Installing new version...\n")
-		fmt.Printf("✓ Updated %s/%s to %s\n", result.Registry, result.Ruleset, result.Version)
-	} else {
-		fmt.Printf("%s/%s is already up to date (%s)\n", result.Registry, result.Ruleset, result.Version)
+	registryName, name, _ := parseRulesetSpec(rulesetSpec)
+	for _, cfg := range configs {
+		if cfg.LockFile != nil && cfg.LockFile.Rulesets[registryName][name].Version != "" {
+			return cfg, nil
+		}
 	}
-
-	return nil
+	return configs[0], nil
 }
 
 // Helper functions
 
-func removeFromManifest(registry, name string, global bool) error {
-	path := getConfigPath("arm.json", global)
+func removeFromManifest(cfg *config.Config, registry, name string) error {
+	path := cfg.ManifestPath()
 	armConfig, err := loadOrCreateJSON(path)
 	if err != nil {
 		return err
@@ -1271,8 +1534,8 @@ func removeFromManifest(registry, name string, global bool) error {
 	return saveJSON(path, armConfig)
 }
 
-func removeFromLockFile(registry, name string) error {
-	path := "arm.lock"
+func removeFromLockFile(cfg *config.Config, registry, name string) error {
+	path := cfg.LockPath()
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil // No lock file to update
 	}
@@ -1287,13 +1550,7 @@ func removeFromLockFile(registry, name string) error {
 		return err
 	}
 
-	if lockFile.Rulesets[registry] != nil {
-		delete(lockFile.Rulesets[registry], name)
-		// Remove registry if empty
-		if len(lockFile.Rulesets[registry]) == 0 {
-			delete(lockFile.Rulesets, registry)
-		}
-	}
+	lockFile.Remove(cfg.Workspace, registry, name)
 
 	lockData, err := json.MarshalIndent(lockFile, "", "  ")
 	if err != nil {
@@ -1365,36 +1622,48 @@ func isDirEmpty(path string) (bool, error) {
 
 // Clean command handler
 
-func handleClean(target string, global, dryRun, force bool) error {
+// cleanOutput is the result of arm clean in JSON output
+type cleanOutput struct {
+	Target    string `json:"target"`
+	DryRun    bool   `json:"dry_run"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+	Cleaned   int    `json:"cleaned"`
+}
+
+func handleClean(ctx context.Context, target string, global, dryRun, force bool) error {
+	out := textOutput(ctx)
 	// Validate target
 	validTargets := []string{"cache", "unused", "all"}
 	if !contains(validTargets, target) {
 		return fmt.Errorf("invalid target '%s'. Valid targets: %s", target, strings.Join(validTargets, ", "))
 	}
+	output := &cleanOutput{Target: target, DryRun: dryRun}
+	setResult(ctx, output)
 
 	if dryRun {
-		fmt.Printf("Would clean target: %s\n", target)
+		logger.Info("Would clean target: %s", target)
 		switch target {
 		case "cache":
-			fmt.Println("  - No cache to clean (caching disabled)")
+			logger.Info("  - Clear all cached registries")
 		case "unused":
-			fmt.Println("  - Remove rulesets not in any manifest")
-			fmt.Println("  - Clean up empty ARM directories")
+			logger.Info("  - Remove rulesets not in any manifest")
+			logger.Info("  - Clean up empty ARM directories")
 		case "all":
-			fmt.Println("  - No cache to clean (caching disabled)")
-			fmt.Println("  - Remove rulesets not in any manifest")
-			fmt.Println("  - Clean up empty ARM directories")
+			logger.Info("  - Clear all cached registries")
+			logger.Info("  - Remove rulesets not in any manifest")
+			logger.Info("  - Clean up empty ARM directories")
 		}
 		return nil
 	}
 
 	// Confirm destructive operation unless force flag is set
 	if !force {
-		fmt.Printf("This will clean target '%s'. Continue? (y/N): ", target)
+		fmt.Fprintf(out, "This will clean target '%s'. Continue? (y/N): ", target)
 		var response string
 		_, _ = fmt.Scanln(&response)
 		if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
-			fmt.Println("Operation cancelled")
+			output.Cancelled = true
+			logger.Info("Operation cancelled")
 			return nil
 		}
 	}
@@ -1405,24 +1674,28 @@ func handleClean(target string, global, dryRun, force bool) error {
 	// Execute cleaning based on target
 	switch target {
 	case "cache":
-		if count, err := cleanCache(); err != nil {
+		if count, err := cleanCache(ctx); err != nil {
+			reportError(ctx, "cache", err)
 			errors = append(errors, fmt.Sprintf("cache: %v", err))
 		} else {
 			cleaned += count
 		}
 	case "unused":
-		if count, err := cleanUnused(global); err != nil {
+		if count, err := cleanUnused(ctx, global); err != nil {
+			reportError(ctx, "unused", err)
 			errors = append(errors, fmt.Sprintf("unused: %v", err))
 		} else {
 			cleaned += count
 		}
 	case "all":
-		if count, err := cleanCache(); err != nil {
+		if count, err := cleanCache(ctx); err != nil {
+			reportError(ctx, "cache", err)
 			errors = append(errors, fmt.Sprintf("cache: %v", err))
 		} else {
 			cleaned += count
 		}
-		if count, err := cleanUnused(global); err != nil {
+		if count, err := cleanUnused(ctx, global); err != nil {
+			reportError(ctx, "unused", err)
 			errors = append(errors, fmt.Sprintf("unused: %v", err))
 		} else {
 			cleaned += count
@@ -1430,102 +1703,128 @@ func handleClean(target string, global, dryRun, force bool) error {
 	}
 
 	// Report results
+	output.Cleaned = cleaned
 	if len(errors) > 0 {
-		fmt.Printf("Cleaned %d items with %d errors:\n", cleaned, len(errors))
+		logger.Error("Cleaned %d items with %d errors:", cleaned, len(errors))
 		for _, err := range errors {
-			fmt.Printf("  %s\n", err)
+			logger.Error("%s", err)
 		}
 		return fmt.Errorf("cleaning completed with errors")
 	}
 
-	fmt.Printf("✓ Cleaned %d items\n", cleaned)
+	logger.Success("Cleaned %d items", cleaned)
 	return nil
 }
 
-func cleanCache() (int, error) {
-	// No cache to clean since caching is disabled
-	return 0, nil
-}
-
-func cleanUnused(_ bool) (int, error) {
-	// Load configuration to get installed rulesets
-	cfg, err := config.Load()
+func cleanCache(ctx context.Context) (int, error) {
+	cfg, err := loadConfig(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Get all configured rulesets from manifest
-	configuredRulesets := make(map[string]map[string]bool)
-	for registry, rulesets := range cfg.Rulesets {
-		if configuredRulesets[registry] == nil {
-			configuredRulesets[registry] = make(map[string]bool)
-		}
-		for name := range rulesets {
-			configuredRulesets[registry][name] = true
+	manager := cache.NewManager(cfg.CacheConfig.Path)
+	entries, err := manager.List()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cache: %w", err)
+	}
+	if err := manager.Clear(); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func cleanUnused(ctx context.Context, global bool) (int, error) {
+	// Load configuration to get installed rulesets, every workspace member's included
+	configs, err := loadScopes(ctx, []config.Scope{config.ScopeFor(global)})
+	if err != nil {
+		return 0, err
+	}
+
+	// Get the rulesets configured for each channel directory, which workspace
+	// members may share
+	var directories []string
+	channelNames := make(map[string]string)
+	configured := make(map[string]map[string]map[string]bool)
+	for _, cfg := range configs {
+		for _, channelName := range sortedKeys(cfg.Channels) {
+			for _, dir := range cfg.Channels[channelName].Directories {
+				expandedDir := expandEnvVars(dir)
+				if configured[expandedDir] == nil {
+					directories = append(directories, expandedDir)
+					channelNames[expandedDir] = channelName
+					configured[expandedDir] = make(map[string]map[string]bool)
+				}
+				for registry, rulesets := range cfg.Rulesets {
+					if configured[expandedDir][registry] == nil {
+						configured[expandedDir][registry] = make(map[string]bool)
+					}
+					for name := range rulesets {
+						configured[expandedDir][registry][name] = true
+					}
+				}
+			}
 		}
 	}
 
 	count := 0
 	// Clean unused rulesets from each channel
-	for channelName, channelConfig := range cfg.Channels {
-		for _, dir := range channelConfig.Directories {
-			expandedDir := expandEnvVars(dir)
-			armPath := filepath.Join(expandedDir, "arm")
+	for _, expandedDir := range directories {
+		channelName, configuredRulesets := channelNames[expandedDir], configured[expandedDir]
+		armPath := filepath.Join(expandedDir, "arm")
+
+		// Check if ARM directory exists
+		if _, err := os.Stat(armPath); os.IsNotExist(err) {
+			continue
+		}
+
+		// Walk through registry directories
+		registries, err := os.ReadDir(armPath)
+		if err != nil {
+			continue
+		}
 
-			// Check if ARM directory exists
-			if _, err := os.Stat(armPath); os.IsNotExist(err) {
+		for _, registryDir := range registries {
+			if !registryDir.IsDir() {
 				continue
 			}
 
-			// Walk through registry directories
-			registries, err := os.ReadDir(armPath)
+			registryName := registryDir.Name()
+			registryPath := filepath.Join(armPath, registryName)
+
+			// Walk through ruleset directories
+			rulesets, err := os.ReadDir(registryPath)
 			if err != nil {
 				continue
 			}
 
-			for _, registryDir := range registries {
-				if !registryDir.IsDir() {
-					continue
-				}
-
-				registryName := registryDir.Name()
-				registryPath := filepath.Join(armPath, registryName)
-
-				// Walk through ruleset directories
-				rulesets, err := os.ReadDir(registryPath)
-				if err != nil {
+			for _, rulesetDir := range rulesets {
+				if !rulesetDir.IsDir() {
 					continue
 				}
 
-				for _, rulesetDir := range rulesets {
-					if !rulesetDir.IsDir() {
-						continue
-					}
-
-					rulesetName := rulesetDir.Name()
+				rulesetName := rulesetDir.Name()
 
-					// Check if this ruleset is configured
-					if configuredRulesets[registryName] == nil || !configuredRulesets[registryName][rulesetName] {
-						// This is an unused ruleset, remove it
-						rulesetPath := filepath.Join(registryPath, rulesetName)
-						if err := os.RemoveAll(rulesetPath); err == nil {
-							fmt.Printf("  Removed unused ruleset: %s/%s from %s\n", registryName, rulesetName, channelName)
-							count++
-						}
+				// Check if this ruleset is configured
+				if configuredRulesets[registryName] == nil || !configuredRulesets[registryName][rulesetName] {
+					// This is an unused ruleset, remove it
+					rulesetPath := filepath.Join(registryPath, rulesetName)
+					if err := os.RemoveAll(rulesetPath); err == nil {
+						logger.Info("  Removed unused ruleset: %s/%s from %s", registryName, rulesetName, channelName)
+						count++
 					}
 				}
-
-				// Clean up empty registry directory
-				if isEmpty, _ := isDirEmpty(registryPath); isEmpty {
-					_ = os.Remove(registryPath)
-				}
 			}
 
-			// Clean up empty ARM directory
-			if isEmpty, _ := isDirEmpty(armPath); isEmpty {
-				_ = os.Remove(armPath)
+			// Clean up empty registry directory
+			if isEmpty, _ := isDirEmpty(registryPath); isEmpty {
+				_ = os.Remove(registryPath)
 			}
 		}
+
+		// Clean up empty ARM directory
+		if isEmpty, _ := isDirEmpty(armPath); isEmpty {
+			_ = os.Remove(armPath)
+		}
 	}
 
 	return count, nil
@@ -1545,47 +1844,88 @@ func expandEnvVars(s string) string {
 	return os.ExpandEnv(s)
 }
 
-// performGitInstallation handles Git registry installations with proper version tracking
-func performGitInstallation(cfg *config.Config, registryName, rulesetName, version, channels, patterns string) error {
-	// Create registry configuration
-	registryConfig := &registry.RegistryConfig{
-		Name: registryName,
-		Type: cfg.RegistryConfigs[registryName]["type"],
-		URL:  cfg.Registries[registryName],
+// fetchedRuleset is the downloaded content of a ruleset version
+type fetchedRuleset struct {
+	dir       string // Temporary directory holding the files
+	resolved  string
+	integrity string
+	source    string // Registry or mirror that served the files
+	files     []string
+}
+
+// fetchCacheKey carries the rulesets fetched during one command
+type fetchCacheKey struct{}
+
+// withFetchCache shares fetched rulesets between the installations made with
+// the returned context, so that workspace members requiring the same version
+// resolve and download it once. The returned function removes the downloads.
+func withFetchCache(ctx context.Context) (context.Context, func()) {
+	fetches := make(map[string]*fetchedRuleset)
+	return context.WithValue(ctx, fetchCacheKey{}, fetches), func() {
+		for _, fetched := range fetches {
+			_ = os.RemoveAll(fetched.dir)
+		}
 	}
+}
 
-	// Create auth configuration
-	authConfig := &registry.AuthConfig{}
-	if regConfig := cfg.RegistryConfigs[registryName]; regConfig != nil {
-		authConfig.Token = regConfig["authToken"]
-		authConfig.Region = regConfig["region"]
-		authConfig.Profile = regConfig["profile"]
+// fetchKey identifies the content a fetch produces: the registry, the ruleset
+// version, the patterns and the integrity the lock file expects of it
+func fetchKey(registryConfig *registry.RegistryConfig, name, version string, patterns []string, locked *config.LockedRuleset) string {
+	normalized := append([]string{}, patterns...)
+	sort.Strings(normalized)
+	key := strings.Join([]string{registryConfig.Type, registryConfig.URL, name, version, strings.Join(normalized, ",")}, "\n")
+	if locked != nil {
+		key += "\n" + locked.Resolved + "\n" + locked.Integrity
 	}
+	return key
+}
 
-	// Create cache manager with configured path
-	cacheManager := cache.NewManager(cfg.CacheConfig.Path)
+// fetchRuleset runs fetch in a new temporary directory, or returns the result
+// of an earlier fetch with the same key made with a fetch cache. The returned
+// function releases the files once they are installed.
+func fetchRuleset(ctx context.Context, key string, fetch func(dir string) (*fetchedRuleset, error)) (*fetchedRuleset, func(), error) {
+	fetches, shared := ctx.Value(fetchCacheKey{}).(map[string]*fetchedRuleset)
+	if fetched := fetches[key]; fetched != nil {
+		return fetched, func() {}, nil
+	}
 
-	// Create Git registry instance with cache
-	reg, err := registry.CreateRegistryWithCacheConfig(registryConfig, authConfig, cacheManager, cfg.CacheConfig, registryName)
+	dir, err := os.MkdirTemp("", "arm-install-*")
 	if err != nil {
-		return fmt.Errorf("failed to create registry: %w", err)
+		return nil, nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	defer func() { _ = reg.Close() }()
-
-	// Cast to GitRegistry to access structured download
-	gitReg, ok := reg.(*registry.GitRegistry)
-	if !ok {
-		return fmt.Errorf("expected Git registry but got %T", reg)
+	fetched, err := fetch(dir)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
 	}
+	fetched.dir = dir
 
-	fmt.Printf("⬇ Downloading %s@%s\n", rulesetName, version)
+	if shared {
+		fetches[key] = fetched
+		return fetched, func() {}, nil
+	}
+	return fetched, func() { _ = os.RemoveAll(dir) }, nil
+}
 
-	// Create temporary directory for download
-	tempDir, err := os.MkdirTemp("", "arm-install-*")
+// performGitInstallation handles Git registry installations with proper version tracking.
+// A non-empty lockedVersion is downloaded instead of resolving version again.
+func performGitInstallation(ctx context.Context, cfg *config.Config, registryName, rulesetName, version, lockedVersion, channels, patterns string) error {
+	builder := newRegistryBuilder(cfg)
+	registryConfig, err := builder.Config(registryName)
+	if err != nil {
+		return err
+	}
+	reg, err := builder.Create(registryConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+		return err
+	}
+	defer func() { _ = reg.Close() }()
+
+	// Git registries and mirrored registries support structured download
+	downloader, ok := reg.(registry.ResultDownloader)
+	if !ok {
+		return fmt.Errorf("registry %s does not support structured download (%T)", registryName, reg)
 	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
 
 	// Parse patterns
 	var patternList []string
@@ -1596,10 +1936,47 @@ func performGitInstallation(cfg *config.Config, registryName, rulesetName, versi
 		}
 	}
 
-	// Download with structured result
-	result, err := gitReg.DownloadRulesetWithResult(context.Background(), rulesetName, version, tempDir, patternList)
+	// Download with structured result, pinned to the locked version when known
+	downloadVersion := version
+	if lockedVersion != "" {
+		downloadVersion = lockedVersion
+	}
+	locked := lockedRuleset(cfg, registryName, rulesetName)
+	fetched, release, err := fetchRuleset(ctx, fetchKey(registryConfig, rulesetName, downloadVersion, patternList, locked), func(tempDir string) (*fetchedRuleset, error) {
+		// Mirrors and caches must serve the same content as recorded in the lock file
+		if checker, ok := reg.(registry.IntegrityChecker); ok && locked != nil {
+			checker.ExpectIntegrity(rulesetName, locked.Resolved, patternList, locked.Integrity)
+		}
+
+		logger.Info("Downloading %s@%s", rulesetName, version)
+		progress.Report(ctx, progress.Downloading, version)
+
+		stop := logger.Timer("Download %s/%s@%s", registryName, rulesetName, downloadVersion)
+		result, err := downloader.DownloadRulesetWithResult(ctx, rulesetName, downloadVersion, tempDir, patternList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download ruleset: %w", err)
+		}
+		stop()
+		if result.Integrity == "" {
+			if result.Integrity, err = registry.ComputeIntegrity(tempDir); err != nil {
+				return nil, err
+			}
+		}
+		return &fetchedRuleset{resolved: result.ResolvedVersion, integrity: result.Integrity, source: result.Source, files: result.Files}, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to download ruleset: %w", err)
+		return err
+	}
+	defer release()
+	result := &registry.DownloadResult{
+		VersionSpec:     version,
+		ResolvedVersion: fetched.resolved,
+		Files:           fetched.files,
+		Source:          fetched.source,
+		Integrity:       fetched.integrity,
+	}
+	if result.Source != "" && result.Source != registryName {
+		logger.Info("  Served by mirror: %s", result.Source)
 	}
 
 	// Parse channels
@@ -1620,105 +1997,109 @@ func performGitInstallation(cfg *config.Config, registryName, rulesetName, versi
 		ResolvedVersion: result.ResolvedVersion, // Actual commit hash
 		SourceFiles:     result.Files,
 		Channels:        targetChannels,
+		Integrity:       result.Integrity,
+	}
+	if result.Source != registryName {
+		req.Source = result.Source
 	}
 
-	installResult, err := installer.Install(req)
+	installResult, err := installer.Install(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to install: %w", err)
 	}
 
 	// Update manifest with original version spec
-	manifestMgr := config.NewManifestManager(false)
+	manifestMgr := cfg.Manifest()
 	if err := manifestMgr.AddRuleset(registryName, rulesetName, result.VersionSpec, patternList); err != nil {
-		fmt.Printf("Warning: Failed to update manifest: %v\n", err)
-	}
-
-	fmt.Printf("✓ Installed %s/%s@%s\n", installResult.Registry, installResult.Ruleset, installResult.Version)
-	fmt.Printf("  Files: %d\n", installResult.FilesCount)
-	fmt.Printf("  Channels: %s\n", strings.Join(installResult.Channels, ", "))
+		warn(ctx, registryName+"/"+rulesetName, "Failed to update manifest: %v", err)
+	}
+
+	recordInstalled(ctx, installedRuleset{
+		Workspace: cfg.Workspace,
+		Registry:  registryName,
+		Name:      rulesetName,
+		Version:   result.VersionSpec,
+		Resolved:  result.ResolvedVersion,
+		Integrity: result.Integrity,
+		Source:    req.Source,
+		Patterns:  patternList,
+		Files:     installResult.FilesCount,
+		Channels:  installResult.Channels,
+	})
+	logger.Success("Installed %s", scopedName(cfg, installResult.Registry+"/"+installResult.Ruleset+"@"+installResult.Version))
+	logger.Info("  Files: %d", installResult.FilesCount)
+	logger.Info("  Channels: %s", strings.Join(installResult.Channels, ", "))
 
 	return nil
 }
 
-// performInstallation performs the actual installation of a ruleset
-func performInstallation(cfg *config.Config, registryName, rulesetName, version, channels, patterns string) error {
-	// Create registry configuration
-	registryConfig := &registry.RegistryConfig{
-		Name: registryName,
-		Type: cfg.RegistryConfigs[registryName]["type"],
-		URL:  cfg.Registries[registryName],
-	}
-
-	// Create auth configuration
-	authConfig := &registry.AuthConfig{}
-	if regConfig := cfg.RegistryConfigs[registryName]; regConfig != nil {
-		authConfig.Token = regConfig["authToken"]
-		authConfig.Region = regConfig["region"]
-		authConfig.Profile = regConfig["profile"]
+// performInstallation performs the actual installation of a ruleset.
+// A non-empty lockedVersion is downloaded instead of resolving version again.
+func performInstallation(ctx context.Context, cfg *config.Config, registryName, rulesetName, version, lockedVersion, channels, patterns string) error {
+	builder := newRegistryBuilder(cfg)
+	registryConfig, err := builder.Config(registryName)
+	if err != nil {
+		return err
 	}
-
-	// Create cache manager with configured path
-	cacheManager := cache.NewManager(cfg.CacheConfig.Path)
-
-	// Create registry instance with cache
-	reg, err := registry.CreateRegistryWithCacheConfig(registryConfig, authConfig, cacheManager, cfg.CacheConfig, registryName)
+	reg, err := builder.Create(registryConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create registry: %w", err)
+		return err
 	}
 	defer func() { _ = reg.Close() }()
 
-	// For Git registries, use structured download to get both versions
-	if regConfig := cfg.RegistryConfigs[registryName]; regConfig != nil && regConfig["type"] == "git" {
-		return performGitInstallation(cfg, registryName, rulesetName, version, channels, patterns)
-	}
-
-	// For non-Git registries, use version as-is (they use concrete versions)
-
-	fmt.Printf("⬇ Downloading %s@%s\n", rulesetName, version)
-
-	// Create temporary directory for download
-	tempDir, err := os.MkdirTemp("", "arm-install-*")
-	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+	// For Git, mirrored and cached registries, use structured download to get both versions
+	_, remoteCached := reg.(*registry.RemoteCacheRegistry)
+	if registryConfig.Type == "git" || len(registryConfig.Mirrors) > 0 || remoteCached || (registryConfig.Offline && registry.SupportsOffline(registryConfig.Type)) {
+		return performGitInstallation(ctx, cfg, registryName, rulesetName, version, lockedVersion, channels, patterns)
 	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
-
-	// Download ruleset with patterns for Git registries
-	var sourceFiles []string
-	regConfig := cfg.RegistryConfigs[registryName]
 
-	if regConfig != nil && regConfig["type"] == "git" {
-		// Parse patterns from command line
-		var patternList []string
-		if patterns != "" {
-			patternList = strings.Split(patterns, ",")
-			for i, p := range patternList {
-				patternList[i] = strings.TrimSpace(p)
-			}
+	// For non-Git registries, resolve the spec against the published versions
+	locked := lockedRuleset(cfg, registryName, rulesetName)
+	fetched, release, err := fetchRuleset(ctx, fetchKey(registryConfig, rulesetName, version+"@"+lockedVersion, nil, locked), func(tempDir string) (*fetchedRuleset, error) {
+		resolvedVersion := lockedVersion
+		if resolvedVersion == "" {
+			progress.Report(ctx, progress.Resolving, version)
+			resolvedVersion = registry.ResolveVersionSpec(ctx, reg, rulesetName, version)
 		}
 
-		// Use Git-specific download method
-		if err := reg.DownloadRulesetWithPatterns(context.Background(), rulesetName, version, tempDir, patternList); err != nil {
-			return fmt.Errorf("failed to download ruleset: %w", err)
+		logger.Info("Downloading %s@%s", rulesetName, version)
+		progress.Report(ctx, progress.Downloading, version)
+
+		stop := logger.Timer("Download %s/%s@%s", registryName, rulesetName, resolvedVersion)
+		if err := reg.DownloadRuleset(ctx, rulesetName, resolvedVersion, tempDir); err != nil {
+			return nil, fmt.Errorf("failed to download ruleset: %w", err)
 		}
+		stop()
 
-		// For Git registries, files are copied directly - find them
-		sourceFiles, err = findDownloadedFiles(tempDir)
+		integrity, err := registry.ComputeIntegrity(tempDir)
 		if err != nil {
-			return fmt.Errorf("failed to find downloaded files: %w", err)
+			return nil, err
 		}
-	} else {
-		// Use standard download method for other registry types
-		if err := reg.DownloadRuleset(context.Background(), rulesetName, version, tempDir); err != nil {
-			return fmt.Errorf("failed to download ruleset: %w", err)
+
+		// Reinstalled versions must match the content recorded in the lock file
+		if lockedVersion != "" && locked != nil && locked.Integrity != "" && locked.Integrity != integrity {
+			return nil, fmt.Errorf("%w for %s/%s@%s: expected %s, got %s", registry.ErrIntegrityMismatch, registryName, rulesetName, lockedVersion, locked.Integrity, integrity)
 		}
 
 		// Extract downloaded tar.gz files
-		sourceFiles, err = extractRuleset(tempDir)
+		sourceFiles, err := extractRuleset(tempDir)
 		if err != nil {
-			return fmt.Errorf("failed to extract ruleset: %w", err)
+			return nil, fmt.Errorf("failed to extract ruleset: %w", err)
 		}
+
+		// Keep the extracted files of network registries so the ruleset can be installed offline
+		if registry.SupportsOffline(registryConfig.Type) {
+			if err := registry.CacheRuleset(builder.CacheManager(), registryConfig, rulesetName, version, resolvedVersion, tempDir); err != nil {
+				warn(ctx, registryName+"/"+rulesetName, "Failed to cache ruleset: %v", err)
+			}
+		}
+		return &fetchedRuleset{resolved: resolvedVersion, integrity: integrity, files: sourceFiles}, nil
+	})
+	if err != nil {
+		return err
 	}
+	defer release()
+	resolvedVersion, sourceFiles, integrity := fetched.resolved, fetched.files, fetched.integrity
 
 	// Parse channels
 	var targetChannels []string
@@ -1732,26 +2113,61 @@ func performInstallation(cfg *config.Config, registryName, rulesetName, version,
 	// Create installer and install
 	installer := install.New(cfg)
 	req := &install.InstallRequest{
-		Registry:    registryName,
-		Ruleset:     rulesetName,
-		Version:     version,
-		SourceFiles: sourceFiles,
-		Channels:    targetChannels,
+		Registry:        registryName,
+		Ruleset:         rulesetName,
+		Version:         version,
+		ResolvedVersion: resolvedVersion,
+		SourceFiles:     sourceFiles,
+		Channels:        targetChannels,
+		Integrity:       integrity,
 	}
 
-	result, err := installer.Install(req)
+	result, err := installer.Install(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to install: %w", err)
 	}
 
-	fmt.Printf("✓ Installed %s/%s@%s\n", result.Registry, result.Ruleset, result.Version)
-	fmt.Printf("  Files: %d\n", result.FilesCount)
-	fmt.Printf("  Channels: %s\n", strings.Join(result.Channels, ", "))
+	recordInstalled(ctx, installedRuleset{
+		Workspace: cfg.Workspace,
+		Registry:  registryName,
+		Name:      rulesetName,
+		Version:   version,
+		Resolved:  resolvedVersion,
+		Integrity: integrity,
+		Files:     result.FilesCount,
+		Channels:  result.Channels,
+	})
+	logger.Success("Installed %s", scopedName(cfg, result.Registry+"/"+result.Ruleset+"@"+result.Version))
+	logger.Info("  Files: %d", result.FilesCount)
+	logger.Info("  Channels: %s", strings.Join(result.Channels, ", "))
 
 	return nil
 }
 
-// extractRuleset extracts files from downloaded ruleset
+// notCachedError combines offline cache misses into a single error listing what is missing
+func notCachedError(errs []error) error {
+	missing := make([]string, 0, len(errs))
+	for _, err := range errs {
+		missing = append(missing, "  "+err.Error())
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("%w in offline mode:\n%s", registry.ErrNotCached, strings.Join(missing, "\n"))
+}
+
+// lockedRuleset returns the lock file entry for a ruleset, if any
+func lockedRuleset(cfg *config.Config, registryName, rulesetName string) *config.LockedRuleset {
+	if cfg.LockFile == nil {
+		return nil
+	}
+	locked, exists := cfg.LockFile.Rulesets[registryName][rulesetName]
+	if !exists {
+		return nil
+	}
+	return &locked
+}
+
+// extractRuleset extracts the downloaded ruleset.tar.gz in place so tarball
+// registries install with the same layout as Git registries
 func extractRuleset(tempDir string) ([]string, error) {
 	// Look for ruleset.tar.gz file
 	tarPath := filepath.Join(tempDir, "ruleset.tar.gz")
@@ -1759,32 +2175,12 @@ func extractRuleset(tempDir string) ([]string, error) {
 		return nil, fmt.Errorf("ruleset.tar.gz not found: %w", err)
 	}
 
-	// Extract tar.gz file
-	extractDir := filepath.Join(tempDir, "extracted")
-	if err := os.MkdirAll(extractDir, 0o755); err != nil {
-		return nil, fmt.Errorf("failed to create extract directory: %w", err)
-	}
-
-	// Use tar command to extract
-	cmd := exec.Command("tar", "-xzf", tarPath, "-C", extractDir)
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to extract tar.gz: %w", err)
-	}
-
-	// Find all extracted files
-	var sourceFiles []string
-	err := filepath.Walk(extractDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			sourceFiles = append(sourceFiles, path)
-		}
-		return nil
-	})
-
+	sourceFiles, err := registry.ExtractTarball(tarPath, tempDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan extracted files: %w", err)
+		return nil, err
+	}
+	if err := os.Remove(tarPath); err != nil {
+		return nil, fmt.Errorf("failed to remove ruleset.tar.gz: %w", err)
 	}
 
 	return sourceFiles, nil
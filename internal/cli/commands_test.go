@@ -1,102 +1,53 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/max-dunn/ai-rules-manager/internal/config"
-	"github.com/max-dunn/ai-rules-manager/internal/update"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
 )
 
-func TestHandleConfigSet(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "config-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
-
-	// Change to temp directory
-	originalWd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(originalWd) }()
-	_ = os.Chdir(tempDir)
-
-	// Test setting a configuration value
-	err = handleConfigSet("git.concurrency", "5", false)
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
-	}
-
-	// Verify the file was created and contains the value
-	content, err := os.ReadFile(".armrc")
-	if err != nil {
-		t.Fatalf("Failed to read .armrc: %v", err)
-	}
-
-	if !strings.Contains(string(content), "[git]") {
-		t.Error("Expected [git] section in .armrc")
-	}
-	if !strings.Contains(string(content), "concurrency = 5") {
-		t.Error("Expected concurrency = 5 in .armrc")
-	}
-}
-
-func TestHandleAddRegistry(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "config-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
-
-	// Change to temp directory
-	originalWd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(originalWd) }()
-	_ = os.Chdir(tempDir)
-
-	// Test adding a Git registry
-	err = handleAddRegistry("my-git", "https://github.com/user/repo", "git", false, map[string]string{
-		"authToken": "test-token",
-		"apiType":   "github",
-	})
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
-	}
-
-	// Verify the file was created and contains the registry
-	content, err := os.ReadFile(".armrc")
-	if err != nil {
-		t.Fatalf("Failed to read .armrc: %v", err)
-	}
-
-	expectedContent := []string{
-		"[registries]",
-		"my-git",
-		"https://github.com/user/repo",
-		"[registries.my-git]",
-		"type",
-		"git",
-		"authToken",
-		"test-token",
-		"apiType",
-		"github",
+func TestParseRulesetSpec(t *testing.T) {
+	tests := []struct {
+		spec             string
+		expectedRegistry string
+		expectedName     string
+		expectedVersion  string
+	}{
+		{"my-rules", "", "my-rules", "latest"},
+		{"my-rules@1.0.0", "", "my-rules", "1.0.0"},
+		{"registry/my-rules", "registry", "my-rules", "latest"},
+		{"registry/my-rules@1.0.0", "registry", "my-rules", "1.0.0"},
 	}
 
-	for _, expected := range expectedContent {
-		if !strings.Contains(string(content), expected) {
-			t.Errorf("Expected '%s' in .armrc, got:\n%s", expected, string(content))
+	for _, test := range tests {
+		registry, name, version := parseRulesetSpec(test.spec)
+		if registry != test.expectedRegistry {
+			t.Errorf("parseRulesetSpec(%s) registry = %s, expected %s", test.spec, registry, test.expectedRegistry)
+		}
+		if name != test.expectedName {
+			t.Errorf("parseRulesetSpec(%s) name = %s, expected %s", test.spec, name, test.expectedName)
+		}
+		if version != test.expectedVersion {
+			t.Errorf("parseRulesetSpec(%s) version = %s, expected %s", test.spec, version, test.expectedVersion)
 		}
 	}
 }
 
-func TestHandleAddChannel(t *testing.T) {
+func TestHandleInstallFromManifest(t *testing.T) {
 	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "config-test")
+	tempDir, err := os.MkdirTemp("", "install-test")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
 	}
@@ -107,212 +58,122 @@ func TestHandleAddChannel(t *testing.T) {
 	defer func() { _ = os.Chdir(originalWd) }()
 	_ = os.Chdir(tempDir)
 
-	// Test adding a channel
-	err = handleAddChannel("cursor", ".cursor/rules,custom/cursor", false)
+	// Test with no configuration (should generate stubs)
+	err = handleInstallFromManifest(context.Background(), false, true, "")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Verify the file was created and contains the channel
-	content, err := os.ReadFile("arm.json")
-	if err != nil {
-		t.Fatalf("Failed to read arm.json: %v", err)
-	}
-
-	if !strings.Contains(string(content), "cursor") {
-		t.Error("Expected 'cursor' channel in arm.json")
-	}
-	if !strings.Contains(string(content), ".cursor/rules") {
-		t.Error("Expected '.cursor/rules' directory in arm.json")
+	// Verify stub files were created
+	if _, err := os.Stat(".armrc"); os.IsNotExist(err) {
+		t.Error("Expected .armrc to be created")
 	}
-	if !strings.Contains(string(content), "custom/cursor") {
-		t.Error("Expected 'custom/cursor' directory in arm.json")
+	if _, err := os.Stat("arm.json"); os.IsNotExist(err) {
+		t.Error("Expected arm.json to be created")
 	}
 }
 
-func TestHandleRemoveRegistry(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "config-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
+func TestHandleInstallFromManifest_LockedVersion(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
 
-	// Change to temp directory
 	originalWd, _ := os.Getwd()
 	defer func() { _ = os.Chdir(originalWd) }()
 	_ = os.Chdir(tempDir)
 
-	// First add a registry
-	err = handleAddRegistry("test-registry", "https://example.com", "https", false, map[string]string{})
-	if err != nil {
-		t.Fatalf("Failed to add registry: %v", err)
+	// Local registry with two versions of the same ruleset
+	registryDir := filepath.Join(tempDir, "registry")
+	for version, content := range map[string]string{"1.0.0": "old", "1.1.0": "new"} {
+		srcDir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(srcDir, "rules.md"), []byte(content), 0o644)
+		if err := registry.CreateTarball(srcDir, filepath.Join(registryDir, "rules", version, "ruleset.tar.gz")); err != nil {
+			t.Fatalf("Failed to create tarball: %v", err)
+		}
 	}
 
-	// Then remove it
-	err = handleRemoveRegistry("test-registry", false)
-	if err != nil {
+	armrc := fmt.Sprintf("[registries]\nlocal = %s\n\n[registries.local]\ntype = local\n", registryDir)
+	armJSON := `{"engines":{"arm":"^1.0.0"},"channels":{"cursor":{"directories":["rules"]}},"rulesets":{"local":{"rules":{"version":"latest"}}}}`
+	lock := `{"rulesets":{"local":{"rules":{"version":"latest","resolved":"1.0.0","registry":"","type":"local"}}}}`
+	_ = os.WriteFile(".armrc", []byte(armrc), 0o644)
+	_ = os.WriteFile("arm.json", []byte(armJSON), 0o644)
+	_ = os.WriteFile("arm.lock", []byte(lock), 0o644)
+
+	if err := handleInstallFromManifest(context.Background(), false, false, ""); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Verify the registry was removed
-	content, err := os.ReadFile(".armrc")
-	if err != nil {
-		t.Fatalf("Failed to read .armrc: %v", err)
+	content, err := os.ReadFile(filepath.Join("rules", "arm", "local", "rules", "latest", "rules.md"))
+	if err != nil || string(content) != "old" {
+		t.Errorf("Expected locked version 1.0.0 to be installed, got %q (%v)", content, err)
 	}
 
-	if strings.Contains(string(content), "test-registry") {
-		t.Error("Expected 'test-registry' to be removed from .armrc")
+	// A locked integrity that no longer matches must fail the install
+	lock = `{"rulesets":{"local":{"rules":{"version":"latest","resolved":"1.0.0","registry":"","type":"local","integrity":"sha256-tampered"}}}}`
+	_ = os.WriteFile("arm.lock", []byte(lock), 0o644)
+	if err := handleInstallFromManifest(context.Background(), false, false, ""); err == nil {
+		t.Error("Expected integrity mismatch to fail the install")
 	}
 }
 
-func TestHandleRemoveChannel(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "config-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
+func TestHandleInstallFromManifest_WorkspaceFetchesOnce(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
 
-	// Change to temp directory
 	originalWd, _ := os.Getwd()
 	defer func() { _ = os.Chdir(originalWd) }()
 	_ = os.Chdir(tempDir)
 
-	// First add a channel
-	err = handleAddChannel("test-channel", "test/dir", false)
-	if err != nil {
-		t.Fatalf("Failed to add channel: %v", err)
-	}
-
-	// Then remove it
-	err = handleRemoveChannel("test-channel", false)
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
-	}
-
-	// Verify the channel was removed
-	content, err := os.ReadFile("arm.json")
-	if err != nil {
-		t.Fatalf("Failed to read arm.json: %v", err)
-	}
-
-	if strings.Contains(string(content), "test-channel") {
-		t.Error("Expected 'test-channel' to be removed from arm.json")
-	}
-}
-
-func TestGetConfigValue(t *testing.T) {
-	cfg := &config.Config{
-		Registries: map[string]string{
-			"default": "https://github.com/user/repo",
-		},
-		RegistryConfigs: map[string]map[string]string{
-			"default": {
-				"type":      "git",
-				"authToken": "test-token",
-			},
-		},
-		TypeDefaults: map[string]map[string]string{
-			"git": {
-				"concurrency": "1",
-				"rateLimit":   "10/minute",
-			},
-		},
-		NetworkConfig: map[string]string{
-			"timeout": "30",
-		},
-	}
-
-	tests := []struct {
-		key      string
-		expected string
-	}{
-		{"registries.default", "https://github.com/user/repo"},
-		{"registries.default.type", "git"},
-		{"registries.default.authToken", "test-token"},
-		{"git.concurrency", "1"},
-		{"git.rateLimit", "10/minute"},
-		{"network.timeout", "30"},
-
-		{"nonexistent.key", ""},
-	}
-
-	for _, test := range tests {
-		result := getConfigValue(cfg, test.key)
-		if result != test.expected {
-			t.Errorf("getConfigValue(%s) = %s, expected %s", test.key, result, test.expected)
+	srcDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(srcDir, "rules.md"), []byte("rules"), 0o644)
+	tarball := filepath.Join(t.TempDir(), "ruleset.tar.gz")
+	if err := registry.CreateTarball(srcDir, tarball); err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+
+	var downloads int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manifest.json":
+			_, _ = w.Write([]byte(`{"rulesets": {"rules": ["1.0.0", "1.1.0"]}}`))
+		case "/rules/1.1.0/ruleset.tar.gz":
+			atomic.AddInt32(&downloads, 1)
+			http.ServeFile(w, r, tarball)
+		default:
+			http.NotFound(w, r)
 		}
-	}
-}
-
-func TestGetConfigPath(t *testing.T) {
-	// Test local path
-	localPath := getConfigPath(".armrc", false)
-	if localPath != ".armrc" {
-		t.Errorf("Expected '.armrc', got %s", localPath)
-	}
-
-	// Test global path
-	globalPath := getConfigPath(".armrc", true)
-	expectedGlobal := filepath.Join(os.Getenv("HOME"), ".arm", ".armrc")
-	if globalPath != expectedGlobal {
-		t.Errorf("Expected %s, got %s", expectedGlobal, globalPath)
-	}
-}
-
-func TestParseRulesetSpec(t *testing.T) {
-	tests := []struct {
-		spec             string
-		expectedRegistry string
-		expectedName     string
-		expectedVersion  string
-	}{
-		{"my-rules", "", "my-rules", "latest"},
-		{"my-rules@1.0.0", "", "my-rules", "1.0.0"},
-		{"registry/my-rules", "registry", "my-rules", "latest"},
-		{"registry/my-rules@1.0.0", "registry", "my-rules", "1.0.0"},
-	}
-
-	for _, test := range tests {
-		registry, name, version := parseRulesetSpec(test.spec)
-		if registry != test.expectedRegistry {
-			t.Errorf("parseRulesetSpec(%s) registry = %s, expected %s", test.spec, registry, test.expectedRegistry)
-		}
-		if name != test.expectedName {
-			t.Errorf("parseRulesetSpec(%s) name = %s, expected %s", test.spec, name, test.expectedName)
-		}
-		if version != test.expectedVersion {
-			t.Errorf("parseRulesetSpec(%s) version = %s, expected %s", test.spec, version, test.expectedVersion)
+	}))
+	defer server.Close()
+	originalTransport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	defer func() { http.DefaultTransport = originalTransport }()
+
+	// The root project and both members require the same ruleset
+	rulesets := `"rulesets": {"web": {"rules": {"version": "^1.0.0"}}}`
+	files := map[string]string{
+		".armrc":                fmt.Sprintf("[registries]\nweb = %s\n\n[registries.web]\ntype = https\n", server.URL),
+		"arm.json":              `{"workspaces": ["packages/*"], "channels": {"cursor": {"directories": ["rules"]}}, ` + rulesets + `}`,
+		"packages/api/arm.json": `{` + rulesets + `}`,
+		"packages/web/arm.json": `{` + rulesets + `}`,
+	}
+	for path, content := range files {
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
 		}
 	}
-}
-
-func TestHandleInstallFromManifest(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "install-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
-
-	// Change to temp directory
-	originalWd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(originalWd) }()
-	_ = os.Chdir(tempDir)
 
-	// Test with no configuration (should generate stubs)
-	err = handleInstallFromManifest(false, true, "")
-	if err != nil {
+	if err := handleInstallFromManifest(context.Background(), false, false, ""); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Verify stub files were created
-	if _, err := os.Stat(".armrc"); os.IsNotExist(err) {
-		t.Error("Expected .armrc to be created")
+	if got := atomic.LoadInt32(&downloads); got != 1 {
+		t.Errorf("Expected the ruleset to be downloaded once, got %d downloads", got)
 	}
-	if _, err := os.Stat("arm.json"); os.IsNotExist(err) {
-		t.Error("Expected arm.json to be created")
+	for _, dir := range []string{".", "packages/api", "packages/web"} {
+		path := filepath.Join(dir, "rules", "arm", "web", "rules", "^1.0.0", "rules.md")
+		if content, err := os.ReadFile(path); err != nil || string(content) != "rules" {
+			t.Errorf("Expected %s to be installed, got %q (%v)", path, content, err)
+		}
 	}
 }
 
@@ -348,19 +209,19 @@ type = git
 	}
 
 	// Test installing from default registry (should require patterns for Git)
-	err = handleInstallRuleset("my-rules", false, true, "", "")
+	err = handleInstallRuleset(context.Background(), "my-rules", false, true, "", "")
 	if err == nil {
 		t.Error("Expected error for Git registry without patterns")
 	}
 
 	// Test with patterns (dry run should succeed)
-	err = handleInstallRuleset("my-rules", false, true, "", "*.md")
+	err = handleInstallRuleset(context.Background(), "my-rules", false, true, "", "*.md")
 	if err != nil {
 		t.Fatalf("Expected no error with patterns, got %v", err)
 	}
 
 	// Test with specific registry
-	err = handleInstallRuleset("default/my-rules@1.0.0", false, true, "", "*.md")
+	err = handleInstallRuleset(context.Background(), "default/my-rules@1.0.0", false, true, "", "*.md")
 	if err != nil {
 		t.Fatalf("Expected no error with specific registry, got %v", err)
 	}
@@ -402,72 +263,132 @@ type = git
 	}
 
 	// Test search with no registry filter
-	err = handleSearch("python", "", false, 10)
+	err = handleSearch(context.Background(), "python", "", false, 10)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Test search with specific registry
-	err = handleSearch("python", "default", false, 10)
+	err = handleSearch(context.Background(), "python", "default", false, 10)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Test search with glob pattern
-	err = handleSearch("python", "my-*", false, 10)
+	err = handleSearch(context.Background(), "python", "my-*", false, 10)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 }
 
 func TestHandleInfo(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "info-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
+	tempDir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
 
-	// Change to temp directory
 	originalWd, _ := os.Getwd()
 	defer func() { _ = os.Chdir(originalWd) }()
 	_ = os.Chdir(tempDir)
 
-	// Create basic configuration
-	armrcContent := `[registries]
-default = https://github.com/user/repo
-
-[registries.default]
-type = git
-`
-	err = os.WriteFile(".armrc", []byte(armrcContent), 0o600)
-	if err != nil {
-		t.Fatalf("Failed to create .armrc: %v", err)
+	// Local registry with three versions of the same ruleset
+	registryDir := filepath.Join(tempDir, "registry")
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		srcDir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(srcDir, "rules.md"), []byte(version), 0o644)
+		if err := registry.CreateTarball(srcDir, filepath.Join(registryDir, "rules", version, "ruleset.tar.gz")); err != nil {
+			t.Fatalf("Failed to create tarball: %v", err)
+		}
 	}
 
-	armJSONContent := `{"engines":{"arm":"^1.0.0"},"channels":{},"rulesets":{}}`
-	err = os.WriteFile("arm.json", []byte(armJSONContent), 0o600)
-	if err != nil {
-		t.Fatalf("Failed to create arm.json: %v", err)
-	}
+	armrc := fmt.Sprintf("[registries]\ndefault = %s\n\n[registries.default]\ntype = local\n", registryDir)
+	lock := `{"rulesets":{"default":{"rules":{"version":"^1.0.0","resolved":"1.0.0","registry":"","type":"local","integrity":"sha256-abc"}}}}`
+	_ = os.WriteFile(".armrc", []byte(armrc), 0o600)
+	_ = os.WriteFile("arm.json", []byte(`{"engines":{"arm":"^1.0.0"},"channels":{},"rulesets":{}}`), 0o600)
+	_ = os.WriteFile("arm.lock", []byte(lock), 0o600)
 
 	// Test info with default registry
-	err = handleInfo("my-rules", false, false)
-	if err != nil {
+	if err := handleInfo(context.Background(), "rules", false, false); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Test info with specific registry and version
-	err = handleInfo("default/my-rules@1.0.0", false, true)
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+	// Test info with JSON output for a version range
+	var details rulesetDetails
+	if doc := captureJSON(t, "info", &details, func(ctx context.Context) error {
+		return handleInfo(ctx, "default/rules@^1.0.0", true, true)
+	}); !doc.Success {
+		t.Fatalf("Expected success, got %+v", doc.Errors)
+	}
+	if details.Resolved != "1.1.0" || details.Latest != "2.0.0" {
+		t.Errorf("Expected ^1.0.0 to resolve to 1.1.0 with latest 2.0.0, got %s and %s", details.Resolved, details.Latest)
+	}
+	if strings.Join(details.Matching, ",") != "1.1.0,1.0.0" {
+		t.Errorf("Expected matching versions [1.1.0 1.0.0], got %v", details.Matching)
+	}
+	if len(details.Versions) != 3 {
+		t.Errorf("Expected all versions to be listed, got %v", details.Versions)
+	}
+	if details.Installed == nil || details.Installed.Resolved != "1.0.0" || details.Installed.Source != "default" {
+		t.Errorf("Expected locked version 1.0.0 from default, got %+v", details.Installed)
+	}
+
+	// Unknown versions and registries fail
+	if err := handleInfo(context.Background(), "rules@3.0.0", false, false); err == nil {
+		t.Error("Expected error for unknown version")
+	}
+	if err := handleInfo(context.Background(), "missing/rules", false, false); err == nil {
+		t.Error("Expected error for unknown registry")
+	}
+}
+
+// captureJSON runs fn as a command with --json, decodes the result of its
+// document into result and returns the document
+func captureJSON(t *testing.T, command string, result interface{}, fn func(context.Context) error) Output {
+	t.Helper()
+	var output bytes.Buffer
+	_ = runWithJSONOutput(context.Background(), &output, command, fn)
+	return decodeOutput(t, output.String(), command, result)
+}
+
+// decodeOutput decodes a JSON output document and its result
+func decodeOutput(t *testing.T, output, command string, result interface{}) Output {
+	t.Helper()
+	var doc struct {
+		Output
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, output)
+	}
+	if doc.SchemaVersion != OutputSchemaVersion || doc.Command != command {
+		t.Errorf("Expected schema version %d for %s, got %d for %s", OutputSchemaVersion, command, doc.SchemaVersion, doc.Command)
 	}
+	if result != nil && len(doc.Result) > 0 {
+		if err := json.Unmarshal(doc.Result, result); err != nil {
+			t.Fatalf("Failed to decode result: %v: %s", err, doc.Result)
+		}
+	}
+	return doc.Output
+}
 
-	// Test info with JSON output
-	err = handleInfo("my-rules", true, false)
+// captureStdout returns what fn writes to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
 	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+		t.Fatalf("Failed to create pipe: %v", err)
 	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		done <- data
+	}()
+
+	fn()
+	_ = writer.Close()
+	return string(<-done)
 }
 
 func TestHandleList(t *testing.T) {
@@ -511,525 +432,191 @@ type = git
 	}
 
 	// Test list with rulesets
-	err = handleList(false, false, false, "")
+	err = handleList(context.Background(), false, false, false, "")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Test list with JSON output
-	err = handleList(false, false, true, "cursor")
+	err = handleList(context.Background(), false, false, true, "cursor")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 }
 
-func TestGetTargetRegistries(t *testing.T) {
-	allRegistries := map[string]string{
-		"default":   "https://github.com/user/repo",
-		"my-git":    "https://github.com/other/repo",
-		"my-s3":     "my-bucket",
-		"test-repo": "https://test.com/repo",
+func TestHandleListScopes(t *testing.T) {
+	tempDir := t.TempDir()
+	globalDir := filepath.Join(tempDir, ".arm")
+	if err := os.MkdirAll(globalDir, 0o755); err != nil {
+		t.Fatalf("Failed to create global dir: %v", err)
 	}
+	originalHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tempDir)
+	defer func() { _ = os.Setenv("HOME", originalHome) }()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
 
-	tests := []struct {
-		filter   string
-		expected []string
-	}{
-		{"", []string{"default", "my-git", "my-s3", "test-repo"}},
-		{"default", []string{"default"}},
-		{"default,my-git", []string{"default", "my-git"}},
-		{"my-*", []string{"my-git", "my-s3"}},
-		{"*-repo", []string{"test-repo"}},
-		{"nonexistent", []string{}},
+	files := map[string]string{
+		filepath.Join(globalDir, ".armrc"):   "[registries]\ndefault = https://github.com/user/repo\n\n[registries.default]\ntype = git\n",
+		filepath.Join(globalDir, "arm.json"): `{"channels": {}, "rulesets": {"default": {"global-rules": {"version": "^1.0.0"}}}}`,
+		"arm.json":                           `{"channels": {}, "rulesets": {"default": {"local-rules": {"version": "^2.0.0"}}}}`,
 	}
-
-	for _, test := range tests {
-		result := getTargetRegistries(allRegistries, test.filter)
-		if len(result) != len(test.expected) {
-			t.Errorf("getTargetRegistries(%s) returned %d items, expected %d", test.filter, len(result), len(test.expected))
-			continue
-		}
-
-		// Convert to map for easier comparison
-		resultMap := make(map[string]bool)
-		for _, r := range result {
-			resultMap[r] = true
-		}
-
-		for _, expected := range test.expected {
-			if !resultMap[expected] {
-				t.Errorf("getTargetRegistries(%s) missing expected result: %s", test.filter, expected)
-			}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
 		}
 	}
-}
-
-// Mock update service for testing
-type mockUpdateService struct {
-	updateResults map[string]*update.UpdateResult
-	updateErrors  map[string]error
-}
-
-func (m *mockUpdateService) UpdateRuleset(ctx context.Context, rulesetSpec string) (*update.UpdateResult, error) {
-	if err, exists := m.updateErrors[rulesetSpec]; exists {
-		return nil, err
-	}
-	if result, exists := m.updateResults[rulesetSpec]; exists {
-		return result, nil
-	}
-	return &update.UpdateResult{
-		Registry:        "default",
-		Ruleset:         "unknown",
-		Version:         "1.0.0",
-		PreviousVersion: "1.0.0",
-		Updated:         false,
-	}, nil
-}
-
-// updateServiceInterface allows mocking the update service
-type updateServiceInterface interface {
-	UpdateRuleset(ctx context.Context, rulesetSpec string) (*update.UpdateResult, error)
-}
-
-// NetworkError represents a network-related error for testing
-type NetworkError struct {
-	Message string
-}
-
-func (e *NetworkError) Error() string {
-	return e.Message
-}
 
-func TestHandleOutdated(t *testing.T) {
 	tests := []struct {
-		name           string
-		setupLockFile  func(tempDir string) error
-		mockResults    map[string]*update.UpdateResult
-		mockErrors     map[string]error
-		jsonOutput     bool
-		expectError    bool
-		expectedOutput []string
+		global, local bool
+		scope         string
+		want          []string
 	}{
-		{
-			name: "no lock file",
-			setupLockFile: func(tempDir string) error {
-				return nil // Don't create lock file
-			},
-			expectError:    true,
-			expectedOutput: []string{"no lock file found"},
-		},
-		{
-			name: "all rulesets up to date",
-			setupLockFile: func(tempDir string) error {
-				lockFile := config.LockFile{
-					Rulesets: map[string]map[string]config.LockedRuleset{
-						"default": {
-							"my-rules":     {Version: "1.0.0"},
-							"python-rules": {Version: "2.1.0"},
-						},
-					},
-				}
-				data, _ := json.MarshalIndent(lockFile, "", "  ")
-				return os.WriteFile(filepath.Join(tempDir, "arm.lock"), data, 0o600)
-			},
-			mockResults: map[string]*update.UpdateResult{
-				"default/my-rules": {
-					Registry:        "default",
-					Ruleset:         "my-rules",
-					Version:         "1.0.0",
-					PreviousVersion: "1.0.0",
-					Updated:         false,
-				},
-				"default/python-rules": {
-					Registry:        "default",
-					Ruleset:         "python-rules",
-					Version:         "2.1.0",
-					PreviousVersion: "2.1.0",
-					Updated:         false,
-				},
-			},
-			expectedOutput: []string{"All rulesets are up to date"},
-		},
-		{
-			name: "some rulesets outdated - human readable",
-			setupLockFile: func(tempDir string) error {
-				lockFile := config.LockFile{
-					Rulesets: map[string]map[string]config.LockedRuleset{
-						"default": {
-							"my-rules":     {Version: "1.0.0"},
-							"python-rules": {Version: "2.0.0"},
-						},
-						"my-git": {
-							"js-rules": {Version: "abc123"},
-						},
-					},
-				}
-				data, _ := json.MarshalIndent(lockFile, "", "  ")
-				return os.WriteFile(filepath.Join(tempDir, "arm.lock"), data, 0o600)
-			},
-			mockResults: map[string]*update.UpdateResult{
-				"default/my-rules": {
-					Registry:        "default",
-					Ruleset:         "my-rules",
-					Version:         "1.2.0",
-					PreviousVersion: "1.0.0",
-					Updated:         true,
-				},
-				"default/python-rules": {
-					Registry:        "default",
-					Ruleset:         "python-rules",
-					Version:         "2.0.0",
-					PreviousVersion: "2.0.0",
-					Updated:         false,
-				},
-				"my-git/js-rules": {
-					Registry:        "my-git",
-					Ruleset:         "js-rules",
-					Version:         "def456",
-					PreviousVersion: "abc123",
-					Updated:         true,
-				},
-			},
-			expectedOutput: []string{
-				"Found 2 outdated ruleset(s)",
-				"default/my-rules",
-				"Current: 1.0.0",
-				"Latest:  1.2.0",
-				"Update:  arm update default/my-rules",
-				"my-git/js-rules",
-				"Current: abc123",
-				"Latest:  def456",
-				"Update:  arm update my-git/js-rules",
-			},
-		},
-		{
-			name: "network errors ignored",
-			setupLockFile: func(tempDir string) error {
-				lockFile := config.LockFile{
-					Rulesets: map[string]map[string]config.LockedRuleset{
-						"default": {
-							"my-rules":      {Version: "1.0.0"},
-							"failing-rules": {Version: "1.0.0"},
-						},
-					},
-				}
-				data, _ := json.MarshalIndent(lockFile, "", "  ")
-				return os.WriteFile(filepath.Join(tempDir, "arm.lock"), data, 0o600)
-			},
-			mockResults: map[string]*update.UpdateResult{
-				"default/my-rules": {
-					Registry:        "default",
-					Ruleset:         "my-rules",
-					Version:         "1.2.0",
-					PreviousVersion: "1.0.0",
-					Updated:         true,
-				},
-			},
-			mockErrors: map[string]error{
-				"default/failing-rules": &NetworkError{Message: "connection timeout"},
-			},
-			expectedOutput: []string{
-				"Found 1 outdated ruleset(s)",
-				"default/my-rules",
-				"Current: 1.0.0",
-				"Latest:  1.2.0",
-			},
-		},
+		{false, false, "both", []string{"local:local-rules", "global:global-rules"}},
+		{true, false, "global", []string{"global:global-rules"}},
+		{false, true, "local", []string{"local:local-rules"}},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create temp directory
-			tempDir, err := os.MkdirTemp("", "outdated-test")
-			if err != nil {
-				t.Fatalf("Failed to create temp dir: %v", err)
-			}
-			defer func() { _ = os.RemoveAll(tempDir) }()
-
-			// Change to temp directory
-			originalWd, _ := os.Getwd()
-			defer func() { _ = os.Chdir(originalWd) }()
-			_ = os.Chdir(tempDir)
-
-			// Setup test environment
-			if err := tt.setupLockFile(tempDir); err != nil {
-				t.Fatalf("Failed to setup lock file: %v", err)
-			}
-
-			// Create basic configuration
-			armrcContent := `[registries]
-default = https://github.com/user/repo
-my-git = https://github.com/other/repo
-
-[registries.default]
-type = git
-
-[registries.my-git]
-type = git
-`
-			err = os.WriteFile(".armrc", []byte(armrcContent), 0o600)
-			if err != nil {
-				t.Fatalf("Failed to create .armrc: %v", err)
-			}
-
-			// Test with mock service
-			mockService := &mockUpdateService{
-				updateResults: tt.mockResults,
-				updateErrors:  tt.mockErrors,
-			}
-
-			// Execute the command with mock
-			err = handleOutdatedWithMockService(false, tt.jsonOutput, mockService)
-
-			// Check error expectation
-			if tt.expectError {
-				if err == nil {
-					t.Error("Expected error but got none")
-				} else {
-					// Verify error message contains expected text
-					for _, expected := range tt.expectedOutput {
-						if !strings.Contains(err.Error(), expected) {
-							t.Errorf("Expected error to contain '%s', got: %v", expected, err)
-						}
-					}
-				}
-			} else {
-				if err != nil {
-					t.Errorf("Expected no error but got: %v", err)
-				}
-			}
+		var listed listOutput
+		captureJSON(t, "list", &listed, func(ctx context.Context) error {
+			return handleList(ctx, tt.global, tt.local, true, "")
 		})
+		var got []string
+		for _, status := range listed.Rulesets {
+			got = append(got, string(status.Scope)+":"+status.Name)
+		}
+		if listed.Scope != tt.scope || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("handleList(global=%v, local=%v) = %s %v, want %s %v", tt.global, tt.local, listed.Scope, got, tt.scope, tt.want)
+		}
 	}
 }
 
-func TestHandleOutdatedJSONValidation(t *testing.T) {
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "outdated-json-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
-
-	// Change to temp directory
-	originalWd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(originalWd) }()
-	_ = os.Chdir(tempDir)
+func TestNewConfigOptions(t *testing.T) {
+	t.Setenv(config.OfflineEnv, "1")
+	t.Setenv(config.WorkspaceEnv, "web")
+	t.Setenv(config.ProfileEnv, "ci")
 
-	// Setup lock file with outdated rulesets
-	lockFile := config.LockFile{
-		Rulesets: map[string]map[string]config.LockedRuleset{
-			"default": {
-				"my-rules": {Version: "1.0.0"},
-			},
-		},
-	}
-	data, _ := json.MarshalIndent(lockFile, "", "  ")
-	err = os.WriteFile("arm.lock", data, 0o600)
+	root := NewRootCommand(nil, &VersionInfo{})
+	cmd, _, err := root.Find([]string{"config", "list"})
 	if err != nil {
-		t.Fatalf("Failed to create lock file: %v", err)
+		t.Fatalf("Failed to find config list: %v", err)
 	}
-
-	// Create basic configuration
-	armrcContent := `[registries]
-default = https://github.com/user/repo
-
-[registries.default]
-type = git
-`
-	err = os.WriteFile(".armrc", []byte(armrcContent), 0o600)
-	if err != nil {
-		t.Fatalf("Failed to create .armrc: %v", err)
+	if opts := newConfigOptions(cmd); opts.Offline == nil || !*opts.Offline || opts.Workspaces[0] != "web" || opts.Profile != "ci" {
+		t.Errorf("Expected the options of the environment, got %+v", opts)
 	}
 
-	// Mock service with outdated ruleset
-	mockService := &mockUpdateService{
-		updateResults: map[string]*update.UpdateResult{
-			"default/my-rules": {
-				Registry:        "default",
-				Ruleset:         "my-rules",
-				Version:         "1.2.0",
-				PreviousVersion: "1.0.0",
-				Updated:         true,
-			},
-		},
-	}
-
-	// Execute with JSON output and capture result
-	var capturedOutput string
-	handleOutdatedWithCapture := func(_, jsonOutput bool, updateService updateServiceInterface) (string, error) {
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			return "", fmt.Errorf("failed to load configuration: %w", err)
-		}
-
-		// Check if we have a lock file
-		if cfg.LockFile == nil {
-			return "", fmt.Errorf("no lock file found - no rulesets installed")
-		}
-
-		type outdatedInfo struct {
-			Registry       string `json:"registry"`
-			Name           string `json:"name"`
-			CurrentVersion string `json:"current_version"`
-			LatestVersion  string `json:"latest_version"`
-			UpdateCommand  string `json:"update_command"`
-		}
-
-		var outdatedRulesets []outdatedInfo
-
-		// Check each installed ruleset
-		for registry, rulesets := range cfg.LockFile.Rulesets {
-			for name := range rulesets {
-				rulesetSpec := fmt.Sprintf("%s/%s", registry, name)
-				result, err := updateService.UpdateRuleset(context.Background(), rulesetSpec)
-				if err != nil {
-					continue // Skip failed resolutions
-				}
-				if result.Updated {
-					outdatedRulesets = append(outdatedRulesets, outdatedInfo{
-						Registry:       result.Registry,
-						Name:           result.Ruleset,
-						CurrentVersion: result.PreviousVersion,
-						LatestVersion:  result.Version,
-						UpdateCommand:  fmt.Sprintf("arm update %s/%s", result.Registry, result.Ruleset),
-					})
-				}
-			}
-		}
-
-		if jsonOutput {
-			data, _ := json.MarshalIndent(map[string]interface{}{
-				"outdated": outdatedRulesets,
-			}, "", "  ")
-			return string(data), nil
-		}
-
-		return "", nil
+	if err := cmd.ParseFlags([]string{"--offline=false", "--workspace", "api,.", "--profile", "dev"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
 	}
-
-	// Execute with JSON output
-	capturedOutput, err = handleOutdatedWithCapture(false, true, mockService)
-	if err != nil {
-		t.Fatalf("Expected no error but got: %v", err)
+	opts := newConfigOptions(cmd)
+	if opts.Offline == nil || *opts.Offline || strings.Join(opts.Workspaces, ",") != "api,." || opts.Profile != "dev" {
+		t.Errorf("Expected flags to override the environment, got %+v", opts)
 	}
 
-	// Validate JSON structure
-	var result map[string]interface{}
-	err = json.Unmarshal([]byte(capturedOutput), &result)
+	// The deprecated --profile of config add registry is the AWS profile
+	cmd, _, err = root.Find([]string{"config", "add", "registry"})
 	if err != nil {
-		t.Fatalf("Invalid JSON output: %v", err)
+		t.Fatalf("Failed to find config add registry: %v", err)
 	}
-
-	// Validate JSON schema
-	outdated, exists := result["outdated"]
-	if !exists {
-		t.Error("JSON output missing 'outdated' field")
+	if err := cmd.ParseFlags([]string{"--profile", "team"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
 	}
-
-	outdatedList, ok := outdated.([]interface{})
-	if !ok {
-		t.Error("'outdated' field is not an array")
+	if opts := newConfigOptions(cmd); opts.Profile != "ci" {
+		t.Errorf("Expected the AWS profile not to select an .armrc profile, got %q", opts.Profile)
 	}
-
-	if len(outdatedList) != 1 {
-		t.Errorf("Expected 1 outdated ruleset, got %d", len(outdatedList))
-	}
-
-	// Validate first outdated ruleset structure
-	firstRuleset, ok := outdatedList[0].(map[string]interface{})
-	if !ok {
-		t.Error("Outdated ruleset is not an object")
+	if os.Getenv(config.OfflineEnv) != "1" || os.Getenv(config.WorkspaceEnv) != "web" {
+		t.Error("Expected the environment to be left unchanged")
 	}
+}
 
-	requiredFields := []string{"registry", "name", "current_version", "latest_version", "update_command"}
-	for _, field := range requiredFields {
-		if _, exists := firstRuleset[field]; !exists {
-			t.Errorf("Missing required field '%s' in JSON output", field)
+func TestHandleListWorkspaces(t *testing.T) {
+	tempDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tempDir)
+	defer func() { _ = os.Setenv("HOME", originalHome) }()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+	// ARM_WORKSPACE only provides the default of --workspace
+	t.Setenv(config.WorkspaceEnv, "packages/api")
+
+	files := map[string]string{
+		".armrc":                "[registries]\ndefault = https://github.com/user/repo\n\n[registries.default]\ntype = git\n",
+		"arm.json":              `{"workspaces": ["packages/*"], "channels": {}, "rulesets": {"default": {"root-rules": {"version": "^1.0.0"}}}}`,
+		"packages/api/arm.json": `{"rulesets": {"default": {"api-rules": {"version": "^2.0.0"}}}}`,
+		"packages/web/arm.json": `{"rulesets": {"default": {"web-rules": {"version": "^3.0.0"}}}}`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
 		}
 	}
 
-	// Validate field values
-	if firstRuleset["registry"] != "default" {
-		t.Errorf("Expected registry 'default', got %v", firstRuleset["registry"])
-	}
-	if firstRuleset["name"] != "my-rules" {
-		t.Errorf("Expected name 'my-rules', got %v", firstRuleset["name"])
-	}
-	if firstRuleset["current_version"] != "1.0.0" {
-		t.Errorf("Expected current_version '1.0.0', got %v", firstRuleset["current_version"])
-	}
-	if firstRuleset["latest_version"] != "1.2.0" {
-		t.Errorf("Expected latest_version '1.2.0', got %v", firstRuleset["latest_version"])
+	tests := []struct {
+		selection []string
+		want      []string
+	}{
+		{nil, []string{":root-rules", "packages/api:api-rules", "packages/web:web-rules"}},
+		{[]string{"web"}, []string{"packages/web:web-rules"}},
+		{[]string{"packages/api", "."}, []string{"packages/api:api-rules", ":root-rules"}},
 	}
-	if firstRuleset["update_command"] != "arm update default/my-rules" {
-		t.Errorf("Expected update_command 'arm update default/my-rules', got %v", firstRuleset["update_command"])
+	for _, tt := range tests {
+		var listed listOutput
+		captureJSON(t, "list", &listed, func(ctx context.Context) error {
+			ctx = context.WithValue(ctx, optionsKey{}, config.Options{Workspaces: tt.selection})
+			return handleList(ctx, false, true, true, "")
+		})
+		var got []string
+		for _, status := range listed.Rulesets {
+			got = append(got, status.Workspace+":"+status.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("handleList() with workspaces %v = %v, want %v", tt.selection, got, tt.want)
+		}
 	}
 }
 
-// handleOutdatedWithMockService is a testable version of handleOutdated that accepts a mock service
-func handleOutdatedWithMockService(_, jsonOutput bool, updateService updateServiceInterface) error {
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+func TestGetTargetRegistries(t *testing.T) {
+	allRegistries := map[string]string{
+		"default":   "https://github.com/user/repo",
+		"my-git":    "https://github.com/other/repo",
+		"my-s3":     "my-bucket",
+		"test-repo": "https://test.com/repo",
 	}
 
-	// Check if we have a lock file
-	if cfg.LockFile == nil {
-		return fmt.Errorf("no lock file found - no rulesets installed")
+	tests := []struct {
+		filter   string
+		expected []string
+	}{
+		{"", []string{"default", "my-git", "my-s3", "test-repo"}},
+		{"default", []string{"default"}},
+		{"default,my-git", []string{"default", "my-git"}},
+		{"my-*", []string{"my-git", "my-s3"}},
+		{"*-repo", []string{"test-repo"}},
+		{"nonexistent", []string{}},
 	}
 
-	type outdatedInfo struct {
-		Registry       string `json:"registry"`
-		Name           string `json:"name"`
-		CurrentVersion string `json:"current_version"`
-		LatestVersion  string `json:"latest_version"`
-		UpdateCommand  string `json:"update_command"`
-	}
+	for _, test := range tests {
+		result := getTargetRegistries(allRegistries, test.filter)
+		if len(result) != len(test.expected) {
+			t.Errorf("getTargetRegistries(%s) returned %d items, expected %d", test.filter, len(result), len(test.expected))
+			continue
+		}
 
-	var outdatedRulesets []outdatedInfo
+		// Convert to map for easier comparison
+		resultMap := make(map[string]bool)
+		for _, r := range result {
+			resultMap[r] = true
+		}
 
-	// Check each installed ruleset
-	for registry, rulesets := range cfg.LockFile.Rulesets {
-		for name := range rulesets {
-			rulesetSpec := fmt.Sprintf("%s/%s", registry, name)
-			result, err := updateService.UpdateRuleset(context.Background(), rulesetSpec)
-			if err != nil {
-				continue // Skip failed resolutions
-			}
-			if result.Updated {
-				outdatedRulesets = append(outdatedRulesets, outdatedInfo{
-					Registry:       result.Registry,
-					Name:           result.Ruleset,
-					CurrentVersion: result.PreviousVersion,
-					LatestVersion:  result.Version,
-					UpdateCommand:  fmt.Sprintf("arm update %s/%s", result.Registry, result.Ruleset),
-				})
+		for _, expected := range test.expected {
+			if !resultMap[expected] {
+				t.Errorf("getTargetRegistries(%s) missing expected result: %s", test.filter, expected)
 			}
 		}
 	}
-
-	if jsonOutput {
-		data, _ := json.MarshalIndent(map[string]interface{}{
-			"outdated": outdatedRulesets,
-		}, "", "  ")
-		fmt.Println(string(data))
-		return nil
-	}
-
-	if len(outdatedRulesets) == 0 {
-		fmt.Println("All rulesets are up to date")
-		return nil
-	}
-
-	fmt.Printf("Found %d outdated ruleset(s):\n\n", len(outdatedRulesets))
-	for _, info := range outdatedRulesets {
-		fmt.Printf("%s/%s\n", info.Registry, info.Name)
-		fmt.Printf("  Current: %s\n", info.CurrentVersion)
-		fmt.Printf("  Latest:  %s\n", info.LatestVersion)
-		fmt.Printf("  Update:  %s\n\n", info.UpdateCommand)
-	}
-
-	return nil
 }
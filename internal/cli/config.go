@@ -0,0 +1,753 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/ini.v1"
+)
+
+// newConfigCommand creates the config command
+func newConfigCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage ARM configuration",
+		Long:  "Configure registries, channels, and other ARM settings",
+	}
+
+	// Set command
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set configuration value",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			return handleConfigSet(cmd.Context(), args[0], args[1], global)
+		},
+	}
+	cmd.AddCommand(setCmd)
+
+	// Get command
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Get configuration value",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			showOrigin, _ := cmd.Flags().GetBool("show-origin")
+			return handleConfigGet(cmd.Context(), args[0], showOrigin)
+		},
+	}
+	getCmd.Flags().Bool("show-origin", false, "Show whether the value comes from the global or local configuration")
+	cmd.AddCommand(getCmd)
+
+	// Unset command
+	unsetCmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove configuration value",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			return handleConfigUnset(cmd.Context(), args[0], global)
+		},
+	}
+	cmd.AddCommand(unsetCmd)
+
+	// Edit command
+	editCmd := &cobra.Command{
+		Use:   "edit <field> <value>",
+		Short: "Edit arm.json field",
+		Long: `Edit a field of arm.json. Editable fields are engines.<name>,
+channels.<name>.directories, rulesets.<registry>.<name>.version and
+rulesets.<registry>.<name>.patterns. List values are comma-separated.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			add, _ := cmd.Flags().GetBool("add")
+			remove, _ := cmd.Flags().GetBool("remove")
+			return handleConfigEdit(cmd.Context(), args[0], args[1], add, remove, global)
+		},
+	}
+	editCmd.Flags().Bool("add", false, "Add values to a list field")
+	editCmd.Flags().Bool("remove", false, "Remove values from a list field")
+	cmd.AddCommand(editCmd)
+
+	// List command
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			showOrigin, _ := cmd.Flags().GetBool("show-origin")
+			return handleConfigList(cmd.Context(), showOrigin)
+		},
+	}
+	listCmd.Flags().Bool("show-origin", false, "Show whether each value comes from the global or local configuration")
+	cmd.AddCommand(listCmd)
+
+	// Add command
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add registry or channel",
+	}
+
+	// Add registry subcommand
+	addRegistryCmd := &cobra.Command{
+		Use:   "registry <name> <value>",
+		Short: "Add registry",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			registryType, _ := cmd.Flags().GetString("type")
+			authToken, _ := cmd.Flags().GetString("authToken")
+			region, _ := cmd.Flags().GetString("region")
+			profile, _ := cmd.Flags().GetString("aws-profile")
+			if profile == "" {
+				profile, _ = cmd.Flags().GetString("profile")
+			}
+			prefix, _ := cmd.Flags().GetString("prefix")
+			apiType, _ := cmd.Flags().GetString("apiType")
+			apiVersion, _ := cmd.Flags().GetString("apiVersion")
+
+			return handleAddRegistry(cmd.Context(), args[0], args[1], registryType, global, map[string]string{
+				"authToken":  authToken,
+				"region":     region,
+				"profile":    profile,
+				"prefix":     prefix,
+				"apiType":    apiType,
+				"apiVersion": apiVersion,
+			})
+		},
+	}
+	addRegistryCmd.Flags().String("type", "", "Registry type (required)")
+	addRegistryCmd.Flags().String("authToken", "", "Authentication token")
+	addRegistryCmd.Flags().String("region", "", "AWS region (for S3 registries)")
+	addRegistryCmd.Flags().String("aws-profile", "", "AWS profile (for S3 registries)")
+	// The former spelling of --aws-profile shadows the global .armrc --profile here
+	addRegistryCmd.Flags().String("profile", "", "AWS profile (for S3 registries)")
+	_ = addRegistryCmd.Flags().MarkDeprecated("profile", "use --aws-profile; select an .armrc profile with ARM_PROFILE")
+	addRegistryCmd.Flags().String("prefix", "", "Path prefix")
+	addRegistryCmd.Flags().String("apiType", "", "API type (for Git registries)")
+	addRegistryCmd.Flags().String("apiVersion", "", "API version")
+	_ = addRegistryCmd.MarkFlagRequired("type")
+	addCmd.AddCommand(addRegistryCmd)
+
+	// Add channel subcommand
+	addChannelCmd := &cobra.Command{
+		Use:   "channel <name>",
+		Short: "Add channel",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			directories, _ := cmd.Flags().GetString("directories")
+			return handleAddChannel(cmd.Context(), args[0], directories, global)
+		},
+	}
+	addChannelCmd.Flags().String("directories", "", "Comma-separated list of directories (required)")
+	_ = addChannelCmd.MarkFlagRequired("directories")
+	addCmd.AddCommand(addChannelCmd)
+
+	cmd.AddCommand(addCmd)
+
+	// Remove command
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove registry or channel",
+	}
+
+	// Remove registry subcommand
+	removeRegistryCmd := &cobra.Command{
+		Use:   "registry <name>",
+		Short: "Remove registry",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			return handleRemoveRegistry(cmd.Context(), args[0], global)
+		},
+	}
+	removeCmd.AddCommand(removeRegistryCmd)
+
+	// Remove channel subcommand
+	removeChannelCmd := &cobra.Command{
+		Use:   "channel <name>",
+		Short: "Remove channel",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			return handleRemoveChannel(cmd.Context(), args[0], global)
+		},
+	}
+	removeCmd.AddCommand(removeChannelCmd)
+
+	cmd.AddCommand(removeCmd)
+
+	return cmd
+}
+
+// Config command handlers
+
+func handleConfigSet(ctx context.Context, key, value string, global bool) error {
+	section, name, err := config.SplitKey(key)
+	if err != nil {
+		return err
+	}
+	if isManifestKey(key) {
+		return fmt.Errorf("%s is stored in arm.json. Use 'arm config edit %s <value>'", key, key)
+	}
+	if err := config.ValidateSetting(section, name, value); err != nil {
+		return err
+	}
+
+	path := getConfigPath(".armrc", global)
+	cfg, err := loadOrCreateINI(path)
+	if err != nil {
+		return err
+	}
+
+	cfg.Section(section).Key(name).SetValue(value)
+
+	setResult(ctx, configEntry{Key: key, Value: value, Scope: scopeName(global), Path: path})
+	return cfg.SaveTo(path)
+}
+
+func handleConfigUnset(ctx context.Context, key string, global bool) error {
+	if isManifestKey(key) {
+		return unsetManifestValue(ctx, key, global)
+	}
+
+	section, name, err := config.SplitKey(key)
+	if err != nil {
+		return err
+	}
+
+	path := getConfigPath(".armrc", global)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("key '%s' not set in %s", key, path)
+	}
+	cfg, err := ini.Load(path)
+	if err != nil {
+		return err
+	}
+
+	iniSection, err := cfg.GetSection(section)
+	if err != nil || !iniSection.HasKey(name) {
+		return fmt.Errorf("key '%s' not set in %s", key, path)
+	}
+	iniSection.DeleteKey(name)
+	if len(iniSection.Keys()) == 0 {
+		cfg.DeleteSection(section)
+	}
+
+	setResult(ctx, configEntry{Key: key, Scope: scopeName(global), Path: path})
+	return cfg.SaveTo(path)
+}
+
+// unsetManifestValue removes an engine, a channel or ruleset patterns from arm.json
+func unsetManifestValue(ctx context.Context, key string, global bool) error {
+	path := getConfigPath("arm.json", global)
+	armConfig, err := loadOrCreateJSON(path)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(key, ".")
+	switch {
+	case parts[0] == "engines" && len(parts) == 2:
+		if _, exists := armConfig.Engines[parts[1]]; !exists {
+			return fmt.Errorf("key '%s' not set in %s", key, path)
+		}
+		delete(armConfig.Engines, parts[1])
+	case parts[0] == "channels" && len(parts) == 2:
+		if _, exists := armConfig.Channels[parts[1]]; !exists {
+			return fmt.Errorf("key '%s' not set in %s", key, path)
+		}
+		delete(armConfig.Channels, parts[1])
+	case parts[0] == "rulesets" && len(parts) == 4 && parts[3] == "patterns":
+		spec, exists := armConfig.Rulesets[parts[1]][parts[2]]
+		if !exists || len(spec.Patterns) == 0 {
+			return fmt.Errorf("key '%s' not set in %s", key, path)
+		}
+		spec.Patterns = nil
+		armConfig.Rulesets[parts[1]][parts[2]] = spec
+	case parts[0] == "rulesets" && len(parts) == 4 && (parts[3] == "update" || parts[3] == "reason"):
+		spec, exists := armConfig.Rulesets[parts[1]][parts[2]]
+		if !exists || getManifestValue(&config.Config{Rulesets: armConfig.Rulesets}, parts) == "" {
+			return fmt.Errorf("key '%s' not set in %s", key, path)
+		}
+		// A ruleset that is no longer pinned has no reason to keep
+		spec.Reason = ""
+		if parts[3] == "update" {
+			spec.Update = ""
+		}
+		armConfig.Rulesets[parts[1]][parts[2]] = spec
+	case parts[0] == "rulesets":
+		return armerr.Errorf(armerr.Config, "cannot unset %s. Use 'arm uninstall' to remove rulesets", key)
+	default:
+		return armerr.Errorf(armerr.Config, "cannot unset %s. Supported arm.json keys: engines.<name>, channels.<name>, rulesets.<registry>.<name>.patterns, rulesets.<registry>.<name>.update, rulesets.<registry>.<name>.reason", key)
+	}
+
+	setResult(ctx, configEntry{Key: key, Scope: scopeName(global), Path: path})
+	return saveJSON(path, armConfig)
+}
+
+// handleConfigEdit edits an arm.json field. List fields are replaced unless
+// add or remove is set, in which case the comma-separated values are added to
+// or removed from the current list.
+func handleConfigEdit(ctx context.Context, field, value string, add, remove, global bool) error {
+	if add && remove {
+		return fmt.Errorf("--add and --remove cannot be used together")
+	}
+
+	path := getConfigPath("arm.json", global)
+	armConfig, err := loadOrCreateJSON(path)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(field, ".")
+	switch {
+	case parts[0] == "engines" && len(parts) == 2:
+		if add || remove {
+			return fmt.Errorf("%s is not a list", field)
+		}
+		armConfig.Engines[parts[1]] = value
+	case parts[0] == "channels" && len(parts) == 3 && parts[2] == "directories":
+		channel, exists := armConfig.Channels[parts[1]]
+		if !exists {
+			return armerr.Errorf(armerr.Config, "channel '%s' not found in %s. Use 'arm config add channel' to create it", parts[1], path)
+		}
+		channel.Directories = editList(channel.Directories, value, add, remove)
+		armConfig.Channels[parts[1]] = channel
+	case parts[0] == "rulesets" && len(parts) == 4 && slices.Contains([]string{"version", "patterns", "update", "reason"}, parts[3]):
+		spec, exists := armConfig.Rulesets[parts[1]][parts[2]]
+		if !exists {
+			return armerr.Errorf(armerr.Config, "ruleset '%s/%s' not found in %s. Use 'arm install' to add it", parts[1], parts[2], path)
+		}
+		if parts[3] != "patterns" && (add || remove) {
+			return fmt.Errorf("%s is not a list", field)
+		}
+		switch parts[3] {
+		case "version":
+			if value == "" {
+				return fmt.Errorf("version cannot be empty")
+			}
+			spec.Version = value
+		case "patterns":
+			spec.Patterns = editList(spec.Patterns, value, add, remove)
+		case "update":
+			spec.Update = value
+			if value != config.UpdatePinned {
+				spec.Reason = ""
+			}
+		case "reason":
+			// Giving a reason pins the ruleset
+			spec.Update, spec.Reason = config.UpdatePinned, value
+		}
+		armConfig.Rulesets[parts[1]][parts[2]] = spec
+	default:
+		return armerr.Errorf(armerr.Config, "unknown field %s. Editable fields: engines.<name>, channels.<name>.directories, rulesets.<registry>.<name>.version, rulesets.<registry>.<name>.patterns, rulesets.<registry>.<name>.update, rulesets.<registry>.<name>.reason", field)
+	}
+
+	if err := config.ValidateARMConfig(armConfig); err != nil {
+		return armerr.Errorf(armerr.Config, "invalid %s: %w", field, err)
+	}
+
+	setResult(ctx, configEntry{Key: field, Value: getManifestValue(&config.Config{
+		Engines:  armConfig.Engines,
+		Channels: armConfig.Channels,
+		Rulesets: armConfig.Rulesets,
+	}, parts), Scope: scopeName(global), Path: path})
+	return saveJSON(path, armConfig)
+}
+
+// editList replaces a list with, or adds or removes, comma-separated values
+func editList(current []string, value string, add, remove bool) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	switch {
+	case add:
+		result := append([]string{}, current...)
+		for _, v := range values {
+			if !slices.Contains(result, v) {
+				result = append(result, v)
+			}
+		}
+		return result
+	case remove:
+		var result []string
+		for _, v := range current {
+			if !slices.Contains(values, v) {
+				result = append(result, v)
+			}
+		}
+		return result
+	default:
+		return values
+	}
+}
+
+func handleConfigGet(ctx context.Context, key string, showOrigin bool) error {
+	out := textOutput(ctx)
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	value := getConfigValue(cfg, key)
+	if value == "" {
+		return armerr.Errorf(armerr.NotFound, "key '%s' not found", key)
+	}
+
+	if showOrigin {
+		origins, err := config.LoadOrigins(cfg.Options.Profile)
+		if err != nil {
+			return err
+		}
+		origin := origins[originKey(key)]
+		setResult(ctx, configEntry{Key: key, Value: value, Scope: origin.Scope, Path: origin.Path, Profile: origin.Profile})
+		fmt.Fprintf(out, "%s\t%s\n", formatOrigin(origin), value)
+		return nil
+	}
+
+	setResult(ctx, configEntry{Key: key, Value: value})
+	fmt.Fprintln(out, value)
+	return nil
+}
+
+func handleConfigList(ctx context.Context, showOrigin bool) error {
+	out := textOutput(ctx)
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var origins map[string]config.Origin
+	if showOrigin {
+		if origins, err = config.LoadOrigins(cfg.Options.Profile); err != nil {
+			return err
+		}
+		// Local paths are relative to the project root commands operate on
+		if root, err := os.Getwd(); err == nil {
+			fmt.Fprintf(out, "# project root: %s\n", root)
+		}
+	}
+	if cfg.Profile != "" {
+		fmt.Fprintf(out, "# profile: %s\n", cfg.Profile)
+	}
+	if showOrigin || cfg.Profile != "" {
+		fmt.Fprintln(out)
+	}
+
+	entries := []configEntry{}
+	printSection := func(name string, values map[string]string, first bool) {
+		if !first {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "[%s]\n", name)
+		for _, key := range sortedKeys(values) {
+			entry := configEntry{Key: name + "." + key, Value: values[key]}
+			if showOrigin {
+				origin := origins[entry.Key]
+				entry.Scope, entry.Path, entry.Profile = origin.Scope, origin.Path, origin.Profile
+				fmt.Fprintf(out, "%s\t", formatOrigin(origin))
+			}
+			entries = append(entries, entry)
+			fmt.Fprintf(out, "%s = %s\n", key, values[key])
+		}
+	}
+
+	printSection("registries", cfg.Registries, true)
+	for _, name := range sortedKeys(cfg.RegistryConfigs) {
+		printSection("registries."+name, cfg.RegistryConfigs[name], false)
+	}
+	for _, typeName := range sortedKeys(cfg.TypeDefaults) {
+		printSection(typeName, cfg.TypeDefaults[typeName], false)
+	}
+	if len(cfg.NetworkConfig) > 0 {
+		printSection("network", cfg.NetworkConfig, false)
+	}
+
+	setResult(ctx, entries)
+	return nil
+}
+
+// configEntry is a configuration value reported in JSON output
+type configEntry struct {
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Scope   string `json:"scope,omitempty"`   // global or local
+	Path    string `json:"path,omitempty"`    // File holding the value
+	Profile string `json:"profile,omitempty"` // Profile whose section holds the value
+}
+
+// scopeName returns the scope of the files a command operates on
+func scopeName(global bool) string {
+	if global {
+		return "global"
+	}
+	return "local"
+}
+
+// isManifestKey reports whether a key addresses arm.json rather than .armrc
+func isManifestKey(key string) bool {
+	section, _, _ := strings.Cut(key, ".")
+	return section == "channels" || section == "rulesets" || section == "engines"
+}
+
+// originKey maps a configuration key to the key LoadOrigins reports it under
+func originKey(key string) string {
+	parts := strings.Split(key, ".")
+	switch {
+	case parts[0] == "rulesets" && len(parts) > 3:
+		return strings.Join(parts[:3], ".")
+	case (parts[0] == "channels" || parts[0] == "engines") && len(parts) > 2:
+		return strings.Join(parts[:2], ".")
+	}
+	return key
+}
+
+// formatOrigin formats the scope and file of a configuration value
+func formatOrigin(origin config.Origin) string {
+	if origin.Path == "" {
+		return "default"
+	}
+	if origin.Profile != "" {
+		return origin.Scope + ":" + origin.Path + "[profile." + origin.Profile + "]"
+	}
+	return origin.Scope + ":" + origin.Path
+}
+
+func handleAddRegistry(ctx context.Context, name, url, registryType string, global bool, options map[string]string) error {
+	if registryType == "" {
+		return fmt.Errorf("registry type is required")
+	}
+
+	// Validate git-local registry path immediately
+	if registryType == "git-local" {
+		if url == "" {
+			return fmt.Errorf("git-local registry requires a local path")
+		}
+
+		// Resolve and validate the path
+		resolvedPath, err := config.ResolvePath(url)
+		if err != nil {
+			return fmt.Errorf("invalid path for git-local registry: %w", err)
+		}
+
+		// Check if it's a Git repository
+		gitDir := filepath.Join(resolvedPath, ".git")
+		if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+			return fmt.Errorf("path is not a Git repository (no .git directory found): %s", resolvedPath)
+		}
+
+		// Use the resolved path for storage
+		url = resolvedPath
+	}
+
+	// Add to registries section
+	path := getConfigPath(".armrc", global)
+	cfg, err := loadOrCreateINI(path)
+	if err != nil {
+		return err
+	}
+
+	cfg.Section("registries").Key(name).SetValue(url)
+
+	// Add registry config section
+	sectionName := fmt.Sprintf("registries.%s", name)
+	section := cfg.Section(sectionName)
+	section.Key("type").SetValue(registryType)
+
+	// Add optional parameters
+	for key, value := range options {
+		if value != "" {
+			section.Key(key).SetValue(value)
+		}
+	}
+
+	return cfg.SaveTo(path)
+}
+
+func handleRemoveRegistry(ctx context.Context, name string, global bool) error {
+	path := getConfigPath(".armrc", global)
+	cfg, err := loadOrCreateINI(path)
+	if err != nil {
+		return err
+	}
+
+	// Remove from registries section
+	cfg.Section("registries").DeleteKey(name)
+
+	// Remove registry config section
+	sectionName := fmt.Sprintf("registries.%s", name)
+	cfg.DeleteSection(sectionName)
+
+	return cfg.SaveTo(path)
+}
+
+func handleAddChannel(ctx context.Context, name, directories string, global bool) error {
+	if directories == "" {
+		return fmt.Errorf("directories are required")
+	}
+
+	path := getConfigPath("arm.json", global)
+	armConfig, err := loadOrCreateJSON(path)
+	if err != nil {
+		return err
+	}
+
+	dirList := strings.Split(directories, ",")
+	for i, dir := range dirList {
+		dirList[i] = strings.TrimSpace(dir)
+		// Global installs run from any directory, so relative paths would scatter files
+		if global && !filepath.IsAbs(dirList[i]) && !strings.HasPrefix(dirList[i], "~") && !strings.HasPrefix(dirList[i], "$") {
+			return armerr.Errorf(armerr.Config, "global channel directory '%s' is not absolute", dirList[i]).
+				WithHint("Use a path such as ~/<assistant>/rules")
+		}
+	}
+
+	armConfig.Channels[name] = config.ChannelConfig{
+		Directories: dirList,
+	}
+
+	return saveJSON(path, armConfig)
+}
+
+func handleRemoveChannel(ctx context.Context, name string, global bool) error {
+	path := getConfigPath("arm.json", global)
+	armConfig, err := loadOrCreateJSON(path)
+	if err != nil {
+		return err
+	}
+
+	delete(armConfig.Channels, name)
+
+	return saveJSON(path, armConfig)
+}
+
+// Helper functions
+
+func getConfigPath(filename string, global bool) string {
+	if global {
+		return filepath.Join(os.Getenv("HOME"), ".arm", filename)
+	}
+	return filename
+}
+
+func loadOrCreateINI(path string) (*ini.File, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Create parent directory if needed
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		return ini.Empty(), nil
+	}
+	return ini.Load(path)
+}
+
+func loadOrCreateJSON(path string) (*config.ARMConfig, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Create parent directory if needed
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		return &config.ARMConfig{
+			Engines:  make(map[string]string),
+			Channels: make(map[string]config.ChannelConfig),
+			Rulesets: make(map[string]map[string]config.RulesetSpec),
+		}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var armConfig config.ARMConfig
+	if err := json.Unmarshal(data, &armConfig); err != nil {
+		return nil, err
+	}
+
+	// Initialize maps if nil
+	if armConfig.Engines == nil {
+		armConfig.Engines = make(map[string]string)
+	}
+	if armConfig.Channels == nil {
+		armConfig.Channels = make(map[string]config.ChannelConfig)
+	}
+	if armConfig.Rulesets == nil {
+		armConfig.Rulesets = make(map[string]map[string]config.RulesetSpec)
+	}
+
+	return &armConfig, nil
+}
+
+func saveJSON(path string, armConfig *config.ARMConfig) error {
+	data, err := json.MarshalIndent(armConfig, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func getConfigValue(cfg *config.Config, key string) string {
+	if isManifestKey(key) {
+		return getManifestValue(cfg, strings.Split(key, "."))
+	}
+
+	section, field, err := config.SplitKey(key)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(section, "profile."):
+		name, rest, _ := strings.Cut(strings.TrimPrefix(key, "profile."), ".")
+		if profile := cfg.Profiles[name]; profile != nil {
+			return getConfigValue(profile, rest)
+		}
+		return ""
+	case section == "registries":
+		return cfg.Registries[field]
+	case strings.HasPrefix(section, "registries."):
+		return cfg.RegistryConfigs[strings.TrimPrefix(section, "registries.")][field]
+	case section == "network":
+		return cfg.NetworkConfig[field]
+	default:
+		return cfg.TypeDefaults[section][field]
+	}
+}
+
+// getManifestValue returns an arm.json value, with lists comma-separated
+func getManifestValue(cfg *config.Config, parts []string) string {
+	switch {
+	case parts[0] == "engines" && len(parts) == 2:
+		return cfg.Engines[parts[1]]
+	case parts[0] == "channels" && (len(parts) == 2 || len(parts) == 3 && parts[2] == "directories"):
+		return strings.Join(cfg.Channels[parts[1]].Directories, ",")
+	case parts[0] == "rulesets" && len(parts) >= 3:
+		spec := cfg.Rulesets[parts[1]][parts[2]]
+		switch {
+		case len(parts) == 3 || len(parts) == 4 && parts[3] == "version":
+			return spec.Version
+		case len(parts) == 4 && parts[3] == "patterns":
+			return strings.Join(spec.Patterns, ",")
+		case len(parts) == 4 && parts[3] == "update":
+			return spec.Update
+		case len(parts) == 4 && parts[3] == "reason":
+			return spec.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,449 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+func TestHandleConfigSet(t *testing.T) {
+	// Create temp directory
+	tempDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Change to temp directory
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// Test setting a configuration value
+	err = handleConfigSet(context.Background(), "git.concurrency", "5", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Verify the file was created and contains the value
+	content, err := os.ReadFile(".armrc")
+	if err != nil {
+		t.Fatalf("Failed to read .armrc: %v", err)
+	}
+
+	if !strings.Contains(string(content), "[git]") {
+		t.Error("Expected [git] section in .armrc")
+	}
+	if !strings.Contains(string(content), "concurrency = 5") {
+		t.Error("Expected concurrency = 5 in .armrc")
+	}
+}
+
+func TestHandleConfigSet_Validation(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	if err := handleConfigSet(context.Background(), "registries.default.retry.maxAttempts", "3", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	content, err := os.ReadFile(".armrc")
+	if err != nil {
+		t.Fatalf("Failed to read .armrc: %v", err)
+	}
+	if !strings.Contains(string(content), "[registries.default]") || !strings.Contains(string(content), "retry.maxAttempts = 3") {
+		t.Errorf("Expected retry.maxAttempts in [registries.default], got:\n%s", content)
+	}
+
+	for _, tt := range []struct{ key, value string }{
+		{"git.concurrency", "many"},
+		{"git.unknownKey", "1"},
+		{"cache.evictionPolicy", "fifo"},
+		{"channels.cursor.directories", ".cursor/rules"},
+	} {
+		if err := handleConfigSet(context.Background(), tt.key, tt.value, false); err == nil {
+			t.Errorf("Expected error setting %s = %s", tt.key, tt.value)
+		}
+	}
+}
+
+func TestHandleConfigUnset(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	if err := handleConfigSet(context.Background(), "git.concurrency", "5", false); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := handleConfigSet(context.Background(), "network.timeout", "30", false); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	if err := handleConfigUnset(context.Background(), "git.concurrency", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	content, err := os.ReadFile(".armrc")
+	if err != nil {
+		t.Fatalf("Failed to read .armrc: %v", err)
+	}
+	if strings.Contains(string(content), "[git]") {
+		t.Errorf("Expected empty [git] section to be removed, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "timeout = 30") {
+		t.Errorf("Expected network.timeout to be kept, got:\n%s", content)
+	}
+
+	if err := handleConfigUnset(context.Background(), "git.concurrency", false); err == nil {
+		t.Error("Expected error unsetting a key that is not set")
+	}
+
+	if err := handleAddChannel(context.Background(), "cursor", ".cursor/rules", false); err != nil {
+		t.Fatalf("Failed to add channel: %v", err)
+	}
+	if err := handleConfigUnset(context.Background(), "channels.cursor", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if content, _ := os.ReadFile("arm.json"); strings.Contains(string(content), "cursor") {
+		t.Error("Expected channel to be removed from arm.json")
+	}
+}
+
+func TestHandleConfigEdit(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	manifest := `{
+  "engines": {},
+  "channels": {"cursor": {"directories": [".cursor/rules"]}},
+  "rulesets": {"default": {"rules": {"version": "^1.0.0", "patterns": ["rules/*.md"]}}}
+}`
+	if err := os.WriteFile("arm.json", []byte(manifest), 0o644); err != nil {
+		t.Fatalf("Failed to write arm.json: %v", err)
+	}
+
+	edits := []struct {
+		field, value string
+		add, remove  bool
+	}{
+		{field: "channels.cursor.directories", value: "custom/cursor", add: true},
+		{field: "rulesets.default.rules.patterns", value: "docs/*.md", add: true},
+		{field: "rulesets.default.rules.patterns", value: "rules/*.md", remove: true},
+		{field: "rulesets.default.rules.version", value: "^2.0.0"},
+		{field: "rulesets.default.rules.reason", value: "Waiting for security review"},
+		{field: "engines.arm", value: "^1.2.0"},
+	}
+	for _, edit := range edits {
+		if err := handleConfigEdit(context.Background(), edit.field, edit.value, edit.add, edit.remove, false); err != nil {
+			t.Fatalf("handleConfigEdit(context.Background(), %s) error = %v", edit.field, err)
+		}
+	}
+
+	armConfig, err := loadOrCreateJSON("arm.json")
+	if err != nil {
+		t.Fatalf("Failed to load arm.json: %v", err)
+	}
+	if dirs := strings.Join(armConfig.Channels["cursor"].Directories, ","); dirs != ".cursor/rules,custom/cursor" {
+		t.Errorf("Expected directories .cursor/rules,custom/cursor, got %s", dirs)
+	}
+	spec := armConfig.Rulesets["default"]["rules"]
+	if spec.Version != "^2.0.0" || strings.Join(spec.Patterns, ",") != "docs/*.md" {
+		t.Errorf("Unexpected ruleset spec %+v", spec)
+	}
+	if spec.Update != config.UpdatePinned || spec.Reason != "Waiting for security review" {
+		t.Errorf("Expected a reason to pin the ruleset, got %+v", spec)
+	}
+	if armConfig.Engines["arm"] != "^1.2.0" {
+		t.Errorf("Expected arm engine ^1.2.0, got %s", armConfig.Engines["arm"])
+	}
+
+	failures := []struct {
+		field, value string
+		add, remove  bool
+	}{
+		{field: "channels.missing.directories", value: "dir"},
+		{field: "channels.cursor.directories", value: ".cursor/rules,custom/cursor", remove: true},
+		{field: "rulesets.default.missing.version", value: "1.0.0"},
+		{field: "rulesets.default.rules.version", value: "1.0.0", add: true},
+		{field: "engines.arm", value: "latest"},
+		{field: "rulesets.default.rules.update", value: "sometimes"},
+		{field: "channels.cursor.name", value: "x"},
+	}
+	for _, failure := range failures {
+		if err := handleConfigEdit(context.Background(), failure.field, failure.value, failure.add, failure.remove, false); err == nil {
+			t.Errorf("Expected error editing %s", failure.field)
+		}
+	}
+}
+
+func TestHandleAddRegistry(t *testing.T) {
+	// Create temp directory
+	tempDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Change to temp directory
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// Test adding a Git registry
+	err = handleAddRegistry(context.Background(), "my-git", "https://github.com/user/repo", "git", false, map[string]string{
+		"authToken": "test-token",
+		"apiType":   "github",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Verify the file was created and contains the registry
+	content, err := os.ReadFile(".armrc")
+	if err != nil {
+		t.Fatalf("Failed to read .armrc: %v", err)
+	}
+
+	expectedContent := []string{
+		"[registries]",
+		"my-git",
+		"https://github.com/user/repo",
+		"[registries.my-git]",
+		"type",
+		"git",
+		"authToken",
+		"test-token",
+		"apiType",
+		"github",
+	}
+
+	for _, expected := range expectedContent {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected '%s' in .armrc, got:\n%s", expected, string(content))
+		}
+	}
+}
+
+func TestAddRegistryProfileFlags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(t.TempDir())
+
+	// Both the deprecated --profile and --aws-profile set the AWS profile
+	for name, flag := range map[string]string{"old-spelling": "--profile", "new-spelling": "--aws-profile"} {
+		root := NewRootCommand(&config.Config{}, &VersionInfo{})
+		root.SetArgs([]string{"config", "add", "registry", name, "rules-bucket", "--type", "s3", "--region", "us-east-1", flag, "team"})
+		root.SetErr(new(strings.Builder))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%s: Execute() error = %v", flag, err)
+		}
+	}
+	// Cache maintenance runs in the background and writes under HOME
+	cache.WaitForMaintenance()
+
+	cfg, err := config.LoadScope(config.ScopeLocal, config.Options{})
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	for _, name := range []string{"old-spelling", "new-spelling"} {
+		if profile := cfg.RegistryConfigs[name]["profile"]; profile != "team" {
+			t.Errorf("%s: expected AWS profile team, got %q", name, profile)
+		}
+	}
+}
+
+func TestHandleAddChannel(t *testing.T) {
+	// Create temp directory
+	tempDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Change to temp directory
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// Test adding a channel
+	err = handleAddChannel(context.Background(), "cursor", ".cursor/rules,custom/cursor", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Verify the file was created and contains the channel
+	content, err := os.ReadFile("arm.json")
+	if err != nil {
+		t.Fatalf("Failed to read arm.json: %v", err)
+	}
+
+	if !strings.Contains(string(content), "cursor") {
+		t.Error("Expected 'cursor' channel in arm.json")
+	}
+	if !strings.Contains(string(content), ".cursor/rules") {
+		t.Error("Expected '.cursor/rules' directory in arm.json")
+	}
+	if !strings.Contains(string(content), "custom/cursor") {
+		t.Error("Expected 'custom/cursor' directory in arm.json")
+	}
+}
+
+func TestHandleRemoveRegistry(t *testing.T) {
+	// Create temp directory
+	tempDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Change to temp directory
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// First add a registry
+	err = handleAddRegistry(context.Background(), "test-registry", "https://example.com", "https", false, map[string]string{})
+	if err != nil {
+		t.Fatalf("Failed to add registry: %v", err)
+	}
+
+	// Then remove it
+	err = handleRemoveRegistry(context.Background(), "test-registry", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Verify the registry was removed
+	content, err := os.ReadFile(".armrc")
+	if err != nil {
+		t.Fatalf("Failed to read .armrc: %v", err)
+	}
+
+	if strings.Contains(string(content), "test-registry") {
+		t.Error("Expected 'test-registry' to be removed from .armrc")
+	}
+}
+
+func TestHandleRemoveChannel(t *testing.T) {
+	// Create temp directory
+	tempDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Change to temp directory
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// First add a channel
+	err = handleAddChannel(context.Background(), "test-channel", "test/dir", false)
+	if err != nil {
+		t.Fatalf("Failed to add channel: %v", err)
+	}
+
+	// Then remove it
+	err = handleRemoveChannel(context.Background(), "test-channel", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Verify the channel was removed
+	content, err := os.ReadFile("arm.json")
+	if err != nil {
+		t.Fatalf("Failed to read arm.json: %v", err)
+	}
+
+	if strings.Contains(string(content), "test-channel") {
+		t.Error("Expected 'test-channel' to be removed from arm.json")
+	}
+}
+
+func TestGetConfigValue(t *testing.T) {
+	cfg := &config.Config{
+		Registries: map[string]string{
+			"default": "https://github.com/user/repo",
+		},
+		RegistryConfigs: map[string]map[string]string{
+			"default": {
+				"type":      "git",
+				"authToken": "test-token",
+			},
+		},
+		TypeDefaults: map[string]map[string]string{
+			"git": {
+				"concurrency": "1",
+				"rateLimit":   "10/minute",
+			},
+		},
+		NetworkConfig: map[string]string{
+			"timeout": "30",
+		},
+		Channels: map[string]config.ChannelConfig{
+			"cursor": {Directories: []string{".cursor/rules", "custom"}},
+		},
+		Rulesets: map[string]map[string]config.RulesetSpec{
+			"default": {"rules": {Version: "^1.0.0", Patterns: []string{"rules/*.md"}}},
+		},
+		Profiles: map[string]*config.Config{
+			"ci": {
+				Registries:      map[string]string{"default": "https://mirror.example.com/rules"},
+				RegistryConfigs: map[string]map[string]string{"default": {"type": "https"}},
+				NetworkConfig:   map[string]string{"offline": "true"},
+			},
+		},
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"registries.default", "https://github.com/user/repo"},
+		{"registries.default.type", "git"},
+		{"registries.default.authToken", "test-token"},
+		{"git.concurrency", "1"},
+		{"git.rateLimit", "10/minute"},
+		{"network.timeout", "30"},
+		{"channels.cursor.directories", ".cursor/rules,custom"},
+		{"rulesets.default.rules", "^1.0.0"},
+		{"rulesets.default.rules.patterns", "rules/*.md"},
+		{"profile.ci.registries.default", "https://mirror.example.com/rules"},
+		{"profile.ci.registries.default.type", "https"},
+		{"profile.ci.network.offline", "true"},
+		{"profile.dev.network.offline", ""},
+
+		{"nonexistent.key", ""},
+	}
+
+	for _, test := range tests {
+		result := getConfigValue(cfg, test.key)
+		if result != test.expected {
+			t.Errorf("getConfigValue(%s) = %s, expected %s", test.key, result, test.expected)
+		}
+	}
+}
+
+func TestGetConfigPath(t *testing.T) {
+	// Test local path
+	localPath := getConfigPath(".armrc", false)
+	if localPath != ".armrc" {
+		t.Errorf("Expected '.armrc', got %s", localPath)
+	}
+
+	// Test global path
+	globalPath := getConfigPath(".armrc", true)
+	expectedGlobal := filepath.Join(os.Getenv("HOME"), ".arm", ".armrc")
+	if globalPath != expectedGlobal {
+		t.Errorf("Expected %s, got %s", expectedGlobal, globalPath)
+	}
+}
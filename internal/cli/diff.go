@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/diff"
+	"github.com/max-dunn/ai-rules-manager/internal/install"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
+	"github.com/max-dunn/ai-rules-manager/internal/update"
+	"github.com/spf13/cobra"
+)
+
+// newDiffCommand creates the diff command
+func newDiffCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <registry/ruleset> [from] [to]",
+		Short: "Show file changes between two versions of a ruleset",
+		Long: `Show the rule files added, removed and changed between two versions of a
+ruleset, per channel, together with the commit log for git registries.
+
+From defaults to the installed version and to to the version 'arm update'
+would install.`,
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var from, to string
+			if len(args) > 1 {
+				from = args[1]
+			}
+			if len(args) > 2 {
+				to = args[2]
+			}
+			global, _ := cmd.Flags().GetBool("global")
+			local, _ := cmd.Flags().GetBool("local")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			return handleDiff(cmd.Context(), args[0], from, to, config.Scopes(global, local), jsonOutput)
+		},
+	}
+
+	cmd.Flags().Bool("local", false, "Compare the local installation only")
+
+	return cmd
+}
+
+func handleDiff(ctx context.Context, rulesetSpec, from, to string, scopes []config.Scope, jsonOutput bool) error {
+	cfg, err := rulesetScope(ctx, scopes, rulesetSpec)
+	if err != nil {
+		return err
+	}
+
+	var preview *update.Preview
+	registryName, name, _ := parseRulesetSpec(rulesetSpec)
+	err = progress.Track(ctx, registryName+"/"+name, func(ctx context.Context) error {
+		return install.WithOperationTimeout(ctx, cfg, registryName, func(ctx context.Context) (err error) {
+			preview, err = update.New(cfg).Diff(ctx, rulesetSpec, from, to)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	setResult(ctx, preview)
+	if jsonOutput {
+		return nil
+	}
+
+	printPreview(textOutput(ctx), preview)
+	return nil
+}
+
+// printPreview prints the commits and per-channel file changes between two versions
+func printPreview(out io.Writer, preview *update.Preview) {
+	fmt.Fprintf(out, "%s/%s %s → %s\n", preview.Registry, preview.Ruleset, shortRevision(preview.From), shortRevision(preview.To))
+	if preview.Held {
+		fmt.Fprintf(out, "  Held (%s)\n\n", heldReason(preview.Reason))
+		return
+	}
+	if preview.Range != "" {
+		fmt.Fprintf(out, "  Manifest range would change to %s\n", preview.Range)
+	}
+	if !preview.Changed() {
+		if preview.From == preview.To {
+			fmt.Fprint(out, "  Already up to date\n\n")
+		} else {
+			fmt.Fprint(out, "  No file changes\n\n")
+		}
+		return
+	}
+
+	if len(preview.Commits) > 0 {
+		fmt.Fprintf(out, "\nCommits (%d):\n", len(preview.Commits))
+		for _, commit := range preview.Commits {
+			fmt.Fprintf(out, "  %s %s (%s, %s)\n", shortRevision(commit.Hash), commit.Subject, commit.Author, commit.Date.Format("2006-01-02"))
+		}
+	}
+
+	for _, target := range preview.Channels {
+		base := path.Join(filepath.ToSlash(target.Directory), "arm", preview.Registry, preview.Ruleset)
+		fmt.Fprintf(out, "\nChannel %s (%s):\n", target.Channel, target.Directory)
+		for _, file := range preview.Files {
+			fmt.Fprint(out, file.Unified("a/"+path.Join(base, target.Version)+"/", "b/"+path.Join(base, preview.To)+"/"))
+		}
+	}
+
+	var added, removed, modified int
+	for _, file := range preview.Files {
+		switch file.Status {
+		case diff.Added:
+			added++
+		case diff.Removed:
+			removed++
+		default:
+			modified++
+		}
+	}
+	fmt.Fprintf(out, "\n%d file(s) changed: %d added, %d removed, %d modified\n\n", len(preview.Files), added, removed, modified)
+}
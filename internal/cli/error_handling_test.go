@@ -2,8 +2,12 @@ package cli
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
 )
 
 func TestErrorClassification(t *testing.T) {
@@ -87,6 +91,22 @@ func TestFormatErrorMessage(t *testing.T) {
 	}
 }
 
+func TestBatchError(t *testing.T) {
+	notFound := armerr.New(armerr.NotFound, "ruleset rules not found")
+	err := batchError("install", []string{"a/rules", "b/rules"}, []error{notFound, fmt.Errorf("wrapped: %w", notFound)})
+	if armerr.KindOf(err) != armerr.NotFound {
+		t.Errorf("Expected shared kind to be kept, got %s", armerr.KindOf(err))
+	}
+	if err.Error() != "failed to install: a/rules, b/rules" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+
+	err = batchError("update", []string{"a/rules", "b/rules"}, []error{notFound, registry.ErrNotCached})
+	if armerr.KindOf(err) != armerr.Unknown {
+		t.Errorf("Expected mixed failures to be unclassified, got %s", armerr.KindOf(err))
+	}
+}
+
 // classifyError determines the type of error for appropriate handling
 func classifyError(err error) string {
 	if err == nil {
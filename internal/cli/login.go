@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// newLoginCommand creates the login command
+func newLoginCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login <registry>",
+		Short: "Store credentials for a registry",
+		Long: `Store credentials for a registry. Credentials are handed to the registry's
+credentialHelper when one is configured, otherwise they are saved to the
+encrypted credentials file (~/.arm/credentials.enc). The token is read from
+standard input, without echo when it is a terminal.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			username, _ := cmd.Flags().GetString("username")
+			tokenStdin, _ := cmd.Flags().GetBool("token-stdin")
+			expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+
+			token, err := readToken(cmd.InOrStdin(), cmd.ErrOrStderr(), !tokenStdin)
+			if err != nil {
+				return err
+			}
+			return handleLogin(cmd.Context(), args[0], username, token, expiresIn)
+		},
+	}
+
+	cmd.Flags().String("username", "", "Username for registries using basic authentication")
+	cmd.Flags().Bool("token-stdin", false, "Read the token from stdin without prompting")
+	cmd.Flags().Duration("expires-in", 0, "Expire the stored credentials after this duration (e.g. 720h)")
+
+	return cmd
+}
+
+// newLogoutCommand creates the logout command
+func newLogoutCommand(_ *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout [registry]",
+		Short: "Remove stored credentials",
+		Long: `Remove stored credentials for a registry, or all credentials in the encrypted
+credentials file when none is given. Credentials held by a credentialHelper,
+which may be shared with other tools such as git, are only erased for a
+registry given by name.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryName := ""
+			if len(args) > 0 {
+				registryName = args[0]
+			}
+			return handleLogout(cmd.Context(), registryName)
+		},
+	}
+}
+
+// readToken reads a token from r. When r is a terminal and prompt is set, it
+// prompts on w and reads the token without echo.
+func readToken(r io.Reader, w io.Writer, prompt bool) (string, error) {
+	if f, ok := r.(*os.File); ok && prompt && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(w, "Token: ")
+		secret, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(w)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		return validToken(string(secret))
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	return validToken(line)
+}
+
+// validToken trims a token read from input and rejects empty ones
+func validToken(token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("no token provided")
+	}
+	return token, nil
+}
+
+func handleLogin(ctx context.Context, registryName, username, token string, expiresIn time.Duration) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	registryURL, exists := cfg.Registries[registryName]
+	if !exists {
+		return registryNotFoundError(registryName)
+	}
+	registryType := cfg.RegistryConfigs[registryName]["type"]
+
+	auth := &registry.AuthConfig{Token: token}
+	if username != "" {
+		auth.Username = username
+		auth.Password = token
+	}
+	if expiresIn > 0 {
+		auth.ExpiresAt = time.Now().Add(expiresIn)
+	}
+
+	provider := registry.NewAuthProviderFromConfig(cfg)
+	if helper := provider.Helper(registryName); helper != nil {
+		if err := helper.Store(ctx, registryType, registryURL, auth); err != nil {
+			return err
+		}
+		logger.Success("Credentials for %s stored in credential helper", registryName)
+		return nil
+	}
+
+	store, err := registry.NewDefaultCredentialStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(registryName, auth); err != nil {
+		return fmt.Errorf("failed to store credentials: %w", err)
+	}
+	logger.Success("Credentials for %s stored in encrypted credentials file", registryName)
+	return nil
+}
+
+func handleLogout(ctx context.Context, registryName string) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	store, err := registry.NewDefaultCredentialStore()
+	if err != nil {
+		return err
+	}
+
+	if registryName == "" {
+		// Credential helpers may hold the user's own credentials for the same
+		// hosts, so only the entries ARM stored itself are removed
+		stored, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, name := range stored {
+			if err := store.Delete(name); err != nil {
+				return fmt.Errorf("failed to remove credentials: %w", err)
+			}
+		}
+		logger.Success("Removed credentials of %d registries from the encrypted credentials file", len(stored))
+
+		provider := registry.NewAuthProviderFromConfig(cfg)
+		for _, name := range sortedKeys(cfg.Registries) {
+			if provider.Helper(name) != nil {
+				logger.Info("Credentials of %s in its credential helper were kept; run 'arm logout %s' to erase them", name, name)
+			}
+		}
+		return nil
+	}
+
+	if _, exists := cfg.Registries[registryName]; !exists {
+		return registryNotFoundError(registryName)
+	}
+	provider := registry.NewAuthProviderFromConfig(cfg)
+	if helper := provider.Helper(registryName); helper != nil {
+		registryType := cfg.RegistryConfigs[registryName]["type"]
+		if err := helper.Erase(ctx, registryType, cfg.Registries[registryName]); err != nil {
+			return err
+		}
+	}
+	if err := store.Delete(registryName); err != nil {
+		return fmt.Errorf("failed to remove credentials: %w", err)
+	}
+	logger.Success("Removed credentials for %s", registryName)
+	return nil
+}
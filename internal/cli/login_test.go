@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+func TestHandleLogout(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// The helper records the actions it is asked to perform
+	actions := filepath.Join(tempDir, "actions")
+	armrc := "[registries]\nshared = https://github.com/org/rules\nprivate = https://rules.example.com\n\n" +
+		"[registries.shared]\ntype = git\ncredentialHelper = !echo >>" + actions + "\n\n" +
+		"[registries.private]\ntype = https\n"
+	if err := os.WriteFile(".armrc", []byte(armrc), 0o600); err != nil {
+		t.Fatalf("Failed to write .armrc: %v", err)
+	}
+
+	store, err := registry.NewDefaultCredentialStore()
+	if err != nil {
+		t.Fatalf("Failed to open credential store: %v", err)
+	}
+	for _, name := range []string{"private", "removed"} {
+		if err := store.Set(name, &registry.AuthConfig{Token: "secret"}); err != nil {
+			t.Fatalf("Failed to store credentials: %v", err)
+		}
+	}
+
+	// Without a registry, only the encrypted credentials file is cleared
+	if err := handleLogout(context.Background(), ""); err != nil {
+		t.Fatalf("handleLogout() error = %v", err)
+	}
+	if stored, _ := store.List(); len(stored) != 0 {
+		t.Errorf("Expected the credentials file to be cleared, got %v", stored)
+	}
+	if _, err := os.Stat(actions); !os.IsNotExist(err) {
+		t.Errorf("Expected the credential helper not to be run, got %v", err)
+	}
+
+	// A registry given by name is erased from its credential helper
+	if err := handleLogout(context.Background(), "shared"); err != nil {
+		t.Fatalf("handleLogout(shared) error = %v", err)
+	}
+	if data, _ := os.ReadFile(actions); strings.TrimSpace(string(data)) != "erase" {
+		t.Errorf("Expected the credential helper to erase the credentials, got %q", data)
+	}
+}
+
+func TestReadToken(t *testing.T) {
+	// Input that is not a terminal is read without a prompt
+	var prompt bytes.Buffer
+	token, err := readToken(strings.NewReader("  secret\n"), &prompt, true)
+	if err != nil || token != "secret" {
+		t.Errorf("readToken() = %q, %v, want secret", token, err)
+	}
+	if prompt.Len() != 0 {
+		t.Errorf("Expected no prompt for input that is not a terminal, got %q", prompt.String())
+	}
+
+	if _, err := readToken(strings.NewReader("\n"), &prompt, false); err == nil {
+		t.Error("Expected an error for an empty token")
+	}
+}
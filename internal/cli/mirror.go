@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/mirror"
+	"github.com/spf13/cobra"
+)
+
+// newMirrorCommand creates the mirror command
+func newMirrorCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Snapshot registries for offline use",
+		Long: `Snapshot the rulesets in arm.json and arm.lock, or whole registries with --all,
+into a self-contained directory. Each registry is written to <dir>/<registry>
+with a manifest.json, so the snapshot can be used as a local registry or
+served as a static HTTPS registry.`,
+		Annotations: map[string]string{pathFlagsAnnotation: "to"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			to, _ := cmd.Flags().GetString("to")
+			all, _ := cmd.Flags().GetBool("all")
+			registries, _ := cmd.Flags().GetString("registries")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return handleMirror(cmd.Context(), to, registries, all, dryRun)
+		},
+	}
+
+	cmd.Flags().String("to", "", "Directory to write the snapshot to")
+	cmd.Flags().Bool("all", false, "Mirror every ruleset and version of the selected registries")
+	cmd.Flags().String("registries", "", "Mirror specific registries only (comma-separated)")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+// Mirror command handlers
+
+func handleMirror(ctx context.Context, to, registries string, all, dryRun bool) error {
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	opts := mirror.Options{Dest: to, All: all, DryRun: dryRun}
+	for _, name := range strings.Split(registries, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Registries = append(opts.Registries, name)
+		}
+	}
+
+	result, err := mirror.New(cfg).Mirror(ctx, opts)
+	if result != nil {
+		skipped := make([]string, 0, len(result.Skipped))
+		for name := range result.Skipped {
+			skipped = append(skipped, name)
+		}
+		sort.Strings(skipped)
+		for _, name := range skipped {
+			reportWarning(ctx, name, "skipped: "+result.Skipped[name])
+			logger.Info("Skipped %s: %s", name, result.Skipped[name])
+		}
+		setResult(ctx, map[string]interface{}{"destination": to, "dry_run": dryRun, "entries": result.Entries})
+
+		for _, entry := range result.Entries {
+			switch {
+			case dryRun:
+				logger.Info("Would mirror %s/%s@%s", entry.Registry, entry.Ruleset, entry.Version)
+			case entry.Existing:
+				logger.Info("  %s/%s@%s already mirrored", entry.Registry, entry.Ruleset, entry.Version)
+			default:
+				logger.Success("Mirrored %s/%s@%s", entry.Registry, entry.Ruleset, entry.Version)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if !dryRun {
+		logger.Info("Mirrored %d ruleset version(s) to %s", len(result.Entries), to)
+	}
+	return nil
+}
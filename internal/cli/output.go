@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// OutputSchemaVersion is the version of the document printed with --json. It
+// is incremented when fields are removed or change meaning; new fields may be
+// added without a version change.
+const OutputSchemaVersion = 1
+
+// Output is the document every command prints to standard output with --json
+type Output struct {
+	SchemaVersion int             `json:"schema_version"`
+	Command       string          `json:"command"`
+	Success       bool            `json:"success"`
+	Result        interface{}     `json:"result"`
+	Warnings      []OutputMessage `json:"warnings"`
+	Errors        []OutputMessage `json:"errors"`
+}
+
+// OutputMessage is a warning or error reported in JSON output
+type OutputMessage struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Target  string `json:"target,omitempty"` // Registry, ruleset or key the message concerns
+	Hint    string `json:"hint,omitempty"`   // Suggested remediation of an error
+}
+
+// codeWarning is the code of warnings; errors use the codes of armerr kinds
+const codeWarning = "warning"
+
+// outputKey is the context key of the output of the running command
+type outputKey struct{}
+
+// commandOutput is where the running command prints
+type commandOutput struct {
+	text   io.Writer     // Human-readable output
+	report *outputReport // JSON document with --json, nil otherwise
+}
+
+// outputReport collects the JSON document of the running command. Rulesets
+// processed concurrently record their warnings and errors through it.
+type outputReport struct {
+	mu       sync.Mutex
+	result   interface{}
+	warnings []OutputMessage
+	errors   []OutputMessage
+}
+
+// withOutput returns a context in which commands print to text and record
+// their JSON document in report, if not nil
+func withOutput(ctx context.Context, text io.Writer, report *outputReport) context.Context {
+	return context.WithValue(ctx, outputKey{}, &commandOutput{text: text, report: report})
+}
+
+// outputOf returns the output of the command running with ctx, standard
+// output when none is set
+func outputOf(ctx context.Context) *commandOutput {
+	if output, ok := ctx.Value(outputKey{}).(*commandOutput); ok {
+		return output
+	}
+	return &commandOutput{text: os.Stdout}
+}
+
+// textOutput returns the writer of the human-readable output of a command,
+// standard error with --json
+func textOutput(ctx context.Context) io.Writer {
+	return outputOf(ctx).text
+}
+
+// setResult records the result of the running command for JSON output
+func setResult(ctx context.Context, result interface{}) {
+	if report := outputOf(ctx).report; report != nil {
+		report.mu.Lock()
+		defer report.mu.Unlock()
+		report.result = result
+	}
+}
+
+// warn prints a warning and records it for JSON output
+func warn(ctx context.Context, target, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	logger.Warn("%s", message)
+	reportWarning(ctx, target, message)
+}
+
+// reportWarning records a warning for JSON output without printing it
+func reportWarning(ctx context.Context, target, message string) {
+	if report := outputOf(ctx).report; report != nil {
+		report.mu.Lock()
+		defer report.mu.Unlock()
+		report.warnings = append(report.warnings, OutputMessage{Code: codeWarning, Message: message, Target: target})
+	}
+}
+
+// reportError records an error affecting part of a command, such as one ruleset
+// of several, for JSON output
+func reportError(ctx context.Context, target string, err error) {
+	if report := outputOf(ctx).report; report != nil {
+		report.mu.Lock()
+		defer report.mu.Unlock()
+		report.errors = append(report.errors, newOutputMessage(target, err))
+	}
+}
+
+// newOutputMessage describes an error with its code and remediation hint
+func newOutputMessage(target string, err error) OutputMessage {
+	return OutputMessage{
+		Code:    armerr.KindOf(err).Code(),
+		Message: err.Error(),
+		Target:  target,
+		Hint:    armerr.HintOf(err),
+	}
+}
+
+// enableJSONOutput makes every command below cmd print an Output document
+// when --json is set, and sets where commands print
+func enableJSONOutput(cmd *cobra.Command) {
+	for _, child := range cmd.Commands() {
+		enableJSONOutput(child)
+	}
+	if cmd.RunE == nil {
+		return
+	}
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); !jsonOutput {
+			cmd.SetContext(withOutput(cmd.Context(), cmd.OutOrStdout(), nil))
+			return run(cmd, args)
+		}
+		return runWithJSONOutput(cmd.Context(), cmd.OutOrStdout(), commandName(cmd), func(ctx context.Context) error {
+			cmd.SetContext(ctx)
+			return run(cmd, args)
+		})
+	}
+}
+
+// failWithJSONOutput prints the Output document of a command that failed
+// before running, such as on invalid flags, when --json is set
+func failWithJSONOutput(cmd *cobra.Command, err error) error {
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); err == nil || !jsonOutput {
+		return err
+	}
+	return runWithJSONOutput(cmd.Context(), cmd.OutOrStdout(), commandName(cmd), func(context.Context) error {
+		return err
+	})
+}
+
+// JSONRequested reports whether a command line, without the program name,
+// sets --json. It lets main report errors that occur before flags are parsed.
+func JSONRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--json" {
+			return true
+		}
+		if value, ok := strings.CutPrefix(arg, "--json="); ok {
+			jsonOutput, err := strconv.ParseBool(value)
+			return err == nil && jsonOutput
+		}
+	}
+	return false
+}
+
+// WriteErrorOutput prints the Output document of the command named by args
+// for an error it has not reported, such as a configuration error occurring
+// before the command ran
+func WriteErrorOutput(w io.Writer, args []string, err error) {
+	var reported reportedError
+	if errors.As(err, &reported) {
+		return
+	}
+	root := NewRootCommand(nil, &VersionInfo{})
+	cmd, _, findErr := root.Find(args)
+	if findErr != nil {
+		cmd = root
+	}
+	_ = runWithJSONOutput(context.Background(), w, commandName(cmd), func(context.Context) error {
+		return err
+	})
+}
+
+// reportedError is an error already printed in an Output document
+type reportedError struct {
+	error
+}
+
+// Unwrap returns the reported error
+func (e reportedError) Unwrap() error {
+	return e.error
+}
+
+// commandName returns the name of a command in Output documents, such as
+// "config set"
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// runWithJSONOutput runs a command and prints its Output document to w.
+// Human-readable output of the command goes to standard error so that w holds
+// only the document.
+func runWithJSONOutput(ctx context.Context, w io.Writer, command string, run func(context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	report := &outputReport{}
+	err := run(withOutput(ctx, os.Stderr, report))
+
+	report.mu.Lock()
+	doc := Output{
+		SchemaVersion: OutputSchemaVersion,
+		Command:       command,
+		Result:        report.result,
+		Warnings:      append([]OutputMessage{}, report.warnings...),
+		Errors:        append([]OutputMessage{}, report.errors...),
+	}
+	report.mu.Unlock()
+	if err != nil {
+		doc.Errors = append(doc.Errors, newOutputMessage("", err))
+	}
+	doc.Success = len(doc.Errors) == 0
+
+	data, marshalErr := json.MarshalIndent(doc, "", "  ")
+	if marshalErr != nil {
+		return fmt.Errorf("failed to marshal output: %w", marshalErr)
+	}
+	if _, writeErr := fmt.Fprintln(w, string(data)); writeErr != nil && err == nil {
+		return writeErr
+	}
+	if err != nil {
+		return reportedError{err}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+func TestRunWithJSONOutput(t *testing.T) {
+	var result map[string]string
+	doc := captureJSON(t, "install", &result, func(ctx context.Context) error {
+		fmt.Fprintln(textOutput(ctx), "human-readable progress")
+		setResult(ctx, map[string]string{"status": "partial"})
+		warn(ctx, "default/rules", "Failed to cache ruleset: %v", errors.New("disk full"))
+		reportError(ctx, "default/other", fmt.Errorf("failed to download: %w", registry.ErrIntegrityMismatch))
+		return fmt.Errorf("%w in offline mode", registry.ErrNotCached)
+	})
+
+	if doc.Success {
+		t.Error("Expected failure to be reported")
+	}
+	if result["status"] != "partial" {
+		t.Errorf("Expected result to be kept on failure, got %v", result)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Target != "default/rules" || doc.Warnings[0].Code != codeWarning {
+		t.Errorf("Unexpected warnings %+v", doc.Warnings)
+	}
+	if len(doc.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %+v", doc.Errors)
+	}
+	if doc.Errors[0].Code != "integrity_mismatch" || doc.Errors[0].Target != "default/other" {
+		t.Errorf("Unexpected ruleset error %+v", doc.Errors[0])
+	}
+	if doc.Errors[1].Code != "not_cached" || doc.Errors[1].Hint != armerr.NotCached.Hint() {
+		t.Errorf("Expected not_cached code and hint for the command error, got %+v", doc.Errors[1])
+	}
+}
+
+func TestJSONOutputForCommands(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(tempDir)
+
+	// run executes arm with --json and returns its output document
+	run := func(command string, result interface{}, args ...string) Output {
+		root := NewRootCommand(nil, &VersionInfo{Version: "1.2.3", Commit: "abc", BuildTime: "today"})
+		root.SetArgs(append(args, "--json"))
+		root.SilenceErrors = true
+		output := captureStdout(t, func() { _ = root.Execute() })
+		return decodeOutput(t, output, command, result)
+	}
+
+	var version VersionInfo
+	if doc := run("version", &version, "version"); !doc.Success {
+		t.Fatalf("Expected success, got %+v", doc.Errors)
+	}
+	if version.Version != "1.2.3" {
+		t.Errorf("Expected version 1.2.3, got %+v", version)
+	}
+
+	var entry configEntry
+	if doc := run("config set", &entry, "config", "set", "git.concurrency", "4"); !doc.Success {
+		t.Fatalf("Expected success, got %+v", doc.Errors)
+	}
+	if entry.Key != "git.concurrency" || entry.Value != "4" || entry.Scope != "local" {
+		t.Errorf("Unexpected config entry %+v", entry)
+	}
+
+	var entries []configEntry
+	if doc := run("config list", &entries, "config", "list"); !doc.Success {
+		t.Fatalf("Expected success, got %+v", doc.Errors)
+	}
+	if len(entries) != 1 || entries[0].Key != "git.concurrency" {
+		t.Errorf("Expected git.concurrency to be listed, got %+v", entries)
+	}
+
+	doc := run("config set", nil, "config", "set", "git.concurrency", "many")
+	if doc.Success || len(doc.Errors) != 1 || doc.Errors[0].Code != "config_invalid" {
+		t.Errorf("Expected invalid value to be reported as an error, got %+v", doc)
+	}
+
+	doc = run("info", nil, "info", "missing/rules")
+	if len(doc.Errors) != 1 || doc.Errors[0].Code != "config_invalid" || doc.Errors[0].Hint == "" {
+		t.Errorf("Expected unknown registry to be reported with a hint, got %+v", doc.Errors)
+	}
+}
+
+func TestJSONOutputBeforeCommandRuns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(t.TempDir())
+	defer logger.Configure(logger.LevelNormal, false)
+
+	tests := []struct {
+		name    string
+		args    []string
+		command string
+	}{
+		{"conflicting flags", []string{"version", "--quiet", "--verbose", "--json"}, "version"},
+		{"unknown profile", []string{"list", "--json", "--profile", "missing"}, "list"},
+		{"invalid flag", []string{"install", "--json", "--unknown"}, "install"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewRootCommand(&config.Config{}, &VersionInfo{})
+			root.SetArgs(tt.args)
+			var err error
+			output := captureStdout(t, func() { err = root.Execute() })
+			if err == nil {
+				t.Fatal("Expected the command to fail")
+			}
+			doc := decodeOutput(t, output, tt.command, nil)
+			if doc.Success || len(doc.Errors) != 1 || doc.Errors[0].Message != err.Error() {
+				t.Errorf("Expected the error in the output document, got %+v", doc)
+			}
+
+			// main prints the document only for errors the command did not report
+			var buf bytes.Buffer
+			WriteErrorOutput(&buf, tt.args, err)
+			if buf.Len() != 0 {
+				t.Errorf("Expected the error not to be reported twice, got %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteErrorOutput(t *testing.T) {
+	if !JSONRequested([]string{"config", "list", "--json"}) || JSONRequested([]string{"list", "--json=false"}) || JSONRequested([]string{"--", "--json"}) {
+		t.Error("Unexpected detection of --json")
+	}
+
+	var buf bytes.Buffer
+	err := armerr.New(armerr.Config, "failed to load configuration")
+	WriteErrorOutput(&buf, []string{"config", "list", "--json"}, err)
+	doc := decodeOutput(t, buf.String(), "config list", nil)
+	if doc.Success || len(doc.Errors) != 1 || doc.Errors[0].Code != armerr.Config.Code() {
+		t.Errorf("Expected the configuration error in the output document, got %+v", doc)
+	}
+}
+
+func TestOutputLevelFlags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(t.TempDir())
+	defer logger.Configure(logger.LevelNormal, false)
+
+	// run executes arm and returns its standard output and error
+	run := func(args ...string) (string, error) {
+		root := NewRootCommand(nil, &VersionInfo{Version: "1.2.3"})
+		root.SetArgs(args)
+		var err error
+		output := captureStdout(t, func() { err = root.Execute() })
+		return output, err
+	}
+
+	if output, err := run("clean", "cache", "--dry-run"); err != nil || !strings.Contains(output, "Would clean target: cache") {
+		t.Errorf("Expected dry run to be described, got %q (%v)", output, err)
+	}
+	if output, err := run("clean", "cache", "--dry-run", "--quiet"); err != nil || output != "" {
+		t.Errorf("Expected no output with --quiet, got %q (%v)", output, err)
+	}
+	if output, _ := run("version", "--quiet"); !strings.Contains(output, "ARM version 1.2.3") {
+		t.Errorf("Expected requested data to be printed with --quiet, got %q", output)
+	}
+	if _, err := run("version", "--quiet", "--verbose"); armerr.KindOf(err) != armerr.Config {
+		t.Errorf("Expected --quiet with --verbose to be rejected, got %v", err)
+	}
+}
+
+func TestRunWithJSONOutputConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	docs := make([]Output, 4)
+	for i := range docs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var output bytes.Buffer
+			_ = runWithJSONOutput(context.Background(), &output, "update", func(ctx context.Context) error {
+				var tasks sync.WaitGroup
+				for j := 0; j < 10; j++ {
+					tasks.Add(1)
+					go func(j int) {
+						defer tasks.Done()
+						reportWarning(ctx, fmt.Sprintf("default/rules-%d", i), fmt.Sprintf("warning %d", j))
+					}(j)
+				}
+				tasks.Wait()
+				setResult(ctx, i)
+				return nil
+			})
+			if err := json.Unmarshal(output.Bytes(), &docs[i]); err != nil {
+				t.Errorf("Expected valid JSON, got %v: %s", err, output.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, doc := range docs {
+		if doc.Result != float64(i) || len(doc.Warnings) != 10 {
+			t.Errorf("Expected the result and warnings of command %d only, got %v with %d warnings", i, doc.Result, len(doc.Warnings))
+			continue
+		}
+		for _, warning := range doc.Warnings {
+			if warning.Target != fmt.Sprintf("default/rules-%d", i) {
+				t.Errorf("Command %d reported a warning of another command: %+v", i, warning)
+			}
+		}
+	}
+}
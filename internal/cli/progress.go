@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
+)
+
+// Intervals between progress updates of one task outside a terminal
+const (
+	logProgressInterval  = 2 * time.Second
+	jsonProgressInterval = 500 * time.Millisecond
+)
+
+// enableProgress makes every command below cmd report the progress of
+// downloads and rulesets: as live status lines on a terminal, as JSON lines on
+// standard error with --json, and as periodic log lines otherwise
+func enableProgress(cmd *cobra.Command) {
+	for _, child := range cmd.Commands() {
+		enableProgress(child)
+	}
+	if cmd.RunE == nil {
+		return
+	}
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		renderer := newProgressRenderer(jsonOutput)
+		if renderer == nil {
+			return run(cmd, args)
+		}
+		defer renderer.Close()
+
+		if overlay, ok := renderer.(logger.Overlay); ok {
+			logger.SetOverlay(overlay)
+			defer logger.SetOverlay(nil)
+		}
+		cmd.SetContext(progress.WithReporter(cmd.Context(), renderer))
+		return run(cmd, args)
+	}
+}
+
+// newProgressRenderer returns the renderer for the current output, or nil
+// with --quiet
+func newProgressRenderer(jsonOutput bool) progress.Renderer {
+	switch {
+	case logger.Quiet():
+		return nil
+	case jsonOutput:
+		return progress.NewJSON(os.Stderr, jsonProgressInterval)
+	case logger.IsTerminal(os.Stderr) && os.Getenv("TERM") != "dumb":
+		return progress.NewTerminal(os.Stderr, terminalWidth())
+	}
+	return progress.NewLog(logger.Info, logProgressInterval)
+}
+
+// terminalWidth returns the width of the terminal from COLUMNS, or 80
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 80
+}
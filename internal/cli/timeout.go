@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+// noTimeoutAnnotation marks commands, such as servers, that run until they are
+// interrupted and ignore the configured command timeouts
+const noTimeoutAnnotation = "arm/no-timeout"
+
+// enableTimeouts bounds every command by --timeout, or by the commandTimeout
+// configured for it, and classifies the errors of interrupted commands
+func enableTimeouts(cmd *cobra.Command, cfg *config.Config) {
+	for _, child := range cmd.Commands() {
+		enableTimeouts(child, cfg)
+	}
+	if cmd.RunE == nil {
+		return
+	}
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if timeout == 0 && cfg != nil && cmd.Annotations[noTimeoutAnnotation] == "" {
+			timeout = cfg.CommandTimeout(topLevelName(cmd))
+		}
+
+		ctx := cmd.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+			cmd.SetContext(ctx)
+		}
+
+		err := run(cmd, args)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return armerr.Errorf(armerr.Network, "%s timed out after %s: %w", cmd.CommandPath(), timeout, err).
+				WithHint("Raise the limit with --timeout or commandTimeout in the [network] section of .armrc")
+		case errors.Is(ctx.Err(), context.Canceled):
+			return armerr.Errorf(armerr.Canceled, "interrupted: %w", err)
+		}
+		return err
+	}
+}
+
+// topLevelName returns the name of the top-level command cmd belongs to, such
+// as cache for arm cache prune
+func topLevelName(cmd *cobra.Command) string {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	return cmd.Name()
+}
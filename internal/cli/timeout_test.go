@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+func TestEnableTimeouts(t *testing.T) {
+	newRoot := func() *cobra.Command {
+		wait := func(cmd *cobra.Command, args []string) error {
+			<-cmd.Context().Done()
+			return cmd.Context().Err()
+		}
+		root := &cobra.Command{Use: "arm", SilenceUsage: true, SilenceErrors: true}
+		root.PersistentFlags().Duration("timeout", 0, "")
+		cacheCmd := &cobra.Command{Use: "cache"}
+		cacheCmd.AddCommand(&cobra.Command{Use: "prune", RunE: wait})
+		root.AddCommand(&cobra.Command{Use: "install", RunE: wait}, &cobra.Command{Use: "update", RunE: wait}, cacheCmd)
+		enableTimeouts(root, &config.Config{NetworkConfig: map[string]string{"commandTimeout.install": "10ms", "commandTimeout.cache": "10ms"}})
+		return root
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "command timeout", args: []string{"install"}},
+		{name: "subcommand", args: []string{"cache", "prune"}},
+		{name: "flag", args: []string{"update", "--timeout=10ms"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newRoot()
+			root.SetArgs(tt.args)
+			err := root.Execute()
+			if armerr.KindOf(err) != armerr.Network || armerr.HintOf(err) == "" {
+				t.Errorf("Expected a timeout error with a hint, got %v", err)
+			}
+		})
+	}
+
+	t.Run("interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		root := newRoot()
+		root.SetArgs([]string{"update"})
+		if err := root.ExecuteContext(ctx); armerr.KindOf(err) != armerr.Canceled {
+			t.Errorf("Expected a canceled error, got %v", err)
+		}
+	})
+}
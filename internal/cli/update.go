@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/install"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+	"github.com/max-dunn/ai-rules-manager/internal/update"
+	"github.com/spf13/cobra"
+)
+
+// newOutdatedCommand creates the outdated command
+func newOutdatedCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "Show outdated rulesets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			local, _ := cmd.Flags().GetBool("local")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			target, _ := cmd.Flags().GetString("target")
+			return handleOutdated(cmd.Context(), config.Scopes(global, local), target, jsonOutput)
+		},
+	}
+
+	cmd.Flags().Bool("local", false, "Check local installations only")
+	cmd.Flags().String("target", "", "Version to report as wanted: patch, minor, latest or major (default latest)")
+
+	return cmd
+}
+
+// newUpdateCommand creates the update command
+func newUpdateCommand(_ *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [ruleset-name]",
+		Short: "Update rulesets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, _ := cmd.Flags().GetBool("global")
+			local, _ := cmd.Flags().GetBool("local")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jsonOutput, _ := cmd.Flags().GetBool("json")
+			target, _ := cmd.Flags().GetString("target")
+			scopes := config.Scopes(global, local)
+			if len(args) == 0 {
+				return handleUpdateAll(cmd.Context(), scopes, dryRun, target, jsonOutput)
+			} else {
+				return handleUpdateRuleset(cmd.Context(), args[0], scopes, dryRun, target, jsonOutput)
+			}
+		},
+	}
+
+	cmd.Flags().Bool("local", false, "Update local installations only")
+
+	cmd.Flags().String("target", "", "Newest version to move to: patch, minor, latest (the manifest range) or major (default latest)")
+
+	return cmd
+}
+
+func handleOutdated(ctx context.Context, scopes []config.Scope, targetFlag string, jsonOutput bool) error {
+	out := textOutput(ctx)
+	target, err := update.ParseTarget(targetFlag)
+	if err != nil {
+		return err
+	}
+
+	// Load configuration
+	configs, err := lockedScopes(ctx, scopes)
+	if err != nil {
+		return err
+	}
+
+	type outdatedInfo struct {
+		Scope          config.Scope `json:"scope"`
+		Workspace      string       `json:"workspace,omitempty"`
+		Registry       string       `json:"registry"`
+		Name           string       `json:"name"`
+		CurrentVersion string       `json:"current_version"`
+		WantedVersion  string       `json:"wanted_version"` // Version arm update would install for the target
+		LatestVersion  string       `json:"latest_version"`
+		Range          string       `json:"range,omitempty"` // Manifest range arm update would record
+		Held           bool         `json:"held,omitempty"`
+		Reason         string       `json:"reason,omitempty"`
+		UpdateCommand  string       `json:"update_command"`
+		DiffCommand    string       `json:"diff_command"`
+		display        string       // Name printed in text output
+	}
+
+	outdatedRulesets := []outdatedInfo{}
+	var failures []install.InstallError
+	for _, cfg := range configs {
+		// Check the installed rulesets of the scope concurrently
+		results, scopeFailures := update.New(cfg).CheckOutdatedAll(ctx, lockedRulesetSpecs(cfg), target, reportProgress)
+		failures = append(failures, scopeFailures...)
+		for _, result := range results {
+			if result == nil || !result.Updated {
+				continue
+			}
+			outdatedRulesets = append(outdatedRulesets, outdatedInfo{
+				Scope:          cfg.Scope,
+				Workspace:      cfg.Workspace,
+				Registry:       result.Registry,
+				Name:           result.Ruleset,
+				CurrentVersion: result.PreviousVersion,
+				WantedVersion:  result.Version,
+				LatestVersion:  result.Latest,
+				Range:          result.Range,
+				Held:           result.Held,
+				Reason:         result.Reason,
+				UpdateCommand:  updateCommand(cfg, result.Registry, result.Ruleset, target),
+				DiffCommand:    fmt.Sprintf("arm diff %s/%s%s", result.Registry, result.Ruleset, scopeFlag(cfg)),
+				display:        scopedName(cfg, result.Registry+"/"+result.Ruleset),
+			})
+		}
+	}
+
+	// Offline checks cannot be trusted when part of the data is missing
+	var notCached []error
+	var otherFailures []install.InstallError
+	for _, failure := range failures {
+		if errors.Is(failure.Error, registry.ErrNotCached) {
+			notCached = append(notCached, failure.Error)
+		} else {
+			otherFailures = append(otherFailures, failure)
+		}
+	}
+	failed := taskFailures(ctx, "check", otherFailures)
+	if len(notCached) > 0 {
+		return notCachedError(notCached)
+	}
+
+	setResult(ctx, map[string]interface{}{"outdated": outdatedRulesets})
+	if jsonOutput {
+		return failed
+	}
+
+	if len(outdatedRulesets) == 0 {
+		if failed == nil {
+			fmt.Fprintln(out, "All rulesets are up to date")
+		}
+		return failed
+	}
+
+	fmt.Fprintf(out, "Found %d outdated ruleset(s):\n\n", len(outdatedRulesets))
+	for _, info := range outdatedRulesets {
+		fmt.Fprintf(out, "%s\n", info.display)
+		fmt.Fprintf(out, "  Current: %s\n", info.CurrentVersion)
+		if info.Held {
+			fmt.Fprintf(out, "  Held:    %s\n", heldReason(info.Reason))
+		} else {
+			fmt.Fprintf(out, "  Wanted:  %s\n", info.WantedVersion)
+		}
+		fmt.Fprintf(out, "  Latest:  %s\n", info.LatestVersion)
+		if info.Range != "" {
+			fmt.Fprintf(out, "  Range:   %s (manifest range would be widened)\n", info.Range)
+		}
+		fmt.Fprintf(out, "  Update:  %s\n", info.UpdateCommand)
+		fmt.Fprintf(out, "  Diff:    %s\n\n", info.DiffCommand)
+	}
+
+	return failed
+}
+
+// reportProgress logs the progress of concurrent ruleset operations
+func reportProgress(current, total int, operation string) {
+	logger.Info("[%d/%d] %s", current, total, operation)
+}
+
+// taskFailures reports each failed ruleset operation and returns an error
+// summarizing them, or nil when none failed
+func taskFailures(ctx context.Context, action string, failures []install.InstallError) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	var failed []string
+	var errs []error
+	for _, failure := range failures {
+		rulesetSpec := failure.Registry + "/" + failure.Ruleset
+		logger.Error("Failed to %s %s: %v", action, rulesetSpec, failure.Error)
+		reportError(ctx, rulesetSpec, failure.Error)
+		failed = append(failed, rulesetSpec)
+		errs = append(errs, failure.Error)
+	}
+	return batchError(action, failed, errs)
+}
+
+// updateCommand returns the arm update command for a ruleset and target
+func updateCommand(cfg *config.Config, registryName, name string, target update.Target) string {
+	if target == update.TargetLatest {
+		return fmt.Sprintf("arm update %s/%s%s", registryName, name, scopeFlag(cfg))
+	}
+	return fmt.Sprintf("arm update %s/%s%s --target=%s", registryName, name, scopeFlag(cfg), target)
+}
+
+// lockedScopes loads the configuration of the scopes that have a lock file
+func lockedScopes(ctx context.Context, scopes []config.Scope) ([]*config.Config, error) {
+	configs, err := loadScopes(ctx, scopes)
+	if err != nil {
+		return nil, err
+	}
+	var locked []*config.Config
+	for _, cfg := range configs {
+		if cfg.LockFile != nil {
+			locked = append(locked, cfg)
+		}
+	}
+	if len(locked) == 0 {
+		return nil, armerr.New(armerr.NotFound, "no lock file found - no rulesets installed")
+	}
+	return locked, nil
+}
+
+// heldReason describes why a pinned ruleset is not updated
+func heldReason(reason string) string {
+	if reason == "" {
+		return "pinned"
+	}
+	return "pinned: " + reason
+}
+
+// updateOutput is the result of arm update in JSON output
+type updateOutput struct {
+	DryRun   bool             `json:"dry_run"`
+	Rulesets []updatedRuleset `json:"rulesets"`
+}
+
+// updatedRuleset is a ruleset checked by arm update
+type updatedRuleset struct {
+	Scope           config.Scope    `json:"scope"`
+	Workspace       string          `json:"workspace,omitempty"`
+	Registry        string          `json:"registry"`
+	Name            string          `json:"name"`
+	PreviousVersion string          `json:"previous_version"`
+	Version         string          `json:"version"`
+	Updated         bool            `json:"updated"`
+	Range           string          `json:"range,omitempty"` // Manifest range widened for a major upgrade
+	Held            bool            `json:"held,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+	Diff            *update.Preview `json:"diff,omitempty"` // Changes a dry run would make
+}
+
+// newUpdatedRuleset converts an update result for JSON output
+func newUpdatedRuleset(cfg *config.Config, result *update.UpdateResult) updatedRuleset {
+	return updatedRuleset{
+		Scope:           cfg.Scope,
+		Workspace:       cfg.Workspace,
+		Registry:        result.Registry,
+		Name:            result.Ruleset,
+		PreviousVersion: result.PreviousVersion,
+		Version:         result.Version,
+		Updated:         result.Updated,
+		Range:           result.Range,
+		Held:            result.Held,
+		Reason:          result.Reason,
+	}
+}
+
+// logUpdateResult reports the outcome of updating a ruleset
+func logUpdateResult(cfg *config.Config, result *update.UpdateResult) {
+	name := scopedName(cfg, result.Registry+"/"+result.Ruleset)
+	switch {
+	case result.Updated:
+		logger.Success("Updated %s %s → %s", name, result.PreviousVersion, result.Version)
+		if result.Range != "" {
+			logger.Info("  Manifest range changed to %s", result.Range)
+		}
+	case result.Held:
+		logger.Info("%s is held at %s (%s)", name, result.Version, heldReason(result.Reason))
+	default:
+		logger.Info("%s is already up to date (%s)", name, result.Version)
+	}
+}
+
+func handleUpdateAll(ctx context.Context, scopes []config.Scope, dryRun bool, targetFlag string, jsonOutput bool) error {
+	output := &updateOutput{DryRun: dryRun, Rulesets: []updatedRuleset{}}
+	setResult(ctx, output)
+
+	target, err := update.ParseTarget(targetFlag)
+	if err != nil {
+		return err
+	}
+
+	// Load configuration
+	configs, err := loadScopes(ctx, scopes)
+	if err != nil {
+		return err
+	}
+
+	var updatedCount int
+	var failed []error
+	for _, cfg := range configs {
+		if dryRun {
+			if err := previewUpdates(ctx, cfg, output, lockedRulesetSpecs(cfg), target, jsonOutput); err != nil {
+				failed = append(failed, err)
+			}
+			continue
+		}
+
+		// Update the installed rulesets of the scope concurrently
+		results, failures := update.New(cfg).UpdateAll(ctx, lockedRulesetSpecs(cfg), target, reportProgress)
+		for _, result := range results {
+			if result == nil {
+				continue
+			}
+			output.Rulesets = append(output.Rulesets, newUpdatedRuleset(cfg, result))
+			logUpdateResult(cfg, result)
+			if result.Updated {
+				updatedCount++
+			}
+		}
+		if err := taskFailures(ctx, "update", failures); err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	if !dryRun {
+		logger.Info("Updated %d ruleset(s)", updatedCount)
+	}
+	return errors.Join(failed...)
+}
+
+func handleUpdateRuleset(ctx context.Context, rulesetSpec string, scopes []config.Scope, dryRun bool, targetFlag string, jsonOutput bool) error {
+	output := &updateOutput{DryRun: dryRun, Rulesets: []updatedRuleset{}}
+	setResult(ctx, output)
+
+	target, err := update.ParseTarget(targetFlag)
+	if err != nil {
+		return err
+	}
+
+	// Load the configuration of the scope the ruleset is installed in
+	cfg, err := rulesetScope(ctx, scopes, rulesetSpec)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return previewUpdates(ctx, cfg, output, []string{rulesetSpec}, target, jsonOutput)
+	}
+
+	var result *update.UpdateResult
+	registryName, name, _ := parseRulesetSpec(rulesetSpec)
+	err = progress.Track(ctx, registryName+"/"+name, func(ctx context.Context) error {
+		return install.WithOperationTimeout(ctx, cfg, registryName, func(ctx context.Context) (err error) {
+			result, err = update.New(cfg).UpdateRuleset(ctx, rulesetSpec, target)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	output.Rulesets = append(output.Rulesets, newUpdatedRuleset(cfg, result))
+	logUpdateResult(cfg, result)
+
+	return nil
+}
+
+// previewUpdates prints the changes arm update would make to each ruleset
+func previewUpdates(ctx context.Context, cfg *config.Config, output *updateOutput, rulesetSpecs []string, target update.Target, jsonOutput bool) error {
+	var progress install.ProgressCallback
+	if len(rulesetSpecs) > 1 {
+		progress = reportProgress
+	}
+	previews, failures := update.New(cfg).PreviewAll(ctx, rulesetSpecs, target, progress)
+	for _, preview := range previews {
+		if preview == nil {
+			continue
+		}
+		output.Rulesets = append(output.Rulesets, updatedRuleset{
+			Scope:           cfg.Scope,
+			Workspace:       cfg.Workspace,
+			Registry:        preview.Registry,
+			Name:            preview.Ruleset,
+			PreviousVersion: preview.From,
+			Version:         preview.To,
+			Updated:         preview.From != preview.To,
+			Range:           preview.Range,
+			Held:            preview.Held,
+			Reason:          preview.Reason,
+			Diff:            preview,
+		})
+		if !jsonOutput {
+			printPreview(textOutput(ctx), preview)
+		}
+	}
+
+	return taskFailures(ctx, "preview", failures)
+}
+
+// lockedRulesetSpecs returns the registry/ruleset specs of the lock file in sorted order
+func lockedRulesetSpecs(cfg *config.Config) []string {
+	var specs []string
+	if cfg.LockFile != nil {
+		for registryName, rulesets := range cfg.LockFile.Rulesets {
+			for name := range rulesets {
+				specs = append(specs, registryName+"/"+name)
+			}
+		}
+	}
+	sort.Strings(specs)
+	return specs
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,6 +21,21 @@ type CacheConfig struct {
 
 	// CleanupInterval is how often to run cache cleanup
 	CleanupInterval time.Duration `json:"cleanup_interval"`
+
+	// EvictionPolicy selects what to evict when the cache exceeds MaxSize (lru or lfu)
+	EvictionPolicy string `json:"eviction_policy"`
+
+	// LinkMode selects how installed files are placed in channels (copy, hardlink or reflink)
+	LinkMode string `json:"link_mode"`
+
+	// Remote is the URL of a shared remote cache consulted before origin registries
+	Remote string `json:"remote,omitempty"`
+
+	// RemoteToken is the bearer token sent to the remote cache
+	RemoteToken string `json:"-"`
+
+	// RemoteUpload pushes content downloaded from origin registries to the remote cache
+	RemoteUpload bool `json:"remote_upload,omitempty"`
 }
 
 // DefaultCacheConfig returns the default cache configuration
@@ -34,6 +50,8 @@ func DefaultCacheConfig() *CacheConfig {
 		MaxSize:         0,              // Unlimited by default
 		TTL:             24 * time.Hour, // 24 hours default
 		CleanupInterval: 6 * time.Hour,  // Cleanup every 6 hours
+		EvictionPolicy:  "lru",          // Evict least recently used first
+		LinkMode:        "copy",         // Install independent copies
 	}
 }
 
@@ -64,6 +82,28 @@ func (c *Config) LoadCacheConfig() *CacheConfig {
 				cfg.CleanupInterval = cleanup
 			}
 		}
+
+		if policy, ok := cacheSection["evictionPolicy"]; ok && policy != "" {
+			cfg.EvictionPolicy = strings.ToLower(policy)
+		}
+
+		if linkMode, ok := cacheSection["linkMode"]; ok && linkMode != "" {
+			cfg.LinkMode = strings.ToLower(linkMode)
+		}
+
+		if remote, ok := cacheSection["remote"]; ok && remote != "" {
+			cfg.Remote = expandEnvVars(remote)
+		}
+
+		if token, ok := cacheSection["remoteToken"]; ok && token != "" {
+			cfg.RemoteToken = expandEnvVars(token)
+		}
+
+		if upload, ok := cacheSection["remoteUpload"]; ok && upload != "" {
+			if enabled, err := strconv.ParseBool(upload); err == nil {
+				cfg.RemoteUpload = enabled
+			}
+		}
 	}
 
 	return cfg
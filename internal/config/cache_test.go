@@ -60,6 +60,11 @@ func TestLoadCacheConfig(t *testing.T) {
 						"maxSize":         "1073741824", // 1GB
 						"ttl":             "12h",
 						"cleanupInterval": "3h",
+						"evictionPolicy":  "LFU",
+						"linkMode":        "hardlink",
+						"remote":          "https://arm-cache.example.com",
+						"remoteToken":     "secret",
+						"remoteUpload":    "true",
 					},
 				},
 			},
@@ -68,6 +73,11 @@ func TestLoadCacheConfig(t *testing.T) {
 				MaxSize:         1073741824,
 				TTL:             12 * time.Hour,
 				CleanupInterval: 3 * time.Hour,
+				EvictionPolicy:  "lfu",
+				LinkMode:        "hardlink",
+				Remote:          "https://arm-cache.example.com",
+				RemoteToken:     "secret",
+				RemoteUpload:    true,
 			},
 		},
 	}
@@ -92,6 +102,18 @@ func TestLoadCacheConfig(t *testing.T) {
 				t.Errorf("Expected cleanup interval %v, got %v", tt.expected.CleanupInterval, result.CleanupInterval)
 			}
 
+			if result.EvictionPolicy != tt.expected.EvictionPolicy {
+				t.Errorf("Expected eviction policy %s, got %s", tt.expected.EvictionPolicy, result.EvictionPolicy)
+			}
+
+			if result.LinkMode != tt.expected.LinkMode {
+				t.Errorf("Expected link mode %s, got %s", tt.expected.LinkMode, result.LinkMode)
+			}
+
+			if result.Remote != tt.expected.Remote || result.RemoteToken != tt.expected.RemoteToken || result.RemoteUpload != tt.expected.RemoteUpload {
+				t.Errorf("Expected remote %s (upload %v), got %s (upload %v)", tt.expected.Remote, tt.expected.RemoteUpload, result.Remote, result.RemoteUpload)
+			}
+
 		})
 	}
 }
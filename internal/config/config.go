@@ -6,8 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 	"github.com/max-dunn/ai-rules-manager/internal/version"
 	"gopkg.in/ini.v1"
 )
@@ -24,6 +27,17 @@ type Config struct {
 	Rulesets map[string]map[string]RulesetSpec // rulesets from arm.json
 	Engines  map[string]string                 // engines from arm.json
 	LockFile *LockFile                         // arm.lock content
+	Scope    Scope                             // Scope of the manifest and lock file, local when empty
+
+	// Workspaces (monorepos)
+	Workspaces []string // Member directory patterns of the root arm.json
+	Workspace  string   // Member directory the configuration belongs to, empty for the root project
+
+	// Profiles
+	Profiles map[string]*Config // [profile.<name>.*] sections of the .armrc files by profile name
+	Profile  string             // Profile merged into the configuration, if any
+
+	Options Options // Command-line settings the configuration was loaded with
 
 	// Cache configuration (loaded from INI sections)
 	CacheConfig *CacheConfig // cache settings
@@ -38,69 +52,120 @@ type ChannelConfig struct {
 type RulesetSpec struct {
 	Version  string   `json:"version"`
 	Patterns []string `json:"patterns,omitempty"`
+	Update   string   `json:"update,omitempty"` // Update policy, auto when empty
+	Reason   string   `json:"reason,omitempty"` // Why the ruleset is pinned
 }
 
+// Update policies of a ruleset
+const (
+	UpdateAuto   = "auto"   // Any version the manifest range allows
+	UpdateMinor  = "minor"  // Minor and patch releases of the installed major version
+	UpdatePatch  = "patch"  // Patch releases of the installed minor version
+	UpdatePinned = "pinned" // Held at the installed version
+)
+
+// UpdatePolicies lists the supported update policies
+var UpdatePolicies = []string{UpdateAuto, UpdateMinor, UpdatePatch, UpdatePinned}
+
 // ARMConfig represents the arm.json file structure
 type ARMConfig struct {
-	Engines  map[string]string                 `json:"engines"`
-	Channels map[string]ChannelConfig          `json:"channels"`
-	Rulesets map[string]map[string]RulesetSpec `json:"rulesets"`
+	Engines    map[string]string                 `json:"engines"`
+	Channels   map[string]ChannelConfig          `json:"channels"`
+	Rulesets   map[string]map[string]RulesetSpec `json:"rulesets"`
+	Workspaces []string                          `json:"workspaces,omitempty"` // Member directory patterns, e.g. packages/*
 }
 
 // LockFile represents the arm.lock file structure
 type LockFile struct {
-	Rulesets map[string]map[string]LockedRuleset `json:"rulesets"`
+	Rulesets   map[string]map[string]LockedRuleset `json:"rulesets"`
+	Workspaces map[string]*WorkspaceLock           `json:"workspaces,omitempty"` // Rulesets of workspace members by directory
 }
 
 // LockedRuleset represents a locked ruleset entry
 type LockedRuleset struct {
-	Version  string `json:"version"`
-	Resolved string `json:"resolved"`
-	Registry string `json:"registry"`
-	Type     string `json:"type"`
-	Region   string `json:"region,omitempty"`
-}
-
-// Load loads the ARM configuration from files with hierarchical merging
-func Load() (*Config, error) {
-	// Load global configuration first
-	globalCfg, err := loadConfigFromPaths(
-		filepath.Join(os.Getenv("HOME"), ".arm", ".armrc"),
-		filepath.Join(os.Getenv("HOME"), ".arm", "arm.json"),
-		"arm.lock", // Lock file is always local
-	)
+	Version   string `json:"version"`
+	Resolved  string `json:"resolved"`
+	Registry  string `json:"registry"`
+	Type      string `json:"type"`
+	Region    string `json:"region,omitempty"`
+	Integrity string `json:"integrity,omitempty"` // Content hash of the installed files
+	Source    string `json:"source,omitempty"`    // Mirror that served the files, if not the registry itself
+}
+
+// Load loads the ARM configuration from files with hierarchical merging. The
+// manifests of both scopes are merged; the lock file is the local one.
+func Load(opts Options) (*Config, error) {
+	globalCfg, localCfg, err := loadScopes()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load global config: %w", err)
-	}
-
-	// Load local configuration
-	localCfg, err := loadConfigFromPaths(".armrc", "arm.json", "arm.lock")
-	if err != nil {
-		return nil, fmt.Errorf("failed to load local config: %w", err)
+		return nil, err
 	}
 
 	// Merge configurations (local overrides global at key level)
 	mergedCfg := mergeConfigs(globalCfg, localCfg)
+	mergedCfg.Options = opts
+	if err := mergedCfg.applyProfile(); err != nil {
+		return nil, err
+	}
 
 	// Validate merged configuration
 	if err := validateConfig(mergedCfg); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+		return nil, armerr.Errorf(armerr.Config, "configuration validation failed: %w", err)
 	}
 
 	return mergedCfg, nil
 }
 
+// LoadScope loads the configuration commands installing, listing and
+// updating rulesets use for scope. The manifest, channels and lock file come
+// from the scope alone. Global scope reads only ~/.arm; local scope also
+// applies the global .armrc, overridden by the local one.
+func LoadScope(scope Scope, opts Options) (*Config, error) {
+	globalCfg, localCfg, err := loadScopes()
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeConfigs(globalCfg, localCfg)
+	cfg := globalCfg
+	cfg.Workspaces = nil
+	if scope != ScopeGlobal {
+		cfg = merged
+		cfg.Channels = localCfg.Channels
+		cfg.Rulesets = localCfg.Rulesets
+		cfg.Workspaces = localCfg.Workspaces
+	}
+
+	// Profiles of both .armrc files apply to either scope
+	cfg.Profiles = merged.Profiles
+	cfg.Options = opts
+	if err := cfg.applyProfile(); err != nil {
+		return nil, err
+	}
+	cfg.Scope = scope
+
+	if err := validateConfig(cfg); err != nil {
+		return nil, armerr.Errorf(armerr.Config, "configuration validation failed: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadScopes loads the global and local configuration files
+func loadScopes() (globalCfg, localCfg *Config, err error) {
+	globalCfg, err = loadConfigFromPaths(ScopeGlobal.Path(".armrc"), ScopeGlobal.Path("arm.json"), ScopeGlobal.Path("arm.lock"))
+	if err != nil {
+		return nil, nil, armerr.Errorf(armerr.Config, "failed to load global config: %w", err)
+	}
+
+	localCfg, err = loadConfigFromPaths(ScopeLocal.Path(".armrc"), ScopeLocal.Path("arm.json"), ScopeLocal.Path("arm.lock"))
+	if err != nil {
+		return nil, nil, armerr.Errorf(armerr.Config, "failed to load local config: %w", err)
+	}
+	return globalCfg, localCfg, nil
+}
+
 // loadConfigFromPaths loads configuration from specified file paths
 func loadConfigFromPaths(iniPath, jsonPath, lockPath string) (*Config, error) {
-	cfg := &Config{
-		Registries:      make(map[string]string),
-		RegistryConfigs: make(map[string]map[string]string),
-		TypeDefaults:    make(map[string]map[string]string),
-		NetworkConfig:   make(map[string]string),
-		Channels:        make(map[string]ChannelConfig),
-		Rulesets:        make(map[string]map[string]RulesetSpec),
-		Engines:         make(map[string]string),
-	}
+	cfg := newConfig()
 
 	// Load INI file
 	if err := cfg.loadINIFile(iniPath, false); err != nil {
@@ -112,11 +177,12 @@ func loadConfigFromPaths(iniPath, jsonPath, lockPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to load JSON file %s: %w", jsonPath, err)
 	}
 
-	// Load lock file (only for local config)
-	if lockPath == "arm.lock" {
-		if err := cfg.loadLockFile(lockPath); err != nil {
-			return nil, fmt.Errorf("failed to load lock file %s: %w", lockPath, err)
-		}
+	// Load lock file, which workspace members share with the root project
+	if lockPath == "" {
+		return cfg, nil
+	}
+	if err := cfg.loadLockFile(lockPath); err != nil {
+		return nil, fmt.Errorf("failed to load lock file %s: %w", lockPath, err)
 	}
 
 	return cfg, nil
@@ -143,7 +209,7 @@ func (c *Config) loadINIFile(path string, required bool) error {
 			continue
 		}
 
-		if err := c.processSection(section); err != nil {
+		if err := c.processSection(sectionName, section); err != nil {
 			return fmt.Errorf("failed to process section [%s]: %w", sectionName, err)
 		}
 	}
@@ -151,16 +217,30 @@ func (c *Config) loadINIFile(path string, required bool) error {
 	return nil
 }
 
-// processSection processes a single INI section
-func (c *Config) processSection(section *ini.Section) error {
-	sectionName := section.Name()
+// newConfig returns an empty configuration
+func newConfig() *Config {
+	return &Config{
+		Registries:      make(map[string]string),
+		RegistryConfigs: make(map[string]map[string]string),
+		TypeDefaults:    make(map[string]map[string]string),
+		NetworkConfig:   make(map[string]string),
+		Channels:        make(map[string]ChannelConfig),
+		Rulesets:        make(map[string]map[string]RulesetSpec),
+		Engines:         make(map[string]string),
+	}
+}
 
+// processSection processes a single INI section under the given name
+func (c *Config) processSection(sectionName string, section *ini.Section) error {
 	// Handle nested sections like [registries.my-registry]
 	if strings.Contains(sectionName, ".") {
 		parts := strings.SplitN(sectionName, ".", 2)
 		if parts[0] == "registries" {
 			return c.processRegistryConfig(parts[1], section)
 		}
+		if parts[0] == "profile" {
+			return c.processProfileSection(parts[1], section)
+		}
 
 		return fmt.Errorf("unsupported nested section: %s", sectionName)
 	}
@@ -255,6 +335,9 @@ func mergeConfigs(global, local *Config) *Config {
 	// Merge rulesets (nested map merge)
 	mergeRulesetMaps(merged.Rulesets, global.Rulesets, local.Rulesets)
 
+	// Merge profiles (key-level merge within each profile)
+	merged.Profiles = mergeProfiles(global.Profiles, local.Profiles)
+
 	// Lock file is always from local (no merging needed)
 	merged.LockFile = local.LockFile
 
@@ -354,6 +437,9 @@ func (c *Config) loadARMJSON(path string, required bool) error {
 	for k, v := range armConfig.Channels {
 		c.Channels[k] = v
 	}
+	if len(armConfig.Workspaces) > 0 {
+		c.Workspaces = armConfig.Workspaces
+	}
 	for registry, rulesets := range armConfig.Rulesets {
 		if c.Rulesets[registry] == nil {
 			c.Rulesets[registry] = make(map[string]RulesetSpec)
@@ -400,6 +486,13 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 
+	// Validate mirror references
+	for name := range cfg.Registries {
+		if err := validateMirrors(name, cfg); err != nil {
+			return fmt.Errorf("registry '%s': %w", name, err)
+		}
+	}
+
 	// Validate engines
 	if err := validateEngines(cfg.Engines); err != nil {
 		return fmt.Errorf("engines: %w", err)
@@ -410,6 +503,11 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("channels: %w", err)
 	}
 
+	// Validate ruleset update policies
+	if err := validateRulesets(cfg.Rulesets); err != nil {
+		return fmt.Errorf("rulesets: %w", err)
+	}
+
 	// Load cache configuration
 	cfg.CacheConfig = cfg.LoadCacheConfig()
 
@@ -428,9 +526,8 @@ func validateRegistry(name, url string, config map[string]string) error {
 	}
 
 	// Validate registry type
-	validTypes := []string{"git", "git-local", "https", "s3", "gitlab", "local"}
-	if !contains(validTypes, registryType) {
-		return fmt.Errorf("unknown registry type '%s'. Supported types: %s", registryType, strings.Join(validTypes, ", "))
+	if !contains(RegistryTypes, registryType) {
+		return fmt.Errorf("unknown registry type '%s'. Supported types: %s", registryType, strings.Join(RegistryTypes, ", "))
 	}
 
 	// Type-specific validation
@@ -473,6 +570,81 @@ func validateRegistry(name, url string, config map[string]string) error {
 	return nil
 }
 
+// validateMirrors validates the mirrors declared by a registry
+func validateMirrors(name string, cfg *Config) error {
+	for _, mirror := range ParseMirrors(cfg.RegistryConfigs[name]["mirrors"]) {
+		if mirror == name {
+			return fmt.Errorf("registry cannot be its own mirror")
+		}
+		if _, exists := cfg.Registries[mirror]; !exists {
+			return fmt.Errorf("mirror '%s' is not a configured registry", mirror)
+		}
+		if len(ParseMirrors(cfg.RegistryConfigs[mirror]["mirrors"])) > 0 {
+			return fmt.Errorf("mirror '%s' cannot declare mirrors of its own", mirror)
+		}
+	}
+	return nil
+}
+
+// ParseMirrors splits a comma-separated mirrors value into registry names
+func ParseMirrors(value string) []string {
+	var mirrors []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			mirrors = append(mirrors, name)
+		}
+	}
+	return mirrors
+}
+
+// RegistrySettings returns the effective settings of a registry: [network],
+// then its type section such as [git], then [registries.<name>], with more
+// specific sections overriding less specific ones
+func (c *Config) RegistrySettings(name string) map[string]string {
+	settings := make(map[string]string)
+	registryType := c.RegistryConfigs[name]["type"]
+	for _, layer := range []map[string]string{c.NetworkConfig, c.TypeDefaults[registryType], c.RegistryConfigs[name]} {
+		for key, value := range layer {
+			if value != "" {
+				settings[key] = value
+			}
+		}
+	}
+	return settings
+}
+
+// OfflineEnv enables offline mode when set to a true value
+const OfflineEnv = "ARM_OFFLINE"
+
+// Offline reports whether registries must be served from the cache only,
+// either through the offline option or the offline key of the [network] section
+func (c *Config) Offline() bool {
+	if c.Options.Offline != nil {
+		return *c.Options.Offline
+	}
+	offline, err := strconv.ParseBool(c.NetworkConfig["offline"])
+	return err == nil && offline
+}
+
+// CommandTimeout returns the time limit of a command from commandTimeout.<command>
+// or commandTimeout in [network], or 0 when it has none
+func (c *Config) CommandTimeout(command string) time.Duration {
+	for _, key := range []string{commandTimeoutPrefix + command, "commandTimeout"} {
+		if value := c.NetworkConfig[key]; value != "" {
+			timeout, _ := ParseSeconds(value)
+			return timeout
+		}
+	}
+	return 0
+}
+
+// OperationTimeout returns the time limit of each ruleset operation against a
+// registry, such as resolving and installing a ruleset, or 0 when it has none
+func (c *Config) OperationTimeout(registryName string) time.Duration {
+	timeout, _ := ParseSeconds(c.RegistrySettings(registryName)["operationTimeout"])
+	return timeout
+}
+
 // validateEngines validates the engines configuration
 func validateEngines(engines map[string]string) error {
 	if len(engines) == 0 {
@@ -508,6 +680,21 @@ func validateChannels(channels map[string]ChannelConfig) error {
 	return nil
 }
 
+// validateRulesets validates the update policies of the rulesets configuration
+func validateRulesets(rulesets map[string]map[string]RulesetSpec) error {
+	for registry, specs := range rulesets {
+		for name, spec := range specs {
+			if spec.Update != "" && !contains(UpdatePolicies, spec.Update) {
+				return fmt.Errorf("ruleset '%s/%s' has invalid update policy %q (must be one of %s)", registry, name, spec.Update, strings.Join(UpdatePolicies, ", "))
+			}
+			if spec.Reason != "" && spec.Update != UpdatePinned {
+				return fmt.Errorf("ruleset '%s/%s' has a reason but is not pinned", registry, name)
+			}
+		}
+	}
+	return nil
+}
+
 // GenerateStubFiles generates stub configuration files if they don't exist
 func GenerateStubFiles(global bool) error {
 	var armrcPath, jsonPath string
@@ -565,6 +752,7 @@ func generateARMRCStub(path string) error {
 # [registries.my-git-registry]
 # type = git
 # authToken = $GITHUB_TOKEN  # optional, for API mode
+# credentialHelper = osxkeychain  # optional, git-credential helper (see arm login)
 # apiType = github           # optional, enables API mode
 # apiVersion = 2022-11-28    # optional, API version
 
@@ -581,6 +769,7 @@ func generateARMRCStub(path string) error {
 
 # [registries.my-https-registry]
 # type = https
+# mirrors = my-s3-registry, my-local-registry  # optional, tried in order if this registry fails
 
 # [registries.my-local-registry]
 # type = local
@@ -608,10 +797,16 @@ func generateARMRCStub(path string) error {
 
 # Network configuration
 # [network]
-# timeout = 30
+# timeout = 30                   # Per request, in seconds or as a duration
+# operationTimeout = 5m          # Per ruleset operation against a registry
+# commandTimeout = 30m           # Per command, or commandTimeout.<command> for one command
 # retry.maxAttempts = 3
 # retry.backoffMultiplier = 2.0
+# retry.initialBackoff = 0.5
 # retry.maxBackoff = 30
+# retry.retryableErrors = 5xx, 429, connection, timeout, throttling
+# offline = false                # Serve registries from the cache only (or --offline / ARM_OFFLINE=1)
+# Retry keys may also be set per type ([git], [s3], ...) or per registry ([registries.name])
 
 # Cache configuration
 # [cache]
@@ -619,6 +814,11 @@ func generateARMRCStub(path string) error {
 # maxSize = 1073741824           # Max cache size in bytes (1GB)
 # ttl = 24h                      # Time-to-live for cache entries
 # cleanupInterval = 6h           # How often to run cleanup
+# evictionPolicy = lru           # Evict least recently (lru) or least frequently (lfu) used registries
+# linkMode = copy                # Install files as copies, hardlinks or reflinks of cached content
+# remote = https://arm-cache.example.com  # Shared cache consulted before registries (see arm cache serve)
+# remoteToken = $ARM_CACHE_TOKEN # Bearer token for the shared cache
+# remoteUpload = false           # Push content downloaded from registries to the shared cache
 
 `
 
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestExpandEnvVars(t *testing.T) {
@@ -586,7 +588,7 @@ concurrency = 2`
 	defer func() { _ = os.Chdir(originalWd) }()
 
 	// Load configuration
-	cfg, err := Load()
+	cfg, err := Load(Options{})
 	if err != nil {
 		t.Fatalf("Failed to load hierarchical config: %v", err)
 	}
@@ -615,6 +617,83 @@ concurrency = 2`
 	}
 }
 
+func TestLoadScope(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalDir := filepath.Join(tmpDir, ".arm")
+	if err := os.MkdirAll(globalDir, 0o755); err != nil {
+		t.Fatalf("Failed to create global dir: %v", err)
+	}
+
+	files := map[string]string{
+		filepath.Join(globalDir, ".armrc"):   "[registries]\ndefault = https://github.com/global/repo\n\n[registries.default]\ntype = git\n",
+		filepath.Join(globalDir, "arm.json"): `{"channels": {"cursor": {"directories": ["~/.cursor/rules"]}}, "rulesets": {"default": {"global-rules": {"version": "^1.0.0"}}}}`,
+		filepath.Join(globalDir, "arm.lock"): `{"rulesets": {"default": {"global-rules": {"version": "1.0.0", "resolved": "1.0.0"}}}}`,
+		filepath.Join(tmpDir, "arm.json"):    `{"channels": {"q": {"directories": [".amazonq/rules"]}}, "rulesets": {"default": {"local-rules": {"version": "^2.0.0"}}}}`,
+		filepath.Join(tmpDir, "arm.lock"):    `{"rulesets": {"default": {"local-rules": {"version": "2.0.0", "resolved": "2.0.0"}}}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	originalHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() { _ = os.Setenv("HOME", originalHome) }()
+
+	originalWd, _ := os.Getwd()
+	_ = os.Chdir(tmpDir)
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	global, err := LoadScope(ScopeGlobal, Options{})
+	if err != nil {
+		t.Fatalf("LoadScope(global) failed: %v", err)
+	}
+	if global.LockPath() != filepath.Join(globalDir, "arm.lock") {
+		t.Errorf("Expected global lock path in ~/.arm, got %q", global.LockPath())
+	}
+	if _, ok := global.LockFile.Rulesets["default"]["global-rules"]; !ok || len(global.LockFile.Rulesets["default"]) != 1 {
+		t.Errorf("Expected only the global lock file, got %v", global.LockFile.Rulesets)
+	}
+	if _, ok := global.Channels["q"]; ok || len(global.Channels) != 1 {
+		t.Errorf("Expected only global channels, got %v", global.Channels)
+	}
+
+	local, err := LoadScope(ScopeLocal, Options{})
+	if err != nil {
+		t.Fatalf("LoadScope(local) failed: %v", err)
+	}
+	if local.LockPath() != "arm.lock" {
+		t.Errorf("Expected local lock path arm.lock, got %q", local.LockPath())
+	}
+	if _, ok := local.Rulesets["default"]["global-rules"]; ok {
+		t.Errorf("Expected local scope to exclude global rulesets, got %v", local.Rulesets)
+	}
+	if _, ok := local.Channels["cursor"]; ok {
+		t.Errorf("Expected local scope to exclude global channels, got %v", local.Channels)
+	}
+	if local.Registries["default"] != "https://github.com/global/repo" {
+		t.Errorf("Expected local scope to use global registries, got %v", local.Registries)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	tests := []struct {
+		global, local bool
+		want          []Scope
+	}{
+		{false, false, []Scope{ScopeLocal, ScopeGlobal}},
+		{true, false, []Scope{ScopeGlobal}},
+		{false, true, []Scope{ScopeLocal}},
+		{true, true, []Scope{ScopeLocal, ScopeGlobal}},
+	}
+	for _, tt := range tests {
+		if got := Scopes(tt.global, tt.local); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scopes(%v, %v) = %v, want %v", tt.global, tt.local, got, tt.want)
+		}
+	}
+}
+
 func TestValidateRegistry(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -706,6 +785,145 @@ func TestValidateRegistry(t *testing.T) {
 	}
 }
 
+func TestValidateMirrors(t *testing.T) {
+	cfg := &Config{
+		Registries: map[string]string{
+			"primary": "https://github.com/org/rules",
+			"backup":  "/srv/rules",
+			"chained": "/srv/chained",
+		},
+		RegistryConfigs: map[string]map[string]string{
+			"primary": {"type": "git", "mirrors": "backup"},
+			"backup":  {"type": "local"},
+			"chained": {"type": "local", "mirrors": "primary"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		mirrors       string
+		expectError   bool
+		errorContains string
+	}{
+		{name: "valid mirror", mirrors: "backup", expectError: false},
+		{name: "no mirrors", mirrors: "", expectError: false},
+		{name: "unknown mirror", mirrors: "backup, missing", expectError: true, errorContains: "not a configured registry"},
+		{name: "self mirror", mirrors: "primary", expectError: true, errorContains: "its own mirror"},
+		{name: "mirror with mirrors", mirrors: "chained", expectError: true, errorContains: "cannot declare mirrors"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.RegistryConfigs["primary"]["mirrors"] = tt.mirrors
+			err := validateMirrors("primary", cfg)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("Expected error to contain %q, got %q", tt.errorContains, err.Error())
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestOffline(t *testing.T) {
+	tests := []struct {
+		name    string
+		network map[string]string
+		env     string
+		want    bool
+	}{
+		{name: "default", want: false},
+		{name: "config", network: map[string]string{"offline": "true"}, want: true},
+		{name: "env", env: "1", want: true},
+		{name: "env overrides config", network: map[string]string{"offline": "true"}, env: "false", want: false},
+		{name: "invalid env ignored", network: map[string]string{"offline": "true"}, env: "maybe", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(OfflineEnv, tt.env)
+			cfg := &Config{NetworkConfig: tt.network, Options: OptionsFromEnv()}
+			if got := cfg.Offline(); got != tt.want {
+				t.Errorf("Expected Offline() = %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv(OfflineEnv, "")
+	t.Setenv(WorkspaceEnv, " web, packages/api ,")
+	t.Setenv(ProfileEnv, "ci")
+
+	opts := OptionsFromEnv()
+	if opts.Offline != nil {
+		t.Errorf("Expected offline mode to be left to the configuration, got %v", *opts.Offline)
+	}
+	if !reflect.DeepEqual(opts.Workspaces, []string{"web", "packages/api"}) || opts.Profile != "ci" {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	cfg := &Config{NetworkConfig: map[string]string{"commandTimeout": "10m", "commandTimeout.install": "90"}}
+	if got := cfg.CommandTimeout("install"); got != 90*time.Second {
+		t.Errorf("Expected the install timeout to be 90s, got %s", got)
+	}
+	if got := cfg.CommandTimeout("update"); got != 10*time.Minute {
+		t.Errorf("Expected the default command timeout of 10m, got %s", got)
+	}
+	if got := (&Config{}).CommandTimeout("update"); got != 0 {
+		t.Errorf("Expected no timeout when unset, got %s", got)
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	cfg := &Config{
+		RegistryConfigs: map[string]map[string]string{"company": {"type": "git", "operationTimeout": "30s"}, "other": {"type": "s3"}},
+		TypeDefaults:    map[string]map[string]string{"git": {"operationTimeout": "5m"}},
+		NetworkConfig:   map[string]string{"operationTimeout": "2m"},
+	}
+	if got := cfg.OperationTimeout("company"); got != 30*time.Second {
+		t.Errorf("Expected the registry timeout of 30s, got %s", got)
+	}
+	if got := cfg.OperationTimeout("other"); got != 2*time.Minute {
+		t.Errorf("Expected the [network] timeout of 2m, got %s", got)
+	}
+}
+
+func TestRegistrySettings(t *testing.T) {
+	cfg := &Config{
+		RegistryConfigs: map[string]map[string]string{
+			"company": {"type": "git", "concurrency": "4", "rateLimit": ""},
+		},
+		TypeDefaults: map[string]map[string]string{
+			"git": {"concurrency": "2", "rateLimit": "30/minute"},
+		},
+		NetworkConfig: map[string]string{"timeout": "45", "retry.maxAttempts": "3"},
+	}
+
+	settings := cfg.RegistrySettings("company")
+	want := map[string]string{
+		"type":              "git",
+		"concurrency":       "4",
+		"rateLimit":         "30/minute",
+		"timeout":           "45",
+		"retry.maxAttempts": "3",
+	}
+	if len(settings) != len(want) {
+		t.Errorf("Expected %d settings, got %v", len(want), settings)
+	}
+	for key, value := range want {
+		if settings[key] != value {
+			t.Errorf("Expected %s = %q, got %q", key, value, settings[key])
+		}
+	}
+}
+
 func TestValidateEngines(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -821,6 +1039,33 @@ func TestValidateChannels(t *testing.T) {
 	}
 }
 
+func TestValidateRulesets(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          RulesetSpec
+		errorContains string
+	}{
+		{name: "no policy", spec: RulesetSpec{Version: "^1.0.0"}},
+		{name: "patch policy", spec: RulesetSpec{Version: "^1.0.0", Update: UpdatePatch}},
+		{name: "pinned with reason", spec: RulesetSpec{Version: "1.0.0", Update: UpdatePinned, Reason: "Security review"}},
+		{name: "unknown policy", spec: RulesetSpec{Update: "weekly"}, errorContains: "invalid update policy"},
+		{name: "reason without pin", spec: RulesetSpec{Update: UpdateMinor, Reason: "Audit"}, errorContains: "not pinned"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRulesets(map[string]map[string]RulesetSpec{"default": {"rules": tt.spec}})
+			if tt.errorContains == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+}
+
 func TestValidateConfig(t *testing.T) {
 	// Test valid configuration
 	validCfg := &Config{
@@ -14,11 +14,21 @@ type ManifestManager struct {
 
 // NewManifestManager creates a new manifest manager
 func NewManifestManager(global bool) *ManifestManager {
-	path := "arm.json"
-	if global {
-		path = filepath.Join(os.Getenv("HOME"), ".arm", "arm.json")
+	return &ManifestManager{path: ScopeFor(global).Path("arm.json")}
+}
+
+// Manifest returns the manager of the arm.json the configuration's rulesets
+// come from: the one of its scope or workspace member
+func (c *Config) Manifest() *ManifestManager {
+	return &ManifestManager{path: c.ManifestPath()}
+}
+
+// ManifestPath returns the path of the configuration's arm.json
+func (c *Config) ManifestPath() string {
+	if c.Workspace != "" {
+		return filepath.Join(c.Workspace, "arm.json")
 	}
-	return &ManifestManager{path: path}
+	return c.Scope.Path("arm.json")
 }
 
 // AddRuleset adds or updates a ruleset in the manifest
@@ -33,15 +43,36 @@ func (m *ManifestManager) AddRuleset(registry, name, version string, patterns []
 		armConfig.Rulesets[registry] = make(map[string]RulesetSpec)
 	}
 
-	// Update ruleset entry
+	// Update ruleset entry, keeping its update policy
+	existing := armConfig.Rulesets[registry][name]
 	armConfig.Rulesets[registry][name] = RulesetSpec{
 		Version:  version,
 		Patterns: patterns,
+		Update:   existing.Update,
+		Reason:   existing.Reason,
 	}
 
 	return m.save(armConfig)
 }
 
+// SetVersion changes the version range of a ruleset in the manifest,
+// reporting whether the manifest declares the ruleset
+func (m *ManifestManager) SetVersion(registry, name, version string) (bool, error) {
+	armConfig, err := m.Load()
+	if err != nil {
+		return false, err
+	}
+
+	spec, exists := armConfig.Rulesets[registry][name]
+	if !exists {
+		return false, nil
+	}
+	spec.Version = version
+	armConfig.Rulesets[registry][name] = spec
+
+	return true, m.save(armConfig)
+}
+
 // RemoveRuleset removes a ruleset from the manifest
 func (m *ManifestManager) RemoveRuleset(registry, name string) error {
 	armConfig, err := m.loadOrCreate()
@@ -60,6 +91,18 @@ func (m *ManifestManager) RemoveRuleset(registry, name string) error {
 	return m.save(armConfig)
 }
 
+// Load reads the manifest, returning an empty manifest when it does not exist
+func (m *ManifestManager) Load() (*ARMConfig, error) {
+	if _, err := os.Stat(m.path); os.IsNotExist(err) {
+		return &ARMConfig{
+			Engines:  make(map[string]string),
+			Channels: make(map[string]ChannelConfig),
+			Rulesets: make(map[string]map[string]RulesetSpec),
+		}, nil
+	}
+	return m.read()
+}
+
 // loadOrCreate loads existing manifest or creates a new one
 func (m *ManifestManager) loadOrCreate() (*ARMConfig, error) {
 	if _, err := os.Stat(m.path); os.IsNotExist(err) {
@@ -67,13 +110,13 @@ func (m *ManifestManager) loadOrCreate() (*ARMConfig, error) {
 		if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
 			return nil, err
 		}
-		return &ARMConfig{
-			Engines:  make(map[string]string),
-			Channels: make(map[string]ChannelConfig),
-			Rulesets: make(map[string]map[string]RulesetSpec),
-		}, nil
+		return m.Load()
 	}
+	return m.read()
+}
 
+// read parses the manifest file
+func (m *ManifestManager) read() (*ARMConfig, error) {
 	data, err := os.ReadFile(m.path)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Options are the settings of a command that apply wherever its configuration
+// is loaded, set by flags such as --offline, --workspace and --profile
+type Options struct {
+	Offline    *bool    // Overrides the offline key of [network] when set
+	Workspaces []string // Workspace members to operate on by path or name
+	Profile    string   // .armrc profile merged over the configuration
+}
+
+// OptionsFromEnv returns the options set by ARM_OFFLINE, ARM_WORKSPACE and
+// ARM_PROFILE, the defaults of the corresponding flags
+func OptionsFromEnv() Options {
+	var opts Options
+	if offline, err := strconv.ParseBool(os.Getenv(OfflineEnv)); err == nil {
+		opts.Offline = &offline
+	}
+	for _, name := range strings.Split(os.Getenv(WorkspaceEnv), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Workspaces = append(opts.Workspaces, name)
+		}
+	}
+	opts.Profile = os.Getenv(ProfileEnv)
+	return opts
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"gopkg.in/ini.v1"
+)
+
+// ProfileEnv selects the .armrc profile merged over the configuration, such as ci
+const ProfileEnv = "ARM_PROFILE"
+
+// processProfileSection processes [profile.<name>.<section>] sections, which
+// hold the sections a profile overrides, such as [profile.ci.network]
+func (c *Config) processProfileSection(name string, section *ini.Section) error {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) < 2 {
+		// [profile.ci] may head the sections of a profile but holds no settings
+		if len(section.Keys()) == 0 {
+			return nil
+		}
+		return fmt.Errorf("profile settings belong in sections such as [profile.%s.network]", name)
+	}
+	if parts[1] == "profile" || strings.HasPrefix(parts[1], "profile.") {
+		return fmt.Errorf("profiles cannot be nested")
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Config)
+	}
+	if c.Profiles[parts[0]] == nil {
+		c.Profiles[parts[0]] = newConfig()
+	}
+	return c.Profiles[parts[0]].processSection(parts[1], section)
+}
+
+// mergeProfiles merges profiles with local settings taking precedence at key level
+func mergeProfiles(global, local map[string]*Config) map[string]*Config {
+	merged := make(map[string]*Config)
+	for name, profile := range global {
+		merged[name] = profile
+	}
+	for name, profile := range local {
+		if merged[name] != nil {
+			profile = mergeConfigs(merged[name], profile)
+		}
+		merged[name] = profile
+	}
+	return merged
+}
+
+// applyProfile merges the registries, network, cache and type settings of the
+// profile selected by c.Options.Profile over the configuration
+func (c *Config) applyProfile() error {
+	name := c.Options.Profile
+	if name == "" {
+		return nil
+	}
+	profile := c.Profiles[name]
+	if profile == nil {
+		err := armerr.Errorf(armerr.Config, "profile '%s' not found", name)
+		if names := c.ProfileNames(); len(names) > 0 {
+			return err.WithHint(fmt.Sprintf("Profiles: %s", strings.Join(names, ", ")))
+		}
+		return err.WithHint(fmt.Sprintf("Define it in .armrc with sections such as [profile.%s.registries]", name))
+	}
+
+	merged := mergeConfigs(c, profile)
+	c.Registries = merged.Registries
+	c.RegistryConfigs = merged.RegistryConfigs
+	c.TypeDefaults = merged.TypeDefaults
+	c.NetworkConfig = merged.NetworkConfig
+	c.Profile = name
+	return nil
+}
+
+// ProfileNames returns the names of the profiles defined in the .armrc files
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
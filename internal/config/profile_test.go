@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalDir := filepath.Join(tmpDir, ".arm")
+	projectDir := filepath.Join(tmpDir, "project")
+	files := map[string]string{
+		filepath.Join(globalDir, ".armrc"): "[registries]\ndefault = https://github.com/org/rules\n\n[registries.default]\ntype = git\n\n" +
+			"[profile.ci.registries]\ndefault = https://mirror.example.com/rules\n\n[profile.ci.registries.default]\ntype = https\n\n" +
+			"[profile.ci.cache]\npath = /ci/cache\n\n[profile.ci.network]\ntimeout = 10\n",
+		filepath.Join(projectDir, ".armrc"):   "[network]\ntimeout = 30\noperationTimeout = 20\n\n[profile.ci]\n\n[profile.ci.network]\ntimeout = 60\n",
+		filepath.Join(projectDir, "arm.json"): `{"channels": {"cursor": {"directories": [".cursor/rules"]}}, "rulesets": {}}`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	t.Setenv("HOME", tmpDir)
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	// Without a profile, profile sections are ignored
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Registries["default"] != "https://github.com/org/rules" || cfg.NetworkConfig["timeout"] != "30" || cfg.Profile != "" {
+		t.Errorf("Expected the base configuration, got %v %v", cfg.Registries, cfg.NetworkConfig)
+	}
+
+	// The profile overrides both files, its local settings the global ones
+	for _, scope := range []Scope{ScopeLocal, ScopeGlobal} {
+		cfg, err := LoadScope(scope, Options{Profile: "ci"})
+		if err != nil {
+			t.Fatalf("LoadScope(%s) error = %v", scope, err)
+		}
+		if cfg.Profile != "ci" {
+			t.Errorf("%s: Profile = %q, want ci", scope, cfg.Profile)
+		}
+		if cfg.Registries["default"] != "https://mirror.example.com/rules" || cfg.RegistryConfigs["default"]["type"] != "https" {
+			t.Errorf("%s: expected the mirror registry, got %v %v", scope, cfg.Registries, cfg.RegistryConfigs)
+		}
+		if cfg.NetworkConfig["timeout"] != "60" {
+			t.Errorf("%s: network timeout = %q, want the local profile's 60", scope, cfg.NetworkConfig["timeout"])
+		}
+		if cfg.CacheConfig.Path != "/ci/cache" {
+			t.Errorf("%s: cache path = %q, want /ci/cache", scope, cfg.CacheConfig.Path)
+		}
+		if _, ok := cfg.Channels["cursor"]; scope == ScopeLocal && !ok {
+			t.Error("Expected arm.json settings to be kept")
+		}
+	}
+
+	origins, err := LoadOrigins("ci")
+	if err != nil {
+		t.Fatalf("LoadOrigins() error = %v", err)
+	}
+	if want := (Origin{Scope: "local", Path: ".armrc", Profile: "ci"}); origins["network.timeout"] != want {
+		t.Errorf("origin of network.timeout = %+v, want %+v", origins["network.timeout"], want)
+	}
+	if want := (Origin{Scope: "local", Path: ".armrc"}); origins["network.operationTimeout"] != want {
+		t.Errorf("origin of network.operationTimeout = %+v, want %+v", origins["network.operationTimeout"], want)
+	}
+
+	if _, err := Load(Options{Profile: "staging"}); err == nil {
+		t.Error("Expected an error for an undefined profile")
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// FindProjectRoot returns the directory of the nearest project at or above
+// dir, one holding an .armrc or arm.json, searching parent directories up to
+// the git root or the filesystem root. A workspace member resolves to the
+// root project listing it. Without a project, found is false and root is the
+// git root, or dir outside a repository, where new configuration files belong.
+func FindProjectRoot(dir string) (root string, found bool, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", false, err
+	}
+
+	root = dir
+	for current := dir; ; current = filepath.Dir(current) {
+		if !found && isProjectDir(current) {
+			root, found = current, true
+		} else if found && isWorkspaceRoot(current, root) {
+			return current, true, nil
+		}
+
+		// The repository containing dir bounds the search
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			if !found {
+				root = current
+			}
+			break
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	return root, found, nil
+}
+
+// isProjectDir reports whether dir holds local configuration files, ~/.arm
+// holding the global ones
+func isProjectDir(dir string) bool {
+	if dir == GlobalDir() {
+		return false
+	}
+	for _, name := range []string{".armrc", "arm.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isWorkspaceRoot reports whether the arm.json in dir lists project as a
+// workspace member
+func isWorkspaceRoot(dir, project string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "arm.json"))
+	if err != nil {
+		return false
+	}
+	var armConfig ARMConfig
+	if err := json.Unmarshal([]byte(expandEnvVarsInJSON(string(data))), &armConfig); err != nil {
+		return false
+	}
+	members, err := workspaceMembers(dir, armConfig.Workspaces)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, project)
+	return err == nil && slices.Contains(members, filepath.ToSlash(rel))
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	files := map[string]string{
+		"repo/.git/HEAD":                  "ref: refs/heads/main\n",
+		"repo/arm.json":                   `{"workspaces": ["packages/*"], "rulesets": {}}`,
+		"repo/packages/web/arm.json":      `{"rulesets": {}}`,
+		"repo/packages/web/src/index.ts":  "",
+		"repo/tools/lint/.armrc":          "[registries]\n",
+		"repo/tools/lint/rules/README.md": "",
+		"repo/docs/guide/README.md":       "",
+		"arm.json":                        `{"rulesets": {}}`,
+		"other/src/main.go":               "",
+	}
+	for path, content := range files {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		dir       string
+		wantRoot  string
+		wantFound bool
+	}{
+		{"project root", "repo", "repo", true},
+		{"subdirectory", "repo/docs/guide", "repo", true},
+		{"workspace member", "repo/packages/web/src", "repo", true},
+		{"nested project", "repo/tools/lint/rules", "repo/tools/lint", true},
+		{"outside a repository", "other/src", ".", true},
+		{"global directory", ".arm", ".", true},
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".arm"), 0o755); err != nil {
+		t.Fatalf("Failed to create .arm: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".arm", "arm.json"), []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("Failed to write global arm.json: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, found, err := FindProjectRoot(filepath.Join(tmpDir, tt.dir))
+			if err != nil {
+				t.Fatalf("FindProjectRoot() error = %v", err)
+			}
+			if want := filepath.Join(tmpDir, tt.wantRoot); root != want || found != tt.wantFound {
+				t.Errorf("FindProjectRoot() = %s, %v, want %s, %v", root, found, want, tt.wantFound)
+			}
+		})
+	}
+
+	// The search stops at the git root, where a new project is created
+	if err := os.Remove(filepath.Join(tmpDir, "repo", "arm.json")); err != nil {
+		t.Fatalf("Failed to remove arm.json: %v", err)
+	}
+	want := filepath.Join(tmpDir, "repo")
+	root, found, err := FindProjectRoot(filepath.Join(want, "docs"))
+	if err != nil || found || root != want {
+		t.Errorf("FindProjectRoot() = %s, %v, %v, want %s without a project", root, found, err, want)
+	}
+
+	// Outside a repository, a new project is created in the directory itself
+	dir := filepath.Join(tmpDir, "other", "src")
+	_ = os.Remove(filepath.Join(tmpDir, "arm.json"))
+	if root, found, err := FindProjectRoot(dir); err != nil || found || root != dir {
+		t.Errorf("FindProjectRoot() = %s, %v, %v, want %s outside a repository", root, found, err, dir)
+	}
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"gopkg.in/ini.v1"
+)
+
+// KeyKind is the value type of a known .armrc key
+type KeyKind string
+
+const (
+	KindString    KeyKind = "string"
+	KindInt       KeyKind = "int"      // Non-negative integer
+	KindFloat     KeyKind = "float"    // Number
+	KindBool      KeyKind = "bool"     // true or false
+	KindDuration  KeyKind = "duration" // Go duration such as 12h
+	KindSeconds   KeyKind = "seconds"  // Number of seconds or Go duration
+	KindEnum      KeyKind = "enum"     // One of KeySpec.Values
+	KindURL       KeyKind = "url"      // http or https URL
+	KindRateLimit KeyKind = "rate"     // <count>/<second|minute|hour>
+	KindList      KeyKind = "list"     // Comma-separated values
+)
+
+// KeySpec describes a known .armrc key
+type KeySpec struct {
+	Kind   KeyKind
+	Values []string // Allowed values of enum keys
+}
+
+// RegistryTypes lists the supported registry types
+var RegistryTypes = []string{"git", "git-local", "https", "s3", "gitlab", "local"}
+
+// typeSections are the sections holding registry type defaults
+var typeSections = []string{"git", "https", "s3", "gitlab", "local"}
+
+// retryKeys may be set in [network], type sections and registry sections
+var retryKeys = map[string]KeySpec{
+	"retry.maxAttempts":       {Kind: KindInt},
+	"retry.backoffMultiplier": {Kind: KindFloat},
+	"retry.initialBackoff":    {Kind: KindSeconds},
+	"retry.maxBackoff":        {Kind: KindSeconds},
+	"retry.maxRetryAfter":     {Kind: KindSeconds},
+	"retry.retryableErrors":   {Kind: KindList},
+}
+
+// sectionSchemas holds the known keys of each fixed section
+var sectionSchemas = map[string]map[string]KeySpec{
+	"cache": {
+		"path":            {Kind: KindString},
+		"maxSize":         {Kind: KindInt},
+		"ttl":             {Kind: KindDuration},
+		"cleanupInterval": {Kind: KindDuration},
+		"evictionPolicy":  {Kind: KindEnum, Values: []string{"lru", "lfu"}},
+		"linkMode":        {Kind: KindEnum, Values: []string{"copy", "hardlink", "reflink"}},
+		"remote":          {Kind: KindURL},
+		"remoteToken":     {Kind: KindString},
+		"remoteUpload":    {Kind: KindBool},
+	},
+	"network": withRetryKeys(map[string]KeySpec{
+		"timeout":          {Kind: KindSeconds},
+		"operationTimeout": {Kind: KindSeconds},
+		"commandTimeout":   {Kind: KindSeconds},
+		"offline":          {Kind: KindBool},
+	}),
+}
+
+// typeDefaultKeys are the known keys of type sections such as [git]
+var typeDefaultKeys = withRetryKeys(map[string]KeySpec{
+	"concurrency":      {Kind: KindInt},
+	"rateLimit":        {Kind: KindRateLimit},
+	"credentialHelper": {Kind: KindString},
+	"timeout":          {Kind: KindSeconds},
+	"operationTimeout": {Kind: KindSeconds},
+})
+
+// registryKeys are the known keys of [registries.<name>] sections
+var registryKeys = withRetryKeys(map[string]KeySpec{
+	"type":                   {Kind: KindEnum, Values: RegistryTypes},
+	"authToken":              {Kind: KindString},
+	"credentialHelper":       {Kind: KindString},
+	"username":               {Kind: KindString},
+	"password":               {Kind: KindString},
+	"apiType":                {Kind: KindString},
+	"apiVersion":             {Kind: KindString},
+	"region":                 {Kind: KindString},
+	"profile":                {Kind: KindString},
+	"prefix":                 {Kind: KindString},
+	"mirrors":                {Kind: KindList},
+	"allowUnverifiedMirrors": {Kind: KindBool},
+	"concurrency":            {Kind: KindInt},
+	"rateLimit":              {Kind: KindRateLimit},
+	"timeout":                {Kind: KindSeconds},
+	"operationTimeout":       {Kind: KindSeconds},
+})
+
+// commandTimeoutPrefix starts the [network] keys setting the timeout of a
+// single command, such as commandTimeout.install
+const commandTimeoutPrefix = "commandTimeout."
+
+// TimeoutCommands are the commands whose timeout can be set on its own
+var TimeoutCommands = []string{"install", "uninstall", "update", "outdated", "diff", "search", "info", "list", "clean", "login", "logout", "mirror", "cache"}
+
+// rateLimitPattern matches rate limits such as 10/minute
+var rateLimitPattern = regexp.MustCompile(`^\d+/(second|minute|hour)$`)
+
+// withRetryKeys adds the retry keys to a section schema
+func withRetryKeys(keys map[string]KeySpec) map[string]KeySpec {
+	for key, spec := range retryKeys {
+		keys[key] = spec
+	}
+	return keys
+}
+
+// SplitKey splits a configuration key into its .armrc section and key name.
+// Registry settings take the form registries.<name>.<key> and profile
+// settings profile.<profile>.<section>.<key>; keys such as
+// retry.maxAttempts keep their dots.
+func SplitKey(key string) (section, name string, err error) {
+	parts := strings.Split(key, ".")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", armerr.Errorf(armerr.Config, "invalid key format %q. Use section.key (e.g., git.concurrency)", key)
+	}
+	if parts[0] == "profile" && len(parts) > 3 {
+		section, name, err := SplitKey(strings.Join(parts[2:], "."))
+		if err != nil {
+			return "", "", err
+		}
+		return "profile." + parts[1] + "." + section, name, nil
+	}
+	if parts[0] == "registries" && len(parts) > 2 {
+		return "registries." + parts[1], strings.Join(parts[2:], "."), nil
+	}
+	return parts[0], strings.Join(parts[1:], "."), nil
+}
+
+// LookupKey returns the schema of a key, failing for unknown sections and keys
+func LookupKey(section, key string) (KeySpec, error) {
+	var keys map[string]KeySpec
+	switch {
+	case strings.HasPrefix(section, "profile."):
+		parts := strings.SplitN(section, ".", 3)
+		if len(parts) < 3 || parts[2] == "profile" || strings.HasPrefix(parts[2], "profile.") {
+			return KeySpec{}, armerr.Errorf(armerr.Config, "unknown section [%s]. Profile settings take the form profile.<name>.<section>.<key>", section)
+		}
+		return LookupKey(parts[2], key)
+	case section == "registries":
+		return KeySpec{Kind: KindString}, nil // Registry name to URL or path
+	case strings.HasPrefix(section, "registries."):
+		keys = registryKeys
+	case contains(typeSections, section):
+		keys = typeDefaultKeys
+	case section == "network" && strings.HasPrefix(key, commandTimeoutPrefix):
+		if command := strings.TrimPrefix(key, commandTimeoutPrefix); !contains(TimeoutCommands, command) {
+			return KeySpec{}, armerr.Errorf(armerr.Config, "unknown command %q in [network] %s (known commands: %s)", command, key, strings.Join(TimeoutCommands, ", "))
+		}
+		return KeySpec{Kind: KindSeconds}, nil
+	default:
+		var exists bool
+		if keys, exists = sectionSchemas[section]; !exists {
+			return KeySpec{}, armerr.Errorf(armerr.Config, "unknown section [%s]", section)
+		}
+	}
+
+	spec, exists := keys[key]
+	if !exists {
+		return KeySpec{}, armerr.Errorf(armerr.Config, "unknown key %q in [%s] (known keys: %s)", key, section, strings.Join(sortedKeys(keys), ", "))
+	}
+	return spec, nil
+}
+
+// ValidateSetting checks a value against the schema of its section and key.
+// Values referencing environment variables are checked once expanded at load.
+func ValidateSetting(section, key, value string) error {
+	spec, err := LookupKey(section, key)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(value, "$") {
+		return nil
+	}
+	if err := spec.Validate(value); err != nil {
+		return armerr.Errorf(armerr.Config, "invalid value for %s.%s: %w", section, key, err)
+	}
+	return nil
+}
+
+// Validate checks that value is of the key's kind
+func (s KeySpec) Validate(value string) error {
+	switch s.Kind {
+	case KindInt:
+		if n, err := strconv.ParseInt(value, 10, 64); err != nil || n < 0 {
+			return fmt.Errorf("%q is not a non-negative integer", value)
+		}
+	case KindFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case KindBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+	case KindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%q is not a duration such as 30m or 12h", value)
+		}
+	case KindSeconds:
+		if _, err := ParseSeconds(value); err != nil {
+			return fmt.Errorf("%q is neither a number of seconds nor a duration", value)
+		}
+	case KindEnum:
+		if !contains(s.Values, strings.ToLower(value)) {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(s.Values, ", "))
+		}
+	case KindURL:
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%q is not an http or https URL", value)
+		}
+	case KindRateLimit:
+		if !rateLimitPattern.MatchString(value) {
+			return fmt.Errorf("%q is not a rate limit such as 10/minute", value)
+		}
+	}
+	return nil
+}
+
+// ValidateARMConfig validates the channels, engines and ruleset update
+// policies of an arm.json file
+func ValidateARMConfig(armConfig *ARMConfig) error {
+	if err := validateEngines(armConfig.Engines); err != nil {
+		return armerr.Errorf(armerr.Config, "engines: %w", err)
+	}
+	if err := validateChannels(armConfig.Channels); err != nil {
+		return armerr.Errorf(armerr.Config, "channels: %w", err)
+	}
+	if err := validateRulesets(armConfig.Rulesets); err != nil {
+		return armerr.Errorf(armerr.Config, "rulesets: %w", err)
+	}
+	return nil
+}
+
+// Origin identifies the file providing a configuration value
+type Origin struct {
+	Scope   string // global or local
+	Path    string
+	Profile string // Profile whose section sets the value, if any
+}
+
+// LoadOrigins reports which file provides the effective value of each
+// configuration key. Keys take the form accepted by arm config get: .armrc
+// keys as section.key and arm.json entries as channels.<name>,
+// rulesets.<registry>.<name> and engines.<name>. Settings of profile, if
+// not empty, override both files.
+func LoadOrigins(profile string) (map[string]Origin, error) {
+	origins := make(map[string]Origin)
+	globalDir := filepath.Join(os.Getenv("HOME"), ".arm")
+
+	// Local files are read last so that they override global ones
+	scopes := []struct{ scope, dir string }{{"global", globalDir}, {"local", ""}}
+	for _, s := range scopes {
+		if err := iniOrigins(Origin{Scope: s.scope, Path: filepath.Join(s.dir, ".armrc")}, origins); err != nil {
+			return nil, err
+		}
+		if err := jsonOrigins(Origin{Scope: s.scope, Path: filepath.Join(s.dir, "arm.json")}, origins); err != nil {
+			return nil, err
+		}
+	}
+	if profile != "" {
+		for _, s := range scopes {
+			if err := iniOrigins(Origin{Scope: s.scope, Path: filepath.Join(s.dir, ".armrc"), Profile: profile}, origins); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return origins, nil
+}
+
+// iniOrigins records the keys set in an .armrc file, outside profiles or in
+// the sections of origin.Profile
+func iniOrigins(origin Origin, origins map[string]Origin) error {
+	path := origin.Path
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	file, err := ini.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	prefix := "profile." + origin.Profile + "."
+	for _, section := range file.Sections() {
+		name := section.Name()
+		switch {
+		case name == ini.DefaultSection:
+			continue
+		case origin.Profile != "":
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix)
+		case strings.HasPrefix(name, "profile."):
+			continue
+		}
+		for _, key := range section.Keys() {
+			origins[name+"."+key.Name()] = origin
+		}
+	}
+	return nil
+}
+
+// jsonOrigins records the entries set in an arm.json file
+func jsonOrigins(origin Origin, origins map[string]Origin) error {
+	path := origin.Path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var armConfig ARMConfig
+	if err := json.Unmarshal([]byte(expandEnvVarsInJSON(string(data))), &armConfig); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for name := range armConfig.Channels {
+		origins["channels."+name] = origin
+	}
+	for registry, rulesets := range armConfig.Rulesets {
+		for name := range rulesets {
+			origins["rulesets."+registry+"."+name] = origin
+		}
+	}
+	for name := range armConfig.Engines {
+		origins["engines."+name] = origin
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a schema in sorted order
+func sortedKeys(keys map[string]KeySpec) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseSeconds parses a value of a seconds key: a plain number of seconds or
+// a Go duration such as 5m
+func ParseSeconds(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
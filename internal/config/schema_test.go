@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		section string
+		name    string
+		wantErr bool
+	}{
+		{key: "git.concurrency", section: "git", name: "concurrency"},
+		{key: "registries.default", section: "registries", name: "default"},
+		{key: "registries.default.type", section: "registries.default", name: "type"},
+		{key: "registries.default.retry.maxAttempts", section: "registries.default", name: "retry.maxAttempts"},
+		{key: "network.retry.initialBackoff", section: "network", name: "retry.initialBackoff"},
+		{key: "profile.ci.network.offline", section: "profile.ci.network", name: "offline"},
+		{key: "profile.ci.registries.default.type", section: "profile.ci.registries.default", name: "type"},
+		{key: "concurrency", wantErr: true},
+		{key: ".concurrency", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		section, name, err := SplitKey(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SplitKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if section != tt.section || name != tt.name {
+			t.Errorf("SplitKey(%q) = %q, %q, want %q, %q", tt.key, section, name, tt.section, tt.name)
+		}
+	}
+}
+
+func TestValidateSetting(t *testing.T) {
+	tests := []struct {
+		section string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"git", "concurrency", "4", false},
+		{"git", "concurrency", "four", true},
+		{"git", "rateLimit", "10/minute", false},
+		{"git", "rateLimit", "10 per minute", true},
+		{"git", "unknown", "1", true},
+		{"unknown", "key", "1", true},
+		{"registries", "default", "https://github.com/org/rules", false},
+		{"registries.default", "type", "gitlab", false},
+		{"registries.default", "type", "svn", true},
+		{"registries.default", "authToken", "$GITHUB_TOKEN", false},
+		{"registries.default", "retry.maxAttempts", "3", false},
+		{"registries.default", "retry.backoffMultiplier", "fast", true},
+		{"network", "timeout", "30", false},
+		{"network", "timeout", "30s", false},
+		{"network", "offline", "maybe", true},
+		{"network", "commandTimeout", "10m", false},
+		{"network", "commandTimeout.install", "600", false},
+		{"network", "commandTimeout.install", "soon", true},
+		{"network", "commandTimeout.deploy", "10m", true},
+		{"git", "operationTimeout", "2m", false},
+		{"registries.default", "timeout", "30", false},
+		{"registries.default", "operationTimeout", "forever", true},
+		{"cache", "ttl", "24h", false},
+		{"cache", "ttl", "1 day", true},
+		{"cache", "evictionPolicy", "lfu", false},
+		{"cache", "evictionPolicy", "fifo", true},
+		{"cache", "remote", "https://cache.example.com", false},
+		{"cache", "remote", "cache.example.com", true},
+		{"cache", "remote", "${ARM_CACHE_URL}", false},
+		{"profile.ci.network", "offline", "true", false},
+		{"profile.ci.cache", "ttl", "1 day", true},
+		{"profile.ci", "offline", "true", true},
+		{"profile.ci.profile.dev.network", "offline", "true", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSetting(tt.section, tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSetting(%s, %s, %s) error = %v, wantErr %v", tt.section, tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadOrigins(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalDir := filepath.Join(tmpDir, ".arm")
+	if err := os.MkdirAll(globalDir, 0o755); err != nil {
+		t.Fatalf("Failed to create global dir: %v", err)
+	}
+
+	globalINI := "[git]\nconcurrency = 1\nrateLimit = 10/minute\n"
+	if err := os.WriteFile(filepath.Join(globalDir, ".armrc"), []byte(globalINI), 0o644); err != nil {
+		t.Fatalf("Failed to write global .armrc: %v", err)
+	}
+	globalJSON := `{"channels": {"cursor": {"directories": [".cursor/rules"]}}}`
+	if err := os.WriteFile(filepath.Join(globalDir, "arm.json"), []byte(globalJSON), 0o644); err != nil {
+		t.Fatalf("Failed to write global arm.json: %v", err)
+	}
+
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	localJSON := `{"rulesets": {"default": {"rules": {"version": "^1.0.0"}}}}`
+	if err := os.WriteFile(filepath.Join(projectDir, "arm.json"), []byte(localJSON), 0o644); err != nil {
+		t.Fatalf("Failed to write local arm.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ".armrc"), []byte("[git]\nconcurrency = 4\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write local .armrc: %v", err)
+	}
+
+	t.Setenv("HOME", tmpDir)
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	origins, err := LoadOrigins("")
+	if err != nil {
+		t.Fatalf("LoadOrigins() error = %v", err)
+	}
+
+	expected := map[string]Origin{
+		"git.concurrency":        {Scope: "local", Path: ".armrc"},
+		"git.rateLimit":          {Scope: "global", Path: filepath.Join(globalDir, ".armrc")},
+		"channels.cursor":        {Scope: "global", Path: filepath.Join(globalDir, "arm.json")},
+		"rulesets.default.rules": {Scope: "local", Path: "arm.json"},
+	}
+	for key, want := range expected {
+		if got := origins[key]; got != want {
+			t.Errorf("origin of %s = %+v, want %+v", key, got, want)
+		}
+	}
+}
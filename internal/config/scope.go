@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Scope selects the manifest, lock file and channels rulesets are installed with
+type Scope string
+
+const (
+	ScopeLocal  Scope = "local"  // arm.json and arm.lock in the working directory
+	ScopeGlobal Scope = "global" // arm.json and arm.lock in ~/.arm
+)
+
+// ScopeFor returns the global scope when global is set and the local scope otherwise
+func ScopeFor(global bool) Scope {
+	if global {
+		return ScopeGlobal
+	}
+	return ScopeLocal
+}
+
+// Scopes returns the scopes selected by --global and --local: both, local
+// first, when neither or both are set
+func Scopes(global, local bool) []Scope {
+	switch {
+	case global && !local:
+		return []Scope{ScopeGlobal}
+	case local && !global:
+		return []Scope{ScopeLocal}
+	}
+	return []Scope{ScopeLocal, ScopeGlobal}
+}
+
+// GlobalDir returns the directory of the global configuration, ~/.arm
+func GlobalDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".arm")
+}
+
+// Path returns the path of a configuration file of the scope, such as arm.lock
+func (s Scope) Path(name string) string {
+	if s == ScopeGlobal {
+		return filepath.Join(GlobalDir(), name)
+	}
+	return name
+}
+
+// LockPath returns the path of the lock file of the configuration's scope
+func (c *Config) LockPath() string {
+	return c.Scope.Path("arm.lock")
+}
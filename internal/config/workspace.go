@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+)
+
+// WorkspaceEnv selects workspace members by path or name, comma-separated,
+// with "." selecting the root project
+const WorkspaceEnv = "ARM_WORKSPACE"
+
+// WorkspaceLock is the lock file section of a workspace member
+type WorkspaceLock struct {
+	Rulesets map[string]map[string]LockedRuleset `json:"rulesets"`
+}
+
+// Member returns the lock file of a workspace member, sharing its entries, or
+// nil when nothing is locked for it. The root project is member "".
+func (l *LockFile) Member(member string) *LockFile {
+	if l == nil || member == "" {
+		return l
+	}
+	section := l.Workspaces[member]
+	if section == nil {
+		return nil
+	}
+	return &LockFile{Rulesets: section.Rulesets}
+}
+
+// Set records the locked version of a ruleset of a workspace member
+func (l *LockFile) Set(member, registry, name string, locked LockedRuleset) {
+	rulesets := l.Rulesets
+	if member != "" {
+		if l.Workspaces == nil {
+			l.Workspaces = make(map[string]*WorkspaceLock)
+		}
+		if l.Workspaces[member] == nil {
+			l.Workspaces[member] = &WorkspaceLock{Rulesets: make(map[string]map[string]LockedRuleset)}
+		}
+		rulesets = l.Workspaces[member].Rulesets
+	}
+	if rulesets[registry] == nil {
+		rulesets[registry] = make(map[string]LockedRuleset)
+	}
+	rulesets[registry][name] = locked
+}
+
+// Remove deletes a ruleset of a workspace member, dropping sections left empty
+func (l *LockFile) Remove(member, registry, name string) {
+	rulesets := l.Rulesets
+	if member != "" {
+		if l.Workspaces[member] == nil {
+			return
+		}
+		rulesets = l.Workspaces[member].Rulesets
+	}
+	if rulesets[registry] != nil {
+		delete(rulesets[registry], name)
+		if len(rulesets[registry]) == 0 {
+			delete(rulesets, registry)
+		}
+	}
+	if member != "" && len(rulesets) == 0 {
+		delete(l.Workspaces, member)
+	}
+}
+
+// Members returns the member directories matched by the workspaces of the
+// root arm.json, relative to the project and in sorted order. Matches
+// without an arm.json are skipped.
+func (c *Config) Members() ([]string, error) {
+	return workspaceMembers(".", c.Workspaces)
+}
+
+// workspaceMembers returns the members of the project in dir matched by patterns
+func workspaceMembers(dir string, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var members []string
+	for _, pattern := range patterns {
+		if filepath.IsAbs(pattern) {
+			return nil, armerr.Errorf(armerr.Config, "workspace '%s' is not inside the project", pattern)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, armerr.Errorf(armerr.Config, "invalid workspace pattern '%s': %w", pattern, err)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(dir, match)
+			if err != nil {
+				return nil, err
+			}
+			member := filepath.ToSlash(rel)
+			if member == "." || member == ".." || strings.HasPrefix(member, "../") {
+				return nil, armerr.Errorf(armerr.Config, "workspace '%s' is not inside the project", rel)
+			}
+			if _, err := os.Stat(filepath.Join(match, "arm.json")); err != nil || seen[member] {
+				continue
+			}
+			seen[member] = true
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// LoadWorkspace loads the configuration of a workspace member of root, the
+// local configuration of the project. The member's .armrc and channels
+// override the root ones, relative channel directories are taken from the
+// member directory, and its rulesets are locked in the root arm.lock.
+func LoadWorkspace(root *Config, member string) (*Config, error) {
+	memberCfg, err := loadConfigFromPaths(filepath.Join(member, ".armrc"), filepath.Join(member, "arm.json"), "")
+	if err != nil {
+		return nil, armerr.Errorf(armerr.Config, "failed to load workspace %s: %w", member, err)
+	}
+
+	cfg := mergeConfigs(root, memberCfg)
+	cfg.Channels = make(map[string]ChannelConfig)
+	mergeChannelMaps(cfg.Channels, root.Channels, memberCfg.Channels)
+	for name, channel := range cfg.Channels {
+		cfg.Channels[name] = rebaseChannel(channel, member)
+	}
+	cfg.Rulesets = memberCfg.Rulesets
+	cfg.LockFile = root.LockFile.Member(member)
+	cfg.Scope = ScopeLocal
+	cfg.Workspace = member
+	cfg.Options = root.Options
+
+	// The profile overrides the member .armrc as it does the root one
+	if err := cfg.applyProfile(); err != nil {
+		return nil, err
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		return nil, armerr.Errorf(armerr.Config, "workspace %s configuration validation failed: %w", member, err)
+	}
+	return cfg, nil
+}
+
+// rebaseChannel makes the relative directories of a channel relative to dir
+func rebaseChannel(channel ChannelConfig, dir string) ChannelConfig {
+	directories := make([]string, len(channel.Directories))
+	for i, directory := range channel.Directories {
+		if filepath.IsAbs(directory) || strings.HasPrefix(directory, "~") || strings.HasPrefix(directory, "$") {
+			directories[i] = directory
+		} else {
+			directories[i] = filepath.Join(dir, directory)
+		}
+	}
+	channel.Directories = directories
+	return channel
+}
+
+// SelectWorkspaces returns the configurations of root and its workspace
+// members that commands operate on. The members of root.Options.Workspaces
+// are selected when set; otherwise root alone, or with all also every member.
+func SelectWorkspaces(root *Config, all bool) ([]*Config, error) {
+	selection := root.Options.Workspaces
+	if len(selection) == 0 && (!all || len(root.Workspaces) == 0) {
+		return []*Config{root}, nil
+	}
+	if root.Scope == ScopeGlobal {
+		if len(selection) > 0 {
+			return nil, armerr.New(armerr.Config, "workspaces are not supported in global scope")
+		}
+		return []*Config{root}, nil
+	}
+
+	members, err := root.Members()
+	if err != nil {
+		return nil, err
+	}
+	if len(selection) == 0 {
+		configs := []*Config{root}
+		for _, member := range members {
+			cfg, err := LoadWorkspace(root, member)
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, cfg)
+		}
+		return configs, nil
+	}
+
+	if len(root.Workspaces) == 0 {
+		return nil, armerr.New(armerr.Config, "arm.json declares no workspaces").
+			WithHint(`List member directories in arm.json, e.g. "workspaces": ["packages/*"]`)
+	}
+	var configs []*Config
+	for _, name := range selection {
+		if name == "." {
+			configs = append(configs, root)
+			continue
+		}
+		member, err := findMember(members, name)
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := LoadWorkspace(root, member)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// findMember returns the member with the given path, or the only one with
+// the given directory name
+func findMember(members []string, name string) (string, error) {
+	name = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(name)), "/")
+	var matches []string
+	for _, member := range members {
+		if member == name {
+			return member, nil
+		}
+		if filepath.Base(member) == name {
+			matches = append(matches, member)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		err := armerr.Errorf(armerr.NotFound, "workspace member '%s' not found", name)
+		if len(members) > 0 {
+			err = err.WithHint(fmt.Sprintf("Workspace members: %s", strings.Join(members, ", ")))
+		}
+		return "", err
+	}
+	return "", armerr.Errorf(armerr.Config, "workspace member '%s' is ambiguous: %s", name, strings.Join(matches, ", ")).
+		WithHint("Select the member by its path")
+}
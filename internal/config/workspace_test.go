@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLockFileMembers(t *testing.T) {
+	lock := &LockFile{Rulesets: make(map[string]map[string]LockedRuleset)}
+	lock.Set("", "default", "root-rules", LockedRuleset{Version: "1.0.0"})
+	lock.Set("packages/web", "default", "web-rules", LockedRuleset{Version: "2.0.0"})
+
+	if lock.Member("") != lock {
+		t.Error("Member(\"\") should return the lock file itself")
+	}
+	if got := lock.Member("packages/web").Rulesets["default"]["web-rules"].Version; got != "2.0.0" {
+		t.Errorf("Expected the member section to hold web-rules 2.0.0, got %q", got)
+	}
+	if _, ok := lock.Rulesets["default"]["web-rules"]; ok {
+		t.Error("Member rulesets must not be locked for the root project")
+	}
+	if lock.Member("packages/api") != nil {
+		t.Error("Expected no lock file for a member without locked rulesets")
+	}
+
+	lock.Remove("packages/web", "default", "web-rules")
+	if _, ok := lock.Workspaces["packages/web"]; ok {
+		t.Errorf("Expected the empty member section to be dropped, got %v", lock.Workspaces)
+	}
+	if lock.Rulesets["default"]["root-rules"].Version != "1.0.0" {
+		t.Error("Removing a member ruleset must not touch the root project")
+	}
+}
+
+func TestSelectWorkspaces(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"arm.json":              `{"workspaces": ["packages/*"], "channels": {"cursor": {"directories": [".cursor/rules", "/shared/rules"]}}, "rulesets": {"default": {"root-rules": {"version": "1.0.0"}}}}`,
+		"arm.lock":              `{"rulesets": {}, "workspaces": {"packages/web": {"rulesets": {"default": {"web-rules": {"version": "2.0.0", "resolved": "2.0.0"}}}}}}`,
+		".armrc":                "[registries]\ndefault = https://github.com/user/repo\n\n[registries.default]\ntype = git\n",
+		"packages/web/arm.json": `{"channels": {"q": {"directories": [".amazonq/rules"]}}, "rulesets": {"default": {"web-rules": {"version": "^2.0.0"}}}}`,
+		"packages/web/.armrc":   "[registries]\nweb = https://github.com/user/web\n\n[registries.web]\ntype = git\n",
+		"packages/api/arm.json": `{"rulesets": {}}`,
+		"packages/docs/README":  "Not a member without arm.json",
+	}
+	for path, content := range files {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	originalHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() { _ = os.Setenv("HOME", originalHome) }()
+	originalWd, _ := os.Getwd()
+	_ = os.Chdir(tmpDir)
+	defer func() { _ = os.Chdir(originalWd) }()
+	root, err := LoadScope(ScopeLocal, Options{})
+	if err != nil {
+		t.Fatalf("LoadScope(local) failed: %v", err)
+	}
+	members, err := root.Members()
+	if err != nil || !reflect.DeepEqual(members, []string{"packages/api", "packages/web"}) {
+		t.Fatalf("Members() = %v, %v, want packages/api and packages/web", members, err)
+	}
+
+	// Without a selection, commands operate on the root project or all members
+	if configs, err := SelectWorkspaces(root, false); err != nil || len(configs) != 1 || configs[0] != root {
+		t.Errorf("SelectWorkspaces(root, false) = %d configs, %v, want root only", len(configs), err)
+	}
+	configs, err := SelectWorkspaces(root, true)
+	if err != nil || len(configs) != 3 {
+		t.Fatalf("SelectWorkspaces(root, true) = %d configs, %v, want root and 2 members", len(configs), err)
+	}
+
+	web := configs[2]
+	if web.Workspace != "packages/web" || web.ManifestPath() != filepath.Join("packages", "web", "arm.json") {
+		t.Errorf("Expected the packages/web member, got %q with manifest %q", web.Workspace, web.ManifestPath())
+	}
+	if web.LockPath() != "arm.lock" || web.LockFile.Rulesets["default"]["web-rules"].Version != "2.0.0" {
+		t.Errorf("Expected the member section of the root lock file, got %s %+v", web.LockPath(), web.LockFile)
+	}
+	if _, ok := web.Rulesets["default"]["root-rules"]; ok {
+		t.Errorf("Expected member rulesets only, got %v", web.Rulesets)
+	}
+	if web.Registries["default"] == "" || web.Registries["web"] == "" {
+		t.Errorf("Expected root and member registries, got %v", web.Registries)
+	}
+	wantCursor := []string{filepath.Join("packages", "web", ".cursor", "rules"), "/shared/rules"}
+	if !reflect.DeepEqual(web.Channels["cursor"].Directories, wantCursor) {
+		t.Errorf("Expected the root channel inherited in the member directory, got %v", web.Channels["cursor"].Directories)
+	}
+	if !reflect.DeepEqual(web.Channels["q"].Directories, []string{filepath.Join("packages", "web", ".amazonq", "rules")}) {
+		t.Errorf("Expected the member channel in the member directory, got %v", web.Channels["q"].Directories)
+	}
+
+	// Members are selected by path or directory name, the root project by "."
+	root.Options.Workspaces = []string{"web", "."}
+	configs, err = SelectWorkspaces(root, true)
+	if err != nil || len(configs) != 2 || configs[0].Workspace != "packages/web" || configs[1] != root {
+		t.Errorf("SelectWorkspaces() with web,. = %d configs, %v", len(configs), err)
+	}
+	if configs[0].Options.Workspaces == nil {
+		t.Error("Expected members to keep the options of the root configuration")
+	}
+	root.Options.Workspaces = []string{"packages/missing"}
+	if _, err := SelectWorkspaces(root, true); err == nil {
+		t.Error("Expected an error for an unknown member")
+	}
+}
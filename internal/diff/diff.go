@@ -0,0 +1,249 @@
+// Package diff compares two versions of a ruleset file by file and renders the
+// changes as unified diffs.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gitdiff "github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// Status is the kind of change made to a file
+type Status string
+
+const (
+	Added    Status = "added"
+	Removed  Status = "removed"
+	Modified Status = "modified"
+)
+
+// FileDiff is the change made to a single file
+type FileDiff struct {
+	Path      string `json:"path"` // Slash-separated path relative to the ruleset
+	Status    Status `json:"status"`
+	Binary    bool   `json:"binary,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Hunks     string `json:"hunks,omitempty"` // Unified diff hunks, without file headers
+}
+
+// Unified renders the diff with file headers, prefixing the old and new paths
+// with fromPrefix and toPrefix
+func (d FileDiff) Unified(fromPrefix, toPrefix string) string {
+	fromPath, toPath := fromPrefix+d.Path, toPrefix+d.Path
+	switch d.Status {
+	case Added:
+		fromPath = "/dev/null"
+	case Removed:
+		toPath = "/dev/null"
+	}
+	if d.Binary {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromPath, toPath)
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n%s", fromPath, toPath, d.Hunks)
+}
+
+// Trees compares the files below fromDir and toDir, returning the changed
+// files sorted by path. Either directory may be empty to compare against
+// nothing.
+func Trees(fromDir, toDir string) ([]FileDiff, error) {
+	fromFiles, err := readTree(fromDir)
+	if err != nil {
+		return nil, err
+	}
+	toFiles, err := readTree(toDir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+	for path := range fromFiles {
+		paths[path] = true
+	}
+	for path := range toFiles {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var diffs []FileDiff
+	for _, path := range sorted {
+		from, inFrom := fromFiles[path]
+		to, inTo := toFiles[path]
+		if inFrom && inTo && bytes.Equal(from, to) {
+			continue
+		}
+		diffs = append(diffs, File(path, from, to, inFrom, inTo))
+	}
+	return diffs, nil
+}
+
+// File compares two versions of a file; inFrom and inTo report whether the
+// file exists in each version
+func File(path string, from, to []byte, inFrom, inTo bool) FileDiff {
+	d := FileDiff{Path: path, Status: Modified}
+	switch {
+	case !inFrom:
+		d.Status = Added
+	case !inTo:
+		d.Status = Removed
+	}
+	if isBinary(from) || isBinary(to) {
+		d.Binary = true
+		return d
+	}
+	d.Hunks, d.Additions, d.Deletions = hunks(string(from), string(to))
+	return d
+}
+
+// line is a line of a diff with its operation: ' ', '-' or '+'
+type line struct {
+	op   byte
+	text string
+}
+
+// hunks computes the unified diff hunks between two texts
+func hunks(from, to string) (string, int, int) {
+	var lines []line
+	var additions, deletions int
+	for _, d := range gitdiff.Do(from, to) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, text := range splitLines(d.Text) {
+			lines = append(lines, line{op: op, text: text})
+			switch op {
+			case '-':
+				deletions++
+			case '+':
+				additions++
+			}
+		}
+	}
+
+	var out strings.Builder
+	oldLine, newLine := 1, 1 // Line numbers at lines[i]
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// Extend the hunk while changes are separated by at most twice the context
+		start := max(0, i-contextLines)
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*contextLines {
+				break
+			}
+		}
+		end = min(len(lines), end+contextLines)
+
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, l := range lines[start:end] {
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		out.WriteString(body.String())
+
+		for _, l := range lines[i:end] {
+			if l.op != '+' {
+				oldLine++
+			}
+			if l.op != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return out.String(), additions, deletions
+}
+
+// hunkRange formats the start and length of a hunk; empty ranges start at the
+// line before the change
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into newline-terminated lines, marking a missing
+// final newline the way diff does
+func splitLines(text string) []string {
+	var lines []string
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text+"\n\\ No newline at end of file\n")
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// isBinary reports whether data looks like binary content
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// readTree reads the files below dir keyed by slash-separated relative path
+func readTree(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	if dir == "" {
+		return files, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		inFrom bool
+		inTo   bool
+		want   string
+	}{
+		{
+			name: "modified", inFrom: true, inTo: true,
+			from: "a\nb\nc\n",
+			to:   "a\nB\nc\n",
+			want: "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "added", inTo: true,
+			to:   "new\n",
+			want: "@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name: "removed", inFrom: true,
+			from: "old\nrule\n",
+			want: "@@ -1,2 +0,0 @@\n-old\n-rule\n",
+		},
+		{
+			name: "context", inFrom: true, inTo: true,
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:   "1\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name: "separate hunks", inFrom: true, inTo: true,
+			from: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			to:   "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "missing final newline", inFrom: true, inTo: true,
+			from: "a\n",
+			to:   "a\nb",
+			want: "@@ -1 +1,2 @@\n a\n+b\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := File("rules.md", []byte(tt.from), []byte(tt.to), tt.inFrom, tt.inTo)
+			if d.Hunks != tt.want {
+				t.Errorf("Hunks = %q, want %q", d.Hunks, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrees(t *testing.T) {
+	fromDir, toDir := t.TempDir(), t.TempDir()
+	write := func(dir, path, content string) {
+		t.Helper()
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	write(fromDir, "same.md", "same\n")
+	write(toDir, "same.md", "same\n")
+	write(fromDir, "rules/python.md", "use black\n")
+	write(toDir, "rules/python.md", "use ruff\n")
+	write(fromDir, "old.md", "old\n")
+	write(toDir, "new.md", "new\n")
+	write(toDir, "logo.png", "\x89PNG\x00")
+
+	diffs, err := Trees(fromDir, toDir)
+	if err != nil {
+		t.Fatalf("Trees failed: %v", err)
+	}
+
+	want := []struct {
+		path   string
+		status Status
+	}{
+		{"logo.png", Added},
+		{"new.md", Added},
+		{"old.md", Removed},
+		{"rules/python.md", Modified},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("Expected %d changed files, got %+v", len(want), diffs)
+	}
+	for i, w := range want {
+		if diffs[i].Path != w.path || diffs[i].Status != w.status {
+			t.Errorf("Change %d = %s %s, want %s %s", i, diffs[i].Status, diffs[i].Path, w.status, w.path)
+		}
+	}
+
+	if !diffs[0].Binary || !strings.HasPrefix(diffs[0].Unified("a/", "b/"), "Binary files /dev/null and b/logo.png differ") {
+		t.Errorf("Expected binary diff, got %q", diffs[0].Unified("a/", "b/"))
+	}
+	if got := diffs[3].Unified("a/", "b/"); got != "--- a/rules/python.md\n+++ b/rules/python.md\n@@ -1 +1 @@\n-use black\n+use ruff\n" {
+		t.Errorf("Unexpected unified diff %q", got)
+	}
+	if diffs[3].Additions != 1 || diffs[3].Deletions != 1 {
+		t.Errorf("Expected one addition and deletion, got +%d -%d", diffs[3].Additions, diffs[3].Deletions)
+	}
+}
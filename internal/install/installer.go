@@ -1,16 +1,22 @@
 package install
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 // Installer manages ruleset installation and file operations
@@ -28,6 +34,8 @@ type InstallRequest struct {
 	ResolvedVersion string   // Actual resolved version (e.g., commit hash)
 	SourceFiles     []string // Files to install from cache/extraction
 	Channels        []string // Target channels (empty = all channels)
+	Integrity       string   // Content hash of the source files, recorded in the lock file
+	Source          string   // Mirror that served the files, if not the registry itself
 }
 
 // InstallResult represents the result of an installation
@@ -44,12 +52,30 @@ type InstallResult struct {
 func New(cfg *config.Config) *Installer {
 	return &Installer{
 		config:   cfg,
-		lockPath: "arm.lock",
+		lockPath: cfg.LockPath(),
 	}
 }
 
-// Install installs a ruleset to configured channels
-func (i *Installer) Install(req *InstallRequest) (*InstallResult, error) {
+// scope returns the scope of the installer's manifest and lock file
+func (i *Installer) scope() config.Scope {
+	if i.config.Scope == "" {
+		return config.ScopeLocal
+	}
+	return i.config.Scope
+}
+
+// scopeFlag returns the command line flag selecting the installer's scope
+func (i *Installer) scopeFlag() string {
+	if i.scope() == config.ScopeGlobal {
+		return " --global"
+	}
+	return ""
+}
+
+// Install installs a ruleset to configured channels. Files are staged beside
+// every channel directory first and moved into place only once all of them are
+// staged, so a failed or canceled install leaves the previous version intact.
+func (i *Installer) Install(ctx context.Context, req *InstallRequest) (*InstallResult, error) {
 	if req.Registry == "" || req.Ruleset == "" || req.Version == "" {
 		return nil, fmt.Errorf("registry, ruleset, and version are required")
 	}
@@ -68,41 +94,63 @@ func (i *Installer) Install(req *InstallRequest) (*InstallResult, error) {
 	}
 
 	if len(targetChannels) == 0 {
-		return nil, fmt.Errorf("no channels configured")
+		return nil, armerr.New(armerr.Config, "no channels configured").
+			WithHint(fmt.Sprintf("Add a %s channel with 'arm config add channel <name> --directories=<dir>%s'", i.scope(), i.scopeFlag()))
 	}
 
+	progress.Report(ctx, progress.Installing, fmt.Sprintf("%d files", len(req.SourceFiles)))
+
 	var installedChannels []string
 	var totalFiles int
+	var staged []*stagedInstall
+	defer func() {
+		for _, stage := range staged {
+			stage.discard()
+		}
+	}()
 
-	// Install to each channel
+	// Stage the files for each channel
 	for _, channelName := range targetChannels {
 		channelConfig, exists := i.config.Channels[channelName]
 		if !exists {
-			return nil, fmt.Errorf("channel '%s' not configured", channelName)
+			return nil, armerr.Errorf(armerr.Config, "channel '%s' not configured", channelName)
 		}
 
 		for _, channelDir := range channelConfig.Directories {
 			// Expand environment variables in channel directory
 			expandedDir := expandPath(channelDir)
+			if i.config.Scope == config.ScopeGlobal && !filepath.IsAbs(expandedDir) {
+				return nil, armerr.Errorf(armerr.Config, "global channel '%s' directory '%s' is not absolute", channelName, channelDir).
+					WithHint("Global channels must not depend on the working directory; use a path such as ~/<assistant>/rules")
+			}
 
-			// Install to this channel directory
-			filesCount, err := i.installToChannel(req, expandedDir)
+			// Stage for this channel directory
+			stop := logger.Timer("Stage %s/%s@%s for %s", req.Registry, req.Ruleset, req.Version, expandedDir)
+			stage, err := i.stageChannel(ctx, req, expandedDir)
 			if err != nil {
 				return nil, fmt.Errorf("failed to install to channel '%s' directory '%s': %w", channelName, expandedDir, err)
 			}
+			stop()
 
-			totalFiles += filesCount
+			staged = append(staged, stage)
+			totalFiles += stage.files
 		}
 
 		installedChannels = append(installedChannels, channelName)
 	}
 
-	// Update lock file with resolved version
-	resolvedVersion := req.ResolvedVersion
-	if resolvedVersion == "" {
-		resolvedVersion = req.Version // Fallback to version if no resolved version provided
+	// Last chance to cancel before anything is replaced
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	if err := i.updateLockFile(req.Registry, req.Ruleset, req.Version, resolvedVersion); err != nil {
+	for _, stage := range staged {
+		if err := stage.commit(); err != nil {
+			return nil, fmt.Errorf("failed to install to '%s': %w", stage.rulesetDir, err)
+		}
+	}
+
+	// Update lock file with resolved version
+	if err := i.recordLockEntry(req); err != nil {
 		return nil, fmt.Errorf("failed to update lock file: %w", err)
 	}
 
@@ -116,25 +164,65 @@ func (i *Installer) Install(req *InstallRequest) (*InstallResult, error) {
 	}, nil
 }
 
-// installToChannel installs files to a specific channel directory
-func (i *Installer) installToChannel(req *InstallRequest, channelDir string) (int, error) {
-	// Create ARM namespace directory structure
-	armDir := filepath.Join(channelDir, "arm")
-	registryDir := filepath.Join(armDir, req.Registry)
-	rulesetDir := filepath.Join(registryDir, req.Ruleset)
-	versionDir := filepath.Join(rulesetDir, req.Version)
+// stagingPrefix starts the name of the directories rulesets are staged in
+const stagingPrefix = ".arm-staging-"
+
+// stagedInstall is a ruleset version copied into a staging directory beside
+// the version directory it replaces
+type stagedInstall struct {
+	dir        string // Staging directory, empty once committed or discarded
+	rulesetDir string
+	version    string
+	files      int
+}
+
+// commit moves the staged version into place and removes previous versions
+func (s *stagedInstall) commit() error {
+	versionDir := filepath.Join(s.rulesetDir, s.version)
+	if err := os.RemoveAll(versionDir); err != nil {
+		return fmt.Errorf("failed to remove version directory: %w", err)
+	}
+	if err := os.Rename(s.dir, versionDir); err != nil {
+		return fmt.Errorf("failed to move staged files into place: %w", err)
+	}
+	s.dir = ""
+	cleanupPreviousVersion(s.rulesetDir, s.version)
+	return nil
+}
 
-	// Create directories if they don't exist
-	if err := os.MkdirAll(versionDir, 0o755); err != nil {
-		return 0, fmt.Errorf("failed to create version directory: %w", err)
+// discard removes the staging directory of an uncommitted install
+func (s *stagedInstall) discard() {
+	if s.dir == "" {
+		return
+	}
+	_ = os.RemoveAll(s.dir)
+	_ = os.Remove(s.rulesetDir) // Only removed when nothing else is installed
+	s.dir = ""
+}
+
+// stageChannel copies the files of a ruleset into a staging directory of a
+// channel directory, stopping when ctx is canceled
+func (i *Installer) stageChannel(ctx context.Context, req *InstallRequest, channelDir string) (*stagedInstall, error) {
+	// Create ARM namespace directory structure
+	rulesetDir := filepath.Join(channelDir, "arm", req.Registry, req.Ruleset)
+	if err := os.MkdirAll(rulesetDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create ruleset directory: %w", err)
 	}
 
-	// Remove previous version after successful installation
-	defer i.cleanupPreviousVersion(rulesetDir, req.Version)
+	// Stage beside the version directory so that committing is a rename
+	versionDir, err := os.MkdirTemp(rulesetDir, stagingPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	stage := &stagedInstall{dir: versionDir, rulesetDir: rulesetDir, version: req.Version}
 
-	// Copy files to version directory
-	filesCount := 0
+	// Copy files to the staging directory
 	for _, sourceFile := range req.SourceFiles {
+		if err := ctx.Err(); err != nil {
+			stage.discard()
+			return nil, err
+		}
+
 		// For Git registries, preserve directory structure by using relative path from temp dir
 		// For other registries, use just the filename
 		var destPath string
@@ -164,17 +252,42 @@ func (i *Installer) installToChannel(req *InstallRequest, channelDir string) (in
 		// Create destination directory if needed
 		destDir := filepath.Dir(destPath)
 		if err := os.MkdirAll(destDir, 0o755); err != nil {
-			return 0, fmt.Errorf("failed to create destination directory: %w", err)
+			stage.discard()
+			return nil, fmt.Errorf("failed to create destination directory: %w", err)
 		}
 
-		if err := i.copyFile(sourceFile, destPath); err != nil {
-			return 0, fmt.Errorf("failed to copy file '%s': %w", sourceFile, err)
+		if err := i.placeFile(sourceFile, destPath); err != nil {
+			stage.discard()
+			return nil, fmt.Errorf("failed to copy file '%s': %w", sourceFile, err)
 		}
 
-		filesCount++
+		stage.files++
 	}
 
-	return filesCount, nil
+	return stage, nil
+}
+
+// placeFile installs a file using the configured cache link mode. Hardlinks and
+// reflinks share storage with the cache's blob store; anything that cannot be
+// linked is copied.
+func (i *Installer) placeFile(src, dst string) error {
+	mode := cache.LinkCopy
+	if i.config.CacheConfig != nil {
+		if parsed, err := cache.ParseLinkMode(i.config.CacheConfig.LinkMode); err == nil {
+			mode = parsed
+		}
+	}
+	if mode == cache.LinkCopy {
+		return i.copyFile(src, dst)
+	}
+
+	blobs := cache.NewBlobStore(i.config.CacheConfig.Path)
+	digest, _, err := blobs.PutFile(src)
+	if err != nil {
+		logger.Debug("Copying %s instead of linking: %v", dst, err)
+		return i.copyFile(src, dst)
+	}
+	return blobs.Link(digest, dst, mode)
 }
 
 // copyFile copies a file from source to destination with proper permissions
@@ -185,6 +298,11 @@ func (i *Installer) copyFile(src, dst string) error {
 	}
 	defer func() { _ = srcFile.Close() }()
 
+	// Replace rather than truncate, the destination may be hardlinked to the cache
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace destination file: %w", err)
+	}
+
 	dstFile, err := os.Create(dst)
 	if err != nil {
 		return fmt.Errorf("failed to create destination file: %w", err)
@@ -204,8 +322,9 @@ func (i *Installer) copyFile(src, dst string) error {
 	return nil
 }
 
-// cleanupPreviousVersion removes previous version directories, keeping only current
-func (i *Installer) cleanupPreviousVersion(rulesetDir, currentVersion string) {
+// cleanupPreviousVersion removes previous version directories, keeping only
+// current, along with staging directories left behind by interrupted installs
+func cleanupPreviousVersion(rulesetDir, currentVersion string) {
 	entries, err := os.ReadDir(rulesetDir)
 	if err != nil {
 		return // Ignore errors during cleanup
@@ -322,6 +441,198 @@ func (i *Installer) ListInstalled(channels []string) (map[string]map[string][]st
 	return result, nil
 }
 
+// Ruleset status flags reported by Status
+const (
+	StatusMissing   = "missing"     // Declared in arm.json but not locked or absent from a channel directory
+	StatusExtra     = "extra"       // Locked or installed but not declared in arm.json
+	StatusOutOfSync = "out of sync" // Lock file and arm.json or channel contents disagree on the version
+)
+
+// RulesetStatus reconciles a ruleset across arm.json, arm.lock and the channel directories
+type RulesetStatus struct {
+	Scope     config.Scope        `json:"scope"`
+	Workspace string              `json:"workspace,omitempty"` // Workspace member, empty for the root project
+	Registry  string              `json:"registry"`
+	Name      string              `json:"name"`
+	Version   string              `json:"version,omitempty"`  // Version spec from arm.json
+	Patterns  []string            `json:"patterns,omitempty"` // Patterns from arm.json
+	Locked    string              `json:"locked,omitempty"`   // Version spec recorded in arm.lock
+	Resolved  string              `json:"resolved,omitempty"` // Resolved version or commit from arm.lock
+	Installed []InstalledLocation `json:"installed"`
+	Flags     []string            `json:"flags,omitempty"`
+}
+
+// InstalledLocation is a channel directory containing a ruleset
+type InstalledLocation struct {
+	Channel   string `json:"channel"`
+	Directory string `json:"directory"`
+	Version   string `json:"version"` // Version directory found on disk
+	Files     int    `json:"files"`
+}
+
+// Status reconciles the configured rulesets, the lock file and the contents of
+// the given channels (all channels when empty), sorted by registry and name
+func (i *Installer) Status(channels []string) ([]RulesetStatus, error) {
+	targetChannels := channels
+	if len(targetChannels) == 0 {
+		for channelName := range i.config.Channels {
+			targetChannels = append(targetChannels, channelName)
+		}
+	}
+	sort.Strings(targetChannels)
+
+	statuses := make(map[string]*RulesetStatus)
+	get := func(registry, ruleset string) *RulesetStatus {
+		key := registry + "/" + ruleset
+		if statuses[key] == nil {
+			statuses[key] = &RulesetStatus{Scope: i.scope(), Workspace: i.config.Workspace, Registry: registry, Name: ruleset, Installed: []InstalledLocation{}}
+		}
+		return statuses[key]
+	}
+
+	for registry, rulesets := range i.config.Rulesets {
+		for name, spec := range rulesets {
+			status := get(registry, name)
+			status.Version = spec.Version
+			status.Patterns = spec.Patterns
+		}
+	}
+	if i.config.LockFile != nil {
+		for registry, rulesets := range i.config.LockFile.Rulesets {
+			for name, locked := range rulesets {
+				status := get(registry, name)
+				status.Locked = locked.Version
+				status.Resolved = locked.Resolved
+			}
+		}
+	}
+
+	// Scan channel directories for installed rulesets
+	var directories int
+	for _, channelName := range targetChannels {
+		channelConfig, exists := i.config.Channels[channelName]
+		if !exists {
+			continue // Skip non-existent channels
+		}
+		for _, channelDir := range channelConfig.Directories {
+			directories++
+			locations, err := scanChannelDir(channelName, channelDir)
+			if err != nil {
+				return nil, err
+			}
+			for key, found := range locations {
+				registry, ruleset, _ := strings.Cut(key, "/")
+				status := get(registry, ruleset)
+				status.Installed = append(status.Installed, found...)
+			}
+		}
+	}
+
+	result := make([]RulesetStatus, 0, len(statuses))
+	for _, status := range statuses {
+		status.Flags = statusFlags(status, directories)
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Registry != result[b].Registry {
+			return result[a].Registry < result[b].Registry
+		}
+		return result[a].Name < result[b].Name
+	})
+	return result, nil
+}
+
+// scanChannelDir returns the rulesets installed in a channel directory, keyed by registry/ruleset
+func scanChannelDir(channelName, channelDir string) (map[string][]InstalledLocation, error) {
+	armDir := filepath.Join(expandPath(channelDir), "arm")
+	registries, err := os.ReadDir(armDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channel directory '%s': %w", channelDir, err)
+	}
+
+	locations := make(map[string][]InstalledLocation)
+	for _, registryEntry := range registries {
+		if !registryEntry.IsDir() {
+			continue
+		}
+		rulesets, err := os.ReadDir(filepath.Join(armDir, registryEntry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, rulesetEntry := range rulesets {
+			if !rulesetEntry.IsDir() {
+				continue
+			}
+			rulesetDir := filepath.Join(armDir, registryEntry.Name(), rulesetEntry.Name())
+			versions, err := os.ReadDir(rulesetDir)
+			if err != nil {
+				continue
+			}
+			key := registryEntry.Name() + "/" + rulesetEntry.Name()
+			for _, versionEntry := range versions {
+				if !versionEntry.IsDir() || strings.HasPrefix(versionEntry.Name(), stagingPrefix) {
+					continue
+				}
+				locations[key] = append(locations[key], InstalledLocation{
+					Channel:   channelName,
+					Directory: channelDir,
+					Version:   versionEntry.Name(),
+					Files:     countFiles(filepath.Join(rulesetDir, versionEntry.Name())),
+				})
+			}
+		}
+	}
+	return locations, nil
+}
+
+// countFiles returns the number of regular files below dir
+func countFiles(dir string) int {
+	count := 0
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// statusFlags derives the flags of a ruleset given the number of scanned channel directories
+func statusFlags(status *RulesetStatus, directories int) []string {
+	var flags []string
+	declared := status.Version != ""
+
+	type channelDir struct{ channel, directory string }
+	installedDirs := make(map[channelDir]bool)
+	for _, location := range status.Installed {
+		installedDirs[channelDir{location.Channel, location.Directory}] = true
+	}
+
+	switch {
+	case declared && (status.Locked == "" || len(installedDirs) < directories):
+		flags = append(flags, StatusMissing)
+	case !declared:
+		flags = append(flags, StatusExtra)
+	}
+
+	outOfSync := declared && status.Locked != "" && status.Locked != status.Version
+	for _, location := range status.Installed {
+		if status.Locked != "" && location.Version != status.Locked {
+			outOfSync = true
+		}
+	}
+	if len(status.Installed) > len(installedDirs) {
+		outOfSync = true // Several versions side by side in one directory
+	}
+	if outOfSync {
+		flags = append(flags, StatusOutOfSync)
+	}
+	return flags
+}
+
 // GetLockFile returns the current lock file content
 func (i *Installer) GetLockFile() (*config.LockFile, error) {
 	return i.loadLockFile()
@@ -345,6 +656,22 @@ func expandPath(path string) string {
 
 // updateLockFile updates the lock file with a new ruleset entry
 func (i *Installer) updateLockFile(registry, ruleset, version, resolvedVersion string) error {
+	return i.recordLockEntry(&InstallRequest{
+		Registry:        registry,
+		Ruleset:         ruleset,
+		Version:         version,
+		ResolvedVersion: resolvedVersion,
+	})
+}
+
+// recordLockEntry writes the lock file entry for an installation request
+func (i *Installer) recordLockEntry(req *InstallRequest) error {
+	registry, ruleset := req.Registry, req.Ruleset
+	resolvedVersion := req.ResolvedVersion
+	if resolvedVersion == "" {
+		resolvedVersion = req.Version // Fallback to version if no resolved version provided
+	}
+
 	i.lockMu.Lock()
 	defer i.lockMu.Unlock()
 
@@ -353,11 +680,6 @@ func (i *Installer) updateLockFile(registry, ruleset, version, resolvedVersion s
 		return err
 	}
 
-	// Initialize registry map if needed
-	if lockFile.Rulesets[registry] == nil {
-		lockFile.Rulesets[registry] = make(map[string]config.LockedRuleset)
-	}
-
 	// Get registry config for metadata
 	registryConfig := i.config.RegistryConfigs[registry]
 	registryType := ""
@@ -368,13 +690,15 @@ func (i *Installer) updateLockFile(registry, ruleset, version, resolvedVersion s
 	}
 
 	// Update entry
-	lockFile.Rulesets[registry][ruleset] = config.LockedRuleset{
-		Version:  version,
-		Resolved: resolvedVersion,
-		Registry: i.config.Registries[registry],
-		Type:     registryType,
-		Region:   region,
-	}
+	lockFile.Set(i.config.Workspace, registry, ruleset, config.LockedRuleset{
+		Version:   req.Version,
+		Resolved:  resolvedVersion,
+		Registry:  i.config.Registries[registry],
+		Type:      registryType,
+		Region:    region,
+		Integrity: req.Integrity,
+		Source:    req.Source,
+	})
 
 	return i.saveLockFile(lockFile)
 }
@@ -390,13 +714,7 @@ func (i *Installer) removeLockEntry(registry, ruleset string) error {
 	}
 
 	// Remove entry if it exists
-	if lockFile.Rulesets[registry] != nil {
-		delete(lockFile.Rulesets[registry], ruleset)
-		// Remove empty registry
-		if len(lockFile.Rulesets[registry]) == 0 {
-			delete(lockFile.Rulesets, registry)
-		}
-	}
+	lockFile.Remove(i.config.Workspace, registry, ruleset)
 
 	return i.saveLockFile(lockFile)
 }
@@ -428,6 +746,11 @@ func (i *Installer) saveLockFile(lockFile *config.LockFile) error {
 		return fmt.Errorf("failed to marshal lock file: %w", err)
 	}
 
+	// The global lock file may be the first file in ~/.arm
+	if err := os.MkdirAll(filepath.Dir(i.lockPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create lock file directory: %w", err)
+	}
+
 	// Atomic write: write to temp file then rename
 	tempPath := i.lockPath + ".tmp"
 	if err := os.WriteFile(tempPath, data, 0o644); err != nil {
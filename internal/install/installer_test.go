@@ -1,10 +1,14 @@
 package install
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
 )
 
@@ -57,7 +61,7 @@ func TestInstaller_Install(t *testing.T) {
 		Channels:    []string{"cursor"},
 	}
 
-	result, err := installer.Install(req)
+	result, err := installer.Install(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Install failed: %v", err)
 	}
@@ -86,6 +90,140 @@ func TestInstaller_Install(t *testing.T) {
 	}
 }
 
+func TestInstaller_InstallKeepsPreviousVersionOnFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	cursorDir := filepath.Join(tempDir, ".cursor", "rules")
+	qDir := filepath.Join(tempDir, ".amazonq", "rules")
+	cfg := &config.Config{
+		Channels: map[string]config.ChannelConfig{
+			"cursor": {Directories: []string{cursorDir}},
+			"q":      {Directories: []string{qDir}},
+		},
+	}
+	installer := New(cfg)
+	installer.lockPath = filepath.Join(tempDir, "arm.lock")
+
+	sourceDir, err := os.MkdirTemp(tempDir, "arm-install-")
+	if err != nil {
+		t.Fatalf("Failed to create source temp dir: %v", err)
+	}
+	sourceFile := filepath.Join(sourceDir, "rule.md")
+	if err := os.WriteFile(sourceFile, []byte("# Rule"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	req := &InstallRequest{Registry: "reg", Ruleset: "rules", Version: "1.0.0", SourceFiles: []string{sourceFile}}
+	if _, err := installer.Install(context.Background(), req); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	// A canceled install and one failing part way leave 1.0.0 in every channel
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := &InstallRequest{Registry: "reg", Ruleset: "rules", Version: "2.0.0", SourceFiles: []string{sourceFile}}
+	if _, err := installer.Install(ctx, canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the install to be canceled, got %v", err)
+	}
+	broken := &InstallRequest{Registry: "reg", Ruleset: "rules", Version: "2.0.0", SourceFiles: []string{sourceFile, filepath.Join(sourceDir, "missing.md")}}
+	if _, err := installer.Install(context.Background(), broken); err == nil {
+		t.Error("Expected the install of a missing file to fail")
+	}
+
+	for _, channelDir := range []string{cursorDir, qDir} {
+		rulesetDir := filepath.Join(channelDir, "arm", "reg", "rules")
+		entries, err := os.ReadDir(rulesetDir)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", rulesetDir, err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "1.0.0" {
+			t.Errorf("Expected only 1.0.0 in %s, got %v", rulesetDir, entries)
+		}
+	}
+}
+
+func TestInstaller_InstallGlobalScope(t *testing.T) {
+	tempDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	_ = os.Setenv("HOME", tempDir)
+	defer func() { _ = os.Setenv("HOME", originalHome) }()
+
+	cfg := &config.Config{
+		Scope: config.ScopeGlobal,
+		Channels: map[string]config.ChannelConfig{
+			"cursor": {Directories: []string{".cursor/rules"}},
+		},
+	}
+	installer := New(cfg)
+	if installer.lockPath != filepath.Join(tempDir, ".arm", "arm.lock") {
+		t.Errorf("Expected the global lock file in ~/.arm, got %q", installer.lockPath)
+	}
+
+	sourceFile := filepath.Join(tempDir, "rule.md")
+	if err := os.WriteFile(sourceFile, []byte("# Rule"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	req := &InstallRequest{Registry: "reg", Ruleset: "rules", Version: "1.0.0", SourceFiles: []string{sourceFile}}
+
+	// Relative directories would install into whatever directory arm runs in
+	if _, err := installer.Install(context.Background(), req); err == nil || !strings.Contains(err.Error(), "not absolute") {
+		t.Errorf("Expected a relative global channel to be rejected, got %v", err)
+	}
+
+	cfg.Channels["cursor"] = config.ChannelConfig{Directories: []string{"~/.cursor/rules"}}
+	if _, err := installer.Install(context.Background(), req); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".cursor", "rules", "arm", "reg", "rules", "1.0.0", "rule.md")); err != nil {
+		t.Errorf("rule.md not installed in the home directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".arm", "arm.lock")); err != nil {
+		t.Errorf("Expected the global lock file to be written: %v", err)
+	}
+}
+
+func TestInstaller_InstallHardlink(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, "cache")
+	channelDir := filepath.Join(tempDir, ".cursor", "rules")
+	cfg := &config.Config{
+		Channels:    map[string]config.ChannelConfig{"cursor": {Directories: []string{channelDir}}},
+		CacheConfig: &config.CacheConfig{Path: cachePath, LinkMode: "hardlink"},
+	}
+	installer := New(cfg)
+	installer.lockPath = filepath.Join(tempDir, "arm.lock")
+
+	sourceDir, err := os.MkdirTemp(tempDir, "arm-install-")
+	if err != nil {
+		t.Fatalf("Failed to create source temp dir: %v", err)
+	}
+	sourceFile := filepath.Join(sourceDir, "rule.md")
+	if err := os.WriteFile(sourceFile, []byte("# Shared rule"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Two versions with the same content share one blob
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		req := &InstallRequest{Registry: "reg", Ruleset: "rules", Version: version, SourceFiles: []string{sourceFile}}
+		if _, err := installer.Install(context.Background(), req); err != nil {
+			t.Fatalf("Install failed: %v", err)
+		}
+	}
+
+	blobs := cache.NewBlobStore(cachePath)
+	digests, err := blobs.List()
+	if err != nil || len(digests) != 1 {
+		t.Fatalf("Expected one shared blob, got %v (%v)", digests, err)
+	}
+	blobPath, _ := blobs.Path(digests[0])
+	blobInfo, _ := os.Stat(blobPath)
+	installedInfo, err := os.Stat(filepath.Join(channelDir, "arm", "reg", "rules", "2.0.0", "rule.md"))
+	if err != nil {
+		t.Fatalf("Expected installed file, got %v", err)
+	}
+	if !os.SameFile(blobInfo, installedInfo) {
+		t.Error("Expected installed file to be hardlinked to the blob")
+	}
+}
+
 func TestInstaller_Uninstall(t *testing.T) {
 	// Create temporary directory for test
 	tempDir, err := os.MkdirTemp("", "arm-uninstall-test")
@@ -192,6 +330,86 @@ func TestInstaller_ListInstalled(t *testing.T) {
 	}
 }
 
+func TestInstaller_Status(t *testing.T) {
+	tempDir := t.TempDir()
+	cursorDir := filepath.Join(tempDir, ".cursor", "rules")
+	qDir := filepath.Join(tempDir, ".amazonq", "rules")
+
+	cfg := &config.Config{
+		Channels: map[string]config.ChannelConfig{
+			"cursor": {Directories: []string{cursorDir}},
+			"q":      {Directories: []string{qDir}},
+		},
+		Rulesets: map[string]map[string]config.RulesetSpec{
+			"team": {
+				"synced":  {Version: "^1.0.0"},
+				"partial": {Version: "latest"},
+				"drifted": {Version: "^2.0.0"},
+			},
+		},
+		LockFile: &config.LockFile{Rulesets: map[string]map[string]config.LockedRuleset{
+			"team": {
+				"synced":  {Version: "^1.0.0", Resolved: "1.2.0"},
+				"partial": {Version: "latest", Resolved: "abc123"},
+				"drifted": {Version: "^1.0.0", Resolved: "1.0.0"},
+			},
+		}},
+	}
+
+	install := func(dir, ruleset, version string) {
+		versionDir := filepath.Join(dir, "arm", "team", ruleset, version)
+		if err := os.MkdirAll(versionDir, 0o755); err != nil {
+			t.Fatalf("Failed to create test installation: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(versionDir, "rules.md"), []byte("rules"), 0o644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	for _, dir := range []string{cursorDir, qDir} {
+		install(dir, "synced", "^1.0.0")
+		install(dir, "drifted", "^1.0.0")
+	}
+	install(cursorDir, "partial", "latest")
+	install(qDir, "stray", "1.0.0")
+
+	statuses, err := New(cfg).Status(nil)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"drifted": StatusOutOfSync,
+		"partial": StatusMissing,
+		"stray":   StatusExtra,
+		"synced":  "",
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("Expected %d rulesets, got %+v", len(expected), statuses)
+	}
+	for _, status := range statuses {
+		flags := strings.Join(status.Flags, ",")
+		if flags != expected[status.Name] {
+			t.Errorf("Expected %s to be flagged %q, got %q", status.Name, expected[status.Name], flags)
+		}
+	}
+
+	synced := statuses[3]
+	if synced.Name != "synced" || synced.Resolved != "1.2.0" || len(synced.Installed) != 2 || synced.Installed[0].Files != 1 {
+		t.Errorf("Expected synced to be installed in both channels, got %+v", synced)
+	}
+
+	// Filtering by channel only considers that channel's directories
+	statuses, err = New(cfg).Status([]string{"cursor"})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Name == "partial" && len(status.Flags) != 0 {
+			t.Errorf("Expected partial to be complete in cursor, got %v", status.Flags)
+		}
+	}
+}
+
 func TestExpandPath(t *testing.T) {
 	// Test tilde expansion
 	homeDir, _ := os.UserHomeDir()
@@ -218,9 +436,6 @@ func TestInstaller_CleanupPreviousVersion(t *testing.T) {
 	}
 	defer func() { _ = os.RemoveAll(tempDir) }()
 
-	cfg := &config.Config{}
-	installer := New(cfg)
-
 	// Create ruleset directory with multiple versions
 	rulesetDir := filepath.Join(tempDir, "test-ruleset")
 	if err := os.MkdirAll(filepath.Join(rulesetDir, "1.0.0"), 0o755); err != nil {
@@ -242,7 +457,7 @@ func TestInstaller_CleanupPreviousVersion(t *testing.T) {
 	}
 
 	// Cleanup previous versions, keeping only 2.0.0
-	installer.cleanupPreviousVersion(rulesetDir, "2.0.0")
+	cleanupPreviousVersion(rulesetDir, "2.0.0")
 
 	// Verify only 2.0.0 remains
 	entries, err := os.ReadDir(rulesetDir)
@@ -331,6 +546,42 @@ func TestInstaller_LockFileManagement(t *testing.T) {
 	}
 }
 
+func TestInstaller_WorkspaceLockFile(t *testing.T) {
+	tempDir := t.TempDir()
+	lockPath := filepath.Join(tempDir, "arm.lock")
+
+	root := New(&config.Config{Registries: map[string]string{"reg": "https://github.com/test/repo"}})
+	root.lockPath = lockPath
+	member := New(&config.Config{Registries: map[string]string{"reg": "https://github.com/test/repo"}, Workspace: "packages/web"})
+	member.lockPath = lockPath
+
+	// Members share the root lock file, each in a section of its own
+	if err := root.updateLockFile("reg", "rules", "1.0.0", "1.0.0"); err != nil {
+		t.Fatalf("Failed to update lock file: %v", err)
+	}
+	if err := member.updateLockFile("reg", "rules", "^2.0.0", "2.1.0"); err != nil {
+		t.Fatalf("Failed to update member lock file: %v", err)
+	}
+	lockFile, err := root.GetLockFile()
+	if err != nil {
+		t.Fatalf("Failed to load lock file: %v", err)
+	}
+	if lockFile.Rulesets["reg"]["rules"].Resolved != "1.0.0" {
+		t.Errorf("Expected the root entry to stay at 1.0.0, got %+v", lockFile.Rulesets)
+	}
+	if got := lockFile.Member("packages/web").Rulesets["reg"]["rules"].Resolved; got != "2.1.0" {
+		t.Errorf("Expected the member entry at 2.1.0, got %q", got)
+	}
+
+	if err := member.removeLockEntry("reg", "rules"); err != nil {
+		t.Fatalf("Failed to remove member lock entry: %v", err)
+	}
+	lockFile, _ = root.GetLockFile()
+	if len(lockFile.Workspaces) != 0 || lockFile.Rulesets["reg"]["rules"].Resolved != "1.0.0" {
+		t.Errorf("Expected only the member entry removed, got %+v", lockFile)
+	}
+}
+
 func TestInstaller_SyncLockFile(t *testing.T) {
 	// Create temporary directory for test
 	tempDir, err := os.MkdirTemp("", "arm-sync-test")
@@ -2,11 +2,17 @@ package install
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 // InstallOrchestrator coordinates parallel installation operations
@@ -29,7 +35,7 @@ type MultiInstallResult struct {
 	Total      int
 }
 
-// InstallError represents a failed installation
+// InstallError represents a failed installation or task
 type InstallError struct {
 	Registry string
 	Ruleset  string
@@ -62,58 +68,137 @@ func (o *InstallOrchestrator) InstallMultiple(ctx context.Context, req *MultiIns
 		return &MultiInstallResult{Total: 0}, nil
 	}
 
-	// Group requests by registry for concurrency control
-	registryGroups := o.groupByRegistry(req.Requests)
+	result := &MultiInstallResult{Total: len(req.Requests)}
+	var resultMu sync.Mutex
+	tasks := make([]Task, 0, len(req.Requests))
+	for _, request := range req.Requests {
+		request := request
+		tasks = append(tasks, Task{
+			Registry:  request.Registry,
+			Ruleset:   request.Ruleset,
+			Operation: fmt.Sprintf("Installing %s/%s", request.Registry, request.Ruleset),
+			Run: func(ctx context.Context) error {
+				installed, err := o.installer.Install(ctx, &request)
+				if err != nil {
+					return err
+				}
+				resultMu.Lock()
+				result.Successful = append(result.Successful, *installed)
+				resultMu.Unlock()
+				return nil
+			},
+		})
+	}
+
+	result.Failed = o.RunTasks(ctx, tasks, req.Progress)
+	return result, nil
+}
+
+// Task is an operation on a ruleset run by RunTasks
+type Task struct {
+	Registry  string
+	Ruleset   string
+	Operation string // Reported to the progress callback once the task finishes
+	Run       func(ctx context.Context) error
+}
+
+// Name returns the registry/ruleset name progress is reported under
+func (t Task) Name() string {
+	return t.Registry + "/" + t.Ruleset
+}
+
+// RunTasks runs tasks in parallel, limiting each registry to its configured
+// concurrency and rate limit. Tasks that have not started when ctx is canceled
+// fail with the context's error. The failed tasks are returned in task order.
+func (o *InstallOrchestrator) RunTasks(ctx context.Context, tasks []Task, callback ProgressCallback) []InstallError {
+	// Group tasks by registry for concurrency control
+	groups := o.groupByRegistry(tasks)
 
 	// Initialize rate limiters for each registry
-	o.initRateLimiters(registryGroups)
+	o.initRateLimiters(groups)
 
-	// Create result channels
-	resultChan := make(chan InstallResult, len(req.Requests))
-	errorChan := make(chan InstallError, len(req.Requests))
+	for _, task := range tasks {
+		progress.Report(progress.WithTask(ctx, task.Name()), progress.Queued, "")
+	}
 
-	var wg sync.WaitGroup
+	errs := make([]error, len(tasks))
 	completed := 0
-	total := len(req.Requests)
 	var progressMu sync.Mutex
+	var wg sync.WaitGroup
 
 	// Process each registry group in parallel
-	for registry, requests := range registryGroups {
+	for registry, indexes := range groups {
 		wg.Add(1)
-		go func(reg string, reqs []InstallRequest) {
+		go func(reg string, indexes []int) {
 			defer wg.Done()
-			o.processRegistryGroup(reg, reqs, resultChan, errorChan, req.Progress, &completed, total, &progressMu)
-		}(registry, requests)
+			o.processRegistryGroup(ctx, reg, indexes, func(i int) {
+				errs[i] = o.runTask(ctx, tasks[i])
+
+				// Report progress (serialized, so callbacks need no locking)
+				progressMu.Lock()
+				defer progressMu.Unlock()
+				completed++
+				if callback != nil {
+					callback(completed, len(tasks), tasks[i].Operation)
+				}
+			})
+		}(registry, indexes)
 	}
 
 	// Wait for all operations to complete
 	wg.Wait()
-	close(resultChan)
-	close(errorChan)
 
-	// Collect results
-	result := &MultiInstallResult{Total: total}
-	for res := range resultChan {
-		result.Successful = append(result.Successful, res)
+	var failed []InstallError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, InstallError{Registry: tasks[i].Registry, Ruleset: tasks[i].Ruleset, Error: err})
+		}
 	}
-	for err := range errorChan {
-		result.Failed = append(result.Failed, err)
+	return failed
+}
+
+// runTask runs a task once its registry's rate limit allows, unless ctx is canceled
+func (o *InstallOrchestrator) runTask(ctx context.Context, task Task) error {
+	return progress.Track(ctx, task.Name(), func(ctx context.Context) error {
+		if err := o.waitForRateLimit(ctx, task.Registry); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return WithOperationTimeout(ctx, o.installer.config, task.Registry, task.Run)
+	})
+}
+
+// WithOperationTimeout runs an operation against a registry, canceling it once
+// the registry's operationTimeout has passed
+func WithOperationTimeout(ctx context.Context, cfg *config.Config, registry string, run func(ctx context.Context) error) error {
+	timeout := cfg.OperationTimeout(registry)
+	if timeout <= 0 {
+		return run(ctx)
 	}
 
-	return result, nil
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := run(opCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+		return armerr.Errorf(armerr.Network, "operation on registry '%s' timed out after %s: %w", registry, timeout, err).
+			WithHint(fmt.Sprintf("Raise operationTimeout in [registries.%s] or [network] of .armrc", registry))
+	}
+	return err
 }
 
-// groupByRegistry groups install requests by registry
-func (o *InstallOrchestrator) groupByRegistry(requests []InstallRequest) map[string][]InstallRequest {
-	groups := make(map[string][]InstallRequest)
-	for _, req := range requests {
-		groups[req.Registry] = append(groups[req.Registry], req)
+// groupByRegistry groups the indexes of tasks by registry
+func (o *InstallOrchestrator) groupByRegistry(tasks []Task) map[string][]int {
+	groups := make(map[string][]int)
+	for i, task := range tasks {
+		groups[task.Registry] = append(groups[task.Registry], i)
 	}
 	return groups
 }
 
 // initRateLimiters initializes rate limiters for registries
-func (o *InstallOrchestrator) initRateLimiters(registryGroups map[string][]InstallRequest) {
+func (o *InstallOrchestrator) initRateLimiters(registryGroups map[string][]int) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
@@ -140,22 +225,8 @@ func (o *InstallOrchestrator) createRateLimiter(registry string) *TokenBucket {
 
 // getRateLimit gets rate limit for a registry
 func (o *InstallOrchestrator) getRateLimit(registry string) string {
-	// Check registry-specific config
-	if registryConfig := o.installer.config.RegistryConfigs[registry]; registryConfig != nil {
-		if rateLimit := registryConfig["rateLimit"]; rateLimit != "" {
-			return rateLimit
-		}
-	}
-
-	// Check type defaults
-	if registryConfig := o.installer.config.RegistryConfigs[registry]; registryConfig != nil {
-		if registryType := registryConfig["type"]; registryType != "" {
-			if typeDefaults := o.installer.config.TypeDefaults[registryType]; typeDefaults != nil {
-				if rateLimit := typeDefaults["rateLimit"]; rateLimit != "" {
-					return rateLimit
-				}
-			}
-		}
+	if rateLimit := o.installer.config.RegistrySettings(registry)["rateLimit"]; rateLimit != "" {
+		return rateLimit
 	}
 
 	// Default rate limit
@@ -190,10 +261,8 @@ func (o *InstallOrchestrator) parseRateLimit(rateLimit string) (int, time.Durati
 	return capacity, refillRate
 }
 
-// processRegistryGroup processes requests for a single registry with concurrency control
-func (o *InstallOrchestrator) processRegistryGroup(registry string, requests []InstallRequest,
-	resultChan chan<- InstallResult, errorChan chan<- InstallError, progress ProgressCallback, completed *int, total int, progressMu *sync.Mutex) {
-
+// processRegistryGroup runs the tasks of a single registry with concurrency control
+func (o *InstallOrchestrator) processRegistryGroup(ctx context.Context, registry string, indexes []int, run func(i int)) {
 	// Get concurrency limit for this registry
 	concurrency := o.getConcurrency(registry)
 
@@ -201,39 +270,19 @@ func (o *InstallOrchestrator) processRegistryGroup(registry string, requests []I
 	sem := make(chan struct{}, concurrency)
 	var wg sync.WaitGroup
 
-	for _, req := range requests {
+	for _, i := range indexes {
 		wg.Add(1)
-		go func(request InstallRequest) {
+		go func(i int) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			// Wait for rate limit
-			o.waitForRateLimit(registry)
-
-			// Report progress (thread-safe)
-			progressMu.Lock()
-			*completed++
-			currentCompleted := *completed
-			progressMu.Unlock()
-			if progress != nil {
-				progress(currentCompleted, total, fmt.Sprintf("Installing %s/%s", request.Registry, request.Ruleset))
-			}
-
-			// Perform installation
-			result, err := o.installer.Install(&request)
-			if err != nil {
-				errorChan <- InstallError{
-					Registry: request.Registry,
-					Ruleset:  request.Ruleset,
-					Error:    err,
-				}
-			} else {
-				resultChan <- *result
+			// Acquire semaphore; canceled tasks fail without waiting for a slot
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
 			}
-		}(req)
+			run(i)
+		}(i)
 	}
 
 	wg.Wait()
@@ -241,45 +290,34 @@ func (o *InstallOrchestrator) processRegistryGroup(registry string, requests []I
 
 // getConcurrency gets concurrency limit for a registry
 func (o *InstallOrchestrator) getConcurrency(registry string) int {
-	// Check registry-specific config
-	if registryConfig := o.installer.config.RegistryConfigs[registry]; registryConfig != nil {
-		if concurrencyStr := registryConfig["concurrency"]; concurrencyStr != "" {
-			if concurrency, err := strconv.Atoi(concurrencyStr); err == nil {
-				return concurrency
-			}
-		}
-	}
-
-	// Check type defaults
-	if registryConfig := o.installer.config.RegistryConfigs[registry]; registryConfig != nil {
-		if registryType := registryConfig["type"]; registryType != "" {
-			if typeDefaults := o.installer.config.TypeDefaults[registryType]; typeDefaults != nil {
-				if concurrencyStr := typeDefaults["concurrency"]; concurrencyStr != "" {
-					if concurrency, err := strconv.Atoi(concurrencyStr); err == nil {
-						return concurrency
-					}
-				}
-			}
-		}
+	if concurrency, err := strconv.Atoi(o.installer.config.RegistrySettings(registry)["concurrency"]); err == nil && concurrency > 0 {
+		return concurrency
 	}
 
 	// Default concurrency
 	return 1
 }
 
-// waitForRateLimit waits for rate limit token
-func (o *InstallOrchestrator) waitForRateLimit(registry string) {
+// waitForRateLimit waits for rate limit token, or until ctx is canceled
+func (o *InstallOrchestrator) waitForRateLimit(ctx context.Context, registry string) error {
 	o.mu.RLock()
 	bucket := o.rateLimiters[registry]
 	o.mu.RUnlock()
 
-	if bucket == nil {
-		return
+	if bucket == nil || bucket.TakeToken() {
+		return nil
 	}
-
+	defer logger.Timer("Rate limit wait for %s", registry)()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 	for !bucket.TakeToken() {
-		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+	return nil
 }
 
 // TakeToken attempts to take a token from the bucket
@@ -2,6 +2,7 @@ package install
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 func TestInstallOrchestrator_InstallMultiple(t *testing.T) {
@@ -186,14 +189,14 @@ func TestInstallOrchestrator_ParseRateLimit(t *testing.T) {
 func TestInstallOrchestrator_GroupByRegistry(t *testing.T) {
 	orchestrator := &InstallOrchestrator{}
 
-	requests := []InstallRequest{
+	tasks := []Task{
 		{Registry: "registry1", Ruleset: "ruleset1"},
 		{Registry: "registry2", Ruleset: "ruleset2"},
 		{Registry: "registry1", Ruleset: "ruleset3"},
 		{Registry: "registry3", Ruleset: "ruleset4"},
 	}
 
-	groups := orchestrator.groupByRegistry(requests)
+	groups := orchestrator.groupByRegistry(tasks)
 
 	if len(groups) != 3 {
 		t.Errorf("Expected 3 registry groups, got %d", len(groups))
@@ -348,3 +351,158 @@ func TestInstallOrchestrator_ErrorHandling(t *testing.T) {
 		t.Errorf("Expected 0 successful installations, got %d", len(result.Successful))
 	}
 }
+
+func TestInstallOrchestrator_RunTasks(t *testing.T) {
+	cfg := &config.Config{
+		RegistryConfigs: map[string]map[string]string{
+			"registry1": {"concurrency": "2", "rateLimit": "100/second"},
+			"registry2": {"concurrency": "1", "rateLimit": "100/second"},
+		},
+	}
+	orchestrator := NewInstallOrchestrator(New(cfg))
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	peak := map[string]int{}
+	var tasks []Task
+	for i := 0; i < 6; i++ {
+		registry := fmt.Sprintf("registry%d", i%2+1)
+		ruleset := fmt.Sprintf("ruleset%d", i)
+		tasks = append(tasks, Task{
+			Registry:  registry,
+			Ruleset:   ruleset,
+			Operation: "Ran " + ruleset,
+			Run: func(ctx context.Context) error {
+				mu.Lock()
+				running[registry]++
+				if running[registry] > peak[registry] {
+					peak[registry] = running[registry]
+				}
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				running[registry]--
+				mu.Unlock()
+				if ruleset == "ruleset1" || ruleset == "ruleset4" {
+					return fmt.Errorf("%s failed", ruleset)
+				}
+				return nil
+			},
+		})
+	}
+
+	var progressCalls []int
+	failures := orchestrator.RunTasks(context.Background(), tasks, func(current, total int, operation string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if total != len(tasks) {
+			t.Errorf("Expected total %d, got %d", len(tasks), total)
+		}
+		progressCalls = append(progressCalls, current)
+	})
+
+	if len(failures) != 2 || failures[0].Ruleset != "ruleset1" || failures[1].Ruleset != "ruleset4" {
+		t.Fatalf("Expected failures of ruleset1 and ruleset4 in task order, got %+v", failures)
+	}
+	if len(progressCalls) != len(tasks) || progressCalls[len(progressCalls)-1] != len(tasks) {
+		t.Errorf("Expected a progress report per task, got %v", progressCalls)
+	}
+	if peak["registry1"] > 2 || peak["registry2"] > 1 {
+		t.Errorf("Registry concurrency exceeded: %v", peak)
+	}
+}
+
+// stateRecorder records the progress states reported for each task
+type stateRecorder struct {
+	mu     sync.Mutex
+	states map[string][]progress.State
+}
+
+func (r *stateRecorder) Report(e progress.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[e.Task] = append(r.states[e.Task], e.State)
+}
+
+func TestInstallOrchestrator_RunTasksReportsProgress(t *testing.T) {
+	orchestrator := NewInstallOrchestrator(New(&config.Config{}))
+	rec := &stateRecorder{states: map[string][]progress.State{}}
+	ctx := progress.WithReporter(context.Background(), rec)
+
+	tasks := []Task{
+		{Registry: "reg", Ruleset: "ok", Run: func(ctx context.Context) error {
+			progress.Report(ctx, progress.Installing, "")
+			return nil
+		}},
+		{Registry: "reg", Ruleset: "broken", Run: func(ctx context.Context) error { return errors.New("boom") }},
+	}
+	orchestrator.RunTasks(ctx, tasks, nil)
+
+	want := map[string]string{
+		"reg/ok":     "[queued running installing done]",
+		"reg/broken": "[queued running failed]",
+	}
+	for task, states := range want {
+		if got := fmt.Sprint(rec.states[task]); got != states {
+			t.Errorf("%s states = %s, want %s", task, got, states)
+		}
+	}
+}
+
+func TestInstallOrchestrator_RunTasksCanceled(t *testing.T) {
+	orchestrator := NewInstallOrchestrator(New(&config.Config{
+		RegistryConfigs: map[string]map[string]string{"registry1": {"concurrency": "1"}},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ran int
+	var tasks []Task
+	for i := 0; i < 3; i++ {
+		tasks = append(tasks, Task{
+			Registry: "registry1",
+			Ruleset:  fmt.Sprintf("ruleset%d", i),
+			Run: func(ctx context.Context) error {
+				ran++
+				cancel()
+				return nil
+			},
+		})
+	}
+
+	failures := orchestrator.RunTasks(ctx, tasks, nil)
+	if ran != 1 {
+		t.Errorf("Expected only the first task to run, ran %d", ran)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("Expected the 2 unstarted tasks to fail, got %+v", failures)
+	}
+	for _, failure := range failures {
+		if !errors.Is(failure.Error, context.Canceled) {
+			t.Errorf("Expected %s to fail with context.Canceled, got %v", failure.Ruleset, failure.Error)
+		}
+	}
+}
+
+func TestWithOperationTimeout(t *testing.T) {
+	cfg := &config.Config{RegistryConfigs: map[string]map[string]string{"slow": {"operationTimeout": "10ms"}}}
+
+	err := WithOperationTimeout(context.Background(), cfg, "slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if armerr.KindOf(err) != armerr.Network || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a network error for the timed out operation, got %v", err)
+	}
+
+	// Registries without a timeout run operations with the caller's context
+	err = WithOperationTimeout(context.Background(), cfg, "fast", func(ctx context.Context) error {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			t.Error("Expected no deadline without operationTimeout")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected the operation to succeed, got %v", err)
+	}
+}
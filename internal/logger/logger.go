@@ -0,0 +1,227 @@
+// Package logger prints leveled, optionally colored messages for the CLI and
+// the packages it drives. Status messages go to standard output and are
+// silenced by --quiet; debug messages go to standard error and are shown only
+// with --verbose; errors are always printed to standard error.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Level controls which messages are printed
+type Level int
+
+const (
+	LevelQuiet   Level = iota // Errors only
+	LevelNormal               // Progress, results and warnings
+	LevelVerbose              // Also HTTP requests, git operations, cache activity and timings
+)
+
+// NoColorEnv disables colored output when set to any value
+const NoColorEnv = "NO_COLOR"
+
+// ANSI escape sequences
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorDim    = "\033[2m"
+)
+
+var (
+	mu      sync.Mutex
+	level   = LevelNormal
+	color   bool
+	overlay Overlay
+
+	// outMu keeps messages and overlay redraws from interleaving
+	outMu sync.Mutex
+
+	// output receives status messages in place of standard output when set,
+	// such as standard error in JSON output mode
+	output io.Writer
+
+	// Writers are looked up on every message so that tests can redirect
+	// os.Stdout and os.Stderr
+	stdout = func() io.Writer {
+		mu.Lock()
+		defer mu.Unlock()
+		if output != nil {
+			return output
+		}
+		return os.Stdout
+	}
+	stderr = func() io.Writer { return os.Stderr }
+)
+
+// SetOutput sets where status messages are printed, or restores standard
+// output when nil
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+}
+
+// Configure sets the level and whether colors are used
+func Configure(l Level, useColor bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+	color = useColor
+}
+
+// SetLevel sets the level of printed messages
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// GetLevel returns the current level
+func GetLevel() Level {
+	mu.Lock()
+	defer mu.Unlock()
+	return level
+}
+
+// Verbose reports whether debug messages are printed
+func Verbose() bool {
+	return GetLevel() >= LevelVerbose
+}
+
+// Quiet reports whether only errors are printed
+func Quiet() bool {
+	return GetLevel() <= LevelQuiet
+}
+
+// Overlay is drawn below the messages on a terminal, such as progress bars.
+// It is hidden while a message is printed and shown again afterwards.
+type Overlay interface {
+	Hide()
+	Show()
+}
+
+// SetOverlay sets the overlay printed messages make room for, or removes it when nil
+func SetOverlay(o Overlay) {
+	mu.Lock()
+	defer mu.Unlock()
+	overlay = o
+}
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorSupported reports whether colors should be used for f. Colors are off
+// when disabled explicitly, when NO_COLOR is set, for dumb terminals and when
+// f is not a terminal.
+func ColorSupported(f *os.File, disabled bool) bool {
+	if disabled || os.Getenv(NoColorEnv) != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// style describes how a kind of message is printed
+type style struct {
+	color string
+	label string // Printed before the message
+	glyph string // Printed before the message on color terminals only
+}
+
+var (
+	infoStyle    = style{}
+	successStyle = style{color: colorGreen, glyph: "✓ "}
+	warnStyle    = style{color: colorYellow, label: "Warning: "}
+	errorStyle   = style{color: colorRed, glyph: "✗ "}
+	debugStyle   = style{color: colorDim, label: "debug: "}
+)
+
+// Info prints a status message
+func Info(format string, args ...interface{}) {
+	if GetLevel() >= LevelNormal {
+		write(stdout(), infoStyle, format, args...)
+	}
+}
+
+// Success prints the successful outcome of an operation
+func Success(format string, args ...interface{}) {
+	if GetLevel() >= LevelNormal {
+		write(stdout(), successStyle, format, args...)
+	}
+}
+
+// Warn prints a warning
+func Warn(format string, args ...interface{}) {
+	if GetLevel() >= LevelNormal {
+		write(stdout(), warnStyle, format, args...)
+	}
+}
+
+// Error prints an error regardless of level
+func Error(format string, args ...interface{}) {
+	write(stderr(), errorStyle, format, args...)
+}
+
+// Debug prints a diagnostic message with --verbose
+func Debug(format string, args ...interface{}) {
+	if Verbose() {
+		write(stderr(), debugStyle, format, args...)
+	}
+}
+
+// Timer logs how long an operation took once the returned function is
+// called, e.g. defer logger.Timer("git clone %s", url)()
+func Timer(format string, args ...interface{}) func() {
+	if !Verbose() {
+		return func() {}
+	}
+	start := time.Now()
+	operation := fmt.Sprintf(format, args...)
+	return func() {
+		Debug("%s took %s", operation, formatDuration(time.Since(start)))
+	}
+}
+
+// write prints a message in the given style followed by a newline
+func write(w io.Writer, s style, format string, args ...interface{}) {
+	message := s.label + fmt.Sprintf(format, args...)
+
+	mu.Lock()
+	useColor, o := color, overlay
+	mu.Unlock()
+
+	if useColor && s.color != "" {
+		message = s.color + s.glyph + message + colorReset
+	}
+
+	outMu.Lock()
+	defer outMu.Unlock()
+	if o != nil {
+		o.Hide()
+		defer o.Show()
+	}
+	_, _ = fmt.Fprintln(w, message)
+}
+
+// formatDuration rounds a duration for display
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(10 * time.Millisecond).String()
+	}
+}
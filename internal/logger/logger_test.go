@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// capture redirects both writers while fn runs and returns what was printed
+func capture(t *testing.T, fn func()) (out, errOut string) {
+	t.Helper()
+	var outBuf, errBuf bytes.Buffer
+	originalOut, originalErr := stdout, stderr
+	stdout = func() io.Writer { return &outBuf }
+	stderr = func() io.Writer { return &errBuf }
+	defer func() {
+		stdout, stderr = originalOut, originalErr
+		Configure(LevelNormal, false)
+	}()
+	fn()
+	return outBuf.String(), errBuf.String()
+}
+
+func TestLevels(t *testing.T) {
+	emit := func() {
+		Info("installing %s", "rules")
+		Success("installed %s", "rules")
+		Warn("slow registry")
+		Debug("GET %s", "https://example.com")
+		Error("failed %s", "other")
+	}
+
+	tests := []struct {
+		name   string
+		level  Level
+		out    string
+		errOut string
+	}{
+		{
+			name:   "quiet",
+			level:  LevelQuiet,
+			out:    "",
+			errOut: "failed other\n",
+		},
+		{
+			name:   "normal",
+			level:  LevelNormal,
+			out:    "installing rules\ninstalled rules\nWarning: slow registry\n",
+			errOut: "failed other\n",
+		},
+		{
+			name:   "verbose",
+			level:  LevelVerbose,
+			out:    "installing rules\ninstalled rules\nWarning: slow registry\n",
+			errOut: "debug: GET https://example.com\nfailed other\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut := capture(t, func() {
+				Configure(tt.level, false)
+				emit()
+			})
+			if out != tt.out {
+				t.Errorf("stdout = %q, want %q", out, tt.out)
+			}
+			if errOut != tt.errOut {
+				t.Errorf("stderr = %q, want %q", errOut, tt.errOut)
+			}
+		})
+	}
+}
+
+func TestColor(t *testing.T) {
+	out, errOut := capture(t, func() {
+		Configure(LevelNormal, true)
+		Success("installed")
+		Error("failed")
+	})
+	if out != colorGreen+"✓ installed"+colorReset+"\n" {
+		t.Errorf("Unexpected colored success %q", out)
+	}
+	if errOut != colorRed+"✗ failed"+colorReset+"\n" {
+		t.Errorf("Unexpected colored error %q", errOut)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	_, errOut := capture(t, func() {
+		Configure(LevelNormal, false)
+		Timer("git clone")()
+		Configure(LevelVerbose, false)
+		Timer("git %s", "fetch")()
+	})
+	if strings.Contains(errOut, "clone") {
+		t.Errorf("Expected no timing without --verbose, got %q", errOut)
+	}
+	if !strings.HasPrefix(errOut, "debug: git fetch took ") {
+		t.Errorf("Expected fetch timing, got %q", errOut)
+	}
+}
+
+func TestColorSupported(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	t.Setenv(NoColorEnv, "")
+	if ColorSupported(file, false) {
+		t.Error("Expected no colors for regular files")
+	}
+
+	t.Setenv(NoColorEnv, "1")
+	if ColorSupported(os.Stdout, false) {
+		t.Error("Expected NO_COLOR to disable colors")
+	}
+}
+
+// markerOverlay writes markers to standard output when it is hidden and shown
+type markerOverlay struct{}
+
+func (markerOverlay) Hide() { _, _ = io.WriteString(stdout(), "[hide]") }
+func (markerOverlay) Show() { _, _ = io.WriteString(stdout(), "[show]") }
+
+func TestOverlay(t *testing.T) {
+	SetOverlay(markerOverlay{})
+	defer SetOverlay(nil)
+
+	out, _ := capture(t, func() {
+		Info("installing %s", "rules")
+	})
+	if want := "[hide]installing rules\n[show]"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
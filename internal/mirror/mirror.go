@@ -0,0 +1,485 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+// manifestName is the index file written at the root of each mirrored registry
+const manifestName = "manifest.json"
+
+// tarballName is the archive stored for each mirrored ruleset version
+const tarballName = "ruleset.tar.gz"
+
+// archivesName is the index of how each archive of a mirrored registry was packed
+const archivesName = "archives.json"
+
+// Options controls what is mirrored and where
+type Options struct {
+	Dest       string   // Directory the snapshot is written to
+	All        bool     // Mirror every ruleset and version instead of the project's
+	Registries []string // Restrict mirroring to these registries (all when empty)
+	DryRun     bool     // Resolve versions without downloading
+}
+
+// Entry describes a single mirrored ruleset version
+type Entry struct {
+	Registry  string `json:"registry"`
+	Ruleset   string `json:"ruleset"`
+	Version   string `json:"version"`
+	Integrity string `json:"integrity,omitempty"`
+	Existing  bool   `json:"existing"` // Already present in the snapshot
+}
+
+// Result contains the outcome of a mirror operation
+type Result struct {
+	Entries []Entry
+	Skipped map[string]string // registry or registry/ruleset -> reason
+}
+
+// target is a ruleset version selected for mirroring
+type target struct {
+	registry string
+	ruleset  string
+	version  string
+	patterns []string
+	locked   *config.LockedRuleset
+}
+
+// archiveRecord describes the content an archive was packed with, so a later
+// run reuses the archive only for the same patterns and integrity
+type archiveRecord struct {
+	Patterns  []string `json:"patterns,omitempty"`
+	Integrity string   `json:"integrity"`
+}
+
+// Service snapshots registries into a self-contained directory.
+//
+// Each registry is written to <dest>/<registry> using the layout shared by
+// local and HTTPS registries (<name>/<version>/ruleset.tar.gz plus
+// manifest.json), so the snapshot can be used as either registry type.
+type Service struct {
+	config *config.Config
+}
+
+// New creates a new mirror service
+func New(cfg *config.Config) *Service {
+	return &Service{config: cfg}
+}
+
+// Mirror resolves the selected rulesets and writes them to opts.Dest
+func (s *Service) Mirror(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Dest == "" {
+		return nil, fmt.Errorf("mirror destination is required")
+	}
+	for _, name := range opts.Registries {
+		if _, exists := s.config.Registries[name]; !exists {
+			return nil, fmt.Errorf("registry '%s' not found", name)
+		}
+	}
+
+	result := &Result{Skipped: make(map[string]string)}
+	for _, registryName := range s.registryNames(opts) {
+		if err := s.mirrorRegistry(ctx, registryName, opts, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// registryNames returns the registries to mirror in sorted order
+func (s *Service) registryNames(opts Options) []string {
+	var names []string
+	if len(opts.Registries) > 0 {
+		names = append(names, opts.Registries...)
+	} else if opts.All {
+		for name := range s.config.Registries {
+			names = append(names, name)
+		}
+	} else {
+		seen := make(map[string]bool)
+		for name := range s.config.Rulesets {
+			seen[name] = true
+		}
+		if s.config.LockFile != nil {
+			for name := range s.config.LockFile.Rulesets {
+				seen[name] = true
+			}
+		}
+		for name := range seen {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mirrorRegistry mirrors the selected rulesets of one registry
+func (s *Service) mirrorRegistry(ctx context.Context, registryName string, opts Options, result *Result) error {
+	if _, exists := s.config.Registries[registryName]; !exists {
+		result.Skipped[registryName] = "registry not configured"
+		return nil
+	}
+
+	registryType := s.config.RegistryConfigs[registryName]["type"]
+	if opts.All && isGitType(registryType) {
+		result.Skipped[registryName] = "git registries cannot be enumerated; mirror the project's rulesets instead"
+		return nil
+	}
+
+	reg, err := s.createRegistry(registryName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reg.Close() }()
+
+	var targets []target
+	if opts.All {
+		targets, err = s.allTargets(ctx, reg, registryName)
+	} else {
+		targets, err = s.projectTargets(ctx, reg, registryName)
+	}
+	if err != nil {
+		return err
+	}
+
+	registryDir := filepath.Join(opts.Dest, registryName)
+	archives, err := readArchives(registryDir)
+	if err != nil {
+		return err
+	}
+	var mirrored []Entry
+	for _, t := range targets {
+		if opts.DryRun {
+			result.Entries = append(result.Entries, Entry{Registry: registryName, Ruleset: t.ruleset, Version: t.version})
+			continue
+		}
+
+		entry, err := s.mirrorRuleset(ctx, reg, registryDir, t, archives[archiveKey(t)])
+		if err != nil {
+			return fmt.Errorf("failed to mirror %s/%s@%s: %w", registryName, t.ruleset, t.version, err)
+		}
+		archives[archiveKey(t)] = archiveRecord{Patterns: t.patterns, Integrity: entry.Integrity}
+		mirrored = append(mirrored, *entry)
+		result.Entries = append(result.Entries, *entry)
+	}
+
+	if opts.DryRun || len(mirrored) == 0 {
+		return nil
+	}
+	if err := writeArchives(registryDir, archives); err != nil {
+		return err
+	}
+	return writeManifest(registryDir, mirrored)
+}
+
+// projectTargets selects the rulesets in arm.json and arm.lock, pinned to locked versions
+func (s *Service) projectTargets(ctx context.Context, reg registry.Registry, registryName string) ([]target, error) {
+	specs := make(map[string]config.RulesetSpec)
+	for name, spec := range s.config.Rulesets[registryName] {
+		specs[name] = spec
+	}
+	if s.config.LockFile != nil {
+		for name, locked := range s.config.LockFile.Rulesets[registryName] {
+			if _, exists := specs[name]; !exists {
+				specs[name] = config.RulesetSpec{Version: locked.Version}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	targets := make([]target, 0, len(names))
+	for _, name := range names {
+		spec := specs[name]
+		t := target{registry: registryName, ruleset: name, patterns: spec.Patterns}
+
+		if s.config.LockFile != nil {
+			if locked, exists := s.config.LockFile.Rulesets[registryName][name]; exists && locked.Resolved != "" {
+				t.locked = &locked
+				t.version = locked.Resolved
+			}
+		}
+		if t.version == "" {
+			version, err := s.resolveVersion(ctx, reg, registryName, name, spec.Version)
+			if err != nil {
+				return nil, err
+			}
+			t.version = version
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// allTargets selects every version of every ruleset in a registry
+func (s *Service) allTargets(ctx context.Context, reg registry.Registry, registryName string) ([]target, error) {
+	rulesets, err := reg.GetRulesets(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rulesets in %s: %w", registryName, err)
+	}
+
+	var targets []target
+	for _, ruleset := range rulesets {
+		versions, err := reg.GetVersions(ctx, ruleset.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of %s/%s: %w", registryName, ruleset.Name, err)
+		}
+		for _, version := range versions {
+			if version == "latest" {
+				continue // Placeholder for registries without versioned content
+			}
+			targets = append(targets, target{registry: registryName, ruleset: ruleset.Name, version: version})
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].ruleset != targets[j].ruleset {
+			return targets[i].ruleset < targets[j].ruleset
+		}
+		return targets[i].version < targets[j].version
+	})
+	return targets, nil
+}
+
+// resolveVersion turns a version spec into the concrete version to mirror
+func (s *Service) resolveVersion(ctx context.Context, reg registry.Registry, registryName, name, versionSpec string) (string, error) {
+	if versionSpec == "" {
+		versionSpec = "latest"
+	}
+
+	registryType := s.config.RegistryConfigs[registryName]["type"]
+	if resolver, ok := reg.(registry.VersionSpecResolver); ok && isGitType(registryType) {
+		resolved, err := resolver.ResolveVersion(ctx, versionSpec)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s/%s@%s: %w", registryName, name, versionSpec, err)
+		}
+		return resolved, nil
+	}
+
+	return registry.ResolveVersionSpec(ctx, reg, name, versionSpec), nil
+}
+
+// mirrorRuleset writes one ruleset version to <registryDir>/<name>/<version>/ruleset.tar.gz.
+// An archive from a previous run is reused when record shows it was packed
+// with the same patterns and content; otherwise the version is downloaded again.
+func (s *Service) mirrorRuleset(ctx context.Context, reg registry.Registry, registryDir string, t target, record archiveRecord) (*Entry, error) {
+	entry := &Entry{Registry: t.registry, Ruleset: t.ruleset, Version: t.version}
+	versionDir := filepath.Join(registryDir, t.ruleset, t.version)
+	tarPath := filepath.Join(versionDir, tarballName)
+
+	// Reuse archives from a previous run when they still match their record
+	// and the lock file
+	if _, err := os.Stat(tarPath); err == nil && samePatterns(record.Patterns, t.patterns) {
+		integrity, err := registry.ComputeIntegrity(versionDir)
+		if err != nil {
+			return nil, err
+		}
+		if integrity == record.Integrity && (t.locked == nil || t.locked.Integrity == "" || t.locked.Integrity == integrity) {
+			entry.Integrity = integrity
+			entry.Existing = true
+			return entry, nil
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "arm-mirror-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	downloadDir := filepath.Join(tempDir, "download")
+	if err := os.MkdirAll(downloadDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	if downloader, ok := reg.(registry.ResultDownloader); ok {
+		if _, err := downloader.DownloadRulesetWithResult(ctx, t.ruleset, t.version, downloadDir, t.patterns); err != nil {
+			return nil, err
+		}
+	} else if err := reg.DownloadRulesetWithPatterns(ctx, t.ruleset, t.version, downloadDir, t.patterns); err != nil {
+		return nil, err
+	}
+
+	if entry.Integrity, err = registry.ComputeIntegrity(downloadDir); err != nil {
+		return nil, err
+	}
+	if t.locked != nil && t.locked.Integrity != "" && t.locked.Integrity != entry.Integrity {
+		return nil, fmt.Errorf("%w: expected %s, got %s", registry.ErrIntegrityMismatch, t.locked.Integrity, entry.Integrity)
+	}
+
+	// Tarball registries are copied as-is, loose files are packed
+	staged := filepath.Join(tempDir, tarballName)
+	if isTarballOnly(downloadDir) {
+		err = copyFile(filepath.Join(downloadDir, tarballName), staged)
+	} else {
+		err = registry.CreateTarball(downloadDir, staged)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(versionDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", versionDir, err)
+	}
+	if err := copyFile(staged, tarPath); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// writeManifest merges mirrored versions into <registryDir>/manifest.json
+func writeManifest(registryDir string, entries []Entry) error {
+	manifest := registry.HTTPSManifest{Rulesets: make(map[string][]string)}
+	manifestPath := filepath.Join(registryDir, manifestName)
+
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("failed to parse existing %s: %w", manifestPath, err)
+		}
+		if manifest.Rulesets == nil {
+			manifest.Rulesets = make(map[string][]string)
+		}
+	}
+
+	for _, entry := range entries {
+		versions := manifest.Rulesets[entry.Ruleset]
+		if !contains(versions, entry.Version) {
+			versions = append(versions, entry.Version)
+		}
+		sort.Strings(versions)
+		manifest.Rulesets[entry.Ruleset] = versions
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", manifestName, err)
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
+// readArchives reads <registryDir>/archives.json, keyed by archiveKey
+func readArchives(registryDir string) (map[string]archiveRecord, error) {
+	archives := make(map[string]archiveRecord)
+	path := filepath.Join(registryDir, archivesName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return archives, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &archives); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return archives, nil
+}
+
+// writeArchives writes the archive records to <registryDir>/archives.json
+func writeArchives(registryDir string, archives map[string]archiveRecord) error {
+	data, err := json.MarshalIndent(archives, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", archivesName, err)
+	}
+	path := filepath.Join(registryDir, archivesName)
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// archiveKey identifies the archive of a target in archives.json
+func archiveKey(t target) string {
+	return t.ruleset + "/" + t.version
+}
+
+// samePatterns reports whether two pattern lists select the same files
+func samePatterns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// createRegistry builds the configured registry, including its mirrors
+func (s *Service) createRegistry(registryName string) (registry.Registry, error) {
+	return registry.NewBuilder(s.config, s.cacheManager()).Build(registryName)
+}
+
+// isGitType reports whether a registry type resolves versions to commits
+func isGitType(registryType string) bool {
+	return registryType == "git" || registryType == "git-local"
+}
+
+// isTarballOnly reports whether dir contains nothing but a ruleset.tar.gz
+func isTarballOnly(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) == 1 && entries[0].Name() == tarballName && entries[0].Type().IsRegular()
+}
+
+// copyFile copies src to dst, replacing dst atomically
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return os.Rename(tmp, dst)
+}
+
+// contains reports whether slice contains item
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheManager returns the configured cache, which offline mode serves from
+func (s *Service) cacheManager() cache.Manager {
+	if s.config.CacheConfig == nil {
+		return nil
+	}
+	return cache.NewManager(s.config.CacheConfig.Path)
+}
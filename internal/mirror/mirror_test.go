@@ -0,0 +1,178 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+// newSourceRegistry creates a local registry with the given ruleset versions
+func newSourceRegistry(t *testing.T, versions map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for version, content := range versions {
+		srcDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(srcDir, "rules.md"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write ruleset: %v", err)
+		}
+		if err := registry.CreateTarball(srcDir, filepath.Join(dir, "rules", version, tarballName)); err != nil {
+			t.Fatalf("Failed to create tarball: %v", err)
+		}
+	}
+	return dir
+}
+
+// newTestConfig configures a single local registry named "src"
+func newTestConfig(t *testing.T, sourceDir string) *config.Config {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return &config.Config{
+		Registries:      map[string]string{"src": sourceDir},
+		RegistryConfigs: map[string]map[string]string{"src": {"type": "local"}},
+		Rulesets:        map[string]map[string]config.RulesetSpec{"src": {"rules": {Version: "latest"}}},
+	}
+}
+
+func TestMirror_ProjectRulesets(t *testing.T) {
+	cfg := newTestConfig(t, newSourceRegistry(t, map[string]string{"1.0.0": "old", "1.1.0": "new"}))
+	dest := t.TempDir()
+
+	result, err := New(cfg).Mirror(context.Background(), Options{Dest: dest})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Version != "1.1.0" {
+		t.Fatalf("Expected rules@1.1.0 to be mirrored, got %+v", result.Entries)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "src", manifestName))
+	if err != nil {
+		t.Fatalf("Expected manifest.json, got %v", err)
+	}
+	var manifest registry.HTTPSManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Invalid manifest.json: %v", err)
+	}
+	if got := manifest.Rulesets["rules"]; len(got) != 1 || got[0] != "1.1.0" {
+		t.Errorf("Expected manifest versions [1.1.0], got %v", got)
+	}
+
+	// The snapshot must be usable as a local registry
+	local, err := registry.NewLocalRegistry(&registry.RegistryConfig{Name: "src", Type: "local", URL: filepath.Join(dest, "src")})
+	if err != nil {
+		t.Fatalf("Failed to open snapshot: %v", err)
+	}
+	downloadDir := t.TempDir()
+	if err := local.DownloadRuleset(context.Background(), "rules", "1.1.0", downloadDir); err != nil {
+		t.Fatalf("Failed to download from snapshot: %v", err)
+	}
+	integrity, _ := registry.ComputeIntegrity(downloadDir)
+	if integrity != result.Entries[0].Integrity {
+		t.Errorf("Expected snapshot integrity %s, got %s", result.Entries[0].Integrity, integrity)
+	}
+
+	// A second run reuses the existing archive
+	result, err = New(cfg).Mirror(context.Background(), Options{Dest: dest})
+	if err != nil || len(result.Entries) != 1 || !result.Entries[0].Existing {
+		t.Errorf("Expected existing archive to be reused, got %+v (%v)", result, err)
+	}
+}
+
+func TestMirror_RepacksChangedArchives(t *testing.T) {
+	cfg := newTestConfig(t, newSourceRegistry(t, map[string]string{"1.0.0": "rules"}))
+	dest := t.TempDir()
+
+	first, err := New(cfg).Mirror(context.Background(), Options{Dest: dest})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// An archive whose content no longer matches its record is packed again
+	otherDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(otherDir, "rules.md"), []byte("stale"), 0o644)
+	tarPath := filepath.Join(dest, "src", "rules", "1.0.0", tarballName)
+	if err := registry.CreateTarball(otherDir, tarPath); err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+	result, err := New(cfg).Mirror(context.Background(), Options{Dest: dest})
+	if err != nil || result.Entries[0].Existing || result.Entries[0].Integrity != first.Entries[0].Integrity {
+		t.Errorf("Expected the changed archive to be packed again, got %+v (%v)", result, err)
+	}
+
+	// So is an archive packed with other patterns
+	cfg.Rulesets["src"]["rules"] = config.RulesetSpec{Version: "latest", Patterns: []string{"*.md"}}
+	result, err = New(cfg).Mirror(context.Background(), Options{Dest: dest})
+	if err != nil || result.Entries[0].Existing {
+		t.Errorf("Expected the archive to be packed again for new patterns, got %+v (%v)", result, err)
+	}
+	result, err = New(cfg).Mirror(context.Background(), Options{Dest: dest})
+	if err != nil || !result.Entries[0].Existing {
+		t.Errorf("Expected the archive to be reused for the same patterns, got %+v (%v)", result, err)
+	}
+}
+
+func TestMirror_PinsLockedVersion(t *testing.T) {
+	cfg := newTestConfig(t, newSourceRegistry(t, map[string]string{"1.0.0": "old", "1.1.0": "new"}))
+	cfg.LockFile = &config.LockFile{Rulesets: map[string]map[string]config.LockedRuleset{
+		"src": {"rules": {Version: "latest", Resolved: "1.0.0", Integrity: "sha256-tampered"}},
+	}}
+
+	_, err := New(cfg).Mirror(context.Background(), Options{Dest: t.TempDir()})
+	if !errors.Is(err, registry.ErrIntegrityMismatch) {
+		t.Fatalf("Expected ErrIntegrityMismatch, got %v", err)
+	}
+
+	cfg.LockFile.Rulesets["src"]["rules"] = config.LockedRuleset{Version: "latest", Resolved: "1.0.0"}
+	result, err := New(cfg).Mirror(context.Background(), Options{Dest: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Version != "1.0.0" {
+		t.Errorf("Expected locked version 1.0.0, got %+v", result.Entries)
+	}
+}
+
+func TestMirror_AllVersions(t *testing.T) {
+	cfg := newTestConfig(t, newSourceRegistry(t, map[string]string{"1.0.0": "old", "1.1.0": "new"}))
+	cfg.Registries["repo"] = "https://github.com/org/rules"
+	cfg.RegistryConfigs["repo"] = map[string]string{"type": "git"}
+	dest := t.TempDir()
+
+	result, err := New(cfg).Mirror(context.Background(), Options{Dest: dest, All: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Entries) != 2 {
+		t.Errorf("Expected both versions to be mirrored, got %+v", result.Entries)
+	}
+	if _, skipped := result.Skipped["repo"]; !skipped {
+		t.Errorf("Expected git registry to be skipped, got %v", result.Skipped)
+	}
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		if _, err := os.Stat(filepath.Join(dest, "src", "rules", version, tarballName)); err != nil {
+			t.Errorf("Expected %s to be mirrored: %v", version, err)
+		}
+	}
+}
+
+func TestMirror_DryRun(t *testing.T) {
+	cfg := newTestConfig(t, newSourceRegistry(t, map[string]string{"1.0.0": "old"}))
+	dest := filepath.Join(t.TempDir(), "snapshot")
+
+	result, err := New(cfg).Mirror(context.Background(), Options{Dest: dest, DryRun: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Entries) != 1 {
+		t.Errorf("Expected one planned entry, got %+v", result.Entries)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("Expected dry run not to write the snapshot")
+	}
+}
@@ -0,0 +1,177 @@
+// Package progress reports the progress of long-running operations, such as
+// downloads, clones and the rulesets of a batch install, to a renderer chosen
+// by the CLI. Reporters travel in the context, so code without one, such as
+// tests and library callers, reports nothing.
+package progress
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// State is the stage a task has reached
+type State string
+
+const (
+	Queued      State = "queued"      // Waiting for its registry's concurrency or rate limit
+	Running     State = "running"     // Started
+	Resolving   State = "resolving"   // Resolving the version to install
+	Downloading State = "downloading" // Transferring files or git objects
+	Installing  State = "installing"  // Writing files to the channels
+	Done        State = "done"
+	Failed      State = "failed"
+)
+
+// Finished reports whether a task in state s has ended
+func (s State) Finished() bool {
+	return s == Done || s == Failed
+}
+
+// UnitBytes marks events counting bytes; other counts are items such as git objects
+const UnitBytes = "bytes"
+
+// Event describes the progress of a task, usually a registry/ruleset pair
+type Event struct {
+	Task    string `json:"task"`
+	State   State  `json:"state"`
+	Message string `json:"message,omitempty"`
+	Current int64  `json:"current,omitempty"`
+	Total   int64  `json:"total,omitempty"` // Zero when unknown
+	Unit    string `json:"unit,omitempty"`
+}
+
+// Reporter receives progress events. Reporters must be safe for concurrent use.
+type Reporter interface {
+	Report(e Event)
+}
+
+type reporterKey struct{}
+
+type taskKey struct{}
+
+// WithReporter returns a context whose operations report to r
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+// WithTask returns a context whose progress events are reported for task
+func WithTask(ctx context.Context, task string) context.Context {
+	return context.WithValue(ctx, taskKey{}, task)
+}
+
+// from returns the reporter and task of ctx, if both are set
+func from(ctx context.Context) (Reporter, string, bool) {
+	r, _ := ctx.Value(reporterKey{}).(Reporter)
+	task, _ := ctx.Value(taskKey{}).(string)
+	return r, task, r != nil && task != ""
+}
+
+// Report reports that the task of ctx has reached state
+func Report(ctx context.Context, state State, message string) {
+	if r, task, ok := from(ctx); ok {
+		r.Report(Event{Task: task, State: state, Message: message})
+	}
+}
+
+// Track runs an operation as task, reporting when it starts and how it ends
+func Track(ctx context.Context, task string, run func(ctx context.Context) error) error {
+	ctx = WithTask(ctx, task)
+	Report(ctx, Running, "")
+	err := run(ctx)
+	if err != nil {
+		Report(ctx, Failed, err.Error())
+	} else {
+		Report(ctx, Done, "")
+	}
+	return err
+}
+
+// NewReader returns a reader reporting the bytes read from r as the download
+// of the task of ctx. Total is the expected size, or zero or less when unknown.
+func NewReader(ctx context.Context, r io.Reader, total int64) io.Reader {
+	reporter, task, ok := from(ctx)
+	if !ok {
+		return r
+	}
+	if total < 0 {
+		total = 0
+	}
+	return &reader{r: r, reporter: reporter, task: task, total: total}
+}
+
+// reader counts the bytes read through it
+type reader struct {
+	r        io.Reader
+	reporter Reporter
+	task     string
+	total    int64
+	current  int64
+}
+
+// Read reads from the underlying reader and reports the bytes read so far
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.current += int64(n)
+		r.reporter.Report(Event{Task: r.task, State: Downloading, Current: r.current, Total: r.total, Unit: UnitBytes})
+	}
+	return n, err
+}
+
+// Writer returns a writer turning the progress messages of a git server, as
+// written to go-git's Progress option, into download events for the task of
+// ctx. It returns nil without a reporter so that go-git skips progress.
+func Writer(ctx context.Context) io.Writer {
+	reporter, task, ok := from(ctx)
+	if !ok {
+		return nil
+	}
+	return &writer{reporter: reporter, task: task}
+}
+
+// writer splits git progress output into lines, which servers overwrite in
+// place with carriage returns
+type writer struct {
+	mu       sync.Mutex
+	reporter Reporter
+	task     string
+	pending  []byte
+}
+
+// gitCount matches the counts of git progress lines such as
+// "Receiving objects:  45% (9/20)"
+var gitCount = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+// Write reports every complete line of p
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for {
+		end := bytes.IndexAny(w.pending, "\r\n")
+		if end < 0 {
+			break
+		}
+		line := string(bytes.TrimSpace(w.pending[:end]))
+		w.pending = w.pending[end+1:]
+		if line != "" {
+			w.reporter.Report(gitEvent(w.task, line))
+		}
+	}
+	return len(p), nil
+}
+
+// gitEvent describes a git progress line
+func gitEvent(task, line string) Event {
+	e := Event{Task: task, State: Downloading, Message: line}
+	if match := gitCount.FindStringSubmatch(line); match != nil {
+		e.Current, _ = strconv.ParseInt(match[1], 10, 64)
+		e.Total, _ = strconv.ParseInt(match[2], 10, 64)
+	}
+	return e
+}
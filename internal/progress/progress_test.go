@@ -0,0 +1,195 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects reported events
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestReportWithoutReporter(t *testing.T) {
+	ctx := WithTask(context.Background(), "default/rules")
+	Report(ctx, Running, "") // Must not panic
+
+	if w := Writer(ctx); w != nil {
+		t.Errorf("Writer() = %v, want nil without a reporter", w)
+	}
+	src := strings.NewReader("data")
+	if r := NewReader(ctx, src, 4); r != io.Reader(src) {
+		t.Error("NewReader() should return the reader unchanged without a reporter")
+	}
+}
+
+func TestTrack(t *testing.T) {
+	rec := &recorder{}
+	ctx := WithReporter(context.Background(), rec)
+
+	_ = Track(ctx, "default/ok", func(ctx context.Context) error {
+		Report(ctx, Installing, "")
+		return nil
+	})
+	err := Track(ctx, "default/broken", func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("Track() error = %v, want the operation's error", err)
+	}
+
+	var got []string
+	for _, e := range rec.events {
+		got = append(got, fmt.Sprintf("%s:%s:%s", e.Task, e.State, e.Message))
+	}
+	want := "default/ok:running:,default/ok:installing:,default/ok:done:,default/broken:running:,default/broken:failed:boom"
+	if strings.Join(got, ",") != want {
+		t.Errorf("events = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	rec := &recorder{}
+	ctx := WithTask(WithReporter(context.Background(), rec), "default/rules")
+
+	data, err := io.ReadAll(NewReader(ctx, strings.NewReader(strings.Repeat("x", 10)), 10))
+	if err != nil || len(data) != 10 {
+		t.Fatalf("ReadAll() = %d bytes, %v", len(data), err)
+	}
+
+	last := rec.events[len(rec.events)-1]
+	if last.State != Downloading || last.Current != 10 || last.Total != 10 || last.Unit != UnitBytes {
+		t.Errorf("last event = %+v, want 10 of 10 bytes downloaded", last)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	rec := &recorder{}
+	ctx := WithTask(WithReporter(context.Background(), rec), "default/rules")
+
+	w := Writer(ctx)
+	// Servers overwrite lines in place and may split them across writes
+	_, _ = io.WriteString(w, "Counting objects: 50% (1/2)\rCounting obj")
+	_, _ = io.WriteString(w, "ects: 100% (2/2), done.\n")
+
+	if len(rec.events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(rec.events), rec.events)
+	}
+	first, second := rec.events[0], rec.events[1]
+	if first.Message != "Counting objects: 50% (1/2)" || first.Current != 1 || first.Total != 2 {
+		t.Errorf("first event = %+v", first)
+	}
+	if second.Message != "Counting objects: 100% (2/2), done." || second.Current != 2 {
+		t.Errorf("second event = %+v", second)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal(&out, 80)
+	term.interval = 0
+
+	term.Report(Event{Task: "default/a", State: Queued})
+	term.Report(Event{Task: "default/b", State: Queued})
+	term.Report(Event{Task: "default/a", State: Downloading, Current: 512, Total: 1024, Unit: UnitBytes})
+
+	want := "  default/a  downloading  [==========          ]  512 B / 1.0 KiB\n0/2 done\n"
+	if got := lastFrame(out.String()); got != want {
+		t.Errorf("frame = %q, want %q", got, want)
+	}
+
+	term.Report(Event{Task: "default/a", State: Done})
+	if got := lastFrame(out.String()); got != "1/2 done\n" {
+		t.Errorf("frame after finishing = %q", got)
+	}
+
+	term.Close()
+	if !strings.HasSuffix(out.String(), "\033[1A\033[J") {
+		t.Errorf("Close() should erase the status lines, output ends %q", out.String())
+	}
+}
+
+func TestTerminalHide(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal(&out, 20)
+	term.Report(Event{Task: "default/a-long-ruleset-name", State: Running})
+
+	if got := lastFrame(out.String()); got != "  default/a-long-ru\n" {
+		t.Errorf("frame = %q, want a line truncated to the width", got)
+	}
+
+	term.Hide()
+	out.Reset()
+	term.Report(Event{Task: "default/a-long-ruleset-name", State: Installing})
+	if out.Len() != 0 {
+		t.Errorf("hidden terminal drew %q", out.String())
+	}
+	term.Show()
+	if out.String() != "  default/a-long-ru\n" {
+		t.Errorf("Show() drew %q", out.String())
+	}
+}
+
+// lastFrame returns the lines drawn after the last erase sequence
+func lastFrame(output string) string {
+	if i := strings.LastIndex(output, "\033[J"); i >= 0 {
+		return output[i+len("\033[J"):]
+	}
+	return output
+}
+
+func TestLog(t *testing.T) {
+	var lines []string
+	now := time.Unix(0, 0)
+	log := NewLog(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}, 2*time.Second)
+	log.now = func() time.Time { return now }
+
+	download := func(current int64) {
+		log.Report(Event{Task: "default/rules", State: Downloading, Current: current, Total: 4096, Unit: UnitBytes})
+	}
+	download(1024)
+	now = now.Add(time.Second)
+	download(2048)
+	now = now.Add(time.Second)
+	download(3072)
+
+	if len(lines) != 1 || lines[0] != "default/rules: downloading 3.0 KiB / 4.0 KiB" {
+		t.Errorf("lines = %q, want one line after the interval", lines)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var out bytes.Buffer
+	j := NewJSON(&out, time.Hour)
+
+	j.Report(Event{Task: "default/rules", State: Running})
+	j.Report(Event{Task: "default/rules", State: Downloading, Current: 1, Total: 3, Unit: UnitBytes})
+	j.Report(Event{Task: "default/rules", State: Downloading, Current: 2, Total: 3, Unit: UnitBytes}) // Throttled
+	j.Report(Event{Task: "default/rules", State: Downloading, Current: 3, Total: 3, Unit: UnitBytes})
+	j.Report(Event{Task: "default/rules", State: Done})
+
+	want := `{"type":"progress","task":"default/rules","state":"running"}
+{"type":"progress","task":"default/rules","state":"downloading","current":1,"total":3,"unit":"bytes"}
+{"type":"progress","task":"default/rules","state":"downloading","current":3,"total":3,"unit":"bytes"}
+{"type":"progress","task":"default/rules","state":"done"}
+`
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,283 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Renderer displays progress events until it is closed
+type Renderer interface {
+	Reporter
+	Close()
+}
+
+// Terminal draws the running tasks as live status lines below the regular
+// output of a terminal, followed by a count of finished tasks. It
+// implements logger.Overlay so that log messages are printed above the lines.
+type Terminal struct {
+	mu       sync.Mutex
+	w        io.Writer
+	width    int
+	interval time.Duration // Minimum time between redraws for byte counts
+	now      func() time.Time
+
+	active   map[string]Event
+	order    []string // Active tasks in the order they were first reported
+	total    int
+	finished int
+	drawn    int // Lines currently on screen
+	hidden   bool
+	closed   bool
+	lastDraw time.Time
+}
+
+// NewTerminal returns a renderer drawing on w, a terminal width columns wide
+func NewTerminal(w io.Writer, width int) *Terminal {
+	return &Terminal{w: w, width: width, interval: 100 * time.Millisecond, now: time.Now, active: make(map[string]Event)}
+}
+
+// Report updates the status line of the event's task
+func (t *Terminal) Report(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, known := t.active[e.Task]
+	switch {
+	case e.State.Finished():
+		if known {
+			delete(t.active, e.Task)
+			t.removeFromOrder(e.Task)
+		} else {
+			t.total++
+		}
+		t.finished++
+	case !known:
+		t.total++
+		t.active[e.Task] = e
+		t.order = append(t.order, e.Task)
+	default:
+		t.active[e.Task] = e
+		// Byte and object counts change too often to redraw for each
+		if e.State == previous.State && t.now().Sub(t.lastDraw) < t.interval {
+			return
+		}
+	}
+	t.draw()
+}
+
+// removeFromOrder drops task from the drawing order
+func (t *Terminal) removeFromOrder(task string) {
+	for i, name := range t.order {
+		if name == task {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// Hide erases the status lines until Show is called
+func (t *Terminal) Hide() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hidden = true
+	t.erase()
+}
+
+// Show draws the status lines again after Hide
+func (t *Terminal) Show() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hidden = false
+	t.draw()
+}
+
+// Close erases the status lines for good
+func (t *Terminal) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.erase()
+	t.closed = true
+}
+
+// draw replaces the status lines on screen with the current ones
+func (t *Terminal) draw() {
+	if t.hidden || t.closed {
+		return
+	}
+	t.erase()
+
+	var lines []string
+	for _, task := range t.order {
+		// Queued tasks only count towards the total
+		if e := t.active[task]; e.State != Queued {
+			lines = append(lines, describeLine(e))
+		}
+	}
+	if t.total > 1 && t.finished < t.total {
+		lines = append(lines, fmt.Sprintf("%d/%d done", t.finished, t.total))
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(truncate(line, t.width-1))
+		b.WriteString("\n")
+	}
+	_, _ = io.WriteString(t.w, b.String())
+	t.drawn = len(lines)
+	t.lastDraw = t.now()
+}
+
+// erase moves the cursor up over the status lines and clears them
+func (t *Terminal) erase() {
+	if t.drawn > 0 {
+		_, _ = fmt.Fprintf(t.w, "\033[%dA\033[J", t.drawn)
+		t.drawn = 0
+	}
+}
+
+// describeLine formats the status line of a task
+func describeLine(e Event) string {
+	parts := []string{e.Task, string(e.State)}
+	if e.Total > 0 {
+		parts = append(parts, bar(e.Current, e.Total, 20))
+	}
+	if detail := describeDetail(e); detail != "" {
+		parts = append(parts, detail)
+	}
+	return "  " + strings.Join(parts, "  ")
+}
+
+// describeDetail formats the message or counts of an event
+func describeDetail(e Event) string {
+	switch {
+	case e.Message != "":
+		return e.Message
+	case e.Total > 0:
+		return formatCount(e.Current, e.Unit) + " / " + formatCount(e.Total, e.Unit)
+	case e.Current > 0:
+		return formatCount(e.Current, e.Unit)
+	}
+	return ""
+}
+
+// bar draws a progress bar width characters wide, plus brackets
+func bar(current, total int64, width int) string {
+	filled := int(current * int64(width) / total)
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+}
+
+// truncate shortens s to at most width characters
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width < 1 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width])
+}
+
+// formatCount formats a count in unit for display
+func formatCount(n int64, unit string) string {
+	if unit != UnitBytes {
+		return fmt.Sprintf("%d", n)
+	}
+	const k = 1024
+	if n < k {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(k), 0
+	for m := n / k; m >= k; m /= k {
+		div *= k
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Log prints the progress of long downloads as periodic log lines, for
+// output that is not a terminal. Tasks finishing within one interval print
+// nothing.
+type Log struct {
+	mu       sync.Mutex
+	logf     func(format string, args ...interface{})
+	interval time.Duration
+	now      func() time.Time
+	last     map[string]time.Time // When each downloading task was last logged
+}
+
+// NewLog returns a renderer printing with logf at most once per interval and task
+func NewLog(logf func(format string, args ...interface{}), interval time.Duration) *Log {
+	return &Log{logf: logf, interval: interval, now: time.Now, last: make(map[string]time.Time)}
+}
+
+// Report logs the event if its task has not been logged for an interval
+func (l *Log) Report(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e.State != Downloading {
+		delete(l.last, e.Task)
+		return
+	}
+	now := l.now()
+	last, ok := l.last[e.Task]
+	if !ok {
+		l.last[e.Task] = now
+		return
+	}
+	if now.Sub(last) < l.interval {
+		return
+	}
+	l.last[e.Task] = now
+	l.logf("%s: %s %s", e.Task, e.State, describeDetail(e))
+}
+
+// Close does nothing; log lines need no cleanup
+func (l *Log) Close() {}
+
+// JSON writes events as JSON lines, with "type" set to "progress". Byte and
+// object counts are written at most once per interval and task.
+type JSON struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	interval time.Duration
+	now      func() time.Time
+	last     map[string]time.Time // When each downloading task was last written
+}
+
+// NewJSON returns a renderer writing to w
+func NewJSON(w io.Writer, interval time.Duration) *JSON {
+	return &JSON{enc: json.NewEncoder(w), interval: interval, now: time.Now, last: make(map[string]time.Time)}
+}
+
+// jsonEvent is a line written by JSON
+type jsonEvent struct {
+	Type string `json:"type"`
+	Event
+}
+
+// Report writes the event unless it is a count written too recently
+func (j *JSON) Report(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if e.State == Downloading {
+		now := j.now()
+		complete := e.Total > 0 && e.Current >= e.Total
+		if last, ok := j.last[e.Task]; ok && now.Sub(last) < j.interval && !complete {
+			return
+		}
+		j.last[e.Task] = now
+	} else {
+		delete(j.last, e.Task)
+	}
+	_ = j.enc.Encode(jsonEvent{Type: "progress", Event: e})
+}
+
+// Close does nothing; every event is written as it is reported
+func (j *JSON) Close() {}
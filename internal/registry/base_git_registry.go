@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 )
 
 // BaseGitRegistry contains shared logic for all Git registry types
@@ -108,7 +110,7 @@ func (b *BaseGitRegistry) FindRulesetByName(rulesets []RulesetInfo, name, versio
 			return &rulesets[i], nil
 		}
 	}
-	return nil, fmt.Errorf("ruleset %s not found", name)
+	return nil, armerr.Errorf(armerr.NotFound, "ruleset %s not found", name)
 }
 
 // DownloadRulesetWithPatterns provides shared download logic with pattern matching
@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+// registryConfigKeys are the settings mapped to RegistryConfig fields or
+// AuthConfig; every other setting is passed on in CustomConfig
+var registryConfigKeys = map[string]bool{
+	"type":                   true,
+	"authToken":              true,
+	"credentialHelper":       true,
+	"username":               true,
+	"password":               true,
+	"apiType":                true,
+	"apiVersion":             true,
+	"region":                 true,
+	"profile":                true,
+	"mirrors":                true,
+	"allowUnverifiedMirrors": true,
+	"concurrency":            true,
+	"rateLimit":              true,
+	"timeout":                true,
+	"offline":                true,
+}
+
+// Builder creates registries from .armrc. Every command should go through a
+// Builder so that all registry settings are honoured the same way: type
+// defaults, credentials, timeouts, concurrency, rate limits, retries, mirrors,
+// offline mode and the local and remote caches.
+type Builder struct {
+	cfg          *config.Config
+	auth         *CredentialAuthProvider
+	cacheManager cache.Manager
+}
+
+// NewBuilder creates a builder for the registries of cfg. The cache manager
+// may be nil, in which case registries are created without a local cache.
+func NewBuilder(cfg *config.Config, cacheManager cache.Manager) *Builder {
+	return &Builder{
+		cfg:          cfg,
+		auth:         NewAuthProviderFromConfig(cfg),
+		cacheManager: cacheManager,
+	}
+}
+
+// CacheManager returns the cache manager registries are created with
+func (b *Builder) CacheManager() cache.Manager {
+	return b.cacheManager
+}
+
+// Config returns the full configuration of a registry, including its
+// credentials and mirrors
+func (b *Builder) Config(name string) (*RegistryConfig, error) {
+	registryConfig, err := b.baseConfig(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mirrorName := range config.ParseMirrors(b.cfg.RegistryConfigs[name]["mirrors"]) {
+		if _, exists := b.cfg.Registries[mirrorName]; !exists {
+			return nil, armerr.Errorf(armerr.Config, "mirror registry '%s' not found", mirrorName)
+		}
+		mirror, err := b.baseConfig(mirrorName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure mirror %s: %w", mirrorName, err)
+		}
+		registryConfig.Mirrors = append(registryConfig.Mirrors, mirror)
+	}
+	return registryConfig, nil
+}
+
+// Build creates the registry with the given name
+func (b *Builder) Build(name string) (Registry, error) {
+	registryConfig, err := b.Config(name)
+	if err != nil {
+		return nil, err
+	}
+	return b.Create(registryConfig)
+}
+
+// Create creates a registry from a configuration returned by Config, with the
+// local cache and, for network registries, the remote cache when configured
+func (b *Builder) Create(registryConfig *RegistryConfig) (Registry, error) {
+	reg, err := CreateRegistryWithCacheConfig(registryConfig, registryConfig.Auth, b.cacheManager, b.cfg.CacheConfig, registryConfig.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry %s: %w", registryConfig.Name, err)
+	}
+	return reg, nil
+}
+
+// baseConfig maps the settings of a registry, without its mirrors
+func (b *Builder) baseConfig(name string) (*RegistryConfig, error) {
+	url, exists := b.cfg.Registries[name]
+	if !exists {
+		return nil, armerr.Errorf(armerr.Config, "registry '%s' not found", name)
+	}
+	settings := b.cfg.RegistrySettings(name)
+
+	auth, err := b.auth.GetCredentials(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials for %s: %w", name, err)
+	}
+
+	registryConfig := &RegistryConfig{
+		Name:        name,
+		Type:        settings["type"],
+		URL:         url,
+		Auth:        auth,
+		RateLimit:   settings["rateLimit"],
+		RetryConfig: RetryConfigFromSettings(settings),
+		Offline:     b.cfg.Offline(),
+	}
+	if allow, err := strconv.ParseBool(settings["allowUnverifiedMirrors"]); err == nil {
+		registryConfig.AllowUnverifiedMirrors = allow
+	}
+	if concurrency, err := strconv.Atoi(settings["concurrency"]); err == nil && concurrency > 0 {
+		registryConfig.Concurrency = concurrency
+	}
+	if timeout, err := parseSecondsOrDuration(settings["timeout"]); err == nil && timeout > 0 {
+		registryConfig.Timeout = timeout
+	}
+
+	for key, value := range settings {
+		if registryConfigKeys[key] || strings.HasPrefix(key, "retry.") {
+			continue
+		}
+		if registryConfig.CustomConfig == nil {
+			registryConfig.CustomConfig = make(map[string]interface{})
+		}
+		registryConfig.CustomConfig[key] = value
+	}
+	return registryConfig, nil
+}
+
+// customString returns a string setting passed on in CustomConfig
+func (c *RegistryConfig) customString(key string) string {
+	value, _ := c.CustomConfig[key].(string)
+	return value
+}
@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+// builderTestConfig returns a configuration exercising every registry setting
+func builderTestConfig() *config.Config {
+	return &config.Config{
+		Registries: map[string]string{
+			"company": "https://github.com/company/rules",
+			"backup":  "https://gitlab.example.com/company/rules",
+			"bucket":  "my-bucket",
+		},
+		RegistryConfigs: map[string]map[string]string{
+			"company": {
+				"type":              "git",
+				"authToken":         "token",
+				"apiType":           "github",
+				"apiVersion":        "2022-11-28",
+				"concurrency":       "4",
+				"mirrors":           "backup",
+				"retry.maxAttempts": "5",
+			},
+			"backup": {"type": "gitlab", "username": "user", "password": "secret"},
+			"bucket": {"type": "s3", "region": "eu-west-1", "profile": "rules", "prefix": "team/rules"},
+		},
+		TypeDefaults: map[string]map[string]string{
+			"git": {"concurrency": "2", "rateLimit": "30/minute"},
+			"s3":  {"rateLimit": "100/hour"},
+		},
+		NetworkConfig: map[string]string{"timeout": "45", "retry.maxAttempts": "2"},
+		CacheConfig:   &config.CacheConfig{},
+	}
+}
+
+func TestBuilderConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	builder := NewBuilder(builderTestConfig(), nil)
+
+	company, err := builder.Config("company")
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	if company.Type != "git" || company.URL != "https://github.com/company/rules" {
+		t.Errorf("Unexpected type or URL: %s %s", company.Type, company.URL)
+	}
+	if company.Concurrency != 4 {
+		t.Errorf("Expected registry concurrency to override the type default, got %d", company.Concurrency)
+	}
+	if company.RateLimit != "30/minute" {
+		t.Errorf("Expected type default rate limit, got %q", company.RateLimit)
+	}
+	if company.Timeout != 45*time.Second {
+		t.Errorf("Expected network timeout, got %s", company.Timeout)
+	}
+	if company.RetryConfig.MaxAttempts != 5 {
+		t.Errorf("Expected registry retry attempts, got %d", company.RetryConfig.MaxAttempts)
+	}
+	if company.Auth.Token != "token" || company.Auth.APIType != "github" || company.Auth.APIVersion != "2022-11-28" {
+		t.Errorf("Unexpected auth %+v", company.Auth)
+	}
+
+	if len(company.Mirrors) != 1 {
+		t.Fatalf("Expected one mirror, got %d", len(company.Mirrors))
+	}
+	mirror := company.Mirrors[0]
+	if mirror.Name != "backup" || mirror.Type != "gitlab" || mirror.Auth.Username != "user" || mirror.Auth.Password != "secret" {
+		t.Errorf("Unexpected mirror %+v with auth %+v", mirror, mirror.Auth)
+	}
+	if mirror.RetryConfig.MaxAttempts != 2 {
+		t.Errorf("Expected mirror to use its own retry settings, got %d", mirror.RetryConfig.MaxAttempts)
+	}
+
+	bucket, err := builder.Config("bucket")
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	if bucket.Auth.Region != "eu-west-1" || bucket.Auth.Profile != "rules" {
+		t.Errorf("Unexpected S3 auth %+v", bucket.Auth)
+	}
+	if bucket.RateLimit != "100/hour" {
+		t.Errorf("Expected s3 rate limit, got %q", bucket.RateLimit)
+	}
+	if prefix := bucket.customString("prefix"); prefix != "team/rules" {
+		t.Errorf("Expected prefix in custom config, got %q", prefix)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := builderTestConfig()
+	cfg.RegistryConfigs["company"]["mirrors"] = "missing"
+	builder := NewBuilder(cfg, nil)
+
+	for _, name := range []string{"unknown", "company"} {
+		if _, err := builder.Build(name); armerr.KindOf(err) != armerr.Config {
+			t.Errorf("Expected a configuration error for %s, got %v", name, err)
+		}
+	}
+}
+
+func TestBuilderOffline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := builderTestConfig()
+	offline := true
+	cfg.Options.Offline = &offline
+
+	reg, err := NewBuilder(cfg, cache.NewManager(t.TempDir())).Build("company")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	defer func() { _ = reg.Close() }()
+
+	if _, ok := reg.(*OfflineRegistry); !ok {
+		t.Errorf("Expected an offline registry, got %T", reg)
+	}
+}
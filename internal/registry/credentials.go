@@ -0,0 +1,551 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+// expirySkew treats credentials as expired slightly before their deadline so
+// that they do not lapse in the middle of a request
+const expirySkew = 30 * time.Second
+
+// credentialsKeyEnv overrides the key file with a passphrase, e.g. in CI
+const credentialsKeyEnv = "ARM_CREDENTIALS_KEY"
+
+// ErrCredentialsExpired is returned when stored credentials have expired
+var ErrCredentialsExpired = armerr.New(armerr.Auth, "credentials expired").
+	WithHint("Log in to the registry again or update its credentialHelper")
+
+// Expired reports whether the credentials carry an expiry that has passed
+func (a *AuthConfig) Expired() bool {
+	if a == nil || a.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(expirySkew).After(a.ExpiresAt)
+}
+
+// hasSecret reports whether the credentials contain anything usable for authentication
+func (a *AuthConfig) hasSecret() bool {
+	return a != nil && (a.Token != "" || a.Password != "")
+}
+
+// CredentialHelper runs an external credential helper using the git-credential protocol.
+//
+// The helper is resolved like git's credential.helper setting: a value starting
+// with "!" is run as a shell command, a path is executed directly and any other
+// name runs "git-credential-<name>" (e.g. osxkeychain, libsecret, manager).
+type CredentialHelper struct {
+	spec string
+}
+
+// NewCredentialHelper creates a credential helper from a credentialHelper setting
+func NewCredentialHelper(spec string) *CredentialHelper {
+	return &CredentialHelper{spec: strings.TrimSpace(spec)}
+}
+
+// Get asks the helper for credentials for the given registry
+func (h *CredentialHelper) Get(ctx context.Context, registryType, registryURL string, current *AuthConfig) (*AuthConfig, error) {
+	attrs := credentialAttributes(registryType, registryURL)
+	if current != nil && current.Username != "" {
+		attrs = append(attrs, "username="+current.Username)
+	}
+
+	output, err := h.run(ctx, "get", attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	values := parseCredentialOutput(output)
+	if values["quit"] == "1" || values["quit"] == "true" {
+		return nil, fmt.Errorf("credential helper %q aborted", h.spec)
+	}
+
+	auth := &AuthConfig{
+		Username: values["username"],
+		Password: values["password"],
+		Token:    values["password"],
+	}
+	if expiry := values["password_expiry_utc"]; expiry != "" {
+		if seconds, err := strconv.ParseInt(expiry, 10, 64); err == nil {
+			auth.ExpiresAt = time.Unix(seconds, 0)
+		}
+	}
+	return auth, nil
+}
+
+// Store hands credentials to the helper for safekeeping
+func (h *CredentialHelper) Store(ctx context.Context, registryType, registryURL string, auth *AuthConfig) error {
+	attrs := credentialAttributes(registryType, registryURL)
+	username := auth.Username
+	if username == "" {
+		username = "token"
+	}
+	password := auth.Password
+	if password == "" {
+		password = auth.Token
+	}
+	attrs = append(attrs, "username="+username, "password="+password)
+	if !auth.ExpiresAt.IsZero() {
+		attrs = append(attrs, "password_expiry_utc="+strconv.FormatInt(auth.ExpiresAt.Unix(), 10))
+	}
+
+	_, err := h.run(ctx, "store", attrs)
+	return err
+}
+
+// Erase asks the helper to forget credentials for the given registry
+func (h *CredentialHelper) Erase(ctx context.Context, registryType, registryURL string) error {
+	_, err := h.run(ctx, "erase", credentialAttributes(registryType, registryURL))
+	return err
+}
+
+// run executes the helper with the given action and attribute lines on stdin
+func (h *CredentialHelper) run(ctx context.Context, action string, attrs []string) ([]byte, error) {
+	if h.spec == "" {
+		return nil, fmt.Errorf("no credential helper configured")
+	}
+
+	cmd := h.command(ctx, action)
+	cmd.Stdin = strings.NewReader(strings.Join(attrs, "\n") + "\n\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %q %s failed: %w: %s", h.spec, action, err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %q %s failed: %w", h.spec, action, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// command builds the process for the helper following git's resolution rules
+func (h *CredentialHelper) command(ctx context.Context, action string) *exec.Cmd {
+	if strings.HasPrefix(h.spec, "!") {
+		script := strings.TrimPrefix(h.spec, "!")
+		return exec.CommandContext(ctx, "sh", "-c", script+` "$@"`, script, action)
+	}
+
+	fields := strings.Fields(h.spec)
+	name := fields[0]
+	if !filepath.IsAbs(name) && !strings.ContainsRune(name, filepath.Separator) {
+		name = "git-credential-" + name
+	}
+	args := append(append([]string{}, fields[1:]...), action)
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// credentialAttributes describes a registry as git-credential attributes
+func credentialAttributes(registryType, registryURL string) []string {
+	if u, err := url.Parse(registryURL); err == nil && u.Scheme != "" && u.Host != "" {
+		attrs := []string{"protocol=" + u.Scheme, "host=" + u.Host}
+		if path := strings.Trim(u.Path, "/"); path != "" {
+			attrs = append(attrs, "path="+path)
+		}
+		return attrs
+	}
+
+	// S3 buckets and local paths have no scheme; key them by registry type
+	return []string{"protocol=" + registryType, "host=" + strings.Trim(registryURL, "/")}
+}
+
+// parseCredentialOutput parses key=value lines written by a credential helper
+func parseCredentialOutput(output []byte) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// CredentialStore keeps registry credentials in an AES-GCM encrypted file
+type CredentialStore struct {
+	path    string
+	keyPath string
+	mu      sync.Mutex
+}
+
+// NewCredentialStore creates a credential store backed by the given files
+func NewCredentialStore(path, keyPath string) *CredentialStore {
+	return &CredentialStore{path: path, keyPath: keyPath}
+}
+
+// NewDefaultCredentialStore creates the credential store under ~/.arm
+func NewDefaultCredentialStore() (*CredentialStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	armDir := filepath.Join(homeDir, ".arm")
+	return NewCredentialStore(filepath.Join(armDir, "credentials.enc"), filepath.Join(armDir, "credentials.key")), nil
+}
+
+// Get returns the stored credentials for a registry, or nil if none are stored
+func (s *CredentialStore) Get(registryName string) (*AuthConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return entries[registryName], nil
+}
+
+// Set stores credentials for a registry
+func (s *CredentialStore) Set(registryName string, auth *AuthConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries[registryName] = auth
+	return s.save(entries)
+}
+
+// Delete removes stored credentials for a registry
+func (s *CredentialStore) Delete(registryName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, exists := entries[registryName]; !exists {
+		return nil
+	}
+	delete(entries, registryName)
+	return s.save(entries)
+}
+
+// List returns the names of registries with stored credentials
+func (s *CredentialStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// load decrypts the credentials file
+func (s *CredentialStore) load() (map[string]*AuthConfig, error) {
+	entries := make(map[string]*AuthConfig)
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("credentials file %s is corrupt", s.path)
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials file (wrong key?): %w", err)
+	}
+
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	return entries, nil
+}
+
+// save encrypts and atomically writes the credentials file
+func (s *CredentialStore) save(entries map[string]*AuthConfig) error {
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, gcm.Seal(nonce, nonce, plaintext, nil), 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// cipher returns the AEAD for the store, creating the key file if requested
+func (s *CredentialStore) cipher(create bool) (cipher.AEAD, error) {
+	key, err := s.key(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// key returns the 256-bit encryption key from the environment or the key file
+func (s *CredentialStore) key(create bool) ([]byte, error) {
+	if passphrase := os.Getenv(credentialsKeyEnv); passphrase != "" {
+		sum := sha256.Sum256([]byte(passphrase))
+		return sum[:], nil
+	}
+
+	key, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("credentials key %s has invalid length", s.keyPath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("failed to read credentials key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate credentials key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := os.WriteFile(s.keyPath, key, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write credentials key: %w", err)
+	}
+	return key, nil
+}
+
+// credentialSource describes where credentials for one registry come from
+type credentialSource struct {
+	registryType string
+	url          string
+	static       *AuthConfig
+	helper       *CredentialHelper
+}
+
+// CredentialAuthProvider resolves credentials from .armrc settings, an external
+// credential helper and the encrypted credential store, in that order
+type CredentialAuthProvider struct {
+	sources map[string]*credentialSource
+	store   *CredentialStore
+	cache   map[string]*AuthConfig
+	offline bool // Offline mode serves from the cache and needs no credentials
+	mu      sync.Mutex
+}
+
+// NewCredentialAuthProvider creates an auth provider backed by the given store (may be nil)
+func NewCredentialAuthProvider(store *CredentialStore) *CredentialAuthProvider {
+	return &CredentialAuthProvider{
+		sources: make(map[string]*credentialSource),
+		store:   store,
+		cache:   make(map[string]*AuthConfig),
+	}
+}
+
+// NewAuthProviderFromConfig creates an auth provider for all configured registries
+func NewAuthProviderFromConfig(cfg *config.Config) *CredentialAuthProvider {
+	store, err := NewDefaultCredentialStore()
+	if err != nil {
+		store = nil
+	}
+	provider := NewCredentialAuthProvider(store)
+	provider.offline = cfg.Offline()
+
+	for name, registryURL := range cfg.Registries {
+		settings := cfg.RegistrySettings(name)
+		provider.AddRegistry(name, settings["type"], registryURL, &AuthConfig{
+			Token:      settings["authToken"],
+			Username:   settings["username"],
+			Password:   settings["password"],
+			Region:     settings["region"],
+			Profile:    settings["profile"],
+			APIType:    settings["apiType"],
+			APIVersion: settings["apiVersion"],
+		}, settings["credentialHelper"])
+	}
+
+	return provider
+}
+
+// AddRegistry registers a registry with its static settings and optional credential helper
+func (p *CredentialAuthProvider) AddRegistry(name, registryType, registryURL string, static *AuthConfig, helper string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	source := &credentialSource{registryType: registryType, url: registryURL, static: static}
+	if helper != "" {
+		source.helper = NewCredentialHelper(helper)
+	}
+	p.sources[name] = source
+	delete(p.cache, name)
+}
+
+// Helper returns the credential helper configured for a registry, if any
+func (p *CredentialAuthProvider) Helper(registryName string) *CredentialHelper {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if source := p.sources[registryName]; source != nil {
+		return source.helper
+	}
+	return nil
+}
+
+// GetCredentials returns credentials for the given registry
+func (p *CredentialAuthProvider) GetCredentials(registryName string) (*AuthConfig, error) {
+	p.mu.Lock()
+	cached := p.cache[registryName]
+	p.mu.Unlock()
+	if cached != nil && !cached.Expired() {
+		return cached, nil
+	}
+	return p.RefreshCredentials(registryName)
+}
+
+// RefreshCredentials re-resolves credentials, asking the helper again when the
+// cached ones have expired
+func (p *CredentialAuthProvider) RefreshCredentials(registryName string) (*AuthConfig, error) {
+	p.mu.Lock()
+	source := p.sources[registryName]
+	previous := p.cache[registryName]
+	delete(p.cache, registryName)
+	p.mu.Unlock()
+
+	if source == nil {
+		return &AuthConfig{}, nil // Return empty auth if not configured
+	}
+
+	auth, err := p.resolve(registryName, source, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.cache[registryName] = auth
+	p.mu.Unlock()
+	return auth, nil
+}
+
+// resolve walks the credential sources for a registry
+func (p *CredentialAuthProvider) resolve(registryName string, source *credentialSource, previous *AuthConfig) (*AuthConfig, error) {
+	base := expandAuthConfig(source.static)
+
+	// Explicit secrets in .armrc or the environment always win, and offline
+	// mode must not run helpers or fail on expired stored credentials
+	if base.hasSecret() || p.offline {
+		return base, nil
+	}
+
+	var stored *AuthConfig
+	if p.store != nil {
+		var err error
+		if stored, err = p.store.Get(registryName); err != nil {
+			return nil, err
+		}
+	}
+
+	if source.helper != nil {
+		current := previous
+		if current == nil {
+			current = stored
+		}
+		// Like git, a failing helper falls through to the next source, and to
+		// no authentication when nothing is stored
+		helperAuth, err := source.helper.Get(context.Background(), source.registryType, source.url, current)
+		if err == nil && helperAuth.hasSecret() && !helperAuth.Expired() {
+			return mergeAuth(base, helperAuth), nil
+		}
+	}
+
+	if stored.hasSecret() {
+		if stored.Expired() {
+			return nil, fmt.Errorf("%w for registry %s; run 'arm login %s'", ErrCredentialsExpired, registryName, registryName)
+		}
+		return mergeAuth(base, stored), nil
+	}
+
+	return base, nil
+}
+
+// expandAuthConfig expands environment variables in auth settings
+func expandAuthConfig(auth *AuthConfig) *AuthConfig {
+	if auth == nil {
+		return &AuthConfig{}
+	}
+	return &AuthConfig{
+		Token:      expandEnvVars(auth.Token),
+		Username:   expandEnvVars(auth.Username),
+		Password:   expandEnvVars(auth.Password),
+		Profile:    expandEnvVars(auth.Profile),
+		Region:     expandEnvVars(auth.Region),
+		APIType:    expandEnvVars(auth.APIType),
+		APIVersion: expandEnvVars(auth.APIVersion),
+		ExpiresAt:  auth.ExpiresAt,
+	}
+}
+
+// mergeAuth combines non-secret settings from base with secrets from creds
+func mergeAuth(base, creds *AuthConfig) *AuthConfig {
+	merged := *base
+	merged.Token = creds.Token
+	merged.Username = creds.Username
+	merged.Password = creds.Password
+	merged.ExpiresAt = creds.ExpiresAt
+	return &merged
+}
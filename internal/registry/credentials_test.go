@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeHelperScript creates a shell credential helper that logs its input and
+// answers "get" with the given output
+func writeHelperScript(t *testing.T, output string) (spec, logPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath = filepath.Join(dir, "helper.log")
+	outputPath := filepath.Join(dir, "helper.out")
+	if err := os.WriteFile(outputPath, []byte(output+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write helper output: %v", err)
+	}
+	script := filepath.Join(dir, "helper.sh")
+	content := fmt.Sprintf(`#!/bin/sh
+echo "action=$1" >> %q
+cat >> %q
+if [ "$1" = "get" ]; then
+cat %q
+fi
+`, logPath, logPath, outputPath)
+	if err := os.WriteFile(script, []byte(content), 0o700); err != nil {
+		t.Fatalf("Failed to write helper: %v", err)
+	}
+	return script, logPath
+}
+
+func TestCredentialHelper_Get(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Unix()
+	spec, logPath := writeHelperScript(t, fmt.Sprintf("username=bot\npassword=secret\npassword_expiry_utc=%d", expiry))
+
+	helper := NewCredentialHelper(spec)
+	auth, err := helper.Get(context.Background(), "git", "https://github.com/org/rules", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if auth.Username != "bot" || auth.Password != "secret" || auth.Token != "secret" {
+		t.Errorf("Unexpected credentials: %+v", auth)
+	}
+	if auth.ExpiresAt.Unix() != expiry {
+		t.Errorf("Expected expiry %d, got %d", expiry, auth.ExpiresAt.Unix())
+	}
+
+	log, _ := os.ReadFile(logPath)
+	for _, want := range []string{"action=get", "protocol=https", "host=github.com", "path=org/rules"} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("Expected helper input to contain %q, got:\n%s", want, log)
+		}
+	}
+}
+
+func TestCredentialHelper_StoreAndErase(t *testing.T) {
+	spec, logPath := writeHelperScript(t, "")
+	helper := NewCredentialHelper("!" + spec)
+
+	err := helper.Store(context.Background(), "s3", "my-bucket", &AuthConfig{Token: "abc"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := helper.Erase(context.Background(), "s3", "my-bucket"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	log, _ := os.ReadFile(logPath)
+	for _, want := range []string{"action=store", "protocol=s3", "host=my-bucket", "username=token", "password=abc", "action=erase"} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("Expected helper input to contain %q, got:\n%s", want, log)
+		}
+	}
+}
+
+func TestCredentialHelper_Command(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"osxkeychain", []string{"git-credential-osxkeychain", "get"}},
+		{"/usr/local/bin/helper --flag", []string{"/usr/local/bin/helper", "--flag", "get"}},
+		{"!pass show arm", []string{"sh", "-c", `pass show arm "$@"`, "pass show arm", "get"}},
+	}
+
+	for _, tt := range tests {
+		cmd := NewCredentialHelper(tt.spec).command(context.Background(), "get")
+		if strings.Join(cmd.Args, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("command(%q) = %v, want %v", tt.spec, cmd.Args, tt.want)
+		}
+	}
+}
+
+func TestCredentialStore_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewCredentialStore(filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key"))
+
+	if err := store.Set("private", &AuthConfig{Token: "s3cret"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	raw, _ := os.ReadFile(filepath.Join(dir, "credentials.enc"))
+	if strings.Contains(string(raw), "s3cret") {
+		t.Error("Expected credentials file to be encrypted")
+	}
+
+	auth, err := store.Get("private")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if auth == nil || auth.Token != "s3cret" {
+		t.Errorf("Expected stored token, got %+v", auth)
+	}
+
+	if err := store.Delete("private"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	names, _ := store.List()
+	if len(names) != 0 {
+		t.Errorf("Expected no stored credentials, got %v", names)
+	}
+}
+
+func TestCredentialStore_WrongKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.enc")
+	store := NewCredentialStore(path, filepath.Join(dir, "credentials.key"))
+	if err := store.Set("private", &AuthConfig{Token: "s3cret"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Setenv(credentialsKeyEnv, "another passphrase")
+	if _, err := store.Get("private"); err == nil {
+		t.Error("Expected decryption with the wrong key to fail")
+	}
+}
+
+func TestCredentialAuthProvider_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	store := NewCredentialStore(filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key"))
+	_ = store.Set("stored", &AuthConfig{Token: "from-store"})
+	_ = store.Set("static", &AuthConfig{Token: "from-store"})
+
+	t.Setenv("ARM_TEST_TOKEN", "from-env")
+	provider := NewCredentialAuthProvider(store)
+	provider.AddRegistry("static", "https", "https://example.com", &AuthConfig{Token: "$ARM_TEST_TOKEN"}, "")
+	provider.AddRegistry("stored", "s3", "bucket", &AuthConfig{Region: "us-east-1"}, "")
+
+	auth, err := provider.GetCredentials("static")
+	if err != nil || auth.Token != "from-env" {
+		t.Errorf("Expected .armrc token to win, got %+v (%v)", auth, err)
+	}
+
+	auth, err = provider.GetCredentials("stored")
+	if err != nil || auth.Token != "from-store" || auth.Region != "us-east-1" {
+		t.Errorf("Expected stored token with region, got %+v (%v)", auth, err)
+	}
+}
+
+func TestCredentialAuthProvider_ExpiredStoredCredentials(t *testing.T) {
+	dir := t.TempDir()
+	store := NewCredentialStore(filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key"))
+	_ = store.Set("expiring", &AuthConfig{Token: "old", ExpiresAt: time.Now().Add(-time.Minute)})
+
+	provider := NewCredentialAuthProvider(store)
+	provider.AddRegistry("expiring", "https", "https://example.com", &AuthConfig{}, "")
+
+	if _, err := provider.GetCredentials("expiring"); !errors.Is(err, ErrCredentialsExpired) {
+		t.Errorf("Expected ErrCredentialsExpired, got %v", err)
+	}
+}
+
+func TestCredentialAuthProvider_RefreshThroughHelper(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Unix()
+	spec, logPath := writeHelperScript(t, fmt.Sprintf("password=fresh\npassword_expiry_utc=%d", expiry))
+
+	dir := t.TempDir()
+	store := NewCredentialStore(filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key"))
+	_ = store.Set("expiring", &AuthConfig{Username: "bot", Token: "old", ExpiresAt: time.Now().Add(-time.Minute)})
+
+	provider := NewCredentialAuthProvider(store)
+	provider.AddRegistry("expiring", "https", "https://example.com", &AuthConfig{}, spec)
+
+	auth, err := provider.RefreshCredentials("expiring")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if auth.Token != "fresh" {
+		t.Errorf("Expected token from the helper, got %q", auth.Token)
+	}
+
+	log, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(log), "username=bot") {
+		t.Errorf("Expected stored username to be passed to helper, got:\n%s", log)
+	}
+}
+
+func TestCredentialAuthProvider_FailingHelperWithoutStoredSecret(t *testing.T) {
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper.sh")
+	if err := os.WriteFile(helper, []byte("#!/bin/sh\nexit 1\n"), 0o700); err != nil {
+		t.Fatalf("Failed to write helper: %v", err)
+	}
+	store := NewCredentialStore(filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key"))
+
+	provider := NewCredentialAuthProvider(store)
+	provider.AddRegistry("public", "https", "https://example.com", &AuthConfig{APIType: "gitlab"}, helper)
+
+	// Like git, a failing helper falls through, here to no authentication
+	auth, err := provider.GetCredentials("public")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if auth.hasSecret() || auth.APIType != "gitlab" {
+		t.Errorf("Expected settings without credentials, got %+v", auth)
+	}
+}
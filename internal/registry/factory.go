@@ -14,10 +14,53 @@ func CreateRegistry(config *RegistryConfig, auth *AuthConfig) (Registry, error)
 
 // CreateRegistryWithCache creates a registry instance with cache manager injection
 func CreateRegistryWithCache(config *RegistryConfig, auth *AuthConfig, cacheManager cache.Manager) (Registry, error) {
+	// Offline mode serves network registries from the cache; mirrors are not needed
+	if config.Offline && SupportsOffline(config.Type) {
+		if err := ValidateRegistryConfig(config); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		return NewOfflineRegistry(config, cacheManager)
+	}
+
+	primary, err := createSingleRegistry(config, auth, cacheManager)
+	if err != nil || len(config.Mirrors) == 0 {
+		return primary, err
+	}
+
+	// Wrap the registry so that its mirrors are tried in order on failure
+	mirrors := make([]Registry, 0, len(config.Mirrors))
+	for _, mirrorConfig := range config.Mirrors {
+		mirrorAuth := mirrorConfig.Auth
+		if mirrorAuth == nil {
+			mirrorAuth = &AuthConfig{}
+		}
+		mirror, err := createSingleRegistry(mirrorConfig, mirrorAuth, cacheManager)
+		if err != nil {
+			_ = primary.Close()
+			for _, created := range mirrors {
+				_ = created.Close()
+			}
+			return nil, fmt.Errorf("failed to create mirror %s: %w", mirrorConfig.Name, err)
+		}
+		mirrors = append(mirrors, mirror)
+	}
+
+	failover := NewFailoverRegistry(primary, mirrors...)
+	failover.allowUnverified = config.AllowUnverifiedMirrors
+	return failover, nil
+}
+
+// createSingleRegistry creates a registry instance without mirrors
+func createSingleRegistry(config *RegistryConfig, auth *AuthConfig, cacheManager cache.Manager) (Registry, error) {
 	if err := ValidateRegistryConfig(config); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	// Keep background maintenance away from the cache this registry uses
+	if cacheManager != nil {
+		cacheManager.MarkInUse(config.Type, config.URL)
+	}
+
 	switch config.Type {
 	case "local":
 		return NewLocalRegistry(config)
@@ -36,16 +79,19 @@ func CreateRegistryWithCache(config *RegistryConfig, auth *AuthConfig, cacheMana
 	}
 }
 
-// CreateRegistryWithCacheConfig creates a registry instance with cache configuration
+// CreateRegistryWithCacheConfig creates a registry instance with cache configuration.
+// Network registries are wrapped to consult the remote cache when one is configured.
+// Cache maintenance runs in the background on the cleanup interval, see
+// cache.DefaultManager.StartMaintenance, rather than on every registry creation.
 func CreateRegistryWithCacheConfig(registryConfig *RegistryConfig, auth *AuthConfig, cacheManager cache.Manager, cacheConfig *config.CacheConfig, registryName string) (Registry, error) {
-	// Perform cache cleanup if cache manager is available
-	if cacheManager != nil && cacheConfig != nil {
-		// Clean up expired entries
-		_ = cacheManager.CleanupExpired(cacheConfig.TTL)
-
-		// Clean up oversized cache
-		_ = cacheManager.CleanupOversized(cacheConfig.MaxSize)
+	reg, err := CreateRegistryWithCache(registryConfig, auth, cacheManager)
+	if err != nil || cacheManager == nil || cacheConfig == nil || cacheConfig.Remote == "" {
+		return reg, err
+	}
+	if registryConfig.Offline || !SupportsOffline(registryConfig.Type) {
+		return reg, nil
 	}
 
-	return CreateRegistryWithCache(registryConfig, auth, cacheManager)
+	client := cache.NewRemoteClient(cacheConfig.Remote, cacheConfig.RemoteToken)
+	return NewRemoteCacheRegistry(reg, registryConfig, cacheManager, client, cacheConfig.RemoteUpload), nil
 }
@@ -0,0 +1,415 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+)
+
+// FailoverRegistry serves requests from a primary registry and falls back to
+// its mirrors, in order, when the primary fails. Content served by a mirror is
+// checked against known integrity hashes, from the lock file or the primary,
+// so that a mirror cannot substitute different rules for a version. Mirror
+// content that cannot be verified is refused unless allowUnverified is set.
+type FailoverRegistry struct {
+	primary         Registry
+	mirrors         []Registry
+	allowUnverified bool
+	expected        map[string]string // integrityKey -> integrity
+	lastSource      map[string]string // name -> registry that served it
+	mu              sync.Mutex
+}
+
+// NewFailoverRegistry creates a registry that tries primary and then each mirror
+func NewFailoverRegistry(primary Registry, mirrors ...Registry) *FailoverRegistry {
+	return &FailoverRegistry{
+		primary:    primary,
+		mirrors:    mirrors,
+		expected:   make(map[string]string),
+		lastSource: make(map[string]string),
+	}
+}
+
+// ExpectIntegrity records the integrity a ruleset version, downloaded with the
+// given patterns, must have when served by a mirror
+func (f *FailoverRegistry) ExpectIntegrity(name, version string, patterns []string, integrity string) {
+	if integrity == "" {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expected[integrityKey(name, version, patterns)] = integrity
+}
+
+// LastSource returns the name of the registry that last served the given ruleset
+func (f *FailoverRegistry) LastSource(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastSource[name]
+}
+
+// Sources returns the primary registry followed by its mirrors
+func (f *FailoverRegistry) Sources() []Registry {
+	return append([]Registry{f.primary}, f.mirrors...)
+}
+
+// GetRulesets returns rulesets from the first available source
+func (f *FailoverRegistry) GetRulesets(ctx context.Context, patterns []string) ([]RulesetInfo, error) {
+	var errs []error
+	for _, source := range f.Sources() {
+		rulesets, err := source.GetRulesets(ctx, patterns)
+		if err == nil {
+			return rulesets, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, f.failure("list rulesets", errs)
+}
+
+// GetRuleset returns ruleset information from the first available source
+func (f *FailoverRegistry) GetRuleset(ctx context.Context, name, version string) (*RulesetInfo, error) {
+	var errs []error
+	for _, source := range f.Sources() {
+		info, err := source.GetRuleset(ctx, name, version)
+		if err == nil {
+			f.recordSource(name, source)
+			return info, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, f.failure("get ruleset "+name, errs)
+}
+
+// GetVersions returns versions from the first available source
+func (f *FailoverRegistry) GetVersions(ctx context.Context, name string) ([]string, error) {
+	var errs []error
+	for _, source := range f.Sources() {
+		versions, err := source.GetVersions(ctx, name)
+		if err == nil {
+			return versions, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, f.failure("list versions of "+name, errs)
+}
+
+// DownloadRuleset downloads a ruleset from the first available source
+func (f *FailoverRegistry) DownloadRuleset(ctx context.Context, name, version, destDir string) error {
+	return f.DownloadRulesetWithPatterns(ctx, name, version, destDir, nil)
+}
+
+// DownloadRulesetWithPatterns downloads a ruleset from the first source that
+// succeeds and, for mirrors, serves content matching the expected integrity
+func (f *FailoverRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, version, destDir string, patterns []string) error {
+	var errs []error
+	for i, source := range f.Sources() {
+		if err := clearDir(destDir); err != nil {
+			return err
+		}
+
+		err := source.DownloadRulesetWithPatterns(ctx, name, version, destDir, patterns)
+		if err == nil {
+			_, err = f.checkIntegrity(i > 0, name, resolvedVersion(ctx, source, name, version), patterns, destDir)
+		}
+		if err == nil {
+			f.recordSource(name, source)
+			return nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	_ = clearDir(destDir)
+	return f.failure("download "+name, errs)
+}
+
+// DownloadRulesetWithResult downloads a ruleset as loose files and reports the
+// resolved version, the serving source and the content integrity
+func (f *FailoverRegistry) DownloadRulesetWithResult(ctx context.Context, name, version, destDir string, patterns []string) (*DownloadResult, error) {
+	var errs []error
+	for i, source := range f.Sources() {
+		if err := clearDir(destDir); err != nil {
+			return nil, err
+		}
+
+		result, err := downloadWithResult(ctx, source, name, version, destDir, patterns)
+		if err == nil {
+			result.Integrity, err = f.checkIntegrity(i > 0, name, result.ResolvedVersion, patterns, destDir)
+		}
+		if err == nil {
+			result.Source = source.GetName()
+			f.recordSource(name, source)
+			return result, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	_ = clearDir(destDir)
+	return nil, f.failure("download "+name, errs)
+}
+
+// ResolveVersion resolves a version spec using the first source able to do so
+func (f *FailoverRegistry) ResolveVersion(ctx context.Context, version string) (string, error) {
+	var errs []error
+	for _, source := range f.Sources() {
+		resolver, ok := source.(VersionSpecResolver)
+		if !ok {
+			continue
+		}
+		resolved, err := resolver.ResolveVersion(ctx, version)
+		if err == nil {
+			return resolved, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return version, nil
+	}
+	return "", f.failure("resolve version "+version, errs)
+}
+
+// CommitLog lists commits using the first source able to do so
+func (f *FailoverRegistry) CommitLog(ctx context.Context, from, to string) ([]Commit, error) {
+	var errs []error
+	for _, source := range f.Sources() {
+		historian, ok := source.(CommitLogger)
+		if !ok {
+			continue
+		}
+		commits, err := historian.CommitLog(ctx, from, to)
+		if err == nil {
+			return commits, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("commit log not supported for registry %s", f.GetName())
+	}
+	return nil, f.failure("list commits "+from+".."+to, errs)
+}
+
+// Search implements the Searcher interface using the first searchable source
+func (f *FailoverRegistry) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	var errs []error
+	for _, source := range f.Sources() {
+		searcher, ok := source.(Searcher)
+		if !ok {
+			continue
+		}
+		results, err := searcher.Search(ctx, query)
+		if err == nil {
+			return results, nil
+		}
+		if errs = append(errs, sourceError(source, err)); ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("search not supported for registry %s", f.GetName())
+	}
+	return nil, f.failure("search", errs)
+}
+
+// GetType returns the primary registry type
+func (f *FailoverRegistry) GetType() string {
+	return f.primary.GetType()
+}
+
+// GetName returns the primary registry name
+func (f *FailoverRegistry) GetName() string {
+	return f.primary.GetName()
+}
+
+// Close closes the primary registry and all mirrors
+func (f *FailoverRegistry) Close() error {
+	var errs []error
+	for _, source := range f.Sources() {
+		if err := source.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkIntegrity computes the integrity of destDir and, for mirrors, compares
+// it with the expected value. Content from the primary becomes the expectation.
+func (f *FailoverRegistry) checkIntegrity(mirror bool, name, version string, patterns []string, destDir string) (string, error) {
+	integrity, err := ComputeIntegrity(destDir)
+	if err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := integrityKey(name, version, patterns)
+	expected := f.expected[key]
+	if mirror && expected == "" && !f.allowUnverified {
+		return "", armerr.Errorf(armerr.Integrity, "cannot verify %s@%s: no integrity is known for the version", name, version).
+			WithHint(fmt.Sprintf("Install it from registry %s once to record its integrity in arm.lock, or set allowUnverifiedMirrors = true for the registry", f.GetName()))
+	}
+	if mirror && expected != "" && expected != integrity {
+		return "", fmt.Errorf("%w for %s@%s: expected %s, got %s", ErrIntegrityMismatch, name, version, expected, integrity)
+	}
+	if expected == "" {
+		f.expected[key] = integrity
+	}
+	return integrity, nil
+}
+
+// recordSource remembers which registry served a ruleset
+func (f *FailoverRegistry) recordSource(name string, source Registry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastSource[name] = source.GetName()
+}
+
+// failure combines per-source errors into a single error
+func (f *FailoverRegistry) failure(operation string, errs []error) error {
+	return fmt.Errorf("failed to %s from registry %s or its mirrors: %w", operation, f.GetName(), errors.Join(errs...))
+}
+
+// downloadWithResult downloads from any registry as loose files with a structured result
+func downloadWithResult(ctx context.Context, source Registry, name, version, destDir string, patterns []string) (*DownloadResult, error) {
+	if downloader, ok := source.(ResultDownloader); ok {
+		return downloader.DownloadRulesetWithResult(ctx, name, version, destDir, patterns)
+	}
+
+	resolved := ResolveVersionSpec(ctx, source, name, version)
+	if err := source.DownloadRulesetWithPatterns(ctx, name, resolved, destDir, patterns); err != nil {
+		return nil, err
+	}
+
+	// Tarball registries deliver a ruleset.tar.gz; unpack it so every source
+	// produces the same layout
+	tarPath := filepath.Join(destDir, tarballName)
+	if _, err := os.Stat(tarPath); err == nil {
+		if _, err := ExtractTarball(tarPath, destDir); err != nil {
+			return nil, err
+		}
+		if err := os.Remove(tarPath); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", tarballName, err)
+		}
+	}
+
+	files, err := filterFiles(destDir, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DownloadResult{
+		VersionSpec:     version,
+		ResolvedVersion: resolved,
+		Files:           files,
+	}, nil
+}
+
+// resolvedVersion returns the concrete version a source serves for a version
+// spec, the one the lock file records integrity for
+func resolvedVersion(ctx context.Context, source Registry, name, version string) string {
+	if resolver, ok := source.(VersionSpecResolver); ok {
+		if resolved, err := resolver.ResolveVersion(ctx, version); err == nil && resolved != "" {
+			return resolved
+		}
+	}
+	return ResolveVersionSpec(ctx, source, name, version)
+}
+
+// ResolveVersionSpec resolves a version spec against a registry's version list,
+// returning the spec unchanged when it cannot be resolved
+func ResolveVersionSpec(ctx context.Context, source Registry, name, versionSpec string) string {
+	versions, err := source.GetVersions(ctx, name)
+	if err != nil || contains(versions, versionSpec) {
+		return versionSpec
+	}
+
+	var resolved string
+	switch {
+	case versionSpec == "" || versionSpec == "latest":
+		resolved, err = ResolveLatestVersion(versions)
+	case IsSemverPattern(versionSpec):
+		resolved, err = ResolveSemverPattern(versionSpec, versions)
+	default:
+		return versionSpec
+	}
+	if err != nil {
+		return versionSpec
+	}
+	return resolved
+}
+
+// filterFiles lists files under dir, removing those that do not match patterns
+func filterFiles(dir string, patterns []string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if len(patterns) > 0 && !MatchesAnyPattern(filepath.ToSlash(rel), patterns) {
+			return os.Remove(path)
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan downloaded files: %w", err)
+	}
+	return files, nil
+}
+
+// clearDir removes everything inside dir, creating it if needed
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0o755)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clear directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// sourceError labels an error with the registry that produced it
+func sourceError(source Registry, err error) error {
+	return fmt.Errorf("%s: %w", source.GetName(), err)
+}
+
+// integrityKey builds the lookup key for expected integrity values. The
+// patterns select the files that are hashed, so they are part of the key.
+func integrityKey(name, version string, patterns []string) string {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	sort.Strings(normalized)
+	return name + "@" + version + ":" + strings.Join(normalized, ",")
+}
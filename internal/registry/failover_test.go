@@ -0,0 +1,337 @@
+package registry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+)
+
+// fakeRegistry is a Registry whose responses are controlled by the test
+type fakeRegistry struct {
+	name     string
+	files    map[string]string
+	versions []string
+	err      error
+	calls    int
+}
+
+func (f *fakeRegistry) GetRulesets(ctx context.Context, patterns []string) ([]RulesetInfo, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []RulesetInfo{{Name: "rules", Registry: f.name}}, nil
+}
+
+func (f *fakeRegistry) GetRuleset(ctx context.Context, name, version string) (*RulesetInfo, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &RulesetInfo{Name: name, Version: version, Registry: f.name}, nil
+}
+
+func (f *fakeRegistry) DownloadRuleset(ctx context.Context, name, version, destDir string) error {
+	return f.DownloadRulesetWithPatterns(ctx, name, version, destDir, nil)
+}
+
+func (f *fakeRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, version, destDir string, patterns []string) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	for rel, content := range f.files {
+		if len(patterns) > 0 && !MatchesAnyPattern(rel, patterns) {
+			continue
+		}
+		path := filepath.Join(destDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeRegistry) GetVersions(ctx context.Context, name string) ([]string, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.versions, nil
+}
+
+func (f *fakeRegistry) GetType() string { return "fake" }
+func (f *fakeRegistry) GetName() string { return f.name }
+func (f *fakeRegistry) Close() error    { return nil }
+
+// writeTarball creates a ruleset.tar.gz containing files
+func writeTarball(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: "./" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write content: %v", err)
+		}
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+}
+
+// filesIntegrity returns the integrity of a ruleset made of files
+func filesIntegrity(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte(content), 0o644)
+	}
+	integrity, err := ComputeIntegrity(dir)
+	if err != nil {
+		t.Fatalf("Failed to compute integrity: %v", err)
+	}
+	return integrity
+}
+
+func TestComputeIntegrity_SameForFilesAndTarball(t *testing.T) {
+	files := map[string]string{"rules/python.md": "use type hints", "README.md": "readme"}
+
+	looseDir := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(looseDir, rel)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte(content), 0o644)
+	}
+
+	tarDir := t.TempDir()
+	writeTarball(t, filepath.Join(tarDir, tarballName), files)
+
+	loose, err := ComputeIntegrity(looseDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	packed, err := ComputeIntegrity(tarDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(loose, integrityPrefix) {
+		t.Errorf("Expected %s prefix, got %s", integrityPrefix, loose)
+	}
+	if loose != packed {
+		t.Errorf("Expected equal integrity, got %s and %s", loose, packed)
+	}
+
+	_ = os.WriteFile(filepath.Join(looseDir, "README.md"), []byte("changed"), 0o644)
+	if err := VerifyIntegrity(looseDir, packed); !errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("Expected ErrIntegrityMismatch, got %v", err)
+	}
+}
+
+func TestFailoverRegistry_FallsBackToMirror(t *testing.T) {
+	primary := &fakeRegistry{name: "primary", err: errors.New("connection refused")}
+	mirror := &fakeRegistry{name: "mirror", files: map[string]string{"rules.md": "rules"}, versions: []string{"1.0.0"}}
+
+	// Without a known integrity, mirror content cannot be verified
+	reg := NewFailoverRegistry(primary, mirror)
+	if _, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "1.0.0", t.TempDir(), nil); armerr.KindOf(err) != armerr.Integrity {
+		t.Errorf("Expected an integrity error for unverified mirror content, got %v", err)
+	}
+
+	reg.ExpectIntegrity("rules", "1.0.0", nil, filesIntegrity(t, mirror.files))
+	result, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "1.0.0", t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Source != "mirror" {
+		t.Errorf("Expected source 'mirror', got %q", result.Source)
+	}
+	if len(result.Files) != 1 || result.Integrity == "" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if reg.LastSource("rules") != "mirror" {
+		t.Errorf("Expected last source 'mirror', got %q", reg.LastSource("rules"))
+	}
+	if reg.GetName() != "primary" {
+		t.Errorf("Expected failover registry to keep primary name, got %s", reg.GetName())
+	}
+}
+
+func TestFailoverRegistry_PrefersPrimary(t *testing.T) {
+	primary := &fakeRegistry{name: "primary", versions: []string{"1.0.0", "1.1.0"}}
+	mirror := &fakeRegistry{name: "mirror", versions: []string{"1.0.0"}}
+
+	reg := NewFailoverRegistry(primary, mirror)
+	versions, err := reg.GetVersions(context.Background(), "rules")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("Expected primary versions, got %v", versions)
+	}
+	if mirror.calls != 0 {
+		t.Errorf("Expected mirror not to be called, got %d calls", mirror.calls)
+	}
+}
+
+func TestFailoverRegistry_RejectsMismatchedMirror(t *testing.T) {
+	primary := &fakeRegistry{name: "primary", err: errors.New("503 Service Unavailable")}
+	tampered := &fakeRegistry{name: "tampered", files: map[string]string{"rules.md": "evil"}, versions: []string{"1.0.0"}}
+	good := &fakeRegistry{name: "good", files: map[string]string{"rules.md": "rules"}, versions: []string{"1.0.0"}}
+
+	expectedDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(expectedDir, "rules.md"), []byte("rules"), 0o644)
+	expected, err := ComputeIntegrity(expectedDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reg := NewFailoverRegistry(primary, tampered, good)
+	reg.ExpectIntegrity("rules", "1.0.0", nil, expected)
+
+	destDir := t.TempDir()
+	result, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "1.0.0", destDir, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Source != "good" {
+		t.Errorf("Expected source 'good', got %q", result.Source)
+	}
+	content, _ := os.ReadFile(filepath.Join(destDir, "rules.md"))
+	if string(content) != "rules" {
+		t.Errorf("Expected content from good mirror, got %q", content)
+	}
+
+	// With only the tampered mirror left, the download must fail
+	reg = NewFailoverRegistry(primary, tampered)
+	reg.ExpectIntegrity("rules", "1.0.0", nil, expected)
+	if _, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "1.0.0", t.TempDir(), nil); !errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("Expected ErrIntegrityMismatch, got %v", err)
+	}
+}
+
+func TestFailoverRegistry_LocalTarballMirror(t *testing.T) {
+	mirrorDir := t.TempDir()
+	writeTarball(t, filepath.Join(mirrorDir, "rules", "1.2.0", tarballName), map[string]string{
+		"python.md": "python",
+		"go.md":     "go",
+	})
+
+	mirror, err := NewLocalRegistry(&RegistryConfig{Name: "offline", Type: "local", URL: mirrorDir})
+	if err != nil {
+		t.Fatalf("Failed to create local registry: %v", err)
+	}
+	primary := &fakeRegistry{name: "primary", err: errors.New("no such host")}
+
+	reg := NewFailoverRegistry(primary, mirror)
+	reg.allowUnverified = true
+	destDir := t.TempDir()
+	result, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "latest", destDir, []string{"python.md"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.ResolvedVersion != "1.2.0" {
+		t.Errorf("Expected latest to resolve to 1.2.0, got %s", result.ResolvedVersion)
+	}
+	if len(result.Files) != 1 || filepath.Base(result.Files[0]) != "python.md" {
+		t.Errorf("Expected only python.md, got %v", result.Files)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, tarballName)); !os.IsNotExist(err) {
+		t.Error("Expected tarball to be removed after extraction")
+	}
+}
+
+func TestFailoverRegistry_ExpectsResolvedVersion(t *testing.T) {
+	primary := &fakeRegistry{name: "primary", err: errors.New("503 Service Unavailable")}
+	mirror := &fakeRegistry{name: "mirror", files: map[string]string{"rules.md": "rules"}, versions: []string{"1.0.0", "1.1.0"}}
+
+	// The lock file records integrity for the resolved version, not the spec
+	reg := NewFailoverRegistry(primary, mirror)
+	reg.ExpectIntegrity("rules", "1.1.0", nil, filesIntegrity(t, mirror.files))
+	if err := reg.DownloadRulesetWithPatterns(context.Background(), "rules", "^1.0.0", t.TempDir(), nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reg = NewFailoverRegistry(primary, mirror)
+	reg.ExpectIntegrity("rules", "1.1.0", nil, filesIntegrity(t, map[string]string{"rules.md": "other"}))
+	if err := reg.DownloadRulesetWithPatterns(context.Background(), "rules", "^1.0.0", t.TempDir(), nil); !errors.Is(err, ErrIntegrityMismatch) {
+		t.Errorf("Expected ErrIntegrityMismatch, got %v", err)
+	}
+}
+
+func TestFailoverRegistry_IntegrityPerPatternSet(t *testing.T) {
+	files := map[string]string{"rules.md": "rules", "extra.txt": "extra"}
+	primary := &fakeRegistry{name: "primary", files: files, versions: []string{"1.0.0"}}
+	mirror := &fakeRegistry{name: "mirror", files: files, versions: []string{"1.0.0"}}
+	reg := NewFailoverRegistry(primary, mirror)
+
+	// The primary records the integrity of the files each pattern set selects
+	for _, patterns := range [][]string{{"*.md"}, {"*.md", "*.txt"}} {
+		if _, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "1.0.0", t.TempDir(), patterns); err != nil {
+			t.Fatalf("%v: Expected no error, got %v", patterns, err)
+		}
+	}
+
+	// The mirror serves both pattern sets, in any order, without a false mismatch
+	primary.err = errors.New("connection refused")
+	for _, patterns := range [][]string{{"*.txt", "*.md"}, {"*.md"}} {
+		result, err := reg.DownloadRulesetWithResult(context.Background(), "rules", "1.0.0", t.TempDir(), patterns)
+		if err != nil {
+			t.Fatalf("%v: Expected no error, got %v", patterns, err)
+		}
+		if result.Source != "mirror" || len(result.Files) != len(patterns) {
+			t.Errorf("%v: Unexpected result: %+v", patterns, result)
+		}
+	}
+}
+
+func TestCreateRegistry_WrapsMirrors(t *testing.T) {
+	mirrorDir := t.TempDir()
+	config := &RegistryConfig{
+		Name: "primary",
+		Type: "local",
+		URL:  t.TempDir(),
+		Mirrors: []*RegistryConfig{
+			{Name: "backup", Type: "local", URL: mirrorDir},
+		},
+	}
+
+	reg, err := CreateRegistry(config, &AuthConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	failover, ok := reg.(*FailoverRegistry)
+	if !ok {
+		t.Fatalf("Expected *FailoverRegistry, got %T", reg)
+	}
+	if sources := failover.Sources(); len(sources) != 2 || sources[1].GetName() != "backup" {
+		t.Errorf("Unexpected sources: %v", sources)
+	}
+}
@@ -13,6 +13,8 @@ type DownloadResult struct {
 	VersionSpec     string   // Original version spec (e.g., "latest")
 	ResolvedVersion string   // Actual commit hash
 	Files           []string // Downloaded file paths
+	Source          string   // Registry that served the download when mirrors are configured
+	Integrity       string   // Content hash of the downloaded files, when computed
 }
 
 // GitRegistry implements the Registry interface for Git repositories
@@ -107,6 +109,11 @@ func (g *GitRegistry) ResolveVersion(ctx context.Context, version string) (strin
 	return g.operations.ResolveVersion(ctx, version)
 }
 
+// CommitLog returns the commits between two resolved versions
+func (g *GitRegistry) CommitLog(ctx context.Context, from, to string) ([]Commit, error) {
+	return g.operations.CommitLog(ctx, from, to)
+}
+
 // GetType returns the registry type
 func (g *GitRegistry) GetType() string {
 	return "git"
@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 )
 
 // Pattern matching utilities
@@ -203,7 +205,31 @@ func ResolveSemverPattern(versionSpec string, availableVersions []string) (strin
 		}
 	}
 
-	return "", fmt.Errorf("no versions satisfy constraint: %s", versionSpec)
+	return "", armerr.Errorf(armerr.Unsatisfiable, "no versions satisfy constraint: %s", versionSpec)
+}
+
+// MatchingVersions returns the versions satisfying a semver pattern, highest first
+func MatchingVersions(versionSpec string, availableVersions []string) ([]string, error) {
+	constraint, err := semver.NewConstraint(versionSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid semver constraint: %w", err)
+	}
+
+	parsed := make(map[*semver.Version]string)
+	var candidates []*semver.Version
+	for _, v := range availableVersions {
+		if ver, err := semver.NewVersion(v); err == nil && constraint.Check(ver) {
+			parsed[ver] = v
+			candidates = append(candidates, ver)
+		}
+	}
+	sort.Sort(sort.Reverse(semver.Collection(candidates)))
+
+	matching := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		matching = append(matching, parsed[candidate])
+	}
+	return matching, nil
 }
 
 // ResolveLatestVersion resolves "latest" to the highest semantic version
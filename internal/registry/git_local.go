@@ -82,6 +82,11 @@ func (g *GitLocalRegistry) DownloadRulesetWithResult(ctx context.Context, name,
 	return g.BaseGitRegistry.DownloadRulesetWithResult(ctx, g.operations, version, destDir, patterns)
 }
 
+// CommitLog returns the commits between two resolved versions
+func (g *GitLocalRegistry) CommitLog(ctx context.Context, from, to string) ([]Commit, error) {
+	return g.operations.CommitLog(ctx, from, to)
+}
+
 // GetType returns the registry type
 func (g *GitLocalRegistry) GetType() string {
 	return "git-local"
@@ -3,6 +3,8 @@ package registry
 import (
 	"context"
 	"fmt"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 )
 
 // GitError represents context-aware Git operation errors
@@ -24,6 +26,14 @@ func (e *GitError) Unwrap() error {
 	return e.Cause
 }
 
+// ErrorKind classifies the failure from its cause
+func (e *GitError) ErrorKind() armerr.Kind {
+	if kind := armerr.KindOf(e.Cause); kind != armerr.Unknown {
+		return kind
+	}
+	return causeKind(e.Cause)
+}
+
 // VersionResolver handles Git version operations
 type VersionResolver interface {
 	ResolveVersion(ctx context.Context, constraint string) (string, error)
@@ -35,8 +45,9 @@ type FileProvider interface {
 	GetFiles(ctx context.Context, version string, patterns []string) (map[string][]byte, error)
 }
 
-// GitOperations combines version resolution and file operations
+// GitOperations combines version resolution, file and history operations
 type GitOperations interface {
 	VersionResolver
 	FileProvider
+	CommitLogger
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/Masterminds/semver/v3"
@@ -131,6 +132,23 @@ func TestIsSemverPattern(t *testing.T) {
 	}
 }
 
+func TestMatchingVersions(t *testing.T) {
+	versions := []string{"1.0.0", "v1.2.0", "1.1.0", "2.0.0", "main", "latest"}
+
+	matching, err := MatchingVersions("^1.0.0", versions)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"v1.2.0", "1.1.0", "1.0.0"}
+	if strings.Join(matching, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, matching)
+	}
+
+	if _, err := MatchingVersions("not-a-range", versions); err == nil {
+		t.Error("Expected error for invalid constraint")
+	}
+}
+
 func TestIsVersionNumber(t *testing.T) {
 	tests := []struct {
 		version  string
@@ -10,6 +10,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 // GitLabRegistry implements the Registry interface for GitLab package registries
@@ -36,7 +39,7 @@ func NewGitLabRegistry(config *RegistryConfig, auth *AuthConfig) (*GitLabRegistr
 	return &GitLabRegistry{
 		config:    config,
 		auth:      auth,
-		client:    &http.Client{Timeout: config.Timeout},
+		client:    newHTTPClient(config),
 		baseURL:   baseURL,
 		projectID: projectID,
 	}, nil
@@ -62,7 +65,7 @@ func (g *GitLabRegistry) GetRulesets(ctx context.Context, patterns []string) ([]
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitLab API error: %s", resp.Status)
+		return nil, fmt.Errorf("GitLab API error: %w", NewHTTPStatusError(resp))
 	}
 
 	var packages []GitLabPackage
@@ -121,7 +124,7 @@ func (g *GitLabRegistry) GetRuleset(ctx context.Context, name, version string) (
 		}
 	}
 
-	return nil, fmt.Errorf("ruleset %s not found", name)
+	return nil, armerr.Errorf(armerr.NotFound, "ruleset %s not found", name)
 }
 
 // DownloadRuleset downloads a ruleset to the specified directory
@@ -154,7 +157,7 @@ func (g *GitLabRegistry) DownloadRulesetWithPatterns(ctx context.Context, name,
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("GitLab API error: %s", resp.Status)
+		return fmt.Errorf("GitLab API error: %w", NewHTTPStatusError(resp))
 	}
 
 	// Create destination directory
@@ -171,7 +174,7 @@ func (g *GitLabRegistry) DownloadRulesetWithPatterns(ctx context.Context, name,
 	defer func() { _ = destFile.Close() }()
 
 	// Copy content
-	_, err = io.Copy(destFile, resp.Body)
+	_, err = io.Copy(destFile, progress.NewReader(ctx, resp.Body, resp.ContentLength))
 	return err
 }
 
@@ -195,7 +198,7 @@ func (g *GitLabRegistry) GetVersions(ctx context.Context, name string) ([]string
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitLab API error: %s", resp.Status)
+		return nil, fmt.Errorf("GitLab API error: %w", NewHTTPStatusError(resp))
 	}
 
 	var packages []GitLabPackage
@@ -9,6 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 // HTTPSRegistry implements the Registry interface for HTTPS registries with manifest.json discovery
@@ -35,7 +38,7 @@ func NewHTTPSRegistry(config *RegistryConfig, auth *AuthConfig) (*HTTPSRegistry,
 	return &HTTPSRegistry{
 		config:  config,
 		auth:    auth,
-		client:  &http.Client{Timeout: config.Timeout},
+		client:  newHTTPClient(config),
 		baseURL: baseURL,
 	}, nil
 }
@@ -80,13 +83,13 @@ func (h *HTTPSRegistry) GetRuleset(ctx context.Context, name, version string) (*
 
 	versions, exists := manifest.Rulesets[name]
 	if !exists {
-		return nil, fmt.Errorf("ruleset %s not found", name)
+		return nil, armerr.Errorf(armerr.NotFound, "ruleset %s not found", name)
 	}
 
 	// If version is "latest", use the last version in the array
 	if version == "latest" {
 		if len(versions) == 0 {
-			return nil, fmt.Errorf("no versions available for ruleset %s", name)
+			return nil, armerr.Errorf(armerr.NotFound, "no versions available for ruleset %s", name)
 		}
 		version = versions[len(versions)-1]
 	} else {
@@ -99,7 +102,7 @@ func (h *HTTPSRegistry) GetRuleset(ctx context.Context, name, version string) (*
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("version %s not found for ruleset %s", version, name)
+			return nil, armerr.Errorf(armerr.NotFound, "version %s not found for ruleset %s", version, name)
 		}
 	}
 
@@ -144,7 +147,7 @@ func (h *HTTPSRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, v
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTPS registry error: %s", resp.Status)
+		return fmt.Errorf("HTTPS registry error: %w", NewHTTPStatusError(resp))
 	}
 
 	// Create destination directory
@@ -161,7 +164,7 @@ func (h *HTTPSRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, v
 	defer func() { _ = destFile.Close() }()
 
 	// Copy content
-	_, err = io.Copy(destFile, resp.Body)
+	_, err = io.Copy(destFile, progress.NewReader(ctx, resp.Body, resp.ContentLength))
 	return err
 }
 
@@ -174,7 +177,7 @@ func (h *HTTPSRegistry) GetVersions(ctx context.Context, name string) ([]string,
 
 	versions, exists := manifest.Rulesets[name]
 	if !exists {
-		return nil, fmt.Errorf("ruleset %s not found", name)
+		return nil, armerr.Errorf(armerr.NotFound, "ruleset %s not found", name)
 	}
 
 	if len(versions) == 0 {
@@ -219,7 +222,7 @@ func (h *HTTPSRegistry) getManifest(ctx context.Context) (*HTTPSManifest, error)
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("manifest fetch error: %s", resp.Status)
+		return nil, fmt.Errorf("manifest fetch error: %w", NewHTTPStatusError(resp))
 	}
 
 	var manifest HTTPSManifest
@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+)
+
+// integrityPrefix identifies the digest algorithm in integrity strings
+const integrityPrefix = "sha256-"
+
+// ErrIntegrityMismatch is returned when downloaded content does not match the expected hash
+var ErrIntegrityMismatch = armerr.New(armerr.Integrity, "integrity mismatch")
+
+// ComputeIntegrity returns a content hash for a downloaded ruleset directory.
+//
+// The hash covers each file's relative path and content, so a ruleset has the
+// same integrity whether it was served as loose files (git) or as a
+// ruleset.tar.gz (S3, HTTPS, local).
+func ComputeIntegrity(dir string) (string, error) {
+	entries := make(map[string]string)
+
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == tarballName {
+			return hashTarball(filePath, entries)
+		}
+
+		digest, err := hashFile(filePath)
+		if err != nil {
+			return err
+		}
+		entries[rel] = digest
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to compute integrity: %w", err)
+	}
+
+	return integrityOf(entries), nil
+}
+
+// ComputeFilesIntegrity returns the integrity of ruleset files keyed by relative
+// path, matching ComputeIntegrity of a directory holding the same files
+func ComputeFilesIntegrity(files map[string][]byte) string {
+	entries := make(map[string]string, len(files))
+	for name, content := range files {
+		sum := sha256.Sum256(content)
+		entries[filepath.ToSlash(name)] = hex.EncodeToString(sum[:])
+	}
+	return integrityOf(entries)
+}
+
+// VerifyIntegrity checks that dir matches the expected integrity string
+func VerifyIntegrity(dir, expected string) error {
+	actual, err := ComputeIntegrity(dir)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrIntegrityMismatch, expected, actual)
+	}
+	return nil
+}
+
+// integrityOf combines sorted path/digest pairs into an integrity string
+func integrityOf(entries map[string]string) string {
+	paths := make([]string, 0, len(entries))
+	for p := range entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", p, entries[p])
+	}
+	return integrityPrefix + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// hashFile returns the hex SHA-256 of a file
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashTarball adds the regular files inside a gzipped tarball to entries
+func hashTarball(tarPath string, entries map[string]string) error {
+	return walkTarball(tarPath, func(name string, r io.Reader) error {
+		h := sha256.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+		entries[name] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+}
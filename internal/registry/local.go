@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 )
 
 // LocalRegistry implements the Registry interface for local filesystem registries
@@ -94,7 +96,7 @@ func (l *LocalRegistry) GetRulesets(ctx context.Context, patterns []string) ([]R
 func (l *LocalRegistry) GetRuleset(ctx context.Context, name, version string) (*RulesetInfo, error) {
 	rulesetPath := filepath.Join(l.path, name)
 	if _, err := os.Stat(rulesetPath); err != nil {
-		return nil, fmt.Errorf("ruleset %s not found", name)
+		return nil, armerr.Errorf(armerr.NotFound, "ruleset %s not found", name)
 	}
 
 	versions, err := l.getVersionsForRuleset(rulesetPath)
@@ -103,7 +105,7 @@ func (l *LocalRegistry) GetRuleset(ctx context.Context, name, version string) (*
 	}
 
 	if len(versions) == 0 {
-		return nil, fmt.Errorf("no versions found for ruleset %s", name)
+		return nil, armerr.Errorf(armerr.NotFound, "no versions found for ruleset %s", name)
 	}
 
 	// If version is "latest", use the last version in the array
@@ -119,7 +121,7 @@ func (l *LocalRegistry) GetRuleset(ctx context.Context, name, version string) (*
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("version %s not found for ruleset %s", version, name)
+			return nil, armerr.Errorf(armerr.NotFound, "version %s not found for ruleset %s", version, name)
 		}
 	}
 
@@ -158,7 +160,7 @@ func (l *LocalRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, v
 
 	// Check if source file exists
 	if _, err := os.Stat(sourcePath); err != nil {
-		return fmt.Errorf("ruleset file not found: %w", err)
+		return armerr.Errorf(armerr.NotFound, "ruleset file not found: %w", err)
 	}
 
 	// Create destination directory
@@ -210,7 +212,7 @@ func (l *LocalRegistry) Close() error {
 // getVersionsForRuleset scans a ruleset directory for available versions
 func (l *LocalRegistry) getVersionsForRuleset(rulesetPath string) ([]string, error) {
 	if _, err := os.Stat(rulesetPath); err != nil {
-		return nil, fmt.Errorf("ruleset directory not found: %w", err)
+		return nil, armerr.Errorf(armerr.NotFound, "ruleset directory not found: %w", err)
 	}
 
 	entries, err := os.ReadDir(rulesetPath)
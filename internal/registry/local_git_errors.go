@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 )
 
 // LocalGitError represents errors specific to local Git registry operations
@@ -32,6 +34,22 @@ func (e *LocalGitError) Unwrap() error {
 	return e.Cause
 }
 
+// ErrorKind classifies the error by its type. Missing, moved or invalid
+// repositories are configuration problems.
+func (e *LocalGitError) ErrorKind() armerr.Kind {
+	switch e.Type {
+	case "REPOSITORY_NOT_FOUND", "REPOSITORY_MOVED", "INVALID_REPOSITORY":
+		return armerr.Config
+	default:
+		return armerr.Unknown
+	}
+}
+
+// ErrorHint returns the suggested corrective action
+func (e *LocalGitError) ErrorHint() string {
+	return e.Suggestion
+}
+
 // Error constructors for common local Git scenarios
 
 func NewRepositoryNotFoundError(path string) *LocalGitError {
@@ -2,13 +2,17 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
 )
 
 // LocalGitOperations implements GitOperations for local Git repositories
@@ -69,8 +73,7 @@ func (l *LocalGitOperations) ResolveVersion(ctx context.Context, constraint stri
 
 // ListVersions returns available versions for the repository
 func (l *LocalGitOperations) ListVersions(ctx context.Context) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", l.repoPath, "tag", "--list", "--sort=-version:refname")
-	output, err := cmd.Output()
+	output, err := l.git(ctx, "tag", "--list", "--sort=-version:refname")
 	if err != nil {
 		return nil, l.enhanceGitError("tag --list --sort=-version:refname", err)
 	}
@@ -119,11 +122,29 @@ func (l *LocalGitOperations) GetFiles(ctx context.Context, version string, patte
 	return files, nil
 }
 
+// CommitLog returns the commits reachable from to but not from, newest first
+func (l *LocalGitOperations) CommitLog(ctx context.Context, from, to string) ([]Commit, error) {
+	output, err := l.git(ctx, "log", "--format=%H%x1f%an%x1f%aI%x1f%s", from+".."+to)
+	if err != nil {
+		return nil, l.enhanceGitError(fmt.Sprintf("log %s..%s", from, to), err)
+	}
+
+	var commits []Commit
+	for _, entry := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(entry, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3]})
+	}
+	return commits, nil
+}
+
 // resolveDefaultBranch resolves "latest" to the default branch's HEAD commit
 func (l *LocalGitOperations) resolveDefaultBranch(ctx context.Context) (string, error) {
 	// Get the default branch name
-	cmd := exec.CommandContext(ctx, "git", "-C", l.repoPath, "symbolic-ref", "refs/remotes/origin/HEAD")
-	output, err := cmd.Output()
+	output, err := l.git(ctx, "symbolic-ref", "refs/remotes/origin/HEAD")
 	if err != nil {
 		// Fallback: try common default branch names
 		for _, branch := range []string{"main", "master"} {
@@ -142,8 +163,7 @@ func (l *LocalGitOperations) resolveDefaultBranch(ctx context.Context) (string,
 
 // resolveBranch resolves a branch name to its commit hash
 func (l *LocalGitOperations) resolveBranch(ctx context.Context, branch string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", l.repoPath, "rev-parse", branch)
-	output, err := cmd.Output()
+	output, err := l.git(ctx, "rev-parse", branch)
 	if err != nil {
 		return "", l.enhanceGitError(fmt.Sprintf("rev-parse %s", branch), err)
 	}
@@ -154,24 +174,34 @@ func (l *LocalGitOperations) resolveBranch(ctx context.Context, branch string) (
 // resolveTagToCommit resolves a tag name to its commit hash, trying common formats
 func (l *LocalGitOperations) resolveTagToCommit(ctx context.Context, tag string) (string, error) {
 	// Try tag as-is first
-	cmd := exec.CommandContext(ctx, "git", "-C", l.repoPath, "rev-parse", tag)
-	if output, err := cmd.Output(); err == nil {
+	if output, err := l.git(ctx, "rev-parse", tag); err == nil {
 		return strings.TrimSpace(string(output)), nil
 	}
 
 	// Try with 'v' prefix
-	cmd = exec.CommandContext(ctx, "git", "-C", l.repoPath, "rev-parse", "v"+tag)
-	if output, err := cmd.Output(); err == nil {
+	if output, err := l.git(ctx, "rev-parse", "v"+tag); err == nil {
 		return strings.TrimSpace(string(output)), nil
 	}
 
-	return "", l.enhanceGitError(fmt.Sprintf("resolve tag %s", tag), fmt.Errorf("tag not found: tried '%s' and 'v%s'", tag, tag))
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "", l.enhanceGitError(fmt.Sprintf("resolve tag %s", tag), armerr.Errorf(armerr.NotFound, "tag not found: tried '%s' and 'v%s'", tag, tag))
+}
+
+// git runs a git command in the repository and returns its standard output
+func (l *LocalGitOperations) git(ctx context.Context, args ...string) ([]byte, error) {
+	defer logger.Timer("git -C %s %s", l.repoPath, strings.Join(args, " "))()
+	output, err := exec.CommandContext(ctx, "git", append([]string{"-C", l.repoPath}, args...)...).Output()
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err() // Killed by cancellation
+	}
+	return output, err
 }
 
 // listFilesAtVersion lists all files in the repository at the specified version
 func (l *LocalGitOperations) listFilesAtVersion(ctx context.Context, version string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", l.repoPath, "ls-tree", "-r", "--name-only", version)
-	output, err := cmd.Output()
+	output, err := l.git(ctx, "ls-tree", "-r", "--name-only", version)
 	if err != nil {
 		return nil, l.enhanceGitError(fmt.Sprintf("ls-tree -r --name-only %s", version), err)
 	}
@@ -189,8 +219,7 @@ func (l *LocalGitOperations) listFilesAtVersion(ctx context.Context, version str
 
 // getFileContent retrieves the content of a specific file at a version using git show
 func (l *LocalGitOperations) getFileContent(ctx context.Context, version, filePath string) ([]byte, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", l.repoPath, "show", version+":"+filePath)
-	output, err := cmd.Output()
+	output, err := l.git(ctx, "show", version+":"+filePath)
 	if err != nil {
 		return nil, l.enhanceGitError(fmt.Sprintf("show %s:%s", version, filePath), err)
 	}
@@ -258,6 +287,11 @@ func validateRepositoryPath(path string) error {
 
 // enhanceGitError analyzes Git command errors and provides better context
 func (l *LocalGitOperations) enhanceGitError(command string, err error) error {
+	// Commands killed by cancellation say nothing about the repository
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("git %s: %w", command, err)
+	}
+
 	// Check if repository still exists
 	if _, statErr := os.Stat(l.repoPath); os.IsNotExist(statErr) {
 		return NewRepositoryMovedError(l.originalPath)
@@ -314,3 +314,37 @@ func createTestTag(t *testing.T, repoDir, tagName string) {
 		t.Fatalf("Failed to create tag %s: %v", tagName, err)
 	}
 }
+
+func TestLocalGitOperations_CommitLog(t *testing.T) {
+	tempDir, cleanup := createTestGitRepo(t)
+	defer cleanup()
+
+	ops, err := NewLocalGitOperations(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create LocalGitOperations: %v", err)
+	}
+
+	createTestCommit(t, tempDir, "Initial commit")
+	createTestTag(t, tempDir, "1.0.0")
+	createTestCommit(t, tempDir, "Add python rules")
+	createTestCommit(t, tempDir, "Switch to ruff")
+	createTestTag(t, tempDir, "1.1.0")
+
+	commits, err := ops.CommitLog(context.Background(), "1.0.0", "1.1.0")
+	if err != nil {
+		t.Fatalf("CommitLog failed: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %+v", commits)
+	}
+	if commits[0].Subject != "Switch to ruff" || commits[1].Subject != "Add python rules" {
+		t.Errorf("Expected newest commit first, got %q and %q", commits[0].Subject, commits[1].Subject)
+	}
+	if commits[0].Author != "Test User" || len(commits[0].Hash) != 40 || commits[0].Date.IsZero() {
+		t.Errorf("Unexpected commit %+v", commits[0])
+	}
+
+	if commits, err := ops.CommitLog(context.Background(), "1.1.0", "1.1.0"); err != nil || len(commits) != 0 {
+		t.Errorf("Expected no commits between identical versions, got %+v (%v)", commits, err)
+	}
+}
@@ -0,0 +1,391 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+)
+
+// ErrNotCached is returned in offline mode when content is missing from the cache
+var ErrNotCached = armerr.New(armerr.NotCached, "not cached")
+
+// NotCachedError describes content that offline mode could not find in the cache
+type NotCachedError struct {
+	Registry string
+	Ruleset  string
+	Version  string
+	Patterns []string
+	Cached   []string // Versions of the ruleset that are cached
+}
+
+// Error implements the error interface
+func (e *NotCachedError) Error() string {
+	target := e.Registry
+	if e.Ruleset != "" {
+		target += "/" + e.Ruleset
+	}
+	if e.Version != "" {
+		target += "@" + e.Version
+	}
+
+	msg := target + " is not cached"
+	if len(e.Patterns) > 0 {
+		msg += " for patterns " + strings.Join(e.Patterns, ", ")
+	}
+	if len(e.Cached) > 0 {
+		msg += " (cached versions: " + strings.Join(e.Cached, ", ") + ")"
+	} else if e.Ruleset != "" {
+		msg += " (no cached versions)"
+	}
+	return msg
+}
+
+// Is reports whether target is ErrNotCached
+func (e *NotCachedError) Is(target error) bool {
+	return target == ErrNotCached
+}
+
+// OfflineRegistry serves a registry from the local cache without network access.
+//
+// Versions and version spec mappings come from the cache's versions.json and
+// files from its ruleset storage, as populated by earlier online installs.
+type OfflineRegistry struct {
+	config   *RegistryConfig
+	metadata *cache.MetadataManager
+	storage  *cache.RulesetStorage
+}
+
+// NewOfflineRegistry creates a registry backed only by cacheManager
+func NewOfflineRegistry(config *RegistryConfig, cacheManager cache.Manager) (*OfflineRegistry, error) {
+	if cacheManager == nil {
+		return nil, fmt.Errorf("offline mode requires the cache for registry %s", config.Name)
+	}
+	return &OfflineRegistry{
+		config:   config,
+		metadata: cacheManager.GetMetadataManager(),
+		storage:  cacheManager.GetRulesetStorage(),
+	}, nil
+}
+
+// SupportsOffline reports whether a registry type contacts the network and
+// is therefore served from the cache in offline mode
+func SupportsOffline(registryType string) bool {
+	switch registryType {
+	case "git", "https", "s3", "gitlab":
+		return true
+	default:
+		return false
+	}
+}
+
+// GetRulesets returns the cached rulesets matching patterns
+func (o *OfflineRegistry) GetRulesets(ctx context.Context, patterns []string) ([]RulesetInfo, error) {
+	names, err := o.metadata.ListRulesets(o.config.Type, o.config.URL)
+	if err != nil {
+		return nil, &NotCachedError{Registry: o.config.Name}
+	}
+
+	var rulesets []RulesetInfo
+	for _, name := range names {
+		if len(patterns) > 0 && !MatchesAnyPattern(name, patterns) {
+			continue
+		}
+		info, err := o.GetRuleset(ctx, name, "latest")
+		if err != nil {
+			continue
+		}
+		rulesets = append(rulesets, *info)
+	}
+	return rulesets, nil
+}
+
+// GetRuleset returns information about a cached ruleset version
+func (o *OfflineRegistry) GetRuleset(ctx context.Context, name, version string) (*RulesetInfo, error) {
+	resolved, err := o.resolve(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return &RulesetInfo{
+		Name:     name,
+		Version:  resolved,
+		Registry: o.config.Name,
+		Type:     o.config.Type,
+		Metadata: map[string]string{"source": "cache"},
+	}, nil
+}
+
+// DownloadRuleset writes a cached ruleset to destDir
+func (o *OfflineRegistry) DownloadRuleset(ctx context.Context, name, version, destDir string) error {
+	return o.DownloadRulesetWithPatterns(ctx, name, version, destDir, nil)
+}
+
+// DownloadRulesetWithPatterns writes a cached ruleset to destDir
+func (o *OfflineRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, version, destDir string, patterns []string) error {
+	_, err := o.DownloadRulesetWithResult(ctx, name, version, destDir, patterns)
+	return err
+}
+
+// DownloadRulesetWithResult writes a cached ruleset to destDir and reports the resolved version
+func (o *OfflineRegistry) DownloadRulesetWithResult(ctx context.Context, name, version, destDir string, patterns []string) (*DownloadResult, error) {
+	resolved, files, err := o.cachedFiles(name, version, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := writeRulesetFiles(destDir, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DownloadResult{
+		VersionSpec:     version,
+		ResolvedVersion: resolved,
+		Files:           paths,
+	}, nil
+}
+
+// Lookup reports the cached version a spec resolves to, failing with a
+// NotCachedError when the ruleset files for it are not in the cache
+func (o *OfflineRegistry) Lookup(name, version string, patterns []string) (string, error) {
+	resolved, _, err := o.cachedFiles(name, version, patterns)
+	return resolved, err
+}
+
+// GetVersions returns the cached versions of a ruleset
+func (o *OfflineRegistry) GetVersions(ctx context.Context, name string) ([]string, error) {
+	versions, _, err := o.metadata.GetVersions(o.config.Type, o.config.URL, name)
+	if err != nil || len(versions) == 0 {
+		return nil, &NotCachedError{Registry: o.config.Name, Ruleset: name}
+	}
+	return versions, nil
+}
+
+// ResolveVersion resolves a version spec using the cached mappings of all rulesets
+func (o *OfflineRegistry) ResolveVersion(ctx context.Context, version string) (string, error) {
+	names, err := o.metadata.ListRulesets(o.config.Type, o.config.URL)
+	if err != nil {
+		return "", &NotCachedError{Registry: o.config.Name, Version: version}
+	}
+
+	candidates := make(map[string]bool)
+	for _, name := range names {
+		if resolved, err := o.resolve(name, version); err == nil {
+			candidates[resolved] = true
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", &NotCachedError{Registry: o.config.Name, Version: version}
+	case 1:
+		for resolved := range candidates {
+			return resolved, nil
+		}
+	}
+
+	resolved := make([]string, 0, len(candidates))
+	for candidate := range candidates {
+		resolved = append(resolved, candidate)
+	}
+	sort.Strings(resolved)
+	return "", fmt.Errorf("version %s of registry %s resolves to different cached versions (%s); specify the version explicitly", version, o.config.Name, strings.Join(resolved, ", "))
+}
+
+// Search implements the Searcher interface over cached ruleset names
+func (o *OfflineRegistry) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	names, err := o.metadata.ListRulesets(o.config.Type, o.config.URL)
+	if err != nil {
+		return nil, &NotCachedError{Registry: o.config.Name}
+	}
+
+	var results []SearchResult
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
+			results = append(results, SearchResult{RulesetName: name, RegistryName: o.config.Name, Match: name})
+		}
+	}
+	return results, nil
+}
+
+// GetType returns the type of the cached registry
+func (o *OfflineRegistry) GetType() string {
+	return o.config.Type
+}
+
+// GetName returns the registry name
+func (o *OfflineRegistry) GetName() string {
+	return o.config.Name
+}
+
+// Close cleans up any resources
+func (o *OfflineRegistry) Close() error {
+	return nil
+}
+
+// resolve maps a version spec to a cached version of a ruleset
+func (o *OfflineRegistry) resolve(name, version string) (string, error) {
+	if version == "" {
+		version = "latest"
+	}
+
+	versions, mappings, err := o.metadata.GetVersions(o.config.Type, o.config.URL, name)
+	if err != nil || len(versions) == 0 {
+		return "", &NotCachedError{Registry: o.config.Name, Ruleset: name, Version: version}
+	}
+
+	if resolved, exists := mappings[version]; exists {
+		return resolved, nil
+	}
+	if contains(versions, version) {
+		return version, nil
+	}
+
+	// Abbreviated commit hashes
+	if o.config.Type == "git" && len(version) >= 7 {
+		for _, cached := range versions {
+			if strings.HasPrefix(cached, version) {
+				return cached, nil
+			}
+		}
+	}
+
+	// Semantic version specs resolve against cached tarball versions
+	if o.config.Type != "git" {
+		var resolved string
+		switch {
+		case version == "latest":
+			resolved, err = ResolveLatestVersion(versions)
+		case IsSemverPattern(version):
+			resolved, err = ResolveSemverPattern(version, versions)
+		}
+		if resolved != "" && err == nil {
+			return resolved, nil
+		}
+	}
+
+	cached := append([]string(nil), versions...)
+	sort.Strings(cached)
+	return "", &NotCachedError{Registry: o.config.Name, Ruleset: name, Version: version, Cached: cached}
+}
+
+// cachedFiles resolves a version spec and loads the cached ruleset files
+func (o *OfflineRegistry) cachedFiles(name, version string, patterns []string) (string, map[string][]byte, error) {
+	resolved, err := o.resolve(name, version)
+	if err != nil {
+		return "", nil, err
+	}
+
+	patterns = o.storagePatterns(patterns)
+	files, err := o.storage.GetRulesetFiles(o.config.Type, o.config.URL, name, resolved, patterns)
+	if err != nil || len(files) == 0 {
+		cached, _ := o.storage.ListRulesetVersions(o.config.Type, o.config.URL, name, patterns)
+		sort.Strings(cached)
+		return "", nil, &NotCachedError{Registry: o.config.Name, Ruleset: name, Version: version, Patterns: patterns, Cached: cached}
+	}
+	return resolved, files, nil
+}
+
+// storagePatterns returns the patterns content was cached under; tarball
+// registries ignore patterns and are cached without them
+func (o *OfflineRegistry) storagePatterns(patterns []string) []string {
+	if o.config.Type == "git" {
+		return patterns
+	}
+	return nil
+}
+
+// CacheRuleset stores downloaded ruleset files from dir in the cache so they
+// can be served in offline mode, mapping versionSpec to resolvedVersion
+func CacheRuleset(cacheManager cache.Manager, config *RegistryConfig, name, versionSpec, resolvedVersion, dir string) error {
+	files, err := readRulesetFiles(dir)
+	if err != nil {
+		return err
+	}
+	return cacheRulesetFiles(cacheManager, config, name, versionSpec, resolvedVersion, files, nil)
+}
+
+// cacheRulesetFiles stores ruleset files under patterns and records the
+// version and spec mapping in the cache metadata
+func cacheRulesetFiles(cacheManager cache.Manager, config *RegistryConfig, name, versionSpec, resolvedVersion string, files map[string][]byte, patterns []string) error {
+	var totalSize int64
+	for _, content := range files {
+		totalSize += int64(len(content))
+	}
+
+	if err := cacheManager.EnsureCacheDir(config.Type, config.URL); err != nil {
+		return fmt.Errorf("failed to ensure cache directory: %w", err)
+	}
+	if err := cacheManager.GetRulesetStorage().StoreRulesetFiles(config.Type, config.URL, name, resolvedVersion, files, patterns); err != nil {
+		return fmt.Errorf("failed to store files in cache: %w", err)
+	}
+
+	metadata := cacheManager.GetMetadataManager()
+	versions, mappings, err := metadata.GetVersions(config.Type, config.URL, name)
+	if err != nil {
+		versions, mappings = nil, make(map[string]string)
+	}
+	if mappings == nil {
+		mappings = make(map[string]string)
+	}
+	if !contains(versions, resolvedVersion) {
+		versions = append(versions, resolvedVersion)
+	}
+	if versionSpec != "" && versionSpec != resolvedVersion {
+		mappings[versionSpec] = resolvedVersion
+	}
+
+	if err := metadata.UpdateVersions(config.Type, config.URL, name, versions, mappings); err != nil {
+		return fmt.Errorf("failed to update versions cache: %w", err)
+	}
+	if err := metadata.UpdateMetadata(config.Type, config.URL, name, resolvedVersion, len(files), totalSize); err != nil {
+		return fmt.Errorf("failed to update metadata cache: %w", err)
+	}
+	return cacheManager.UpdateCacheInfo(config.Type, config.URL, resolvedVersion)
+}
+
+// readRulesetFiles reads the files under dir keyed by slash-separated relative path
+func readRulesetFiles(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ruleset files: %w", err)
+	}
+	return files, nil
+}
+
+// writeRulesetFiles writes files to destDir and returns their sorted paths
+func writeRulesetFiles(destDir string, files map[string][]byte) ([]string, error) {
+	paths := make([]string, 0, len(files))
+	for rel, content := range files {
+		target := filepath.Join(destDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(target, content, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+		paths = append(paths, target)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+)
+
+func TestOfflineRegistry_ServesCachedRuleset(t *testing.T) {
+	cacheManager := cache.NewManager(t.TempDir())
+	config := &RegistryConfig{Name: "team", Type: "https", URL: "https://rules.example.com", Offline: true}
+
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "rules"), 0o755); err != nil {
+		t.Fatalf("Failed to create ruleset: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "rules", "python.md"), []byte("python"), 0o644); err != nil {
+		t.Fatalf("Failed to write ruleset: %v", err)
+	}
+	if err := CacheRuleset(cacheManager, config, "standards", "^1.0.0", "1.2.0", srcDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reg, err := CreateRegistryWithCache(config, &AuthConfig{}, cacheManager)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	offline, ok := reg.(*OfflineRegistry)
+	if !ok {
+		t.Fatalf("Expected OfflineRegistry, got %T", reg)
+	}
+
+	for _, version := range []string{"^1.0.0", "1.2.0", "latest"} {
+		destDir := t.TempDir()
+		result, err := offline.DownloadRulesetWithResult(context.Background(), "standards", version, destDir, nil)
+		if err != nil {
+			t.Fatalf("Expected %s to be served from cache, got %v", version, err)
+		}
+		if result.ResolvedVersion != "1.2.0" {
+			t.Errorf("Expected %s to resolve to 1.2.0, got %s", version, result.ResolvedVersion)
+		}
+		content, err := os.ReadFile(filepath.Join(destDir, "rules", "python.md"))
+		if err != nil || string(content) != "python" {
+			t.Errorf("Expected cached file content, got %q (%v)", content, err)
+		}
+	}
+
+	_, err = offline.Lookup("standards", "2.0.0", nil)
+	if !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Expected ErrNotCached, got %v", err)
+	}
+	if want := "team/standards@2.0.0 is not cached (cached versions: 1.2.0)"; err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+
+	_, err = offline.GetRuleset(context.Background(), "missing", "latest")
+	if !errors.Is(err, ErrNotCached) || !strings.Contains(err.Error(), "no cached versions") {
+		t.Errorf("Expected missing ruleset to be reported as not cached, got %v", err)
+	}
+}
+
+func TestCreateRegistryWithCache_OfflineLocalTypes(t *testing.T) {
+	config := &RegistryConfig{Name: "dev", Type: "local", URL: t.TempDir(), Offline: true}
+
+	reg, err := CreateRegistryWithCache(config, &AuthConfig{}, cache.NewManager(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := reg.(*LocalRegistry); !ok {
+		t.Errorf("Expected local registries to be unaffected by offline mode, got %T", reg)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
 )
 
@@ -43,6 +44,33 @@ type Searcher interface {
 	Search(ctx context.Context, query string) ([]SearchResult, error)
 }
 
+// ResultDownloader is implemented by registries that report the resolved
+// version and downloaded files of a download
+type ResultDownloader interface {
+	DownloadRulesetWithResult(ctx context.Context, name, version, destDir string, patterns []string) (*DownloadResult, error)
+}
+
+// VersionSpecResolver is implemented by registries that resolve version specs
+// (branches, tags, semver ranges) to concrete versions such as commit hashes
+type VersionSpecResolver interface {
+	ResolveVersion(ctx context.Context, version string) (string, error)
+}
+
+// CommitLogger is implemented by git registries that list the commits between
+// two resolved versions
+type CommitLogger interface {
+	// CommitLog returns the commits reachable from to but not from, newest first
+	CommitLog(ctx context.Context, from, to string) ([]Commit, error)
+}
+
+// Commit describes a commit of a git registry
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+}
+
 // SearchResult contains minimal search result information
 type SearchResult struct {
 	RulesetName  string `json:"ruleset_name"`
@@ -73,6 +101,8 @@ type AuthConfig struct {
 	Region     string `json:"region"`      // For AWS regions
 	APIType    string `json:"api_type"`    // For API-specific auth
 	APIVersion string `json:"api_version"` // For API versioning
+
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero when the credentials do not expire
 }
 
 // RegistryConfig contains registry configuration
@@ -86,6 +116,12 @@ type RegistryConfig struct {
 	Timeout      time.Duration          `json:"timeout"`
 	RetryConfig  *RetryConfig           `json:"retry_config,omitempty"`
 	CustomConfig map[string]interface{} `json:"custom_config,omitempty"`
+	Mirrors      []*RegistryConfig      `json:"mirrors,omitempty"` // Tried in order when this registry fails; each uses its own Auth
+	Offline      bool                   `json:"offline,omitempty"` // Serve network registries from the cache only
+
+	// AllowUnverifiedMirrors accepts mirror content for versions without a
+	// known integrity, such as those of a first install
+	AllowUnverifiedMirrors bool `json:"allow_unverified_mirrors,omitempty"`
 }
 
 // ResolvePath resolves the registry path using the config package
@@ -97,7 +133,9 @@ func (c *RegistryConfig) ResolvePath(path string) (string, error) {
 type RetryConfig struct {
 	MaxAttempts       int           `json:"max_attempts"`
 	BackoffMultiplier float64       `json:"backoff_multiplier"`
+	InitialBackoff    time.Duration `json:"initial_backoff"`
 	MaxBackoff        time.Duration `json:"max_backoff"`
+	MaxRetryAfter     time.Duration `json:"max_retry_after"` // Longest Retry-After hint honoured
 	RetryableErrors   []string      `json:"retryable_errors"`
 }
 
@@ -106,7 +144,7 @@ type AuthProvider interface {
 	// GetCredentials returns credentials for the given registry
 	GetCredentials(registryName string) (*AuthConfig, error)
 
-	// RefreshCredentials refreshes expired credentials
+	// RefreshCredentials re-resolves credentials, e.g. after they expired
 	RefreshCredentials(registryName string) (*AuthConfig, error)
 }
 
@@ -135,24 +173,20 @@ func (p *DefaultAuthProvider) GetCredentials(registryName string) (*AuthConfig,
 	}
 
 	// Expand environment variables in auth config
-	expandedAuth := &AuthConfig{
-		Token:      expandEnvVars(auth.Token),
-		Username:   expandEnvVars(auth.Username),
-		Password:   expandEnvVars(auth.Password),
-		Profile:    expandEnvVars(auth.Profile),
-		Region:     expandEnvVars(auth.Region),
-		APIType:    expandEnvVars(auth.APIType),
-		APIVersion: expandEnvVars(auth.APIVersion),
-	}
-
-	return expandedAuth, nil
+	return expandAuthConfig(auth), nil
 }
 
-// RefreshCredentials refreshes expired credentials
+// RefreshCredentials re-reads credentials. Static credentials cannot be
+// renewed, so expired ones are reported as ErrCredentialsExpired.
 func (p *DefaultAuthProvider) RefreshCredentials(registryName string) (*AuthConfig, error) {
-	// For now, just return the current credentials
-	// This can be extended for OAuth flows, AWS credential refresh, etc.
-	return p.GetCredentials(registryName)
+	auth, err := p.GetCredentials(registryName)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Expired() {
+		return nil, fmt.Errorf("%w for registry %s", ErrCredentialsExpired, registryName)
+	}
+	return auth, nil
 }
 
 // expandEnvVars expands environment variables in strings
@@ -176,6 +210,11 @@ func expandEnvVars(s string) string {
 
 // ValidateRegistryConfig validates a registry configuration
 func ValidateRegistryConfig(config *RegistryConfig) error {
+	return armerr.Wrap(armerr.Config, validateRegistryFields(config))
+}
+
+// validateRegistryFields checks the fields required by the registry type
+func validateRegistryFields(config *RegistryConfig) error {
 	if config.Name == "" {
 		return fmt.Errorf("registry name cannot be empty")
 	}
@@ -0,0 +1,290 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+)
+
+// IntegrityChecker is implemented by registries that verify content served by
+// secondary sources, such as mirrors or a remote cache, against known hashes
+type IntegrityChecker interface {
+	ExpectIntegrity(name, version string, patterns []string, integrity string)
+}
+
+// RemoteCacheRegistry serves downloads of a network registry through the local
+// cache and a shared remote cache before falling back to the origin registry.
+//
+// The version spec is always resolved by the origin; content for the resolved
+// version is then looked up by its cache keys, first locally and then on the
+// remote cache. Remote content is verified blob by blob and against the
+// integrity the lock file records; versions without one, such as those of a
+// first install, are downloaded from the origin. Content downloaded from the
+// origin is stored locally and, when uploads are enabled, pushed to the remote
+// cache on a best-effort basis.
+type RemoteCacheRegistry struct {
+	origin       Registry
+	config       *RegistryConfig
+	cacheManager cache.Manager
+	client       *cache.RemoteClient
+	upload       bool
+	expected     map[string]string // integrityKey -> integrity
+	mu           sync.Mutex
+}
+
+// NewRemoteCacheRegistry wraps origin so that downloads consult the remote cache first
+func NewRemoteCacheRegistry(origin Registry, config *RegistryConfig, cacheManager cache.Manager, client *cache.RemoteClient, upload bool) *RemoteCacheRegistry {
+	return &RemoteCacheRegistry{
+		origin:       origin,
+		config:       config,
+		cacheManager: cacheManager,
+		client:       client,
+		upload:       upload,
+		expected:     make(map[string]string),
+	}
+}
+
+// Origin returns the wrapped registry
+func (r *RemoteCacheRegistry) Origin() Registry {
+	return r.origin
+}
+
+// ExpectIntegrity records the integrity cached content of a version, selected
+// by the given patterns, must have
+func (r *RemoteCacheRegistry) ExpectIntegrity(name, version string, patterns []string, integrity string) {
+	if checker, ok := r.origin.(IntegrityChecker); ok {
+		checker.ExpectIntegrity(name, version, patterns, integrity)
+	}
+	if integrity == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expected[integrityKey(name, version, patterns)] = integrity
+}
+
+// GetRulesets returns rulesets from the origin
+func (r *RemoteCacheRegistry) GetRulesets(ctx context.Context, patterns []string) ([]RulesetInfo, error) {
+	return r.origin.GetRulesets(ctx, patterns)
+}
+
+// GetRuleset returns ruleset information from the origin
+func (r *RemoteCacheRegistry) GetRuleset(ctx context.Context, name, version string) (*RulesetInfo, error) {
+	return r.origin.GetRuleset(ctx, name, version)
+}
+
+// GetVersions returns versions from the origin
+func (r *RemoteCacheRegistry) GetVersions(ctx context.Context, name string) ([]string, error) {
+	return r.origin.GetVersions(ctx, name)
+}
+
+// DownloadRuleset downloads a ruleset through the caches
+func (r *RemoteCacheRegistry) DownloadRuleset(ctx context.Context, name, version, destDir string) error {
+	return r.DownloadRulesetWithPatterns(ctx, name, version, destDir, nil)
+}
+
+// DownloadRulesetWithPatterns downloads a ruleset through the caches as loose files
+func (r *RemoteCacheRegistry) DownloadRulesetWithPatterns(ctx context.Context, name, version, destDir string, patterns []string) error {
+	_, err := r.DownloadRulesetWithResult(ctx, name, version, destDir, patterns)
+	return err
+}
+
+// DownloadRulesetWithResult downloads a ruleset as loose files from the local
+// cache, the remote cache or the origin, in that order
+func (r *RemoteCacheRegistry) DownloadRulesetWithResult(ctx context.Context, name, version, destDir string, patterns []string) (*DownloadResult, error) {
+	if resolved, ok := r.resolve(ctx, name, version); ok {
+		if files, err := r.cachedFiles(ctx, name, version, resolved, patterns); err == nil {
+			if result, err := r.serve(version, resolved, destDir, files); err == nil {
+				return result, nil
+			}
+		}
+		// Misses, unreachable remote caches and mismatching content fall back to the origin
+	}
+	return r.downloadFromOrigin(ctx, name, version, destDir, patterns)
+}
+
+// ResolveVersion resolves a version spec using the origin
+func (r *RemoteCacheRegistry) ResolveVersion(ctx context.Context, version string) (string, error) {
+	if resolver, ok := r.origin.(VersionSpecResolver); ok {
+		return resolver.ResolveVersion(ctx, version)
+	}
+	return version, nil
+}
+
+// CommitLog lists commits using the origin
+func (r *RemoteCacheRegistry) CommitLog(ctx context.Context, from, to string) ([]Commit, error) {
+	if historian, ok := r.origin.(CommitLogger); ok {
+		return historian.CommitLog(ctx, from, to)
+	}
+	return nil, fmt.Errorf("commit log not supported for registry %s", r.GetName())
+}
+
+// Search implements the Searcher interface using the origin
+func (r *RemoteCacheRegistry) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	if searcher, ok := r.origin.(Searcher); ok {
+		return searcher.Search(ctx, query)
+	}
+	return nil, fmt.Errorf("search not supported for registry %s", r.GetName())
+}
+
+// GetType returns the origin registry type
+func (r *RemoteCacheRegistry) GetType() string {
+	return r.origin.GetType()
+}
+
+// GetName returns the origin registry name
+func (r *RemoteCacheRegistry) GetName() string {
+	return r.origin.GetName()
+}
+
+// Close closes the origin registry
+func (r *RemoteCacheRegistry) Close() error {
+	return r.origin.Close()
+}
+
+// resolve maps a version spec to the concrete version content is cached under
+func (r *RemoteCacheRegistry) resolve(ctx context.Context, name, version string) (string, bool) {
+	if r.config.Type == "git" {
+		resolver, ok := r.origin.(VersionSpecResolver)
+		if !ok {
+			return "", false
+		}
+		resolved, err := resolver.ResolveVersion(ctx, version)
+		return resolved, err == nil && resolved != ""
+	}
+
+	// Exact versions need no lookup; specs resolve against the published versions
+	if version != "" && version != "latest" && !IsSemverPattern(version) {
+		return version, true
+	}
+	resolved := ResolveVersionSpec(ctx, r.origin, name, version)
+	return resolved, resolved != version
+}
+
+// cachedFiles returns the files of a resolved version from the local cache or,
+// failing that, the remote cache, whose content is then kept locally
+func (r *RemoteCacheRegistry) cachedFiles(ctx context.Context, name, version, resolved string, patterns []string) (map[string][]byte, error) {
+	storagePatterns := r.storagePatterns(patterns)
+	storage := r.cacheManager.GetRulesetStorage()
+	if files, err := storage.GetRulesetFiles(r.config.Type, r.config.URL, name, resolved, storagePatterns); err == nil && len(files) > 0 {
+		return r.checkIntegrity(name, resolved, patterns, r.matchingFiles(files, patterns), false)
+	}
+
+	// Content shared by other clients is only trusted when it can be verified
+	if r.expectedIntegrity(name, resolved, patterns) == "" {
+		return nil, fmt.Errorf("no locked integrity for %s@%s to verify remote cache content", name, resolved)
+	}
+
+	registryKey, err := r.cacheManager.GetCacheKey(r.config.Type, r.config.URL)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.client.Fetch(ctx, r.cacheManager.GetBlobStore(), registryKey, r.cacheManager.GetRulesetCacheKey(name, storagePatterns), resolved)
+	if err != nil {
+		logger.Debug("Remote cache miss: %s %s@%s: %v", r.config.URL, name, resolved, err)
+		return nil, err
+	}
+	logger.Debug("Remote cache hit: %s %s@%s", r.config.URL, name, resolved)
+	if _, err := r.checkIntegrity(name, resolved, patterns, r.matchingFiles(files, patterns), true); err != nil {
+		return nil, err
+	}
+	if err := cacheRulesetFiles(r.cacheManager, r.config, name, version, resolved, files, storagePatterns); err != nil {
+		return nil, err
+	}
+	return r.matchingFiles(files, patterns), nil
+}
+
+// serve writes cached files to destDir
+func (r *RemoteCacheRegistry) serve(version, resolved, destDir string, files map[string][]byte) (*DownloadResult, error) {
+	if err := clearDir(destDir); err != nil {
+		return nil, err
+	}
+	paths, err := writeRulesetFiles(destDir, files)
+	if err != nil {
+		_ = clearDir(destDir)
+		return nil, err
+	}
+	return &DownloadResult{
+		VersionSpec:     version,
+		ResolvedVersion: resolved,
+		Files:           paths,
+		Integrity:       ComputeFilesIntegrity(files),
+	}, nil
+}
+
+// downloadFromOrigin downloads from the origin, keeps the content in the local
+// cache and pushes it to the remote cache when uploads are enabled
+func (r *RemoteCacheRegistry) downloadFromOrigin(ctx context.Context, name, version, destDir string, patterns []string) (*DownloadResult, error) {
+	if err := clearDir(destDir); err != nil {
+		return nil, err
+	}
+
+	// Tarball registries are cached whole and filtered afterwards
+	storagePatterns := r.storagePatterns(patterns)
+	result, err := downloadWithResult(ctx, r.origin, name, version, destDir, storagePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	if files, err := readRulesetFiles(destDir); err == nil {
+		if cacheRulesetFiles(r.cacheManager, r.config, name, version, result.ResolvedVersion, files, storagePatterns) == nil && r.upload {
+			// Uploads are best effort; the origin already served the content
+			_ = r.client.Push(ctx, r.cacheManager, r.config.Type, r.config.URL, name, result.ResolvedVersion, storagePatterns)
+		}
+	}
+
+	if len(patterns) > 0 && r.config.Type != "git" {
+		if result.Files, err = filterFiles(destDir, patterns); err != nil {
+			return nil, err
+		}
+		result.Integrity = ""
+	}
+	return result, nil
+}
+
+// expectedIntegrity returns the integrity recorded for a version and patterns, if any
+func (r *RemoteCacheRegistry) expectedIntegrity(name, version string, patterns []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.expected[integrityKey(name, version, patterns)]
+}
+
+// checkIntegrity compares cached files with the integrity expected for the
+// version. Without an expected integrity, files pass unless required is set.
+func (r *RemoteCacheRegistry) checkIntegrity(name, version string, patterns []string, files map[string][]byte, required bool) (map[string][]byte, error) {
+	expected := r.expectedIntegrity(name, version, patterns)
+	if expected == "" && required {
+		return nil, fmt.Errorf("no integrity recorded for cached %s@%s", name, version)
+	}
+	if integrity := ComputeFilesIntegrity(files); expected != "" && integrity != expected {
+		return nil, fmt.Errorf("%w for cached %s@%s: expected %s, got %s", ErrIntegrityMismatch, name, version, expected, integrity)
+	}
+	return files, nil
+}
+
+// storagePatterns returns the patterns content is cached under; tarball
+// registries are cached without them
+func (r *RemoteCacheRegistry) storagePatterns(patterns []string) []string {
+	if r.config.Type == "git" {
+		return patterns
+	}
+	return nil
+}
+
+// matchingFiles returns the cached files matching patterns. Git content is
+// cached per pattern set and returned as is.
+func (r *RemoteCacheRegistry) matchingFiles(files map[string][]byte, patterns []string) map[string][]byte {
+	if len(patterns) == 0 || r.config.Type == "git" {
+		return files
+	}
+	matching := make(map[string][]byte, len(files))
+	for rel, content := range files {
+		if MatchesAnyPattern(rel, patterns) {
+			matching[rel] = content
+		}
+	}
+	return matching
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
+)
+
+func TestRemoteCacheRegistry_ReadThrough(t *testing.T) {
+	server := httptest.NewServer(cache.NewServer(cache.NewManager(t.TempDir()), cache.ServerOptions{AllowUpload: true}))
+	defer server.Close()
+	config := &RegistryConfig{Name: "team", Type: "https", URL: "https://rules.example.com"}
+	files := map[string]string{"rules/go.md": "go rules", "rules/extra.txt": "extra"}
+
+	// The first installation downloads from the origin and uploads to the remote cache
+	origin := &fakeRegistry{name: "team", files: files}
+	uploader := NewRemoteCacheRegistry(origin, config, cache.NewManager(t.TempDir()), cache.NewRemoteClient(server.URL, ""), true)
+	result, err := uploader.DownloadRulesetWithResult(context.Background(), "go", "1.0.0", t.TempDir(), []string{"**/*.md"})
+	if err != nil {
+		t.Fatalf("Expected origin download to succeed, got %v", err)
+	}
+	if origin.calls == 0 || len(result.Files) != 1 {
+		t.Fatalf("Expected the origin to serve the matching file, got %d calls and %v", origin.calls, result.Files)
+	}
+
+	// Without an integrity in the lock file, remote content cannot be verified
+	// and the origin serves the version
+	unlocked := &fakeRegistry{name: "team", files: files}
+	first := NewRemoteCacheRegistry(unlocked, config, cache.NewManager(t.TempDir()), cache.NewRemoteClient(server.URL, ""), false)
+	if _, err := first.DownloadRulesetWithResult(context.Background(), "go", "1.0.0", t.TempDir(), []string{"**/*.md"}); err != nil {
+		t.Fatalf("Expected origin download to succeed, got %v", err)
+	}
+	if unlocked.calls == 0 {
+		t.Error("Expected the origin to serve a version without locked integrity")
+	}
+
+	// Another installation of the locked version with an empty local cache is
+	// served by the remote cache
+	other := &fakeRegistry{name: "team", files: files}
+	otherCache := cache.NewManager(t.TempDir())
+	reader := NewRemoteCacheRegistry(other, config, otherCache, cache.NewRemoteClient(server.URL, ""), false)
+	reader.ExpectIntegrity("go", "1.0.0", []string{"**/*.md"}, ComputeFilesIntegrity(map[string][]byte{"rules/go.md": []byte("go rules")}))
+	destDir := t.TempDir()
+	result, err = reader.DownloadRulesetWithResult(context.Background(), "go", "1.0.0", destDir, []string{"**/*.md"})
+	if err != nil {
+		t.Fatalf("Expected remote cache download to succeed, got %v", err)
+	}
+	if other.calls != 0 {
+		t.Errorf("Expected the origin not to be contacted, got %d calls", other.calls)
+	}
+	if content, err := os.ReadFile(filepath.Join(destDir, "rules", "go.md")); err != nil || string(content) != "go rules" {
+		t.Errorf("Expected go.md from the remote cache, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "rules", "extra.txt")); !os.IsNotExist(err) {
+		t.Error("Expected files not matching the patterns to be left out")
+	}
+	if result.ResolvedVersion != "1.0.0" || result.Integrity == "" {
+		t.Errorf("Expected resolved version and integrity, got %+v", result)
+	}
+
+	// Remote content is now cached locally and available offline
+	offline, _ := NewOfflineRegistry(config, otherCache)
+	if _, err := offline.Lookup("go", "1.0.0", nil); err != nil {
+		t.Errorf("Expected the remote content to be cached locally, got %v", err)
+	}
+}
+
+func TestRemoteCacheRegistry_IntegrityMismatchFallsBackToOrigin(t *testing.T) {
+	server := httptest.NewServer(cache.NewServer(cache.NewManager(t.TempDir()), cache.ServerOptions{AllowUpload: true}))
+	defer server.Close()
+	config := &RegistryConfig{Name: "team", Type: "https", URL: "https://rules.example.com"}
+
+	// Poison the remote cache with different content for the version
+	poisoned := &fakeRegistry{name: "team", files: map[string]string{"rules.md": "tampered"}}
+	uploader := NewRemoteCacheRegistry(poisoned, config, cache.NewManager(t.TempDir()), cache.NewRemoteClient(server.URL, ""), true)
+	if _, err := uploader.DownloadRulesetWithResult(context.Background(), "go", "1.0.0", t.TempDir(), nil); err != nil {
+		t.Fatalf("Expected download to succeed, got %v", err)
+	}
+
+	genuine := map[string][]byte{"rules.md": []byte("genuine")}
+	origin := &fakeRegistry{name: "team", files: map[string]string{"rules.md": "genuine"}}
+	reader := NewRemoteCacheRegistry(origin, config, cache.NewManager(t.TempDir()), cache.NewRemoteClient(server.URL, ""), false)
+	reader.ExpectIntegrity("go", "1.0.0", nil, ComputeFilesIntegrity(genuine))
+
+	destDir := t.TempDir()
+	if _, err := reader.DownloadRulesetWithResult(context.Background(), "go", "1.0.0", destDir, nil); err != nil {
+		t.Fatalf("Expected download to succeed, got %v", err)
+	}
+	if origin.calls == 0 {
+		t.Error("Expected the origin to serve content not matching the lock file")
+	}
+	if content, _ := os.ReadFile(filepath.Join(destDir, "rules.md")); string(content) != "genuine" {
+		t.Errorf("Expected genuine content, got %q", content)
+	}
+}
@@ -10,10 +10,16 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 // RemoteGitOperations implements GitOperations for remote Git repositories
@@ -28,7 +34,7 @@ func NewRemoteGitOperations(config *RegistryConfig, auth *AuthConfig) *RemoteGit
 	return &RemoteGitOperations{
 		config: config,
 		auth:   auth,
-		client: &http.Client{Timeout: config.Timeout},
+		client: newHTTPClient(config),
 	}
 }
 
@@ -82,6 +88,14 @@ func (r *RemoteGitOperations) GetFiles(ctx context.Context, version string, patt
 	return r.getFilesClone(ctx, version, patterns)
 }
 
+// CommitLog returns the commits reachable from to but not from, newest first
+func (r *RemoteGitOperations) CommitLog(ctx context.Context, from, to string) ([]Commit, error) {
+	if r.auth.APIType == "github" {
+		return r.commitLogAPI(ctx, from, to)
+	}
+	return r.commitLogClone(ctx, from, to)
+}
+
 // API-based implementations
 
 func (r *RemoteGitOperations) resolveLatestAPI(ctx context.Context) (string, error) {
@@ -114,7 +128,7 @@ func (r *RemoteGitOperations) resolveBranchAPI(ctx context.Context, branch strin
 
 	if resp.StatusCode != http.StatusOK {
 		return "", &GitError{Operation: "resolve_branch", Repo: r.config.URL, Version: branch,
-			Cause: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, resp.Status)}
+			Cause: fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))}
 	}
 
 	var branchInfo struct {
@@ -154,7 +168,7 @@ func (r *RemoteGitOperations) resolveTagToCommitAPI(ctx context.Context, tag str
 
 	if resp.StatusCode != http.StatusOK {
 		return "", &GitError{Operation: "resolve_tag", Repo: r.config.URL, Version: tag,
-			Cause: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, resp.Status)}
+			Cause: fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))}
 	}
 
 	var tagInfo struct {
@@ -206,7 +220,7 @@ func (r *RemoteGitOperations) resolveAnnotatedTagAPI(ctx context.Context, tagSHA
 
 	if resp.StatusCode != http.StatusOK {
 		return "", &GitError{Operation: "resolve_annotated_tag", Repo: r.config.URL,
-			Cause: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, resp.Status)}
+			Cause: fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))}
 	}
 
 	var annotatedTag struct {
@@ -247,7 +261,7 @@ func (r *RemoteGitOperations) resolveDefaultBranchAPI(ctx context.Context) (stri
 
 	if resp.StatusCode != http.StatusOK {
 		return "", &GitError{Operation: "resolve_default_branch", Repo: r.config.URL,
-			Cause: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, resp.Status)}
+			Cause: fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))}
 	}
 
 	var repoInfo struct {
@@ -285,7 +299,7 @@ func (r *RemoteGitOperations) getVersionsAPI(ctx context.Context) ([]string, err
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, &GitError{Operation: "list_versions", Repo: r.config.URL,
-			Cause: fmt.Errorf("GitHub API error: %s", resp.Status)}
+			Cause: fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))}
 	}
 
 	var tags []struct {
@@ -303,6 +317,67 @@ func (r *RemoteGitOperations) getVersionsAPI(ctx context.Context) ([]string, err
 	return versions, nil
 }
 
+func (r *RemoteGitOperations) commitLogAPI(ctx context.Context, from, to string) ([]Commit, error) {
+	owner, repo, err := r.parseGitHubURL()
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s", owner, repo, from, to)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, http.NoBody)
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+
+	if r.auth.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.auth.Token)
+	}
+	if r.auth.APIVersion != "" {
+		req.Header.Set("X-GitHub-Api-Version", r.auth.APIVersion)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to,
+			Cause: fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))}
+	}
+
+	var comparison struct {
+		Commits []struct {
+			SHA    string `json:"sha"`
+			Commit struct {
+				Author struct {
+					Name string    `json:"name"`
+					Date time.Time `json:"date"`
+				} `json:"author"`
+				Message string `json:"message"`
+			} `json:"commit"`
+		} `json:"commits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&comparison); err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+
+	// The API lists commits oldest first
+	commits := make([]Commit, 0, len(comparison.Commits))
+	for i := len(comparison.Commits) - 1; i >= 0; i-- {
+		c := comparison.Commits[i]
+		commits = append(commits, Commit{
+			Hash:    c.SHA,
+			Author:  c.Commit.Author.Name,
+			Date:    c.Commit.Author.Date,
+			Subject: commitSubject(c.Commit.Message),
+		})
+	}
+	return commits, nil
+}
+
 func (r *RemoteGitOperations) getFilesAPI(ctx context.Context, version string, patterns []string) (map[string][]byte, error) {
 	owner, repo, err := r.parseGitHubURL()
 	if err != nil {
@@ -343,10 +418,11 @@ func (r *RemoteGitOperations) resolveLatestClone(ctx context.Context) (string, e
 }
 
 func (r *RemoteGitOperations) resolveBranchClone(ctx context.Context, branch string) (string, error) {
-	repoDir, err := r.getCachedRepository(ctx)
+	repoDir, cleanup, err := r.getCachedRepository(ctx)
 	if err != nil {
 		return "", &GitError{Operation: "resolve_branch", Repo: r.config.URL, Version: branch, Cause: err}
 	}
+	defer cleanup()
 
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
@@ -360,7 +436,7 @@ func (r *RemoteGitOperations) resolveBranchClone(ctx context.Context, branch str
 		branchRef, err = repo.Reference(plumbing.ReferenceName("refs/remotes/origin/"+branch), true)
 		if err != nil {
 			return "", &GitError{Operation: "resolve_branch", Repo: r.config.URL, Version: branch,
-				Cause: fmt.Errorf("branch '%s' not found: %w", branch, err)}
+				Cause: armerr.Errorf(armerr.NotFound, "branch '%s' not found: %w", branch, err)}
 		}
 	}
 
@@ -368,10 +444,11 @@ func (r *RemoteGitOperations) resolveBranchClone(ctx context.Context, branch str
 }
 
 func (r *RemoteGitOperations) getVersionsClone(ctx context.Context) ([]string, error) {
-	repoDir, err := r.getCachedRepository(ctx)
+	repoDir, cleanup, err := r.getCachedRepository(ctx)
 	if err != nil {
 		return nil, &GitError{Operation: "list_versions", Repo: r.config.URL, Cause: err}
 	}
+	defer cleanup()
 
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
@@ -399,10 +476,11 @@ func (r *RemoteGitOperations) getVersionsClone(ctx context.Context) ([]string, e
 }
 
 func (r *RemoteGitOperations) resolveTagToCommitClone(ctx context.Context, tag string) (string, error) {
-	repoDir, err := r.getCachedRepository(ctx)
+	repoDir, cleanup, err := r.getCachedRepository(ctx)
 	if err != nil {
 		return "", &GitError{Operation: "resolve_tag", Repo: r.config.URL, Version: tag, Cause: err}
 	}
+	defer cleanup()
 
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
@@ -416,7 +494,7 @@ func (r *RemoteGitOperations) resolveTagToCommitClone(ctx context.Context, tag s
 		tagRef, err = repo.Reference(plumbing.ReferenceName("refs/tags/v"+tag), true)
 		if err != nil {
 			return "", &GitError{Operation: "resolve_tag", Repo: r.config.URL, Version: tag,
-				Cause: fmt.Errorf("tag '%s' not found: %w", tag, err)}
+				Cause: armerr.Errorf(armerr.NotFound, "tag '%s' not found: %w", tag, err)}
 		}
 	}
 
@@ -424,10 +502,11 @@ func (r *RemoteGitOperations) resolveTagToCommitClone(ctx context.Context, tag s
 }
 
 func (r *RemoteGitOperations) resolveDefaultBranchClone(ctx context.Context) (string, error) {
-	repoDir, err := r.getCachedRepository(ctx)
+	repoDir, cleanup, err := r.getCachedRepository(ctx)
 	if err != nil {
 		return "", &GitError{Operation: "resolve_default_branch", Repo: r.config.URL, Cause: err}
 	}
+	defer cleanup()
 
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
@@ -444,11 +523,68 @@ func (r *RemoteGitOperations) resolveDefaultBranchClone(ctx context.Context) (st
 	return headRef.Hash().String(), nil
 }
 
+func (r *RemoteGitOperations) commitLogClone(ctx context.Context, from, to string) ([]Commit, error) {
+	repoDir, cleanup, err := r.getCachedRepository(ctx)
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+	defer cleanup()
+
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+
+	fromHash, err := repo.ResolveRevision(plumbing.Revision(from))
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: from,
+			Cause: armerr.Errorf(armerr.NotFound, "revision '%s' not found: %w", from, err)}
+	}
+	toHash, err := repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to,
+			Cause: armerr.Errorf(armerr.NotFound, "revision '%s' not found: %w", to, err)}
+	}
+
+	// Commits already contained in from are excluded, as in git log from..to
+	excluded := make(map[plumbing.Hash]bool)
+	fromLog, err := repo.Log(&git.LogOptions{From: *fromHash})
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: from, Cause: err}
+	}
+	_ = fromLog.ForEach(func(c *object.Commit) error {
+		excluded[c.Hash] = true
+		return nil
+	})
+
+	toLog, err := repo.Log(&git.LogOptions{From: *toHash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+	var commits []Commit
+	err = toLog.ForEach(func(c *object.Commit) error {
+		if !excluded[c.Hash] {
+			commits = append(commits, Commit{
+				Hash:    c.Hash.String(),
+				Author:  c.Author.Name,
+				Date:    c.Author.When,
+				Subject: commitSubject(c.Message),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &GitError{Operation: "commit_log", Repo: r.config.URL, Version: to, Cause: err}
+	}
+	return commits, nil
+}
+
 func (r *RemoteGitOperations) getFilesClone(ctx context.Context, version string, patterns []string) (map[string][]byte, error) {
-	repoDir, err := r.getCachedRepository(ctx)
+	repoDir, cleanup, err := r.getCachedRepository(ctx)
 	if err != nil {
 		return nil, &GitError{Operation: "get_files", Repo: r.config.URL, Version: version, Cause: err}
 	}
+	defer cleanup()
 
 	// Checkout specific version
 	if err := r.checkoutVersion(repoDir, version); err != nil {
@@ -491,22 +627,30 @@ func (r *RemoteGitOperations) parseGitHubURL() (owner, repo string, err error) {
 	return matches[1], matches[2], nil
 }
 
-func (r *RemoteGitOperations) getCachedRepository(ctx context.Context) (string, error) {
+// getCachedRepository clones the repository into a temporary directory, which
+// the returned cleanup function removes
+func (r *RemoteGitOperations) getCachedRepository(ctx context.Context) (string, func(), error) {
 	// Create temporary directory for repository operations
 	tempDir, err := os.MkdirTemp("", "arm-git-*")
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	repoDir := filepath.Join(tempDir, "repository")
+	cleanup := func() { _ = os.RemoveAll(tempDir) }
 
 	// Always clone fresh repository (no caching)
-	return r.cloneRepository(ctx, repoDir)
+	repoDir, err := r.cloneRepository(ctx, filepath.Join(tempDir, "repository"))
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return repoDir, cleanup, nil
 }
 
 // getCachedRepositoryAt clones repository to specific directory
 func (r *RemoteGitOperations) getCachedRepositoryAt(ctx context.Context, repoDir string) (string, error) {
 	// Check if repository already exists and is valid
 	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
+		logger.Debug("Using cached clone of %s at %s", r.config.URL, repoDir)
 		return repoDir, nil
 	}
 
@@ -587,9 +731,10 @@ func (r *RemoteGitOperations) getFilesCloneAtWithRulesetCache(ctx context.Contex
 func (r *RemoteGitOperations) cloneRepository(ctx context.Context, repoDir string) (string, error) {
 	cloneURL := strings.TrimPrefix(r.config.URL, "file://")
 
+	progress.Report(ctx, progress.Downloading, "cloning repository")
 	cloneOptions := &git.CloneOptions{
 		URL:      cloneURL,
-		Progress: nil,
+		Progress: progress.Writer(ctx),
 	}
 
 	if r.auth.Token != "" {
@@ -604,8 +749,16 @@ func (r *RemoteGitOperations) cloneRepository(ctx context.Context, repoDir strin
 		}
 	}
 
-	_, err := git.PlainCloneContext(ctx, repoDir, false, cloneOptions)
+	err := Retry(ctx, r.config.RetryConfig, func() error {
+		// Remove partial clones left behind by a failed attempt
+		_ = os.RemoveAll(repoDir)
+		defer logger.Timer("git clone %s", cloneURL)()
+		_, cloneErr := git.PlainCloneContext(ctx, repoDir, false, cloneOptions)
+		return cloneErr
+	})
 	if err != nil {
+		// A partial clone would later be mistaken for a complete one
+		_ = os.RemoveAll(repoDir)
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
 
@@ -616,6 +769,7 @@ func (r *RemoteGitOperations) checkoutVersion(repoDir, version string) error {
 	if version == "latest" {
 		return nil // Already on latest after clone/pull
 	}
+	defer logger.Timer("git checkout %s", version)()
 
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
@@ -742,7 +896,7 @@ func (r *RemoteGitOperations) getFileTreeAPI(ctx context.Context, owner, repo st
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("GitHub API error: %w", NewHTTPStatusError(resp))
 	}
 
 	var treeResp struct {
@@ -799,7 +953,7 @@ func (r *RemoteGitOperations) downloadFileContentAPI(ctx context.Context, owner,
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error %d for %s: %s", resp.StatusCode, filePath, resp.Status)
+		return nil, fmt.Errorf("GitHub API error for %s: %w", filePath, NewHTTPStatusError(resp))
 	}
 
 	var content struct {
@@ -823,3 +977,9 @@ func (r *RemoteGitOperations) downloadFileContentAPI(ctx context.Context, owner,
 
 	return io.ReadAll(fileResp.Body)
 }
+
+// commitSubject returns the first line of a commit message
+func commitSubject(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(subject)
+}
@@ -0,0 +1,508 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+)
+
+// Retryable error classes accepted in RetryConfig.RetryableErrors
+const (
+	RetryClassServerError = "5xx"
+	RetryClassRateLimited = "429"
+	RetryClassConnection  = "connection"
+	RetryClassTimeout     = "timeout"
+	RetryClassThrottling  = "throttling"
+)
+
+// allRetryClasses lists every transient error class in evaluation order
+var allRetryClasses = []string{
+	RetryClassServerError,
+	RetryClassRateLimited,
+	RetryClassConnection,
+	RetryClassTimeout,
+	RetryClassThrottling,
+}
+
+// throttlingErrorCodes are AWS-style error codes that indicate throttling
+var throttlingErrorCodes = []string{
+	"Throttling",
+	"ThrottlingException",
+	"ThrottledException",
+	"RequestThrottled",
+	"RequestThrottledException",
+	"TooManyRequestsException",
+	"RequestLimitExceeded",
+	"SlowDown",
+}
+
+// DefaultRetryConfig returns the retry configuration used when none is configured
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxAttempts:       3,
+		BackoffMultiplier: 2.0,
+		InitialBackoff:    500 * time.Millisecond,
+		MaxBackoff:        30 * time.Second,
+		MaxRetryAfter:     5 * time.Minute,
+		RetryableErrors:   append([]string{}, allRetryClasses...),
+	}
+}
+
+// HTTPStatusError represents a non-success HTTP response from a registry
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP %s from %s", e.Status, e.URL)
+}
+
+// ErrorKind classifies the response status
+func (e *HTTPStatusError) ErrorKind() armerr.Kind {
+	return statusKind(e.StatusCode)
+}
+
+// NewHTTPStatusError creates an HTTPStatusError from a response
+func NewHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	url := ""
+	if resp.Request != nil && resp.Request.URL != nil {
+		url = resp.Request.URL.String()
+	}
+	return &HTTPStatusError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// ClassifyError returns the transient error class of err, or an empty string
+// when the error should not be retried
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// Cancellation by the caller is never transient
+	if errors.Is(err, context.Canceled) {
+		return ""
+	}
+
+	if class := classifyStatusCode(statusCodeOf(err)); class != "" {
+		return class
+	}
+
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && contains(throttlingErrorCodes, coded.ErrorCode()) {
+		return RetryClassThrottling
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return RetryClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return RetryClassTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return RetryClassConnection
+	}
+
+	// Some libraries flatten the underlying error into a string
+	msg := err.Error()
+	if strings.Contains(msg, "connection reset by peer") ||
+		strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "unexpected EOF") {
+		return RetryClassConnection
+	}
+
+	return ""
+}
+
+// statusCodeOf extracts an HTTP status code from known error types
+func statusCodeOf(err error) int {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+
+	// AWS SDK response errors
+	var sdkErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &sdkErr) {
+		return sdkErr.HTTPStatusCode()
+	}
+
+	// go-git wraps HTTP transport errors without supporting errors.As
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		err = unexpected.Err
+	}
+	var gitErr *githttp.Err
+	if errors.As(err, &gitErr) {
+		return gitErr.StatusCode()
+	}
+
+	return 0
+}
+
+// statusKind maps an HTTP status code to an error kind
+func statusKind(code int) armerr.Kind {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return armerr.Auth
+	case code == http.StatusNotFound:
+		return armerr.NotFound
+	case code == http.StatusTooManyRequests || code >= 500:
+		return armerr.Network
+	default:
+		return armerr.Unknown
+	}
+}
+
+// causeKind classifies an unclassified error from a git or HTTP transport
+func causeKind(err error) armerr.Kind {
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return armerr.Auth
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return armerr.NotFound
+	}
+	if kind := statusKind(statusCodeOf(err)); kind != armerr.Unknown {
+		return kind
+	}
+	if ClassifyError(err) != "" {
+		return armerr.Network
+	}
+	return armerr.Unknown
+}
+
+// classifyStatusCode maps an HTTP status code to a transient error class
+func classifyStatusCode(code int) string {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return RetryClassRateLimited
+	case code >= 500 && code != http.StatusNotImplemented && code != http.StatusHTTPVersionNotSupported:
+		return RetryClassServerError
+	default:
+		return ""
+	}
+}
+
+// IsRetryable reports whether err belongs to a transient class enabled in the config
+func (c *RetryConfig) IsRetryable(err error) bool {
+	return c.allows(ClassifyError(err))
+}
+
+// allows reports whether the given error class is enabled
+func (c *RetryConfig) allows(class string) bool {
+	if class == "" {
+		return false
+	}
+	if len(c.RetryableErrors) == 0 {
+		return true
+	}
+	for _, enabled := range c.RetryableErrors {
+		if strings.EqualFold(strings.TrimSpace(enabled), class) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry (1 = first retry)
+func (c *RetryConfig) Backoff(retry int) time.Duration {
+	initial := c.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultRetryConfig().InitialBackoff
+	}
+	multiplier := c.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := time.Duration(float64(initial) * math.Pow(multiplier, float64(retry-1)))
+	if c.MaxBackoff > 0 && (delay > c.MaxBackoff || delay <= 0) {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
+
+// attempts returns the total number of attempts allowed (at least one)
+func (c *RetryConfig) attempts() int {
+	if c.MaxAttempts < 1 {
+		return 1
+	}
+	return c.MaxAttempts
+}
+
+// delayFor returns the wait before the next attempt. Retry-After hints may
+// exceed MaxBackoff and are honoured up to MaxRetryAfter.
+func (c *RetryConfig) delayFor(retry int, retryAfter time.Duration) time.Duration {
+	if c.MaxRetryAfter > 0 && retryAfter > c.MaxRetryAfter {
+		retryAfter = c.MaxRetryAfter
+	}
+	if delay := c.Backoff(retry); delay > retryAfter {
+		return delay
+	}
+	return retryAfter
+}
+
+// Retry runs op until it succeeds, fails with a non-transient error, or the
+// configured attempts are exhausted. A nil config uses DefaultRetryConfig.
+func Retry(ctx context.Context, config *RetryConfig, op func() error) error {
+	if config == nil {
+		config = DefaultRetryConfig()
+	}
+
+	var err error
+	for attempt := 1; attempt <= config.attempts(); attempt++ {
+		if err = op(); err == nil {
+			return nil
+		}
+		if attempt == config.attempts() || !config.IsRetryable(err) {
+			break
+		}
+
+		var retryAfter time.Duration
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+
+		if waitErr := sleepContext(ctx, config.delayFor(attempt, retryAfter)); waitErr != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryTransport is an http.RoundTripper that retries transient failures
+type retryTransport struct {
+	base    http.RoundTripper
+	config  *RetryConfig
+	timeout time.Duration // Limit for each attempt, including reading the body; zero for none
+}
+
+// NewRetryTransport wraps base so that transient failures are retried according to config
+func NewRetryTransport(base http.RoundTripper, config *RetryConfig) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if config == nil {
+		config = DefaultRetryConfig()
+	}
+	return &retryTransport{base: base, config: config}
+}
+
+// RoundTrip executes the request, retrying transient errors and status codes
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests with bodies can only be replayed when the body can be recreated
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		start := time.Now()
+		resp, err := t.roundTripOnce(req)
+		logRoundTrip(req, resp, err, attempt, time.Since(start))
+
+		var class string
+		var retryAfter time.Duration
+		if err != nil {
+			class = ClassifyError(err)
+		} else {
+			class = classifyStatusCode(resp.StatusCode)
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+
+		if !replayable || attempt >= t.config.attempts() || !t.config.allows(class) {
+			return resp, err
+		}
+
+		// Discard the failed response before trying again
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		if waitErr := sleepContext(req.Context(), t.config.delayFor(attempt, retryAfter)); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+// roundTripOnce runs a single attempt, bounded by the per-attempt timeout
+func (t *retryTransport) roundTripOnce(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The deadline also covers reading the body and is released on Close
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnCloseBody releases an attempt's context when its body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// logRoundTrip logs an HTTP request with --verbose
+func logRoundTrip(req *http.Request, resp *http.Response, err error, attempt int, elapsed time.Duration) {
+	if !logger.Verbose() {
+		return
+	}
+	retry := ""
+	if attempt > 1 {
+		retry = fmt.Sprintf(" (attempt %d)", attempt)
+	}
+	if err != nil {
+		logger.Debug("HTTP %s %s%s failed after %s: %v", req.Method, req.URL.Redacted(), retry, elapsed.Round(time.Millisecond), err)
+		return
+	}
+	logger.Debug("HTTP %s %s%s -> %s in %s", req.Method, req.URL.Redacted(), retry, resp.Status, elapsed.Round(time.Millisecond))
+}
+
+// newHTTPClient creates an HTTP client for a registry with retries enabled.
+// The registry timeout applies to each attempt rather than to the whole
+// request, so that backoff between attempts does not count against it.
+func newHTTPClient(config *RegistryConfig) *http.Client {
+	retryConfig := config.RetryConfig
+	if retryConfig == nil {
+		retryConfig = DefaultRetryConfig()
+	}
+	return &http.Client{Transport: &retryTransport{
+		base:    http.DefaultTransport,
+		config:  retryConfig,
+		timeout: config.Timeout,
+	}}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryConfigFromSettings builds a retry configuration from .armrc key/value
+// sections. Later layers override earlier ones, so callers typically pass
+// [network], the type defaults section and then [registries.<name>].
+func RetryConfigFromSettings(layers ...map[string]string) *RetryConfig {
+	cfg := DefaultRetryConfig()
+
+	for _, settings := range layers {
+		if settings == nil {
+			continue
+		}
+
+		if v, ok := settings["retry.maxAttempts"]; ok && v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				cfg.MaxAttempts = n
+			}
+		}
+		if v, ok := settings["retry.backoffMultiplier"]; ok && v != "" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 1 {
+				cfg.BackoffMultiplier = f
+			}
+		}
+		if v, ok := settings["retry.initialBackoff"]; ok && v != "" {
+			if d, err := parseSecondsOrDuration(v); err == nil {
+				cfg.InitialBackoff = d
+			}
+		}
+		if v, ok := settings["retry.maxBackoff"]; ok && v != "" {
+			if d, err := parseSecondsOrDuration(v); err == nil {
+				cfg.MaxBackoff = d
+			}
+		}
+		if v, ok := settings["retry.maxRetryAfter"]; ok && v != "" {
+			if d, err := parseSecondsOrDuration(v); err == nil {
+				cfg.MaxRetryAfter = d
+			}
+		}
+		if v, ok := settings["retry.retryableErrors"]; ok && v != "" {
+			var classes []string
+			for _, class := range strings.Split(v, ",") {
+				if class = strings.TrimSpace(class); class != "" {
+					classes = append(classes, class)
+				}
+			}
+			cfg.RetryableErrors = classes
+		}
+	}
+
+	return cfg
+}
+
+// parseSecondsOrDuration parses either a plain number of seconds or a Go duration string
+func parseSecondsOrDuration(value string) (time.Duration, error) {
+	return config.ParseSeconds(value)
+}
@@ -0,0 +1,427 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+)
+
+// testRetryConfig returns a retry configuration with short delays for tests
+func testRetryConfig(attempts int) *RetryConfig {
+	cfg := DefaultRetryConfig()
+	cfg.MaxAttempts = attempts
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = 10 * time.Millisecond
+	return cfg
+}
+
+// faultyHandler fails the first n requests with status before delegating to next
+func faultyHandler(n int32, status int, header http.Header, next http.HandlerFunc) (http.HandlerFunc, *int32) {
+	var calls int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= n {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		next(w, r)
+	}, &calls
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte("ok"))
+}
+
+func TestRetryTransport_RetriesServerErrors(t *testing.T) {
+	handler, calls := faultyHandler(2, http.StatusBadGateway, nil, okHandler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, testRetryConfig(3))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+}
+
+func TestRetryTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	handler, calls := faultyHandler(10, http.StatusServiceUnavailable, nil, okHandler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, testRetryConfig(3))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected final status 503, got %d", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+}
+
+func TestRetryTransport_DoesNotRetryClientErrors(t *testing.T) {
+	handler, calls := faultyHandler(10, http.StatusNotFound, nil, okHandler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, testRetryConfig(3))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
+
+func TestRetryTransport_HonoursRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"1"}}
+	handler, calls := faultyHandler(1, http.StatusTooManyRequests, header, okHandler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cfg := testRetryConfig(2)
+	cfg.MaxBackoff = 2 * time.Second
+
+	client := &http.Client{Transport: NewRetryTransport(nil, cfg)}
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected to wait for Retry-After, waited %v", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestRetryTransport_RetriesConnectionReset(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// Abort the connection with a RST instead of a response
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack failed: %v", err)
+				return
+			}
+			if tcpConn, ok := conn.(*net.TCPConn); ok {
+				_ = tcpConn.SetLinger(0)
+			}
+			_ = conn.Close()
+			return
+		}
+		okHandler(w, r)
+	}))
+	defer server.Close()
+
+	transport := &http.Transport{DisableKeepAlives: true}
+	client := &http.Client{Transport: NewRetryTransport(transport, testRetryConfig(3))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestRetryTransport_RespectsRetryableErrors(t *testing.T) {
+	handler, calls := faultyHandler(10, http.StatusBadGateway, nil, okHandler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cfg := testRetryConfig(3)
+	cfg.RetryableErrors = []string{RetryClassRateLimited}
+
+	client := &http.Client{Transport: NewRetryTransport(nil, cfg)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Expected 1 request when 5xx is not retryable, got %d", got)
+	}
+}
+
+func TestHTTPClient_TimeoutAppliesPerAttempt(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			<-r.Context().Done() // Hang until the attempt times out
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			okHandler(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Backoff alone exceeds the timeout, which must not end the request
+	cfg := testRetryConfig(3)
+	cfg.InitialBackoff = 100 * time.Millisecond
+	cfg.MaxBackoff = time.Second
+	client := newHTTPClient(&RegistryConfig{Timeout: 80 * time.Millisecond, RetryConfig: cfg})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("Expected body ok, got %q (%v)", body, err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+}
+
+func TestHTTPSRegistry_RetriesManifest(t *testing.T) {
+	handler, calls := faultyHandler(2, http.StatusBadGateway, nil, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(HTTPSManifest{
+			Rulesets: map[string][]string{"python-rules": {"1.0.0"}},
+		})
+	})
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	config := &RegistryConfig{
+		Name:        "test-https",
+		Type:        "https",
+		URL:         server.URL,
+		Timeout:     30 * time.Second,
+		RetryConfig: testRetryConfig(3),
+	}
+	registry, err := NewHTTPSRegistry(config, &AuthConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Skip TLS verification for test
+	registry.client.Transport = NewRetryTransport(&http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}, config.RetryConfig)
+
+	versions, err := registry.GetVersions(context.Background(), "python-rules")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(versions) != 1 || versions[0] != "1.0.0" {
+		t.Errorf("Expected [1.0.0], got %v", versions)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cfg := testRetryConfig(3)
+
+	attempts := 0
+	err := Retry(context.Background(), cfg, func() error {
+		attempts++
+		if attempts < 3 {
+			return &HTTPStatusError{StatusCode: http.StatusInternalServerError, Status: "500"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	attempts = 0
+	permanent := errors.New("repository not found")
+	err = Retry(context.Background(), cfg, func() error {
+		attempts++
+		return permanent
+	})
+	if !errors.Is(err, permanent) {
+		t.Errorf("Expected permanent error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt for permanent error, got %d", attempts)
+	}
+}
+
+type throttlingError struct{}
+
+func (throttlingError) Error() string     { return "slow down" }
+func (throttlingError) ErrorCode() string { return "SlowDown" }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"server error", &HTTPStatusError{StatusCode: 502}, RetryClassServerError},
+		{"not implemented", &HTTPStatusError{StatusCode: 501}, ""},
+		{"rate limited", &HTTPStatusError{StatusCode: 429}, RetryClassRateLimited},
+		{"not found", &HTTPStatusError{StatusCode: 404}, ""},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), RetryClassConnection},
+		{"truncated response", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), RetryClassConnection},
+		{"end of input", fmt.Errorf("decode: %w", io.EOF), ""},
+		{"deadline", context.DeadlineExceeded, RetryClassTimeout},
+		{"canceled", context.Canceled, ""},
+		{"throttling", fmt.Errorf("s3: %w", throttlingError{}), RetryClassThrottling},
+		{"other", errors.New("invalid manifest"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want armerr.Kind
+	}{
+		{"unauthorized", &HTTPStatusError{StatusCode: 401}, armerr.Auth},
+		{"forbidden", fmt.Errorf("GitLab API error: %w", &HTTPStatusError{StatusCode: 403}), armerr.Auth},
+		{"not found", &HTTPStatusError{StatusCode: 404}, armerr.NotFound},
+		{"server error", &HTTPStatusError{StatusCode: 503}, armerr.Network},
+		{"bad request", &HTTPStatusError{StatusCode: 400}, armerr.Unknown},
+		{"git auth", &GitError{Operation: "clone", Cause: transport.ErrAuthenticationRequired}, armerr.Auth},
+		{"git missing repository", &GitError{Operation: "clone", Cause: transport.ErrRepositoryNotFound}, armerr.NotFound},
+		{"git connection reset", &GitError{Operation: "fetch", Cause: syscall.ECONNRESET}, armerr.Network},
+		{"git canceled", &GitError{Operation: "fetch", Cause: context.Canceled}, armerr.Canceled},
+		{"not cached", fmt.Errorf("rules@1.0.0: %w", ErrNotCached), armerr.NotCached},
+		{"integrity", fmt.Errorf("%w: expected sha256-a", ErrIntegrityMismatch), armerr.Integrity},
+		{"credentials expired", ErrCredentialsExpired, armerr.Auth},
+		{"unsatisfiable", func() error { _, err := ResolveSemverPattern("^9.0.0", []string{"v1.0.0"}); return err }(), armerr.Unsatisfiable},
+		{"invalid registry", ValidateRegistryConfig(&RegistryConfig{Name: "x", Type: "svn"}), armerr.Config},
+		{"missing local repository", NewRepositoryNotFoundError("/missing"), armerr.Config},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := armerr.KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	cfg := &RetryConfig{
+		BackoffMultiplier: 2,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        300 * time.Millisecond,
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := cfg.Backoff(i + 1); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestRetryConfig_DelayFor(t *testing.T) {
+	cfg := &RetryConfig{
+		BackoffMultiplier: 2,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		MaxRetryAfter:     time.Minute,
+	}
+
+	tests := []struct {
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{10 * time.Second, 10 * time.Second}, // beyond maxBackoff
+		{time.Hour, time.Minute},             // beyond maxRetryAfter
+	}
+	for _, tt := range tests {
+		if got := cfg.delayFor(1, tt.retryAfter); got != tt.want {
+			t.Errorf("delayFor(1, %v) = %v, want %v", tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestRetryConfigFromSettings(t *testing.T) {
+	network := map[string]string{
+		"retry.maxAttempts":     "5",
+		"retry.maxBackoff":      "60",
+		"retry.maxRetryAfter":   "10m",
+		"retry.initialBackoff":  "250ms",
+		"retry.retryableErrors": "5xx, 429",
+	}
+	registry := map[string]string{
+		"retry.maxAttempts":       "2",
+		"retry.backoffMultiplier": "1.5",
+	}
+
+	cfg := RetryConfigFromSettings(network, nil, registry)
+
+	if cfg.MaxAttempts != 2 {
+		t.Errorf("Expected registry override of maxAttempts to 2, got %d", cfg.MaxAttempts)
+	}
+	if cfg.BackoffMultiplier != 1.5 {
+		t.Errorf("Expected backoffMultiplier 1.5, got %v", cfg.BackoffMultiplier)
+	}
+	if cfg.MaxBackoff != 60*time.Second {
+		t.Errorf("Expected maxBackoff 60s, got %v", cfg.MaxBackoff)
+	}
+	if cfg.MaxRetryAfter != 10*time.Minute {
+		t.Errorf("Expected maxRetryAfter 10m, got %v", cfg.MaxRetryAfter)
+	}
+	if cfg.InitialBackoff != 250*time.Millisecond {
+		t.Errorf("Expected initialBackoff 250ms, got %v", cfg.InitialBackoff)
+	}
+	if len(cfg.RetryableErrors) != 2 || cfg.RetryableErrors[1] != "429" {
+		t.Errorf("Expected retryableErrors [5xx 429], got %v", cfg.RetryableErrors)
+	}
+}
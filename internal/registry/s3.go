@@ -2,15 +2,22 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 )
 
 // S3Registry implements the Registry interface for S3 buckets
@@ -29,7 +36,7 @@ func NewS3Registry(config *RegistryConfig, auth *AuthConfig) (*S3Registry, error
 	}
 
 	// Load AWS configuration
-	awsConfig, err := loadAWSConfig(context.Background(), auth)
+	awsConfig, err := loadAWSConfig(context.Background(), auth, config.RetryConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -37,8 +44,11 @@ func NewS3Registry(config *RegistryConfig, auth *AuthConfig) (*S3Registry, error
 	// Create S3 client
 	client := s3.NewFromConfig(awsConfig)
 
-	// Parse bucket and prefix from URL
+	// Parse bucket and prefix from URL; the prefix setting takes precedence
 	bucket, prefix := parseBucketURL(config.URL)
+	if custom := strings.Trim(config.customString("prefix"), "/"); custom != "" {
+		prefix = custom + "/"
+	}
 
 	return &S3Registry{
 		config: config,
@@ -123,7 +133,7 @@ func (s *S3Registry) GetRuleset(ctx context.Context, name, version string) (*Rul
 		}
 	}
 
-	return nil, fmt.Errorf("ruleset %s not found", name)
+	return nil, armerr.Errorf(armerr.NotFound, "ruleset %s not found", name)
 }
 
 // DownloadRuleset downloads a ruleset to the specified directory
@@ -165,7 +175,7 @@ func (s *S3Registry) DownloadRulesetWithPatterns(ctx context.Context, name, vers
 	defer func() { _ = destFile.Close() }()
 
 	// Copy tarball content
-	_, err = io.Copy(destFile, result.Body)
+	_, err = io.Copy(destFile, progress.NewReader(ctx, result.Body, aws.ToInt64(result.ContentLength)))
 	return err
 }
 
@@ -223,9 +233,13 @@ func (s *S3Registry) Close() error {
 }
 
 // loadAWSConfig loads AWS configuration with credential chain
-func loadAWSConfig(ctx context.Context, auth *AuthConfig) (aws.Config, error) {
+func loadAWSConfig(ctx context.Context, auth *AuthConfig, retryConfig *RetryConfig) (aws.Config, error) {
+	retryer := config.WithRetryer(func() aws.Retryer {
+		return newS3Retryer(retryConfig)
+	})
+
 	// Start with default config loading (uses credential chain)
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(auth.Region))
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(auth.Region), retryer)
 	if err != nil {
 		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -235,6 +249,7 @@ func loadAWSConfig(ctx context.Context, auth *AuthConfig) (aws.Config, error) {
 		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(auth.Region),
 			config.WithSharedConfigProfile(auth.Profile),
+			retryer,
 		)
 		if err != nil {
 			return aws.Config{}, fmt.Errorf("failed to load AWS config with profile %s: %w", auth.Profile, err)
@@ -244,6 +259,39 @@ func loadAWSConfig(ctx context.Context, auth *AuthConfig) (aws.Config, error) {
 	return cfg, nil
 }
 
+// newS3Retryer maps the registry retry configuration onto the AWS SDK retryer.
+// The configured error classes decide what is retried; errors ARM does not
+// classify keep the SDK's own verdict.
+func newS3Retryer(retryConfig *RetryConfig) aws.Retryer {
+	if retryConfig == nil {
+		retryConfig = DefaultRetryConfig()
+	}
+
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = retryConfig.attempts()
+		o.Backoff = retry.BackoffDelayerFunc(func(attempt int, err error) (time.Duration, error) {
+			return retryConfig.delayFor(attempt, s3RetryAfter(err)), nil
+		})
+
+		sdkRetryables := o.Retryables
+		o.Retryables = []retry.IsErrorRetryable{retry.IsErrorRetryableFunc(func(err error) aws.Ternary {
+			if class := ClassifyError(err); class != "" {
+				return aws.BoolTernary(retryConfig.allows(class))
+			}
+			return retry.IsErrorRetryables(sdkRetryables).IsErrorRetryable(err)
+		})}
+	})
+}
+
+// s3RetryAfter returns the Retry-After hint of a failed S3 response, if any
+func s3RetryAfter(err error) time.Duration {
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		return parseRetryAfter(respErr.Response.Header.Get("Retry-After"))
+	}
+	return 0
+}
+
 // parseBucketURL parses bucket name and prefix from URL
 func parseBucketURL(url string) (bucket, prefix string) {
 	// Handle formats like:
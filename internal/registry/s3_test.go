@@ -1,7 +1,9 @@
 package registry
 
 import (
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestNewS3RegistryInvalidConfig(t *testing.T) {
@@ -153,3 +155,37 @@ func TestS3RegistryClose(t *testing.T) {
 		t.Errorf("Expected no error from Close(), got: %v", err)
 	}
 }
+
+// statusCodeError mimics an AWS SDK response error
+type statusCodeError int
+
+func (e statusCodeError) Error() string       { return fmt.Sprintf("HTTP %d", int(e)) }
+func (e statusCodeError) HTTPStatusCode() int { return int(e) }
+
+func TestNewS3Retryer(t *testing.T) {
+	cfg := &RetryConfig{
+		MaxAttempts:       4,
+		BackoffMultiplier: 3,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		RetryableErrors:   []string{RetryClassRateLimited},
+	}
+	retryer := newS3Retryer(cfg)
+
+	if got := retryer.MaxAttempts(); got != 4 {
+		t.Errorf("MaxAttempts() = %d, want 4", got)
+	}
+	if !retryer.IsErrorRetryable(statusCodeError(429)) {
+		t.Error("Expected 429 to be retried")
+	}
+	if retryer.IsErrorRetryable(statusCodeError(503)) {
+		t.Error("Expected 503 not to be retried when 5xx is not a retryable error class")
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second}
+	for i, want := range expected {
+		if got, err := retryer.RetryDelay(i+1, statusCodeError(429)); err != nil || got != want {
+			t.Errorf("RetryDelay(%d) = %v, %v; want %v", i+1, got, err, want)
+		}
+	}
+}
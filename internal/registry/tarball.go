@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tarballName is the file name used by tarball-based registries
+const tarballName = "ruleset.tar.gz"
+
+// CreateTarball packs the files under srcDir into a gzipped tarball at tarPath,
+// using paths relative to srcDir in sorted order
+func CreateTarball(srcDir, tarPath string) error {
+	var files []string
+	err := filepath.Walk(srcDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", srcDir, err)
+	}
+	sort.Strings(files)
+
+	if err := os.MkdirAll(filepath.Dir(tarPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	out, err := os.Create(tarPath)
+	if err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		if err := addTarFile(tw, filepath.Join(srcDir, filepath.FromSlash(rel)), rel); err != nil {
+			return fmt.Errorf("failed to add %s to tarball: %w", rel, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write tarball: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write tarball: %w", err)
+	}
+	return out.Close()
+}
+
+// addTarFile writes a single regular file to a tar stream
+func addTarFile(tw *tar.Writer, filePath, name string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// ExtractTarball extracts a gzipped tarball into destDir and returns the extracted file paths
+func ExtractTarball(tarPath, destDir string) ([]string, error) {
+	var files []string
+	err := walkTarball(tarPath, func(name string, r io.Reader) error {
+		target := filepath.Join(destDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, r); err != nil {
+			_ = out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		files = append(files, target)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", filepath.Base(tarPath), err)
+	}
+	return files, nil
+}
+
+// walkTarball calls fn for each regular file in a gzipped tarball with a cleaned relative name
+func walkTarball(tarPath string, fn func(name string, r io.Reader) error) error {
+	file, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if name == "" || !ValidatePath(name) {
+			return fmt.Errorf("invalid path in tarball: %s", header.Name)
+		}
+		if err := fn(name, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarball_RoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	files := map[string]string{"rules/python.md": "python", "README.md": "readme"}
+	for rel, content := range files {
+		path := filepath.Join(srcDir, rel)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte(content), 0o644)
+	}
+
+	tarDir := t.TempDir()
+	tarPath := filepath.Join(tarDir, tarballName)
+	if err := CreateTarball(srcDir, tarPath); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The packed ruleset keeps the integrity of the loose files
+	loose, _ := ComputeIntegrity(srcDir)
+	packed, _ := ComputeIntegrity(tarDir)
+	if loose != packed {
+		t.Errorf("Expected equal integrity, got %s and %s", loose, packed)
+	}
+
+	destDir := t.TempDir()
+	extracted, err := ExtractTarball(tarPath, destDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(extracted) != len(files) {
+		t.Errorf("Expected %d files, got %v", len(files), extracted)
+	}
+	for rel, want := range files {
+		got, err := os.ReadFile(filepath.Join(destDir, rel))
+		if err != nil || string(got) != want {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", rel, want, got, err)
+		}
+	}
+}
+
+func TestExtractTarball_ContainsTraversal(t *testing.T) {
+	root := t.TempDir()
+	tarPath := filepath.Join(root, tarballName)
+	writeTarball(t, tarPath, map[string]string{"../escape.md": "evil"})
+
+	destDir := filepath.Join(root, "dest")
+	if _, err := ExtractTarball(tarPath, destDir); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.md")); !os.IsNotExist(err) {
+		t.Error("Expected extraction to stay inside the destination")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "escape.md")); err != nil {
+		t.Errorf("Expected escape.md inside the destination: %v", err)
+	}
+}
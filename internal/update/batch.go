@@ -0,0 +1,56 @@
+package update
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/max-dunn/ai-rules-manager/internal/install"
+)
+
+// CheckOutdatedAll checks rulesets concurrently, limited by the concurrency
+// and rate limit of each registry. Results are in the order of rulesetSpecs,
+// nil for the rulesets listed in the returned failures.
+func (s *Service) CheckOutdatedAll(ctx context.Context, rulesetSpecs []string, target Target, progress install.ProgressCallback) ([]*UpdateResult, []install.InstallError) {
+	results := make([]*UpdateResult, len(rulesetSpecs))
+	failures := s.runAll(ctx, rulesetSpecs, "Checked", progress, func(ctx context.Context, i int) (err error) {
+		results[i], err = s.CheckOutdated(ctx, rulesetSpecs[i], target)
+		return err
+	})
+	return results, failures
+}
+
+// UpdateAll updates rulesets concurrently like CheckOutdatedAll
+func (s *Service) UpdateAll(ctx context.Context, rulesetSpecs []string, target Target, progress install.ProgressCallback) ([]*UpdateResult, []install.InstallError) {
+	results := make([]*UpdateResult, len(rulesetSpecs))
+	failures := s.runAll(ctx, rulesetSpecs, "Updated", progress, func(ctx context.Context, i int) (err error) {
+		results[i], err = s.UpdateRuleset(ctx, rulesetSpecs[i], target)
+		return err
+	})
+	return results, failures
+}
+
+// PreviewAll previews the updates of rulesets concurrently like CheckOutdatedAll
+func (s *Service) PreviewAll(ctx context.Context, rulesetSpecs []string, target Target, progress install.ProgressCallback) ([]*Preview, []install.InstallError) {
+	previews := make([]*Preview, len(rulesetSpecs))
+	failures := s.runAll(ctx, rulesetSpecs, "Compared", progress, func(ctx context.Context, i int) (err error) {
+		previews[i], err = s.PreviewUpdate(ctx, rulesetSpecs[i], target)
+		return err
+	})
+	return previews, failures
+}
+
+// runAll runs an operation for each ruleset through the install orchestrator
+func (s *Service) runAll(ctx context.Context, rulesetSpecs []string, verb string, progress install.ProgressCallback, run func(ctx context.Context, i int) error) []install.InstallError {
+	tasks := make([]install.Task, len(rulesetSpecs))
+	for i, rulesetSpec := range rulesetSpecs {
+		i := i
+		registryName, name, _ := parseRulesetSpec(rulesetSpec)
+		tasks[i] = install.Task{
+			Registry:  registryName,
+			Ruleset:   name,
+			Operation: fmt.Sprintf("%s %s", verb, rulesetSpec),
+			Run:       func(ctx context.Context) error { return run(ctx, i) },
+		}
+	}
+	return s.orchestrator.RunTasks(ctx, tasks, progress)
+}
@@ -0,0 +1,34 @@
+package update
+
+import (
+	"context"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+)
+
+func TestCheckOutdatedAll(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo, commits := policyTestRepo(t, "1.0.0", "1.1.0")
+	cfg := policyTestConfig(t, repo, commits["1.0.0"], config.RulesetSpec{Version: "^1.0.0"})
+
+	var reported int
+	results, failures := New(cfg).CheckOutdatedAll(context.Background(), []string{"local/rules", "missing/rules"}, TargetLatest, func(current, total int, operation string) {
+		reported++
+	})
+
+	if len(results) != 2 || results[0] == nil || results[1] != nil {
+		t.Fatalf("Expected a result for local/rules only, got %+v", results)
+	}
+	if !results[0].Updated || results[0].Version != commits["1.1.0"] {
+		t.Errorf("Expected local/rules to be outdated, got %+v", results[0])
+	}
+	if len(failures) != 1 || failures[0].Registry != "missing" || armerr.KindOf(failures[0].Error) != armerr.NotFound {
+		t.Errorf("Expected missing/rules to fail as not installed, got %+v", failures)
+	}
+	if reported != 2 {
+		t.Errorf("Expected 2 progress reports, got %d", reported)
+	}
+}
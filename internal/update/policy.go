@@ -0,0 +1,184 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+// Target limits how far arm update moves a ruleset
+type Target string
+
+const (
+	TargetPatch  Target = "patch"  // Newest patch release of the installed minor version
+	TargetMinor  Target = "minor"  // Newest minor or patch release of the installed major version
+	TargetLatest Target = "latest" // Newest version the manifest range allows
+	TargetMajor  Target = "major"  // Newest version, widening the manifest range when needed
+)
+
+// targetRanks orders targets from the most to the least conservative
+var targetRanks = map[Target]int{TargetPatch: 0, TargetMinor: 1, TargetLatest: 2, TargetMajor: 3}
+
+// ParseTarget parses the value of --target, defaulting to latest
+func ParseTarget(value string) (Target, error) {
+	if value == "" {
+		return TargetLatest, nil
+	}
+	target := Target(value)
+	if _, valid := targetRanks[target]; !valid {
+		return "", armerr.Errorf(armerr.Config, "invalid target %q", value).
+			WithHint("Use --target=patch, minor, latest or major")
+	}
+	return target, nil
+}
+
+// limit returns the target allowed by a ruleset update policy
+func (t Target) limit(policy string) Target {
+	var allowed Target
+	switch policy {
+	case config.UpdatePatch:
+		allowed = TargetPatch
+	case config.UpdateMinor:
+		allowed = TargetMinor
+	default:
+		return t
+	}
+	if targetRanks[allowed] < targetRanks[t] {
+		return allowed
+	}
+	return t
+}
+
+// plan is the version arm update moves a ruleset to
+type plan struct {
+	Version string // The current version when nothing newer is allowed
+	Range   string // Manifest range accepting Version when the current range does not
+	Held    bool   // Pinned by the ruleset update policy
+	Reason  string // Why the ruleset is pinned
+}
+
+// planUpdate applies the update policy of a ruleset and the requested target
+// to choose the version arm update installs
+func (s *Service) planUpdate(ctx context.Context, registryName, name, currentVersion string, target Target) (*plan, error) {
+	spec := s.config.Rulesets[registryName][name]
+	if spec.Update == config.UpdatePinned {
+		return &plan{Version: currentVersion, Held: true, Reason: spec.Reason}, nil
+	}
+
+	target = target.limit(spec.Update)
+	constraint, constraintErr := semver.NewConstraint(spec.Version)
+	if target == TargetLatest || target == TargetMajor && constraintErr != nil {
+		// The manifest range decides; branches and latest have no range to widen
+		version, err := s.targetVersion(ctx, registryName, name, currentVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &plan{Version: version}, nil
+	}
+
+	reg, err := s.createRegistry(registryName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reg.Close() }()
+
+	available, err := reg.GetVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	versions := semverVersions(available)
+
+	installed, err := s.installedVersion(ctx, reg, registryName, currentVersion, versions)
+	if err != nil {
+		return nil, err
+	}
+	if installed == nil && target != TargetMajor {
+		return nil, armerr.Errorf(armerr.Unsatisfiable, "cannot apply --target=%s to %s/%s: installed version %s is not a release", target, registryName, name, currentVersion).
+			WithHint("Install a tagged version, or update with --target=latest")
+	}
+
+	var chosen *taggedVersion
+	for i, v := range versions {
+		switch {
+		case v.semver.Prerelease() != "":
+			continue
+		case installed != nil && !v.semver.GreaterThan(installed):
+			continue
+		case target == TargetPatch && (v.semver.Major() != installed.Major() || v.semver.Minor() != installed.Minor()):
+			continue
+		case target == TargetMinor && v.semver.Major() != installed.Major():
+			continue
+		case target != TargetMajor && constraintErr == nil && !constraint.Check(v.semver):
+			continue
+		}
+		chosen = &versions[i]
+		break
+	}
+	if chosen == nil {
+		return &plan{Version: currentVersion}, nil
+	}
+
+	result := &plan{Version: chosen.tag}
+	if constraintErr == nil && !constraint.Check(chosen.semver) {
+		result.Range = "^" + chosen.semver.String()
+	}
+
+	// Git registries install the commit of the chosen tag
+	if resolver, ok := reg.(registry.VersionSpecResolver); ok && isGitType(s.config.RegistryConfigs[registryName]["type"]) {
+		if result.Version, err = resolver.ResolveVersion(ctx, chosen.tag); err != nil {
+			return nil, fmt.Errorf("failed to resolve version: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// installedVersion returns the release a ruleset is installed at, or nil when
+// it was installed from a branch or untagged commit
+func (s *Service) installedVersion(ctx context.Context, reg registry.Registry, registryName, currentVersion string, versions []taggedVersion) (*semver.Version, error) {
+	resolver, ok := reg.(registry.VersionSpecResolver)
+	if !ok || !isGitType(s.config.RegistryConfigs[registryName]["type"]) {
+		if installed, err := semver.NewVersion(currentVersion); err == nil {
+			return installed, nil
+		}
+		return nil, nil
+	}
+
+	// Git registries lock commits; find the newest tag pointing at it
+	for _, v := range versions {
+		commit, err := resolver.ResolveVersion(ctx, v.tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve version: %w", err)
+		}
+		if commit == currentVersion {
+			return v.semver, nil
+		}
+	}
+	return nil, nil
+}
+
+// taggedVersion is a release with the tag it was published under
+type taggedVersion struct {
+	tag    string
+	semver *semver.Version
+}
+
+// semverVersions returns the semantic versions of a version list, newest first
+func semverVersions(available []string) []taggedVersion {
+	var versions []taggedVersion
+	for _, tag := range available {
+		if v, err := semver.NewVersion(strings.TrimPrefix(tag, "v")); err == nil {
+			versions = append(versions, taggedVersion{tag: tag, semver: v})
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].semver.GreaterThan(versions[j].semver)
+	})
+	return versions
+}
@@ -0,0 +1,229 @@
+package update
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+func TestParseTarget(t *testing.T) {
+	for value, want := range map[string]Target{"": TargetLatest, "patch": TargetPatch, "minor": TargetMinor, "major": TargetMajor} {
+		if got, err := ParseTarget(value); err != nil || got != want {
+			t.Errorf("ParseTarget(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseTarget("newest"); armerr.KindOf(err) != armerr.Config {
+		t.Errorf("Expected a configuration error for an unknown target, got %v", err)
+	}
+}
+
+func TestTargetLimit(t *testing.T) {
+	tests := []struct {
+		target Target
+		policy string
+		want   Target
+	}{
+		{TargetMajor, "", TargetMajor},
+		{TargetMajor, config.UpdateAuto, TargetMajor},
+		{TargetMajor, config.UpdateMinor, TargetMinor},
+		{TargetLatest, config.UpdatePatch, TargetPatch},
+		{TargetPatch, config.UpdateMinor, TargetPatch},
+	}
+	for _, tt := range tests {
+		if got := tt.target.limit(tt.policy); got != tt.want {
+			t.Errorf("%s.limit(%q) = %s, want %s", tt.target, tt.policy, got, tt.want)
+		}
+	}
+}
+
+// policyTestRepo creates a git repository with a commit per tag and returns
+// its path and the commit of each tag
+func policyTestRepo(t *testing.T, tags ...string) (string, map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "-q")
+	git("config", "user.name", "Test User")
+	git("config", "user.email", "test@example.com")
+	if err := os.MkdirAll(filepath.Join(dir, "rules"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	commits := make(map[string]string)
+	for _, tag := range tags {
+		if err := os.WriteFile(filepath.Join(dir, "rules", "python.md"), []byte("rules "+tag+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		git("add", "-A")
+		git("commit", "-qm", "Release "+tag)
+		git("tag", tag)
+		commits[tag] = git("rev-parse", "HEAD")
+	}
+	return dir, commits
+}
+
+// policyTestConfig configures a git-local registry with a ruleset installed at
+// the commit of installed
+func policyTestConfig(t *testing.T, repo, installed string, spec config.RulesetSpec) *config.Config {
+	t.Helper()
+	return &config.Config{
+		Registries:      map[string]string{"local": repo},
+		RegistryConfigs: map[string]map[string]string{"local": {"type": "git-local"}},
+		Channels:        map[string]config.ChannelConfig{"cursor": {Directories: []string{"rules"}}},
+		Rulesets:        map[string]map[string]config.RulesetSpec{"local": {"rules": spec}},
+		LockFile: &config.LockFile{Rulesets: map[string]map[string]config.LockedRuleset{
+			"local": {"rules": {Version: spec.Version, Resolved: installed, Registry: repo, Type: "git-local"}},
+		}},
+		CacheConfig: &config.CacheConfig{Path: t.TempDir()},
+	}
+}
+
+func TestPlanUpdate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo, commits := policyTestRepo(t, "1.0.0", "1.0.1", "1.1.0", "2.0.0")
+
+	tests := []struct {
+		name      string
+		policy    string
+		target    Target
+		wantTag   string
+		wantRange string
+	}{
+		{name: "patch", target: TargetPatch, wantTag: "1.0.1"},
+		{name: "minor", target: TargetMinor, wantTag: "1.1.0"},
+		{name: "latest", target: TargetLatest, wantTag: "1.1.0"},
+		{name: "major", target: TargetMajor, wantTag: "2.0.0", wantRange: "^2.0.0"},
+		{name: "patch policy", policy: config.UpdatePatch, target: TargetMajor, wantTag: "1.0.1"},
+		{name: "minor policy", policy: config.UpdateMinor, target: TargetLatest, wantTag: "1.1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := policyTestConfig(t, repo, commits["1.0.0"], config.RulesetSpec{Version: "^1.0.0", Update: tt.policy})
+			planned, err := New(cfg).planUpdate(context.Background(), "local", "rules", commits["1.0.0"], tt.target)
+			if err != nil {
+				t.Fatalf("planUpdate failed: %v", err)
+			}
+			if planned.Version != commits[tt.wantTag] {
+				t.Errorf("Expected the commit of %s, got %s", tt.wantTag, planned.Version)
+			}
+			if planned.Range != tt.wantRange {
+				t.Errorf("Expected range %q, got %q", tt.wantRange, planned.Range)
+			}
+		})
+	}
+
+	t.Run("pinned", func(t *testing.T) {
+		cfg := policyTestConfig(t, repo, commits["1.0.0"], config.RulesetSpec{Version: "^1.0.0", Update: config.UpdatePinned, Reason: "Audit"})
+		planned, err := New(cfg).planUpdate(context.Background(), "local", "rules", commits["1.0.0"], TargetMajor)
+		if err != nil {
+			t.Fatalf("planUpdate failed: %v", err)
+		}
+		if !planned.Held || planned.Reason != "Audit" || planned.Version != commits["1.0.0"] {
+			t.Errorf("Expected the ruleset to be held, got %+v", planned)
+		}
+	})
+
+	t.Run("untagged", func(t *testing.T) {
+		cfg := policyTestConfig(t, repo, strings.Repeat("0", 40), config.RulesetSpec{Version: "^1.0.0"})
+		_, err := New(cfg).planUpdate(context.Background(), "local", "rules", strings.Repeat("0", 40), TargetPatch)
+		if armerr.KindOf(err) != armerr.Unsatisfiable {
+			t.Errorf("Expected an unsatisfiable error for an untagged install, got %v", err)
+		}
+	})
+}
+
+func TestUpdateRulesetMajorRewritesRange(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo, commits := policyTestRepo(t, "1.0.0", "2.0.0")
+
+	dir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	spec := config.RulesetSpec{Version: "^1.0.0"}
+	if err := config.NewManifestManager(false).AddRuleset("local", "rules", spec.Version, nil); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	cfg := policyTestConfig(t, repo, commits["1.0.0"], spec)
+
+	preview, err := New(cfg).PreviewUpdate(context.Background(), "local/rules", TargetMajor)
+	if err != nil {
+		t.Fatalf("PreviewUpdate failed: %v", err)
+	}
+	if preview.To != commits["2.0.0"] || preview.Range != "^2.0.0" || !preview.Changed() {
+		t.Errorf("Expected a preview of the major upgrade, got %+v", preview)
+	}
+
+	result, err := New(cfg).UpdateRuleset(context.Background(), "local/rules", TargetMajor)
+	if err != nil {
+		t.Fatalf("UpdateRuleset failed: %v", err)
+	}
+	if !result.Updated || result.Version != commits["2.0.0"] || result.Range != "^2.0.0" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	manifest, err := config.NewManifestManager(false).Load()
+	if err != nil {
+		t.Fatalf("Failed to load manifest: %v", err)
+	}
+	if version := manifest.Rulesets["local"]["rules"].Version; version != "^2.0.0" {
+		t.Errorf("Expected the manifest range to be widened to ^2.0.0, got %s", version)
+	}
+}
+
+func TestOutdatedAgreesWithUpdate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// A local registry serves versioned tarballs rather than commits
+	repo := t.TempDir()
+	for _, version := range []string{"1.0.0", "1.0.1", "1.1.0", "2.0.0"} {
+		srcDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(srcDir, "python.md"), []byte("rules "+version+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := registry.CreateTarball(srcDir, filepath.Join(repo, "rules", version, "ruleset.tar.gz")); err != nil {
+			t.Fatalf("Failed to create tarball: %v", err)
+		}
+	}
+	cfg := policyTestConfig(t, repo, "1.0.0", config.RulesetSpec{Version: "^1.0.0"})
+	cfg.RegistryConfigs["local"]["type"] = "local"
+	cfg.LockFile.Rulesets["local"]["rules"] = config.LockedRuleset{Version: "^1.0.0", Resolved: "1.0.0", Registry: repo, Type: "local"}
+
+	for target, want := range map[Target]string{TargetPatch: "1.0.1", TargetMinor: "1.1.0", TargetLatest: "1.1.0", TargetMajor: "2.0.0"} {
+		outdated, err := New(cfg).CheckOutdated(context.Background(), "local/rules", target)
+		if err != nil {
+			t.Fatalf("CheckOutdated(%s) failed: %v", target, err)
+		}
+		preview, err := New(cfg).PreviewUpdate(context.Background(), "local/rules", target)
+		if err != nil {
+			t.Fatalf("PreviewUpdate(%s) failed: %v", target, err)
+		}
+		if !outdated.Updated || outdated.Version != want || preview.To != want {
+			t.Errorf("%s: expected outdated and update to move to %s, got %+v and %s", target, want, outdated, preview.To)
+		}
+		if outdated.Latest != "2.0.0" {
+			t.Errorf("%s: expected latest 2.0.0, got %s", target, outdated.Latest)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/diff"
+	"github.com/max-dunn/ai-rules-manager/internal/logger"
+	"github.com/max-dunn/ai-rules-manager/internal/registry"
+)
+
+// Preview describes the changes between two versions of a ruleset
+type Preview struct {
+	Registry string            `json:"registry"`
+	Ruleset  string            `json:"ruleset"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Channels []ChannelTarget   `json:"channels"`
+	Files    []diff.FileDiff   `json:"files"`
+	Commits  []registry.Commit `json:"commits,omitempty"` // Git registries only
+	Range    string            `json:"range,omitempty"`   // Manifest range arm update would record
+	Held     bool              `json:"held,omitempty"`    // Pinned by the ruleset update policy
+	Reason   string            `json:"reason,omitempty"`  // Why the ruleset is pinned
+}
+
+// ChannelTarget is a channel directory the ruleset is installed into
+type ChannelTarget struct {
+	Channel   string `json:"channel"`
+	Directory string `json:"directory"`
+	Version   string `json:"version"` // Version directory currently installed
+}
+
+// Changed reports whether the files differ between the two versions
+func (p *Preview) Changed() bool {
+	return len(p.Files) > 0
+}
+
+// PreviewUpdate compares the installed version of a ruleset with the version
+// arm update would install for the target
+func (s *Service) PreviewUpdate(ctx context.Context, rulesetSpec string, target Target) (*Preview, error) {
+	return s.diff(ctx, rulesetSpec, "", "", target)
+}
+
+// Diff compares two versions of a ruleset file by file, fetching both through
+// the registry and cache. An empty from defaults to the installed version and
+// an empty to to the version arm update would install.
+func (s *Service) Diff(ctx context.Context, rulesetSpec, from, to string) (*Preview, error) {
+	return s.diff(ctx, rulesetSpec, from, to, TargetLatest)
+}
+
+// diff compares two versions of a ruleset, choosing a missing to version for
+// the target
+func (s *Service) diff(ctx context.Context, rulesetSpec, from, to string, target Target) (*Preview, error) {
+	registryName, name, _ := parseRulesetSpec(rulesetSpec)
+	if registryName == "" {
+		return nil, armerr.Errorf(armerr.Config, "ruleset '%s' must be given as <registry>/<ruleset>", rulesetSpec)
+	}
+
+	var next plan
+	if from == "" || to == "" {
+		if s.config.LockFile == nil || s.config.LockFile.Rulesets[registryName] == nil {
+			return nil, armerr.Errorf(armerr.NotFound, "ruleset '%s/%s' is not installed", registryName, name)
+		}
+		locked, installed := s.config.LockFile.Rulesets[registryName][name]
+		if !installed {
+			return nil, armerr.Errorf(armerr.NotFound, "ruleset '%s/%s' is not installed", registryName, name)
+		}
+		if from == "" {
+			from = lockedVersion(locked)
+		}
+		if to == "" {
+			planned, err := s.planUpdate(ctx, registryName, name, lockedVersion(locked), target)
+			if err != nil {
+				return nil, err
+			}
+			next, to = *planned, planned.Version
+		}
+	}
+
+	reg, err := s.createRegistry(registryName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reg.Close() }()
+
+	// Git registries compare and log resolved commits
+	gitRegistry := isGitType(s.config.RegistryConfigs[registryName]["type"])
+	if resolver, ok := reg.(registry.VersionSpecResolver); ok && gitRegistry {
+		if from, err = resolver.ResolveVersion(ctx, from); err != nil {
+			return nil, fmt.Errorf("failed to resolve version: %w", err)
+		}
+		if to, err = resolver.ResolveVersion(ctx, to); err != nil {
+			return nil, fmt.Errorf("failed to resolve version: %w", err)
+		}
+	}
+
+	preview := &Preview{
+		Registry: registryName,
+		Ruleset:  name,
+		From:     from,
+		To:       to,
+		Channels: s.channelTargets(registryName, name, from),
+		Files:    []diff.FileDiff{},
+		Range:    next.Range,
+		Held:     next.Held,
+		Reason:   next.Reason,
+	}
+	if from == to {
+		return preview, nil
+	}
+
+	if preview.Files, err = s.diffVersions(ctx, reg, registryName, name, from, to); err != nil {
+		return nil, err
+	}
+
+	if historian, ok := reg.(registry.CommitLogger); ok && gitRegistry {
+		commits, err := historian.CommitLog(ctx, from, to)
+		if err != nil {
+			logger.Warn("Failed to list commits of %s/%s: %v", registryName, name, err)
+		}
+		preview.Commits = commits
+	}
+	return preview, nil
+}
+
+// diffVersions downloads two versions of a ruleset and compares their files
+func (s *Service) diffVersions(ctx context.Context, reg registry.Registry, registryName, name, from, to string) ([]diff.FileDiff, error) {
+	patterns := s.patterns(registryName, name)
+
+	dirs := make([]string, 2)
+	for i, version := range []string{from, to} {
+		dir, err := os.MkdirTemp("", "arm-diff-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+
+		if _, err := s.downloadRuleset(ctx, reg, name, version, dir, patterns); err != nil {
+			return nil, fmt.Errorf("failed to download %s@%s: %w", name, version, err)
+		}
+		dirs[i] = dir
+	}
+
+	files, err := diff.Trees(dirs[0], dirs[1])
+	if err != nil {
+		return nil, err
+	}
+	if files == nil {
+		files = []diff.FileDiff{}
+	}
+	return files, nil
+}
+
+// channelTargets returns the channel directories a ruleset is installed into,
+// or every configured channel directory when it is not installed
+func (s *Service) channelTargets(registryName, name, version string) []ChannelTarget {
+	targets := []ChannelTarget{}
+	if statuses, err := s.installer.Status(nil); err == nil {
+		for _, status := range statuses {
+			if status.Registry != registryName || status.Name != name {
+				continue
+			}
+			for _, location := range status.Installed {
+				targets = append(targets, ChannelTarget{Channel: location.Channel, Directory: location.Directory, Version: location.Version})
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		for channel, channelConfig := range s.config.Channels {
+			for _, directory := range channelConfig.Directories {
+				targets = append(targets, ChannelTarget{Channel: channel, Directory: directory, Version: version})
+			}
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Channel != targets[j].Channel {
+			return targets[i].Channel < targets[j].Channel
+		}
+		return targets[i].Directory < targets[j].Directory
+	})
+	return targets
+}
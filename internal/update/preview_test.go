@@ -0,0 +1,113 @@
+package update
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/config"
+	"github.com/max-dunn/ai-rules-manager/internal/diff"
+)
+
+// previewTestRepo creates a git repository tagged 1.0.0 and 1.1.0
+func previewTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	run("init", "-q")
+	run("config", "user.name", "Test User")
+	run("config", "user.email", "test@example.com")
+	write("rules/python.md", "use black\n")
+	write("rules/old.md", "old\n")
+	run("add", "-A")
+	run("commit", "-qm", "Initial rules")
+	run("tag", "1.0.0")
+	write("rules/python.md", "use ruff\n")
+	write("rules/go.md", "gofmt\n")
+	run("rm", "-q", "rules/old.md")
+	run("add", "-A")
+	run("commit", "-qm", "Switch to ruff")
+	run("tag", "1.1.0")
+	return dir
+}
+
+func TestDiff(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &config.Config{
+		Registries:      map[string]string{"local": previewTestRepo(t)},
+		RegistryConfigs: map[string]map[string]string{"local": {"type": "git-local"}},
+		Channels:        map[string]config.ChannelConfig{"cursor": {Directories: []string{filepath.Join(t.TempDir(), "rules")}}},
+		CacheConfig:     &config.CacheConfig{Path: t.TempDir()},
+	}
+	service := New(cfg)
+
+	preview, err := service.Diff(context.Background(), "local/rules", "1.0.0", "1.1.0")
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(preview.From) != 40 || len(preview.To) != 40 {
+		t.Errorf("Expected versions resolved to commits, got %s and %s", preview.From, preview.To)
+	}
+
+	want := map[string]diff.Status{
+		"rules/go.md":     diff.Added,
+		"rules/old.md":    diff.Removed,
+		"rules/python.md": diff.Modified,
+	}
+	if len(preview.Files) != len(want) {
+		t.Fatalf("Expected %d changed files, got %+v", len(want), preview.Files)
+	}
+	for _, file := range preview.Files {
+		if want[file.Path] != file.Status {
+			t.Errorf("File %s is %s, want %s", file.Path, file.Status, want[file.Path])
+		}
+	}
+
+	if len(preview.Commits) != 1 || preview.Commits[0].Subject != "Switch to ruff" {
+		t.Errorf("Expected the commit between the tags, got %+v", preview.Commits)
+	}
+	if len(preview.Channels) != 1 || preview.Channels[0].Channel != "cursor" {
+		t.Errorf("Expected the configured channel, got %+v", preview.Channels)
+	}
+
+	same, err := service.Diff(context.Background(), "local/rules", "1.1.0", "1.1.0")
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if same.Changed() || len(same.Commits) != 0 {
+		t.Errorf("Expected no changes between identical versions, got %+v", same)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	service := New(&config.Config{})
+
+	if _, err := service.Diff(context.Background(), "rules", "1.0.0", "1.1.0"); armerr.KindOf(err) != armerr.Config {
+		t.Errorf("Expected a configuration error without a registry, got %v", err)
+	}
+	if _, err := service.PreviewUpdate(context.Background(), "local/rules", TargetLatest); armerr.KindOf(err) != armerr.NotFound {
+		t.Errorf("Expected a not found error for a ruleset that is not installed, got %v", err)
+	}
+}
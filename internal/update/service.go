@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
+	"github.com/max-dunn/ai-rules-manager/internal/cache"
 	"github.com/max-dunn/ai-rules-manager/internal/config"
 	"github.com/max-dunn/ai-rules-manager/internal/install"
+	"github.com/max-dunn/ai-rules-manager/internal/progress"
 	"github.com/max-dunn/ai-rules-manager/internal/registry"
 )
 
@@ -19,33 +24,45 @@ type UpdateResult struct {
 	Updated         bool
 	Version         string
 	PreviousVersion string
+	Latest          string // Newest available version, set by CheckOutdated
+	Range           string // Manifest range rewritten to accept a major upgrade
+	Held            bool   // Pinned by the ruleset update policy
+	Reason          string // Why the ruleset is pinned
 }
 
 // Service handles ruleset updates
 type Service struct {
-	config    *config.Config
-	installer *install.Installer
+	config       *config.Config
+	installer    *install.Installer
+	orchestrator *install.InstallOrchestrator
+
+	cacheOnce sync.Once
+	cache     cache.Manager
+	manifest  sync.Mutex // Serializes manifest rewrites of concurrent updates
 }
 
 // New creates a new update service
 func New(cfg *config.Config) *Service {
+	installer := install.New(cfg)
 	return &Service{
-		config:    cfg,
-		installer: install.New(cfg),
+		config:       cfg,
+		installer:    installer,
+		orchestrator: install.NewInstallOrchestrator(installer),
 	}
 }
 
-// CheckOutdated checks if a ruleset is outdated by comparing installed version with latest available
-func (s *Service) CheckOutdated(ctx context.Context, rulesetSpec string) (*UpdateResult, error) {
+// CheckOutdated checks if a ruleset is outdated by comparing installed version
+// with latest available, reporting the version arm update would install for
+// the target as Version
+func (s *Service) CheckOutdated(ctx context.Context, rulesetSpec string, target Target) (*UpdateResult, error) {
 	registry, name, _ := parseRulesetSpec(rulesetSpec)
 
 	// Get current locked version
 	if s.config.LockFile == nil || s.config.LockFile.Rulesets[registry] == nil {
-		return nil, fmt.Errorf("ruleset '%s/%s' is not installed", registry, name)
+		return nil, armerr.Errorf(armerr.NotFound, "ruleset '%s/%s' is not installed", registry, name)
 	}
 
-	locked := s.config.LockFile.Rulesets[registry][name]
-	currentVersion := locked.Version
+	currentVersion := lockedVersion(s.config.LockFile.Rulesets[registry][name])
 
 	// Always resolve against latest available version for outdated check
 	latestVersion, err := s.resolveLatestVersion(ctx, registry, name, currentVersion, "latest")
@@ -53,151 +70,158 @@ func (s *Service) CheckOutdated(ctx context.Context, rulesetSpec string) (*Updat
 		return nil, fmt.Errorf("failed to resolve latest version: %w", err)
 	}
 
+	planned, err := s.planUpdate(ctx, registry, name, currentVersion, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ruleset is outdated when arm update would move it, or when its
+	// update policy holds it back from a newer version
+	if latestVersion == currentVersion {
+		latestVersion = planned.Version
+	}
 	result := &UpdateResult{
 		Registry:        registry,
 		Ruleset:         name,
-		Version:         latestVersion,
+		Version:         planned.Version,
 		PreviousVersion: currentVersion,
-		Updated:         latestVersion != currentVersion,
+		Latest:          latestVersion,
+		Range:           planned.Range,
+		Held:            planned.Held,
+		Reason:          planned.Reason,
+		Updated:         planned.Version != currentVersion || planned.Held && latestVersion != currentVersion,
 	}
 
 	// Don't perform actual update for outdated check
 	return result, nil
 }
 
-// UpdateRuleset updates a single ruleset to the latest version matching its constraint
-func (s *Service) UpdateRuleset(ctx context.Context, rulesetSpec string) (*UpdateResult, error) {
+// UpdateRuleset updates a single ruleset to the newest version its update
+// policy and the target allow, widening the manifest range for major upgrades
+func (s *Service) UpdateRuleset(ctx context.Context, rulesetSpec string, target Target) (*UpdateResult, error) {
 	registry, name, _ := parseRulesetSpec(rulesetSpec)
 
 	// Get current locked version
 	if s.config.LockFile == nil || s.config.LockFile.Rulesets[registry] == nil {
-		return nil, fmt.Errorf("ruleset '%s/%s' is not installed", registry, name)
+		return nil, armerr.Errorf(armerr.NotFound, "ruleset '%s/%s' is not installed", registry, name)
 	}
 
-	locked := s.config.LockFile.Rulesets[registry][name]
-	currentVersion := locked.Version
+	currentVersion := lockedVersion(s.config.LockFile.Rulesets[registry][name])
 
-	// Get version constraint from manifest
-	var versionSpec string
-	if s.config.Rulesets[registry] != nil && s.config.Rulesets[registry][name].Version != "" {
-		versionSpec = s.config.Rulesets[registry][name].Version
-	} else {
-		versionSpec = "latest"
-	}
-
-	// Resolve latest version
-	latestVersion, err := s.resolveLatestVersion(ctx, registry, name, currentVersion, versionSpec)
+	planned, err := s.planUpdate(ctx, registry, name, currentVersion, target)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve latest version: %w", err)
+		return nil, err
 	}
 
 	result := &UpdateResult{
 		Registry:        registry,
 		Ruleset:         name,
-		Version:         latestVersion,
+		Version:         planned.Version,
 		PreviousVersion: currentVersion,
-		Updated:         latestVersion != currentVersion,
+		Held:            planned.Held,
+		Reason:          planned.Reason,
+		Updated:         planned.Version != currentVersion,
 	}
 
 	// Perform actual file operations if version changed
 	if result.Updated {
-		if err := s.performUpdate(ctx, registry, name, latestVersion); err != nil {
+		if err := s.performUpdate(ctx, registry, name, planned.Version); err != nil {
 			return nil, fmt.Errorf("failed to perform update: %w", err)
 		}
+		if planned.Range != "" {
+			if err := s.setRange(registry, name, planned.Range); err != nil {
+				return nil, fmt.Errorf("failed to update manifest: %w", err)
+			}
+			result.Range = planned.Range
+		}
 	}
 
 	return result, nil
 }
 
-// resolveLatestVersion resolves a version spec to the latest matching version
-func (s *Service) resolveLatestVersion(ctx context.Context, registryName, _, currentVersion, versionSpec string) (string, error) {
-	// Create registry configuration
-	registryConfig := &registry.RegistryConfig{
-		Name: registryName,
-		Type: s.config.RegistryConfigs[registryName]["type"],
-		URL:  s.config.Registries[registryName],
-	}
+// setRange records a new version range for a ruleset in the manifest of the
+// service's scope
+func (s *Service) setRange(registryName, name, versionRange string) error {
+	s.manifest.Lock()
+	defer s.manifest.Unlock()
 
-	// Create auth configuration
-	authConfig := &registry.AuthConfig{}
-	if regConfig := s.config.RegistryConfigs[registryName]; regConfig != nil {
-		authConfig.Token = regConfig["authToken"]
-		authConfig.Region = regConfig["region"]
-		authConfig.Profile = regConfig["profile"]
+	_, err := s.config.Manifest().SetVersion(registryName, name, versionRange)
+	return err
+}
+
+// targetVersion returns the version arm update installs: the latest version
+// matching the constraint in the manifest
+func (s *Service) targetVersion(ctx context.Context, registryName, name, currentVersion string) (string, error) {
+	versionSpec := "latest"
+	if spec := s.config.Rulesets[registryName][name]; spec.Version != "" {
+		versionSpec = spec.Version
 	}
 
-	// Create registry instance
-	reg, err := registry.CreateRegistry(registryConfig, authConfig)
+	latestVersion, err := s.resolveLatestVersion(ctx, registryName, name, currentVersion, versionSpec)
 	if err != nil {
-		return currentVersion, fmt.Errorf("failed to create registry: %w", err)
+		return "", fmt.Errorf("failed to resolve latest version: %w", err)
 	}
-	defer func() { _ = reg.Close() }()
-
-	// For Git registries, resolve the version spec
-	if regConfig := s.config.RegistryConfigs[registryName]; regConfig != nil && (regConfig["type"] == "git" || regConfig["type"] == "git-local") {
-		if regConfig["type"] == "git" {
-			gitReg, ok := reg.(*registry.GitRegistry)
-			if !ok {
-				return currentVersion, fmt.Errorf("expected Git registry but got %T", reg)
-			}
-
-			resolvedVersion, err := gitReg.ResolveVersion(ctx, versionSpec)
-			if err != nil {
-				return currentVersion, fmt.Errorf("failed to resolve version: %w", err)
-			}
+	return latestVersion, nil
+}
 
-			return resolvedVersion, nil
-		} else if regConfig["type"] == "git-local" {
-			gitLocalReg, ok := reg.(*registry.GitLocalRegistry)
-			if !ok {
-				return currentVersion, fmt.Errorf("expected GitLocal registry but got %T", reg)
-			}
+// lockedVersion returns the installed version of a lock file entry: the
+// resolved version or commit when recorded
+func lockedVersion(locked config.LockedRuleset) string {
+	if locked.Resolved != "" {
+		return locked.Resolved
+	}
+	return locked.Version
+}
 
-			resolvedVersion, err := gitLocalReg.ResolveVersion(ctx, versionSpec)
-			if err != nil {
-				return currentVersion, fmt.Errorf("failed to resolve version: %w", err)
-			}
+// resolveLatestVersion resolves a version spec to the latest matching version,
+// or the current version when no listed version matches
+func (s *Service) resolveLatestVersion(ctx context.Context, registryName, name, currentVersion, versionSpec string) (string, error) {
+	reg, err := s.createRegistry(registryName)
+	if err != nil {
+		return currentVersion, err
+	}
+	defer func() { _ = reg.Close() }()
 
-			return resolvedVersion, nil
+	// Git registries (and their mirrors) resolve the version spec to a commit
+	if resolver, ok := reg.(registry.VersionSpecResolver); ok && isGitType(s.config.RegistryConfigs[registryName]["type"]) {
+		progress.Report(ctx, progress.Resolving, versionSpec)
+		resolvedVersion, err := resolver.ResolveVersion(ctx, versionSpec)
+		if err != nil {
+			return currentVersion, fmt.Errorf("failed to resolve version: %w", err)
 		}
+
+		return resolvedVersion, nil
 	}
 
-	// For other registry types, return current version
+	// Other registry types resolve the spec against their version list
+	progress.Report(ctx, progress.Resolving, versionSpec)
+	versions, err := reg.GetVersions(ctx, name)
+	if err != nil {
+		return currentVersion, fmt.Errorf("failed to list versions: %w", err)
+	}
+	if resolved := registry.ResolveVersionSpec(ctx, reg, name, versionSpec); resolved != "latest" && slices.Contains(versions, resolved) {
+		return resolved, nil
+	}
 	return currentVersion, nil
 }
 
 // performUpdate performs the actual file operations for an update
 func (s *Service) performUpdate(ctx context.Context, registryName, name, newVersion string) error {
-	// Get patterns from manifest
-	var patterns []string
-	if s.config.Rulesets[registryName] != nil && s.config.Rulesets[registryName][name].Patterns != nil {
-		patterns = s.config.Rulesets[registryName][name].Patterns
-	}
-
-	// Create registry configuration
-	registryConfig := &registry.RegistryConfig{
-		Name: registryName,
-		Type: s.config.RegistryConfigs[registryName]["type"],
-		URL:  s.config.Registries[registryName],
-	}
-
-	// Create auth configuration
-	authConfig := &registry.AuthConfig{}
-	if regConfig := s.config.RegistryConfigs[registryName]; regConfig != nil {
-		authConfig.Token = regConfig["authToken"]
-		authConfig.Region = regConfig["region"]
-		authConfig.Profile = regConfig["profile"]
+	reg, err := s.createRegistry(registryName)
+	if err != nil {
+		return err
 	}
+	defer func() { _ = reg.Close() }()
 
-	// Create registry instance
-	reg, err := registry.CreateRegistry(registryConfig, authConfig)
+	tempDir, err := createTempDir()
 	if err != nil {
-		return fmt.Errorf("failed to create registry: %w", err)
+		return err
 	}
-	defer func() { _ = reg.Close() }()
+	defer func() { _ = os.RemoveAll(tempDir) }()
 
 	// Download new version
-	sourceFiles, err := s.downloadRuleset(ctx, reg, name, newVersion, patterns)
+	progress.Report(ctx, progress.Downloading, newVersion)
+	result, err := s.downloadRuleset(ctx, reg, name, newVersion, tempDir, s.patterns(registryName, name))
 	if err != nil {
 		return fmt.Errorf("failed to download ruleset: %w", err)
 	}
@@ -207,58 +231,60 @@ func (s *Service) performUpdate(ctx context.Context, registryName, name, newVers
 		Registry:    registryName,
 		Ruleset:     name,
 		Version:     newVersion,
-		SourceFiles: sourceFiles,
+		SourceFiles: result.Files,
 		Channels:    nil, // Use all configured channels
+		Integrity:   result.Integrity,
+	}
+	if result.Source != "" && result.Source != registryName {
+		req.Source = result.Source
 	}
 
-	_, err = s.installer.Install(req)
+	_, err = s.installer.Install(ctx, req)
 	return err
 }
 
-// downloadRuleset downloads a ruleset and returns the source files
-func (s *Service) downloadRuleset(ctx context.Context, reg registry.Registry, name, version string, patterns []string) ([]string, error) {
-	// For Git registries, use structured download
-	if gitReg, ok := reg.(*registry.GitRegistry); ok {
-		tempDir, err := createTempDir()
-		if err != nil {
+// downloadRuleset downloads a ruleset into tempDir and returns the source
+// files with their integrity
+func (s *Service) downloadRuleset(ctx context.Context, reg registry.Registry, name, version, tempDir string, patterns []string) (*registry.DownloadResult, error) {
+	var result *registry.DownloadResult
+	var err error
+	if downloader, ok := reg.(registry.ResultDownloader); ok {
+		// Git, git-local and mirrored registries use structured download
+		if result, err = downloader.DownloadRulesetWithResult(ctx, name, version, tempDir, patterns); err != nil {
 			return nil, err
 		}
-
-		result, err := gitReg.DownloadRulesetWithResult(ctx, name, version, tempDir, patterns)
-		if err != nil {
+	} else {
+		// For other registry types, use standard download
+		if err := reg.DownloadRuleset(ctx, name, version, tempDir); err != nil {
 			return nil, err
 		}
 
-		return result.Files, nil
-	}
-
-	// For Git-local registries, use structured download
-	if gitLocalReg, ok := reg.(*registry.GitLocalRegistry); ok {
-		tempDir, err := createTempDir()
-		if err != nil {
-			return nil, err
+		// Tarball registries deliver a ruleset.tar.gz; unpack it so every
+		// registry produces the same layout
+		tarPath := filepath.Join(tempDir, "ruleset.tar.gz")
+		if _, err := os.Stat(tarPath); err == nil {
+			if _, err := registry.ExtractTarball(tarPath, tempDir); err != nil {
+				return nil, err
+			}
+			if err := os.Remove(tarPath); err != nil {
+				return nil, fmt.Errorf("failed to remove ruleset.tar.gz: %w", err)
+			}
 		}
 
-		result, err := gitLocalReg.DownloadRulesetWithResult(ctx, name, version, tempDir, patterns)
+		// Find downloaded files
+		files, err := findFiles(tempDir)
 		if err != nil {
 			return nil, err
 		}
-
-		return result.Files, nil
+		result = &registry.DownloadResult{VersionSpec: version, ResolvedVersion: version, Files: files}
 	}
 
-	// For other registry types, use standard download
-	tempDir, err := createTempDir()
-	if err != nil {
-		return nil, err
-	}
-
-	if err := reg.DownloadRuleset(ctx, name, version, tempDir); err != nil {
-		return nil, err
+	if result.Integrity == "" {
+		if result.Integrity, err = registry.ComputeIntegrity(tempDir); err != nil {
+			return nil, err
+		}
 	}
-
-	// Find downloaded files
-	return findFiles(tempDir)
+	return result, nil
 }
 
 // createTempDir creates a temporary directory for downloads
@@ -301,3 +327,29 @@ func parseRulesetSpec(spec string) (registry, name, version string) {
 
 	return registry, name, version
 }
+
+// patterns returns the patterns of a ruleset in the manifest
+func (s *Service) patterns(registryName, name string) []string {
+	return s.config.Rulesets[registryName][name].Patterns
+}
+
+// cacheManager returns the configured cache, which offline mode serves from,
+// shared by concurrent operations of the service
+func (s *Service) cacheManager() cache.Manager {
+	s.cacheOnce.Do(func() {
+		if s.config.CacheConfig != nil {
+			s.cache = cache.NewManager(s.config.CacheConfig.Path)
+		}
+	})
+	return s.cache
+}
+
+// createRegistry builds the configured registry with its mirrors and caches
+func (s *Service) createRegistry(registryName string) (registry.Registry, error) {
+	return registry.NewBuilder(s.config, s.cacheManager()).Build(registryName)
+}
+
+// isGitType reports whether a registry type resolves versions to commits
+func isGitType(registryType string) bool {
+	return registryType == "git" || registryType == "git-local"
+}
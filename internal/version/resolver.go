@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
+
+	"github.com/max-dunn/ai-rules-manager/internal/armerr"
 )
 
 // Build information - will be injected by ldflags
@@ -53,7 +55,8 @@ type semverResolver struct{}
 func (r *semverResolver) Resolve(versionSpec string, availableVersions []string) (string, error) {
 	constraint, err := semver.NewConstraint(versionSpec)
 	if err != nil {
-		return "", fmt.Errorf("invalid version constraint '%s': %w", versionSpec, err)
+		return "", armerr.Errorf(armerr.Unsatisfiable, "invalid version constraint '%s': %w", versionSpec, err).
+			WithHint("Use a semver constraint such as ^1.0.0, ~1.2.0 or >=1.0.0")
 	}
 
 	// Filter and sort available versions
@@ -69,7 +72,7 @@ func (r *semverResolver) Resolve(versionSpec string, availableVersions []string)
 	}
 
 	if len(validVersions) == 0 {
-		return "", fmt.Errorf("no versions satisfy constraint '%s'", versionSpec)
+		return "", armerr.Errorf(armerr.Unsatisfiable, "no versions satisfy constraint '%s'", versionSpec)
 	}
 
 	// Sort and return highest version
@@ -99,7 +102,7 @@ func (r *gitResolver) Resolve(versionSpec string, availableVersions []string) (s
 				return versionSpec, nil
 			}
 		}
-		return "", fmt.Errorf("version '%s' not found", versionSpec)
+		return "", armerr.Errorf(armerr.Unsatisfiable, "version '%s' not found", versionSpec)
 	}
 }
 
@@ -127,7 +130,7 @@ func (r *exactResolver) Resolve(versionSpec string, availableVersions []string)
 			return v, nil
 		}
 	}
-	return "", fmt.Errorf("exact version '%s' not found", targetVersion)
+	return "", armerr.Errorf(armerr.Unsatisfiable, "exact version '%s' not found", targetVersion)
 }
 
 func (r *exactResolver) Validate(versionSpec string) error {
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -51,7 +52,7 @@ func TestInstallWorkflow_Integration(t *testing.T) {
 	}
 
 	// Execute install
-	result, err := installer.Install(req)
+	result, err := installer.Install(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Install failed: %v", err)
 	}
@@ -150,7 +151,7 @@ func TestInstallWorkflow_ErrorScenarios(t *testing.T) {
 			req := tt.setupReq()
 			installer := install.New(cfg)
 
-			_, err := installer.Install(req)
+			_, err := installer.Install(context.Background(), req)
 			if err == nil {
 				t.Errorf("Expected error but got none")
 			} else if err.Error() != tt.expectError {